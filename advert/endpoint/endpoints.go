@@ -0,0 +1,189 @@
+package advertendpoint
+
+import (
+	"context"
+
+	"microsrv/pb"
+	"microsrv/schedule"
+
+	"microsrv/advert/service"
+
+	"github.com/go-kit/kit/endpoint"
+)
+
+// Endpoints struct
+type Endpoints struct {
+	HealthEndpoint             endpoint.Endpoint // used by Consul for the healthcheck
+	CreateAdvertEndpoint       endpoint.Endpoint
+	GetAdvertEndpoint          endpoint.Endpoint
+	ListAdvertsEndpoint        endpoint.Endpoint
+	SaveAdvertEndpoint         endpoint.Endpoint
+	DeleteAdvertEndpoint       endpoint.Endpoint
+	PublishAdvertEndpoint      endpoint.Endpoint
+	RefreshPublicationEndpoint endpoint.Endpoint
+}
+
+// MakeServerEndpoints func
+func MakeServerEndpoints(s advertservice.Service) Endpoints {
+	return Endpoints{
+		HealthEndpoint:             HealthEndpoint(s),
+		CreateAdvertEndpoint:       CreateEndpoint(s),
+		GetAdvertEndpoint:          GetEndpoint(s),
+		ListAdvertsEndpoint:        ListEndpoint(s),
+		SaveAdvertEndpoint:         SaveEndpoint(s),
+		DeleteAdvertEndpoint:       DeleteEndpoint(s),
+		PublishAdvertEndpoint:      PublishEndpoint(s),
+		RefreshPublicationEndpoint: RefreshEndpoint(s),
+	}
+}
+
+// compile time assertions for our response types implementing endpoint.Failer.
+var (
+	_ endpoint.Failer = HealthResponse{}
+	_ endpoint.Failer = AdvertResponse{}
+	_ endpoint.Failer = AdvertsResponse{}
+	_ endpoint.Failer = DeleteResponse{}
+)
+
+// HealthEndpoint constructs a Health endpoint wrapping the service.
+func HealthEndpoint(s advertservice.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		healthy := s.Health()
+		return HealthResponse{Healthy: healthy}, nil
+	}
+}
+
+// CreateEndpoint func
+func CreateEndpoint(s advertservice.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(advertservice.Advert)
+		res, e := s.CreateAdvert(ctx, req)
+		return AdvertResponse{Advert: res, Err: e}, nil
+	}
+}
+
+// GetEndpoint func
+func GetEndpoint(s advertservice.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(*pb.AdvertByID)
+		res, e := s.GetAdvert(ctx, uint(req.ID))
+		return AdvertResponse{Advert: res, Err: e}, nil
+	}
+}
+
+// ListEndpoint func
+func ListEndpoint(s advertservice.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(*pb.AdvertQuery)
+		res, e := s.ListAdverts(ctx, QueryFromPB(req))
+		return AdvertsResponse{Page: res, Err: e}, nil
+	}
+}
+
+// SaveEndpoint func
+func SaveEndpoint(s advertservice.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(SaveRequest)
+		res, e := s.SaveAdvert(ctx, req.ID, req.Advert)
+		return AdvertResponse{Advert: res, Err: e}, nil
+	}
+}
+
+// DeleteEndpoint func
+func DeleteEndpoint(s advertservice.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(*pb.AdvertByID)
+		e := s.DeleteAdvert(ctx, uint(req.ID))
+		return DeleteResponse{Err: e}, nil
+	}
+}
+
+// PublishEndpoint func
+func PublishEndpoint(s advertservice.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(PublishRequest)
+		res, e := s.PublishAdvert(ctx, req.ID, req.AdNum, req.Schedule)
+		return AdvertResponse{Advert: res, Err: e}, nil
+	}
+}
+
+// RefreshEndpoint func
+func RefreshEndpoint(s advertservice.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(*pb.AdvertByID)
+		res, e := s.RefreshPublication(ctx, uint(req.ID))
+		return AdvertResponse{Advert: res, Err: e}, nil
+	}
+}
+
+// QueryFromPB converts a listing request.
+func QueryFromPB(req *pb.AdvertQuery) advertservice.Query {
+	return advertservice.Query{
+		Week:          req.Week,
+		DebtorID:      uint(req.DebtorID),
+		ResponsibleID: uint(req.ResponsibleID),
+		ProjectID:     uint(req.ProjectID),
+		Limit:         int(req.Limit),
+		From:          int(req.From),
+	}
+}
+
+// Failer is an interface that should be implemented by response types.
+// Response encoders can check if responses are Failer, and if so if they've
+// failed, and if so encode them using a separate write path based on the error.
+type Failer interface {
+	Failed() error
+}
+
+// SaveRequest collects the request parameters for the SaveAdvert method.
+type SaveRequest struct {
+	ID     uint
+	Advert advertservice.Advert
+}
+
+// PublishRequest collects the request parameters for the PublishAdvert
+// method.
+type PublishRequest struct {
+	ID       uint              `json:"-"`
+	AdNum    string            `json:"ad_num,omitempty"`
+	Schedule *schedule.Request `json:"schedule,omitempty"`
+}
+
+// AdvertResponse collects the response values of the methods returning
+// an advert.
+type AdvertResponse struct {
+	Advert advertservice.Advert `json:"advert"`
+	Err    error                `json:"err,omitempty"`
+}
+
+// Failed implements Failer.
+func (r AdvertResponse) Failed() error { return r.Err }
+
+// AdvertsResponse collects the response values for the ListAdverts method.
+type AdvertsResponse struct {
+	Page advertservice.Page
+	Err  error `json:"err,omitempty"`
+}
+
+// Failed implements Failer.
+func (r AdvertsResponse) Failed() error { return r.Err }
+
+// DeleteResponse collects the response values for the DeleteAdvert method.
+type DeleteResponse struct {
+	Err error `json:"err,omitempty"`
+}
+
+// Failed implements Failer.
+func (r DeleteResponse) Failed() error { return r.Err }
+
+// HealthRequest collects the request parameters for the Health method.
+type HealthRequest struct{}
+
+// HealthResponse collects the response values for the Health method.
+type HealthResponse struct {
+	Healthy bool  `json:"healthy,omitempty"`
+	Err     error `json:"err,omitempty"`
+}
+
+// Failed implements Failer.
+func (r HealthResponse) Failed() error { return r.Err }
@@ -0,0 +1,193 @@
+package advertendpoint
+
+import (
+	"context"
+	"time"
+
+	"microsrv/advert/workflow"
+	"microsrv/pb"
+
+	"github.com/go-kit/kit/endpoint"
+)
+
+// TaskEndpoints struct
+type TaskEndpoints struct {
+	SetTemplateEndpoint      endpoint.Endpoint
+	GetTemplateEndpoint      endpoint.Endpoint
+	InstantiateTasksEndpoint endpoint.Endpoint
+	ListTasksEndpoint        endpoint.Endpoint
+	AssignTaskEndpoint       endpoint.Endpoint
+	CompleteTaskEndpoint     endpoint.Endpoint
+	ReopenTaskEndpoint       endpoint.Endpoint
+	AttachToTaskEndpoint     endpoint.Endpoint
+	OverdueTasksEndpoint     endpoint.Endpoint
+}
+
+// MakeTaskEndpoints func
+func MakeTaskEndpoints(s workflow.Service) TaskEndpoints {
+	return TaskEndpoints{
+		SetTemplateEndpoint:      SetTemplateEndpoint(s),
+		GetTemplateEndpoint:      GetTemplateEndpoint(s),
+		InstantiateTasksEndpoint: InstantiateTasksEndpoint(s),
+		ListTasksEndpoint:        ListTasksEndpoint(s),
+		AssignTaskEndpoint:       AssignTaskEndpoint(s),
+		CompleteTaskEndpoint:     CompleteTaskEndpoint(s),
+		ReopenTaskEndpoint:       ReopenTaskEndpoint(s),
+		AttachToTaskEndpoint:     AttachToTaskEndpoint(s),
+		OverdueTasksEndpoint:     OverdueTasksEndpoint(s),
+	}
+}
+
+// compile time assertions for our response types implementing endpoint.Failer.
+var (
+	_ endpoint.Failer = TemplateResponse{}
+	_ endpoint.Failer = TasksResponse{}
+	_ endpoint.Failer = TaskResponse{}
+	_ endpoint.Failer = AttachmentResponse{}
+)
+
+// SetTemplateEndpoint func
+func SetTemplateEndpoint(s workflow.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(TemplateRequest)
+		res, e := s.SetTemplate(ctx, req.AdvertTypeID, req.Steps)
+		return TemplateResponse{AdvertTypeID: req.AdvertTypeID, Steps: res, Err: e}, nil
+	}
+}
+
+// GetTemplateEndpoint func
+func GetTemplateEndpoint(s workflow.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(*pb.TaskTemplateByAdvertType)
+		res, e := s.Template(ctx, uint(req.AdvertTypeID))
+		return TemplateResponse{AdvertTypeID: uint(req.AdvertTypeID), Steps: res, Err: e}, nil
+	}
+}
+
+// InstantiateTasksEndpoint func
+func InstantiateTasksEndpoint(s workflow.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(*pb.InstantiateTasksRequest)
+		res, e := s.Instantiate(ctx, uint(req.AdvertID), uint(req.AdvertTypeID))
+		return TasksResponse{Tasks: res, Err: e}, nil
+	}
+}
+
+// ListTasksEndpoint func
+func ListTasksEndpoint(s workflow.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(*pb.AdvertByID)
+		res, e := s.Tasks(ctx, uint(req.ID))
+		return TasksResponse{Tasks: res, Err: e}, nil
+	}
+}
+
+// AssignTaskEndpoint func
+func AssignTaskEndpoint(s workflow.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(TransitionRequest)
+		res, e := s.Assign(ctx, req.ID, req.Transition)
+		return TaskResponse{Task: res, Err: e}, nil
+	}
+}
+
+// CompleteTaskEndpoint func
+func CompleteTaskEndpoint(s workflow.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(TransitionRequest)
+		res, e := s.Complete(ctx, req.ID, req.Transition)
+		return TaskResponse{Task: res, Err: e}, nil
+	}
+}
+
+// ReopenTaskEndpoint func
+func ReopenTaskEndpoint(s workflow.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(TransitionRequest)
+		res, e := s.Reopen(ctx, req.ID, req.Transition)
+		return TaskResponse{Task: res, Err: e}, nil
+	}
+}
+
+// AttachToTaskEndpoint func
+func AttachToTaskEndpoint(s workflow.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(AttachRequest)
+		res, e := s.Attach(ctx, req.ID, req.Attachment)
+		return AttachmentResponse{Attachment: res, Err: e}, nil
+	}
+}
+
+// OverdueTasksEndpoint func
+func OverdueTasksEndpoint(s workflow.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(OverdueRequest)
+		res, e := s.Overdue(ctx, req.At)
+		return TasksResponse{Tasks: res, Err: e}, nil
+	}
+}
+
+// TemplateRequest collects the request parameters for the SetTemplate
+// method.
+type TemplateRequest struct {
+	AdvertTypeID uint
+	Steps        []workflow.Step
+}
+
+// TemplateResponse collects the response values of the methods returning
+// a template.
+type TemplateResponse struct {
+	AdvertTypeID uint
+	Steps        []workflow.Step
+	Err          error `json:"err,omitempty"`
+}
+
+// Failed implements Failer.
+func (r TemplateResponse) Failed() error { return r.Err }
+
+// TasksResponse collects the response values of the methods returning a
+// task list.
+type TasksResponse struct {
+	Tasks []workflow.Task
+	Err   error `json:"err,omitempty"`
+}
+
+// Failed implements Failer.
+func (r TasksResponse) Failed() error { return r.Err }
+
+// TransitionRequest collects the request parameters for the methods
+// changing a task.
+type TransitionRequest struct {
+	ID         uint
+	Transition workflow.Transition
+}
+
+// TaskResponse collects the response values of the methods changing a
+// task.
+type TaskResponse struct {
+	Task workflow.Task
+	Err  error `json:"err,omitempty"`
+}
+
+// Failed implements Failer.
+func (r TaskResponse) Failed() error { return r.Err }
+
+// AttachRequest collects the request parameters for the Attach method.
+type AttachRequest struct {
+	ID         uint
+	Attachment workflow.Attachment
+}
+
+// AttachmentResponse collects the response values for the Attach method.
+type AttachmentResponse struct {
+	Attachment workflow.Attachment
+	Err        error `json:"err,omitempty"`
+}
+
+// Failed implements Failer.
+func (r AttachmentResponse) Failed() error { return r.Err }
+
+// OverdueRequest collects the request parameters for the Overdue method.
+type OverdueRequest struct {
+	At time.Time
+}
@@ -0,0 +1,143 @@
+package advertservice
+
+import (
+	"time"
+
+	"microsrv/apperr"
+
+	"github.com/jinzhu/gorm"
+)
+
+// Advert is a publication about the biddings of a debtor. The related
+// entities are referenced by id, they are served by their own services.
+type Advert struct {
+	gorm.Model
+	Week          float32
+	DebtorID      uint
+	BiddingID     uint
+	TradingCodeID uint
+	CreatorID     uint
+	AdvertTypeID  uint
+	AdvertOther   string
+	OrganiserID   uint
+	ResponsibleID uint
+	ProjectID     uint
+	LawyerID      uint
+	Comment       string `gorm:"type:text"`
+	RequestCount  uint
+	LotsCount     uint
+	Publication   Publication `gorm:"embedded;embedded_prefix:publication_"`
+}
+
+// TableName of the adverts.
+func (Advert) TableName() string {
+	return "adverts"
+}
+
+// Publication is the state of the kommersant order of an advert, as last
+// reported by the kommersant service. AdNum is empty until the advert is
+// sent for publication.
+type Publication struct {
+	AdNum       string
+	State       string
+	PublishedAt *time.Time
+	IssueNo     string
+	Message     string
+	Job         string
+	CheckedAt   *time.Time
+}
+
+// States of a publication, those of the kommersant orders. Sending marks
+// an advert claimed for an order that is being created.
+const (
+	Sending   = "sending"
+	Draft     = "draft"
+	Submitted = "submitted"
+	Accepted  = "accepted"
+	Published = "published"
+	Rejected  = "rejected"
+)
+
+// Sent reports whether the advert has a kommersant order that is not
+// rejected.
+func (p Publication) Sent() bool {
+	return p.AdNum != "" && p.State != Rejected
+}
+
+// Pending reports whether the state of the order may still change.
+func (p Publication) Pending() bool {
+	return p.AdNum != "" && p.State != Published && p.State != Rejected
+}
+
+// Query selects the adverts of a week, a debtor, a responsible or a
+// project. Zero fields are not filtered on.
+type Query struct {
+	Week          float32
+	DebtorID      uint
+	ResponsibleID uint
+	ProjectID     uint
+	Limit         int
+	From          int
+}
+
+// Page of adverts. Count is the number of adverts matching the query.
+type Page struct {
+	Adverts []Advert
+	Count   uint
+}
+
+// ValidateAdvert requires the debtor of the advert and a non negative
+// week.
+func ValidateAdvert(a Advert) error {
+	fields := []apperr.Field{}
+	if a.DebtorID == 0 {
+		fields = append(fields, apperr.Field{Field: "debtorID", Description: "is required"})
+	}
+	if a.Week < 0 {
+		fields = append(fields, apperr.Field{Field: "week", Description: "must not be negative"})
+	}
+	if len(fields) > 0 {
+		return apperr.NewValidation("invalid advert", fields...)
+	}
+	return nil
+}
+
+// editAdvert copies the editable fields of src to dst, the publication is
+// left as it is.
+func editAdvert(dst *Advert, src Advert) {
+	dst.Week = src.Week
+	dst.DebtorID = src.DebtorID
+	dst.BiddingID = src.BiddingID
+	dst.TradingCodeID = src.TradingCodeID
+	dst.CreatorID = src.CreatorID
+	dst.AdvertTypeID = src.AdvertTypeID
+	dst.AdvertOther = src.AdvertOther
+	dst.OrganiserID = src.OrganiserID
+	dst.ResponsibleID = src.ResponsibleID
+	dst.ProjectID = src.ProjectID
+	dst.LawyerID = src.LawyerID
+	dst.Comment = src.Comment
+	dst.RequestCount = src.RequestCount
+	dst.LotsCount = src.LotsCount
+}
+
+// advertUpdates returns the editable fields of the advert, zero values
+// included.
+func advertUpdates(a Advert) map[string]interface{} {
+	return map[string]interface{}{
+		"Week":          a.Week,
+		"DebtorID":      a.DebtorID,
+		"BiddingID":     a.BiddingID,
+		"TradingCodeID": a.TradingCodeID,
+		"CreatorID":     a.CreatorID,
+		"AdvertTypeID":  a.AdvertTypeID,
+		"AdvertOther":   a.AdvertOther,
+		"OrganiserID":   a.OrganiserID,
+		"ResponsibleID": a.ResponsibleID,
+		"ProjectID":     a.ProjectID,
+		"LawyerID":      a.LawyerID,
+		"Comment":       a.Comment,
+		"RequestCount":  a.RequestCount,
+		"LotsCount":     a.LotsCount,
+	}
+}
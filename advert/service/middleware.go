@@ -0,0 +1,144 @@
+package advertservice
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"microsrv/schedule"
+
+	"github.com/go-kit/kit/log"
+)
+
+// Middleware describes a service (as opposed to endpoint) middleware.
+type Middleware func(Service) Service
+
+// LoggingMiddleware takes a logger as a dependency and returns a ServiceMiddleware.
+func LoggingMiddleware(logger log.Logger) Middleware {
+	return func(next Service) Service {
+		return loggingMiddleware{next, logger}
+	}
+}
+
+type loggingMiddleware struct {
+	next   Service
+	logger log.Logger
+}
+
+// Health func
+func (mw loggingMiddleware) Health() bool {
+	defer func(begin time.Time) {
+		mw.logger.Log(
+			"method", "Health",
+			"healthy", true,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return mw.next.Health()
+}
+
+// CreateAdvert func
+func (mw loggingMiddleware) CreateAdvert(ctx context.Context, a Advert) (res Advert, err error) {
+	defer func(begin time.Time) {
+		mw.logger.Log(
+			"method", "CreateAdvert",
+			"Advert.ID", res.ID,
+			"Debtor.ID", a.DebtorID,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return mw.next.CreateAdvert(ctx, a)
+}
+
+// GetAdvert func
+func (mw loggingMiddleware) GetAdvert(ctx context.Context, id uint) (Advert, error) {
+	defer func(begin time.Time) {
+		mw.logger.Log(
+			"method", "GetAdvert",
+			"Advert.ID", id,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return mw.next.GetAdvert(ctx, id)
+}
+
+// ListAdverts func
+func (mw loggingMiddleware) ListAdverts(ctx context.Context, q Query) (Page, error) {
+	defer func(begin time.Time) {
+		mw.logger.Log(
+			"method", "ListAdverts",
+			"Query", fmt.Sprintf("%+v", q),
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return mw.next.ListAdverts(ctx, q)
+}
+
+// SaveAdvert func
+func (mw loggingMiddleware) SaveAdvert(ctx context.Context, id uint, a Advert) (res Advert, err error) {
+	defer func(begin time.Time) {
+		mw.logger.Log(
+			"method", "SaveAdvert",
+			"Advert.ID", id,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return mw.next.SaveAdvert(ctx, id, a)
+}
+
+// DeleteAdvert func
+func (mw loggingMiddleware) DeleteAdvert(ctx context.Context, id uint) (err error) {
+	defer func(begin time.Time) {
+		mw.logger.Log(
+			"method", "DeleteAdvert",
+			"Advert.ID", id,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return mw.next.DeleteAdvert(ctx, id)
+}
+
+// PublishAdvert func
+func (mw loggingMiddleware) PublishAdvert(ctx context.Context, id uint, adNum string, sch *schedule.Request) (res Advert, err error) {
+	defer func(begin time.Time) {
+		mw.logger.Log(
+			"method", "PublishAdvert",
+			"Advert.ID", id,
+			"AdNum", res.Publication.AdNum,
+			"State", res.Publication.State,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return mw.next.PublishAdvert(ctx, id, adNum, sch)
+}
+
+// RefreshPublication func
+func (mw loggingMiddleware) RefreshPublication(ctx context.Context, id uint) (res Advert, err error) {
+	defer func(begin time.Time) {
+		mw.logger.Log(
+			"method", "RefreshPublication",
+			"Advert.ID", id,
+			"State", res.Publication.State,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return mw.next.RefreshPublication(ctx, id)
+}
+
+// RefreshPublications func
+func (mw loggingMiddleware) RefreshPublications(ctx context.Context) (changed int, err error) {
+	defer func(begin time.Time) {
+		mw.logger.Log(
+			"method", "RefreshPublications",
+			"changed", changed,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return mw.next.RefreshPublications(ctx)
+}
@@ -0,0 +1,80 @@
+package advertservice
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	kommersantmodel "microsrv/kommersant/model"
+	kommersantsvc "microsrv/kommersant/service"
+	"microsrv/schedule"
+)
+
+// Publisher sends adverts to the kommersant service.
+type Publisher interface {
+	// Publish creates the publication order adNum, submitted right away or
+	// by the kommersant scheduler when sch is not nil.
+	Publish(ctx context.Context, adNum string, sch *schedule.Request) (Publication, error)
+	// Status returns the current state of the order adNum.
+	Status(ctx context.Context, adNum string) (Publication, error)
+}
+
+// NewKommersantPublisher returns a Publisher calling the kommersant
+// service in process.
+func NewKommersantPublisher(s kommersantsvc.Service) Publisher {
+	return kommersantPublisher{s}
+}
+
+type kommersantPublisher struct {
+	s kommersantsvc.Service
+}
+
+// Publish implements Publisher.
+func (p kommersantPublisher) Publish(ctx context.Context, adNum string, sch *schedule.Request) (Publication, error) {
+	res, err := p.s.Create(ctx, kommersantmodel.CreateRequest{AdNum: adNum, Schedule: sch})
+	if err != nil {
+		return Publication{}, err
+	}
+	return publication(adNum, res), nil
+}
+
+// Status implements Publisher.
+func (p kommersantPublisher) Status(ctx context.Context, adNum string) (Publication, error) {
+	res, err := p.s.Result(ctx, kommersantmodel.CreateRequest{AdNum: adNum})
+	if err != nil {
+		return Publication{}, err
+	}
+	return publication(adNum, res), nil
+}
+
+func publication(adNum string, res kommersantmodel.CreateResponse) Publication {
+	return Publication{
+		AdNum:       adNum,
+		State:       string(res.State),
+		PublishedAt: res.PublishedAt,
+		IssueNo:     res.IssueNo,
+		Message:     res.Message,
+		Job:         res.Job,
+	}
+}
+
+// RefreshJob is the name of the scheduler handler that refreshes the
+// pending publications.
+const RefreshJob = "advert.refresh"
+
+// ScheduleRefresh registers the RefreshJob handler on the scheduler and
+// adds a job running it every interval.
+func ScheduleRefresh(s Service, scheduler *schedule.Scheduler, interval time.Duration) (schedule.Job, error) {
+	scheduler.Register(RefreshJob, func(ctx context.Context, payload []byte) error {
+		_, err := s.RefreshPublications(ctx)
+		return err
+	})
+	req := schedule.Request{Type: schedule.PERIODICALY, Intervals: int32(interval / time.Second)}
+	return scheduler.Add(req, RefreshJob, nil)
+}
+
+// defaultAdNum is the kommersant order number of an advert sent without
+// one.
+func defaultAdNum(id uint) string {
+	return strconv.FormatUint(uint64(id), 10)
+}
@@ -0,0 +1,246 @@
+package advertservice
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"microsrv/advert/workflow"
+	"microsrv/apperr"
+	"microsrv/schedule"
+
+	"github.com/go-kit/kit/log"
+)
+
+// Service describes the advert registry.
+type Service interface {
+	Health() bool
+	// CreateAdvert stores the advert and creates its task list from the
+	// template of its advert type, if there is one.
+	CreateAdvert(ctx context.Context, a Advert) (Advert, error)
+	GetAdvert(ctx context.Context, id uint) (Advert, error)
+	// ListAdverts returns a page of the adverts matching the query, in id
+	// order.
+	ListAdverts(ctx context.Context, q Query) (Page, error)
+	// SaveAdvert updates the editable fields of the advert, zero values
+	// included. The publication is only changed by the kommersant service.
+	SaveAdvert(ctx context.Context, id uint, a Advert) (Advert, error)
+	DeleteAdvert(ctx context.Context, id uint) error
+	// PublishAdvert sends the advert to the kommersant service as the
+	// order adNum, its id when adNum is empty. A rejected advert may be
+	// sent again under another number.
+	PublishAdvert(ctx context.Context, id uint, adNum string, sch *schedule.Request) (Advert, error)
+	// RefreshPublication updates the publication of the advert from the
+	// kommersant service.
+	RefreshPublication(ctx context.Context, id uint) (Advert, error)
+	// RefreshPublications refreshes the pending publications and returns
+	// the number of those that changed. An advert failing to refresh does
+	// not stop the others.
+	RefreshPublications(ctx context.Context) (int, error)
+}
+
+var (
+	// ErrInconsistentIDs var
+	ErrInconsistentIDs = apperr.NewValidation("inconsistent IDs", apperr.Field{Field: "id", Description: "differs from the advert id"})
+	// ErrAlreadyExists var
+	ErrAlreadyExists = apperr.NewConflict("advert already exists")
+	// ErrNotFound var
+	ErrNotFound = apperr.NewNotFound("advert not found")
+	// ErrAlreadySent var
+	ErrAlreadySent = apperr.NewConflict("advert is already sent for publication")
+	// ErrNotSent var
+	ErrNotSent = apperr.NewConflict("advert is not sent for publication")
+)
+
+// claimTimeout bounds a PublishAdvert call, a claim older than that
+// without an order is abandoned.
+const claimTimeout = 10 * time.Minute
+
+// NewBasicService returns a Service that keeps adverts in the store and
+// sends them for publication to the publisher. The task lists are created
+// by tasks, the adverts have none when it is nil. The refreshes and the
+// cleanups that fail are reported to the logger.
+func NewBasicService(store Store, publisher Publisher, tasks workflow.Service, logger log.Logger) Service {
+	return basicService{store: store, publisher: publisher, tasks: tasks, logger: logger}
+}
+
+type basicService struct {
+	store     Store
+	publisher Publisher
+	tasks     workflow.Service
+	logger    log.Logger
+}
+
+// Health implementation of the Service.
+func (s basicService) Health() bool {
+	return s.store.Ping() == nil
+}
+
+func (s basicService) CreateAdvert(ctx context.Context, a Advert) (Advert, error) {
+	if err := ValidateAdvert(a); err != nil {
+		return Advert{}, err
+	}
+	a.Publication = Publication{}
+	if err := s.store.Create(ctx, &a); err != nil {
+		return Advert{}, err
+	}
+	if s.tasks != nil && a.AdvertTypeID != 0 {
+		_, err := s.tasks.Instantiate(ctx, a.ID, a.AdvertTypeID)
+		if err != nil && err != workflow.ErrNoTemplate {
+			// The advert is deleted again, so that a retry does not leave
+			// one without its task list.
+			if derr := s.store.Delete(ctx, a.ID); derr != nil {
+				s.logger.Log("method", "CreateAdvert", "id", a.ID, "err", derr)
+			}
+			return Advert{}, err
+		}
+	}
+	return s.store.Get(ctx, a.ID)
+}
+
+func (s basicService) GetAdvert(ctx context.Context, id uint) (Advert, error) {
+	return s.store.Get(ctx, id)
+}
+
+func (s basicService) ListAdverts(ctx context.Context, q Query) (Page, error) {
+	if q.Limit == 0 {
+		q.Limit = 20
+	}
+	return s.store.List(ctx, q)
+}
+
+func (s basicService) SaveAdvert(ctx context.Context, id uint, a Advert) (Advert, error) {
+	if a.ID != 0 && a.ID != id {
+		return Advert{}, ErrInconsistentIDs
+	}
+	if err := ValidateAdvert(a); err != nil {
+		return Advert{}, err
+	}
+	stored, err := s.store.Get(ctx, id)
+	if err != nil {
+		return stored, err
+	}
+	editAdvert(&stored, a)
+	if err := s.store.Update(ctx, &stored); err != nil {
+		return stored, err
+	}
+	return s.store.Get(ctx, id)
+}
+
+func (s basicService) DeleteAdvert(ctx context.Context, id uint) error {
+	return s.store.Delete(ctx, id)
+}
+
+// PublishAdvert claims the advert for the order before creating it, so
+// that concurrent calls cannot send the advert twice. Once claimed the
+// advert refers to the order by adNum: should the publication not be
+// stored, RefreshPublications fetches it from the kommersant service.
+func (s basicService) PublishAdvert(ctx context.Context, id uint, adNum string, sch *schedule.Request) (Advert, error) {
+	a, err := s.store.Get(ctx, id)
+	if err != nil {
+		return a, err
+	}
+	if a.Publication.Sent() {
+		return a, ErrAlreadySent
+	}
+	if adNum == "" {
+		adNum = defaultAdNum(id)
+	}
+	now := time.Now()
+	claim := Publication{AdNum: adNum, State: Sending, CheckedAt: &now}
+	if err := s.store.ClaimPublication(ctx, id, claim); err != nil {
+		return a, err
+	}
+	pub, err := s.publisher.Publish(ctx, adNum, sch)
+	if err != nil {
+		// The order may be created even though the response is lost,
+		// the advert keeps it if the kommersant service has it.
+		if !uncertain(err) {
+			return a, s.release(ctx, a, err)
+		}
+		var serr error
+		if pub, serr = s.publisher.Status(ctx, adNum); serr != nil {
+			return a, s.release(ctx, a, err)
+		}
+	}
+	return s.setPublication(ctx, a, pub)
+}
+
+// release gives the claimed advert its previous publication back and
+// returns err.
+func (s basicService) release(ctx context.Context, a Advert, err error) error {
+	if rerr := s.store.SetPublication(ctx, a.ID, a.Publication); rerr != nil {
+		return rerr
+	}
+	return err
+}
+
+// uncertain reports whether a failed call may still have been carried out.
+func uncertain(err error) bool {
+	kind := apperr.KindOf(err)
+	return kind == apperr.Internal || kind == apperr.Unavailable
+}
+
+func (s basicService) RefreshPublication(ctx context.Context, id uint) (Advert, error) {
+	a, err := s.store.Get(ctx, id)
+	if err != nil {
+		return a, err
+	}
+	if a.Publication.AdNum == "" {
+		return a, ErrNotSent
+	}
+	pub, err := s.publisher.Status(ctx, a.Publication.AdNum)
+	if err != nil {
+		// A claim whose order was never created is given up once the
+		// PublishAdvert call that made it is surely over.
+		if a.Publication.State == Sending && apperr.KindOf(err) == apperr.NotFound &&
+			a.Publication.CheckedAt != nil && time.Since(*a.Publication.CheckedAt) > claimTimeout {
+			if err := s.store.SetPublication(ctx, a.ID, Publication{}); err != nil {
+				return a, err
+			}
+			return s.store.Get(ctx, a.ID)
+		}
+		return a, err
+	}
+	return s.setPublication(ctx, a, pub)
+}
+
+func (s basicService) RefreshPublications(ctx context.Context) (int, error) {
+	adverts, err := s.store.Pending(ctx)
+	if err != nil {
+		return 0, err
+	}
+	changed, failed := 0, 0
+	for _, a := range adverts {
+		if err := ctx.Err(); err != nil {
+			return changed, err
+		}
+		res, err := s.RefreshPublication(ctx, a.ID)
+		if err != nil {
+			s.logger.Log("method", "RefreshPublications", "id", a.ID, "adNum", a.Publication.AdNum, "err", err)
+			failed++
+			continue
+		}
+		if res.Publication.State != a.Publication.State {
+			changed++
+		}
+	}
+	if failed > 0 {
+		return changed, fmt.Errorf("%d of %d publications not refreshed", failed, len(adverts))
+	}
+	return changed, nil
+}
+
+// setPublication stores the publication reported for the advert. The
+// scheduler job of the order is kept until the order is submitted.
+func (s basicService) setPublication(ctx context.Context, a Advert, pub Publication) (Advert, error) {
+	if pub.Job == "" && pub.State == Draft {
+		pub.Job = a.Publication.Job
+	}
+	now := time.Now()
+	pub.CheckedAt = &now
+	if err := s.store.SetPublication(ctx, a.ID, pub); err != nil {
+		return a, err
+	}
+	return s.store.Get(ctx, a.ID)
+}
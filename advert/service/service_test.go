@@ -0,0 +1,211 @@
+package advertservice
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"microsrv/advert/workflow"
+	kommersantsvc "microsrv/kommersant/service"
+	"microsrv/schedule"
+
+	"github.com/go-kit/kit/log"
+)
+
+// testPublisher passes the calls to an in-process kommersant service,
+// failing them as told.
+type testPublisher struct {
+	Publisher
+	mtx sync.Mutex
+	// publishErr fails Publish, after creating the order when created.
+	publishErr error
+	created    bool
+}
+
+func newTestPublisher() *testPublisher {
+	svc := kommersantsvc.NewBasicService(kommersantsvc.NewMemStore(), kommersantsvc.NewMemPublisher(), nil)
+	return &testPublisher{Publisher: NewKommersantPublisher(svc)}
+}
+
+func (p *testPublisher) Publish(ctx context.Context, adNum string, sch *schedule.Request) (Publication, error) {
+	p.mtx.Lock()
+	err, created := p.publishErr, p.created
+	p.mtx.Unlock()
+	if err == nil || created {
+		pub, perr := p.Publisher.Publish(ctx, adNum, sch)
+		if err == nil {
+			return pub, perr
+		}
+	}
+	return Publication{}, err
+}
+
+// failingStore fails SetPublication once when fail is set.
+type failingStore struct {
+	Store
+	fail bool
+}
+
+var errStore = errors.New("store is down")
+
+func (s *failingStore) SetPublication(ctx context.Context, id uint, p Publication) error {
+	if s.fail {
+		s.fail = false
+		return errStore
+	}
+	return s.Store.SetPublication(ctx, id, p)
+}
+
+func newTestAdvert(t *testing.T, s Service) Advert {
+	a, err := s.CreateAdvert(context.Background(), Advert{DebtorID: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return a
+}
+
+func TestPublishAdvertOnce(t *testing.T) {
+	s := NewBasicService(NewMemStore(), newTestPublisher(), nil, log.NewNopLogger())
+	a := newTestAdvert(t, s)
+	ctx := context.Background()
+
+	var mtx sync.Mutex
+	sent, refused := 0, 0
+	wg := sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := s.PublishAdvert(ctx, a.ID, "", nil)
+			mtx.Lock()
+			defer mtx.Unlock()
+			switch err {
+			case nil:
+				sent++
+			case ErrAlreadySent:
+				refused++
+			default:
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if sent != 1 || refused != 9 {
+		t.Fatalf("%d sent, %d refused", sent, refused)
+	}
+	got, _ := s.GetAdvert(ctx, a.ID)
+	if got.Publication.State != Submitted || got.Publication.AdNum != defaultAdNum(a.ID) {
+		t.Fatalf("publication %+v", got.Publication)
+	}
+}
+
+func TestPublishAdvertFailure(t *testing.T) {
+	p := newTestPublisher()
+	s := NewBasicService(NewMemStore(), p, nil, log.NewNopLogger())
+	a := newTestAdvert(t, s)
+	ctx := context.Background()
+
+	// A refused order releases the advert.
+	p.publishErr = kommersantsvc.ErrAlreadyExists
+	if _, err := s.PublishAdvert(ctx, a.ID, "A-1", nil); err != kommersantsvc.ErrAlreadyExists {
+		t.Fatalf("PublishAdvert: %v", err)
+	}
+	got, _ := s.GetAdvert(ctx, a.ID)
+	if got.Publication.Sent() {
+		t.Fatalf("refused publication kept %+v", got.Publication)
+	}
+
+	// A lost response keeps the order that was created.
+	p.publishErr, p.created = errors.New("connection reset"), true
+	got, err := s.PublishAdvert(ctx, a.ID, "A-2", nil)
+	if err != nil || got.Publication.AdNum != "A-2" || got.Publication.State != Submitted {
+		t.Fatalf("PublishAdvert with a lost response = %+v, %v", got.Publication, err)
+	}
+
+	// A lost request releases the advert.
+	b := newTestAdvert(t, s)
+	p.created = false
+	if _, err := s.PublishAdvert(ctx, b.ID, "B-1", nil); err != p.publishErr {
+		t.Fatalf("PublishAdvert with a lost request: %v", err)
+	}
+	got, _ = s.GetAdvert(ctx, b.ID)
+	if got.Publication.Sent() {
+		t.Fatalf("lost publication kept %+v", got.Publication)
+	}
+}
+
+func TestPublishAdvertNotStored(t *testing.T) {
+	store := &failingStore{Store: NewMemStore()}
+	s := NewBasicService(store, newTestPublisher(), nil, log.NewNopLogger())
+	a := newTestAdvert(t, s)
+	ctx := context.Background()
+
+	store.fail = true
+	if _, err := s.PublishAdvert(ctx, a.ID, "", nil); err != errStore {
+		t.Fatalf("PublishAdvert: %v", err)
+	}
+	got, _ := s.GetAdvert(ctx, a.ID)
+	if got.Publication.State != Sending || !got.Publication.Pending() {
+		t.Fatalf("claimed publication %+v", got.Publication)
+	}
+	if _, err := s.PublishAdvert(ctx, a.ID, "", nil); err != ErrAlreadySent {
+		t.Fatalf("second PublishAdvert: %v", err)
+	}
+	changed, err := s.RefreshPublications(ctx)
+	if err != nil || changed != 1 {
+		t.Fatalf("RefreshPublications = %d, %v", changed, err)
+	}
+	got, _ = s.GetAdvert(ctx, a.ID)
+	if got.Publication.State != Submitted {
+		t.Fatalf("refreshed publication %+v", got.Publication)
+	}
+}
+
+func TestRefreshPublicationsContinues(t *testing.T) {
+	p := newTestPublisher()
+	store := NewMemStore()
+	s := NewBasicService(store, p, nil, log.NewNopLogger())
+	ctx := context.Background()
+	a, b := newTestAdvert(t, s), newTestAdvert(t, s)
+	for _, id := range []uint{a.ID, b.ID} {
+		if _, err := s.PublishAdvert(ctx, id, "", nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The order of a is unknown to the kommersant service.
+	store.SetPublication(ctx, a.ID, Publication{AdNum: "lost", State: Submitted})
+	store.SetPublication(ctx, b.ID, Publication{AdNum: defaultAdNum(b.ID), State: Draft})
+
+	changed, err := s.RefreshPublications(ctx)
+	if err == nil || changed != 1 {
+		t.Fatalf("RefreshPublications = %d, %v", changed, err)
+	}
+	got, _ := s.GetAdvert(ctx, b.ID)
+	if got.Publication.State != Submitted {
+		t.Fatalf("publication after a failed one %+v", got.Publication)
+	}
+}
+
+// failingTasks fails to instantiate the task lists.
+type failingTasks struct{ workflow.Service }
+
+var errTasks = errors.New("workflow is down")
+
+func (failingTasks) Instantiate(ctx context.Context, advertID, advertTypeID uint) ([]workflow.Task, error) {
+	return nil, errTasks
+}
+
+func TestCreateAdvertWithoutTasks(t *testing.T) {
+	store := NewMemStore()
+	s := NewBasicService(store, newTestPublisher(), failingTasks{}, log.NewNopLogger())
+	ctx := context.Background()
+
+	if _, err := s.CreateAdvert(ctx, Advert{DebtorID: 1, AdvertTypeID: 1}); err != errTasks {
+		t.Fatalf("CreateAdvert: %v", err)
+	}
+	p, err := s.ListAdverts(ctx, Query{})
+	if err != nil || len(p.Adverts) != 0 {
+		t.Fatalf("adverts left %+v, %v", p, err)
+	}
+}
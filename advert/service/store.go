@@ -0,0 +1,315 @@
+package advertservice
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"microsrv/config"
+
+	"github.com/jinzhu/gorm"
+	_ "github.com/jinzhu/gorm/dialects/mysql"    // Mysql driver
+	_ "github.com/jinzhu/gorm/dialects/postgres" // Postgres driver
+)
+
+// Store persists adverts.
+type Store interface {
+	Create(ctx context.Context, a *Advert) error
+	Get(ctx context.Context, id uint) (Advert, error)
+	List(ctx context.Context, q Query) (Page, error)
+	// Update writes the editable fields of the stored advert, zero values
+	// included.
+	Update(ctx context.Context, a *Advert) error
+	// SetPublication writes the publication of the advert with the id.
+	SetPublication(ctx context.Context, id uint, p Publication) error
+	// ClaimPublication writes the publication of the advert with the id
+	// unless it is already sent, in a single conditional update, so that
+	// only one of concurrent claims succeeds. It returns ErrAlreadySent
+	// when the advert is sent.
+	ClaimPublication(ctx context.Context, id uint, p Publication) error
+	// Pending returns the adverts with a publication that may still change.
+	Pending(ctx context.Context) ([]Advert, error)
+	Delete(ctx context.Context, id uint) error
+	Ping() error
+}
+
+// NewStore returns the Store selected by cfg.Driver. The adverts table is
+// created by the migrations of package migrate.
+func NewStore(cfg config.DB) (Store, error) {
+	switch cfg.Driver {
+	case "", "mysql":
+		return NewDB("mysql", cfg.DSN())
+	case "postgres":
+		return NewDB("postgres", cfg.DSN())
+	case "memory":
+		return NewMemStore(), nil
+	default:
+		return nil, fmt.Errorf("unknown database driver %q", cfg.Driver)
+	}
+}
+
+type databaseStore struct{ db *gorm.DB }
+
+// NewDB returns a Store backed by MySQL or PostgreSQL.
+func NewDB(dialect, DSN string) (Store, error) {
+	db, err := gorm.Open(dialect, DSN)
+	if err != nil {
+		return nil, err
+	}
+	return &databaseStore{db: db}, nil
+}
+
+func (ds *databaseStore) Create(ctx context.Context, a *Advert) error {
+	if a.ID != 0 && !ds.db.Unscoped().First(&Advert{}, a.ID).RecordNotFound() {
+		return ErrAlreadyExists
+	}
+	return ds.db.Create(a).Error
+}
+
+func (ds *databaseStore) Get(ctx context.Context, id uint) (Advert, error) {
+	a := Advert{}
+	err := ds.db.First(&a, id).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return a, ErrNotFound
+	}
+	return a, err
+}
+
+func (ds *databaseStore) List(ctx context.Context, p Query) (Page, error) {
+	res := Page{Adverts: []Advert{}}
+	q := ds.db.Model(&Advert{})
+	if p.Week != 0 {
+		q = q.Where("week = ?", p.Week)
+	}
+	if p.DebtorID != 0 {
+		q = q.Where("debtor_id = ?", p.DebtorID)
+	}
+	if p.ResponsibleID != 0 {
+		q = q.Where("responsible_id = ?", p.ResponsibleID)
+	}
+	if p.ProjectID != 0 {
+		q = q.Where("project_id = ?", p.ProjectID)
+	}
+	count := 0
+	if err := q.Count(&count).Error; err != nil {
+		return res, err
+	}
+	res.Count = uint(count)
+	err := q.
+		Order("id").
+		Limit(p.Limit).
+		Offset(p.From).
+		Find(&res.Adverts).
+		Error
+	return res, err
+}
+
+func (ds *databaseStore) Update(ctx context.Context, a *Advert) error {
+	return ds.db.Model(a).Updates(advertUpdates(*a)).Error
+}
+
+func (ds *databaseStore) SetPublication(ctx context.Context, id uint, p Publication) error {
+	return ds.db.Model(&Advert{}).Where("id = ?", id).Updates(publicationUpdates(p)).Error
+}
+
+func (ds *databaseStore) ClaimPublication(ctx context.Context, id uint, p Publication) error {
+	q := ds.db.Model(&Advert{}).
+		Where("id = ?", id).
+		Where("publication_ad_num = '' OR publication_ad_num IS NULL OR publication_state = ?", Rejected).
+		Updates(publicationUpdates(p))
+	if q.Error != nil {
+		return q.Error
+	}
+	if q.RowsAffected == 0 {
+		if _, err := ds.Get(ctx, id); err != nil {
+			return err
+		}
+		return ErrAlreadySent
+	}
+	return nil
+}
+
+func publicationUpdates(p Publication) map[string]interface{} {
+	return map[string]interface{}{
+		"publication_ad_num":       p.AdNum,
+		"publication_state":        p.State,
+		"publication_published_at": p.PublishedAt,
+		"publication_issue_no":     p.IssueNo,
+		"publication_message":      p.Message,
+		"publication_job":          p.Job,
+		"publication_checked_at":   p.CheckedAt,
+	}
+}
+
+func (ds *databaseStore) Pending(ctx context.Context) ([]Advert, error) {
+	adverts := []Advert{}
+	err := ds.db.
+		Where("publication_ad_num <> ''").
+		Where("publication_state NOT IN (?)", []string{Published, Rejected}).
+		Order("id").
+		Find(&adverts).
+		Error
+	return adverts, err
+}
+
+func (ds *databaseStore) Delete(ctx context.Context, id uint) error {
+	q := ds.db.Delete(Advert{}, id)
+	if q.Error != nil {
+		return q.Error
+	}
+	if q.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (ds *databaseStore) Ping() error {
+	return ds.db.DB().Ping()
+}
+
+type memStore struct {
+	mtx     sync.RWMutex
+	adverts map[uint]Advert
+	nextID  uint
+}
+
+// NewMemStore returns an in-memory Store.
+func NewMemStore() Store {
+	return &memStore{adverts: map[uint]Advert{}}
+}
+
+func (s *memStore) Create(ctx context.Context, a *Advert) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if a.ID != 0 {
+		if _, ok := s.adverts[a.ID]; ok {
+			return ErrAlreadyExists
+		}
+	} else {
+		s.nextID++
+		a.ID = s.nextID
+	}
+	if a.ID > s.nextID {
+		s.nextID = a.ID
+	}
+	now := time.Now()
+	a.CreatedAt, a.UpdatedAt, a.DeletedAt = now, now, nil
+	s.adverts[a.ID] = *a
+	return nil
+}
+
+func (s *memStore) Get(ctx context.Context, id uint) (Advert, error) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	return s.get(id)
+}
+
+// get returns a live advert. Must be called with mtx held.
+func (s *memStore) get(id uint) (Advert, error) {
+	a, ok := s.adverts[id]
+	if !ok || a.DeletedAt != nil {
+		return Advert{}, ErrNotFound
+	}
+	return a, nil
+}
+
+func (s *memStore) List(ctx context.Context, p Query) (Page, error) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	matched := []Advert{}
+	for _, a := range s.adverts {
+		switch {
+		case a.DeletedAt != nil:
+		case p.Week != 0 && a.Week != p.Week:
+		case p.DebtorID != 0 && a.DebtorID != p.DebtorID:
+		case p.ResponsibleID != 0 && a.ResponsibleID != p.ResponsibleID:
+		case p.ProjectID != 0 && a.ProjectID != p.ProjectID:
+		default:
+			matched = append(matched, a)
+		}
+	}
+	sort.Slice(matched, func(i, j int) bool { return matched[i].ID < matched[j].ID })
+	res := Page{Adverts: []Advert{}, Count: uint(len(matched))}
+	if p.From < len(matched) {
+		matched = matched[p.From:]
+		if len(matched) > p.Limit {
+			matched = matched[:p.Limit]
+		}
+		res.Adverts = matched
+	}
+	return res, nil
+}
+
+func (s *memStore) Update(ctx context.Context, a *Advert) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	stored, err := s.get(a.ID)
+	if err != nil {
+		return err
+	}
+	editAdvert(&stored, *a)
+	stored.UpdatedAt = time.Now()
+	s.adverts[a.ID] = stored
+	return nil
+}
+
+func (s *memStore) SetPublication(ctx context.Context, id uint, p Publication) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	a, err := s.get(id)
+	if err != nil {
+		return err
+	}
+	a.Publication = p
+	a.UpdatedAt = time.Now()
+	s.adverts[id] = a
+	return nil
+}
+
+func (s *memStore) ClaimPublication(ctx context.Context, id uint, p Publication) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	a, err := s.get(id)
+	if err != nil {
+		return err
+	}
+	if a.Publication.Sent() {
+		return ErrAlreadySent
+	}
+	a.Publication = p
+	a.UpdatedAt = time.Now()
+	s.adverts[id] = a
+	return nil
+}
+
+func (s *memStore) Pending(ctx context.Context) ([]Advert, error) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	adverts := []Advert{}
+	for _, a := range s.adverts {
+		if a.DeletedAt == nil && a.Publication.Pending() {
+			adverts = append(adverts, a)
+		}
+	}
+	sort.Slice(adverts, func(i, j int) bool { return adverts[i].ID < adverts[j].ID })
+	return adverts, nil
+}
+
+func (s *memStore) Delete(ctx context.Context, id uint) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	a, err := s.get(id)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	a.DeletedAt = &now
+	s.adverts[id] = a
+	return nil
+}
+
+func (s *memStore) Ping() error {
+	return nil
+}
@@ -0,0 +1,276 @@
+package transport
+
+import (
+	"context"
+
+	"microsrv/advert/endpoint"
+	"microsrv/advert/service"
+	"microsrv/apperr"
+	"microsrv/pb"
+	"microsrv/schedule"
+
+	"github.com/go-kit/kit/log"
+	grpctransport "github.com/go-kit/kit/transport/grpc"
+	"github.com/golang/protobuf/ptypes"
+	oldcontext "golang.org/x/net/context"
+)
+
+type grpcServer struct {
+	createAdvert       grpctransport.Handler
+	getAdvert          grpctransport.Handler
+	listAdverts        grpctransport.Handler
+	saveAdvert         grpctransport.Handler
+	deleteAdvert       grpctransport.Handler
+	publishAdvert      grpctransport.Handler
+	refreshPublication grpctransport.Handler
+}
+
+// NewGRPCServer makes a set of endpoints available as a gRPC AdvertSvcServer.
+func NewGRPCServer(endpoints advertendpoint.Endpoints, logger log.Logger) pb.AdvertSvcServer {
+	options := []grpctransport.ServerOption{
+		grpctransport.ServerErrorLogger(logger),
+	}
+
+	return &grpcServer{
+		createAdvert: grpctransport.NewServer(
+			endpoints.CreateAdvertEndpoint,
+			decodeGRPCAdvert,
+			encodeGRPCAdvertResponse,
+			options...,
+		),
+		getAdvert: grpctransport.NewServer(
+			endpoints.GetAdvertEndpoint,
+			decodeGRPCAdvertByID,
+			encodeGRPCAdvertResponse,
+			options...,
+		),
+		listAdverts: grpctransport.NewServer(
+			endpoints.ListAdvertsEndpoint,
+			decodeGRPCAdvertQuery,
+			encodeGRPCAdvertsResponse,
+			options...,
+		),
+		saveAdvert: grpctransport.NewServer(
+			endpoints.SaveAdvertEndpoint,
+			decodeGRPCSaveAdvert,
+			encodeGRPCAdvertResponse,
+			options...,
+		),
+		deleteAdvert: grpctransport.NewServer(
+			endpoints.DeleteAdvertEndpoint,
+			decodeGRPCAdvertByID,
+			encodeGRPCDeleteResponse,
+			options...,
+		),
+		publishAdvert: grpctransport.NewServer(
+			endpoints.PublishAdvertEndpoint,
+			decodeGRPCPublishAdvert,
+			encodeGRPCAdvertResponse,
+			options...,
+		),
+		refreshPublication: grpctransport.NewServer(
+			endpoints.RefreshPublicationEndpoint,
+			decodeGRPCAdvertByID,
+			encodeGRPCAdvertResponse,
+			options...,
+		),
+	}
+}
+
+// CreateAdvert implementation of the method of the AdvertSvcServer
+// interface.
+func (s *grpcServer) CreateAdvert(ctx oldcontext.Context, req *pb.Advert) (*pb.Advert, error) {
+	_, res, err := s.createAdvert.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, apperr.ToGRPC(err)
+	}
+	return res.(*pb.Advert), nil
+}
+
+func decodeGRPCAdvert(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	return advertFromPB(grpcReq.(*pb.Advert)), nil
+}
+
+func encodeGRPCAdvertResponse(_ context.Context, response interface{}) (interface{}, error) {
+	result := response.(advertendpoint.AdvertResponse)
+	if result.Err != nil {
+		return nil, result.Err
+	}
+	return advertToPB(result.Advert), nil
+}
+
+// GetAdvert implementation of the method of the AdvertSvcServer interface.
+func (s *grpcServer) GetAdvert(ctx oldcontext.Context, req *pb.AdvertByID) (*pb.Advert, error) {
+	_, res, err := s.getAdvert.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, apperr.ToGRPC(err)
+	}
+	return res.(*pb.Advert), nil
+}
+
+func decodeGRPCAdvertByID(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	return grpcReq.(*pb.AdvertByID), nil
+}
+
+// ListAdverts implementation of the method of the AdvertSvcServer
+// interface.
+func (s *grpcServer) ListAdverts(ctx oldcontext.Context, req *pb.AdvertQuery) (*pb.Adverts, error) {
+	_, res, err := s.listAdverts.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, apperr.ToGRPC(err)
+	}
+	return res.(*pb.Adverts), nil
+}
+
+func decodeGRPCAdvertQuery(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	return grpcReq.(*pb.AdvertQuery), nil
+}
+
+func encodeGRPCAdvertsResponse(_ context.Context, response interface{}) (interface{}, error) {
+	result := response.(advertendpoint.AdvertsResponse)
+	if result.Err != nil {
+		return nil, result.Err
+	}
+	return advertsToPB(result.Page.Adverts, result.Page.Count), nil
+}
+
+// SaveAdvert implementation of the method of the AdvertSvcServer
+// interface. The advert is selected by its ID.
+func (s *grpcServer) SaveAdvert(ctx oldcontext.Context, req *pb.Advert) (*pb.Advert, error) {
+	_, res, err := s.saveAdvert.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, apperr.ToGRPC(err)
+	}
+	return res.(*pb.Advert), nil
+}
+
+func decodeGRPCSaveAdvert(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.Advert)
+	return advertendpoint.SaveRequest{ID: uint(req.ID), Advert: advertFromPB(req)}, nil
+}
+
+// DeleteAdvert implementation of the method of the AdvertSvcServer
+// interface.
+func (s *grpcServer) DeleteAdvert(ctx oldcontext.Context, req *pb.AdvertByID) (*pb.ErrorResponse, error) {
+	_, res, err := s.deleteAdvert.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, apperr.ToGRPC(err)
+	}
+	return res.(*pb.ErrorResponse), nil
+}
+
+func encodeGRPCDeleteResponse(_ context.Context, response interface{}) (interface{}, error) {
+	result := response.(advertendpoint.DeleteResponse)
+	if result.Err != nil {
+		return nil, result.Err
+	}
+	return &pb.ErrorResponse{}, nil
+}
+
+// PublishAdvert implementation of the method of the AdvertSvcServer
+// interface.
+func (s *grpcServer) PublishAdvert(ctx oldcontext.Context, req *pb.PublishAdvertRequest) (*pb.Advert, error) {
+	_, res, err := s.publishAdvert.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, apperr.ToGRPC(err)
+	}
+	return res.(*pb.Advert), nil
+}
+
+func decodeGRPCPublishAdvert(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.PublishAdvertRequest)
+	res := advertendpoint.PublishRequest{ID: uint(req.ID), AdNum: req.AdNum}
+	if req.Schedule != nil {
+		res.Schedule = &schedule.Request{
+			Type:      schedule.Type(req.Schedule.Type),
+			Intervals: req.Schedule.Intervals,
+			Max:       int16(req.Schedule.Max),
+		}
+		if req.Schedule.FirstRun != nil {
+			t, err := ptypes.Timestamp(req.Schedule.FirstRun)
+			if err != nil {
+				return nil, apperr.NewValidation(err.Error(), apperr.Field{Field: "schedule.first_run", Description: "is out of range"})
+			}
+			res.Schedule.FirstRun = &t
+		}
+	}
+	return res, nil
+}
+
+// RefreshPublication implementation of the method of the AdvertSvcServer
+// interface.
+func (s *grpcServer) RefreshPublication(ctx oldcontext.Context, req *pb.AdvertByID) (*pb.Advert, error) {
+	_, res, err := s.refreshPublication.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, apperr.ToGRPC(err)
+	}
+	return res.(*pb.Advert), nil
+}
+
+// advertFromPB takes the editable fields of the advert, its publication is
+// only changed by the kommersant service.
+func advertFromPB(a *pb.Advert) advertservice.Advert {
+	res := advertservice.Advert{
+		Week:          a.Week,
+		DebtorID:      uint(a.DebtorID),
+		BiddingID:     uint(a.BiddingID),
+		TradingCodeID: uint(a.TradingCodeID),
+		CreatorID:     uint(a.CreatorID),
+		AdvertTypeID:  uint(a.AdvertID),
+		AdvertOther:   a.AdvertOther,
+		OrganiserID:   uint(a.OrganiserID),
+		ResponsibleID: uint(a.ResponsibleID),
+		ProjectID:     uint(a.ProjectID),
+		LawyerID:      uint(a.LawyerID),
+		Comment:       a.Comment,
+		RequestCount:  uint(a.RequestCount),
+		LotsCount:     uint(a.LotsCount),
+	}
+	res.ID = uint(a.ID)
+	return res
+}
+
+func advertToPB(a advertservice.Advert) *pb.Advert {
+	res := &pb.Advert{
+		ID:            uint32(a.ID),
+		Week:          a.Week,
+		DebtorID:      uint32(a.DebtorID),
+		BiddingID:     uint32(a.BiddingID),
+		TradingCodeID: uint32(a.TradingCodeID),
+		CreatorID:     int32(a.CreatorID),
+		AdvertID:      uint32(a.AdvertTypeID),
+		AdvertOther:   a.AdvertOther,
+		OrganiserID:   uint32(a.OrganiserID),
+		ResponsibleID: uint32(a.ResponsibleID),
+		ProjectID:     uint32(a.ProjectID),
+		LawyerID:      uint32(a.LawyerID),
+		Comment:       a.Comment,
+		RequestCount:  uint32(a.RequestCount),
+		LotsCount:     uint32(a.LotsCount),
+	}
+	res.CreatedAt, _ = ptypes.TimestampProto(a.CreatedAt)
+	if p := a.Publication; p.AdNum != "" {
+		res.Publication = &pb.Publication{
+			AdNum:   p.AdNum,
+			State:   p.State,
+			IssueNo: p.IssueNo,
+			Message: p.Message,
+			Job:     p.Job,
+		}
+		if p.PublishedAt != nil {
+			res.Publication.PublishedAt, _ = ptypes.TimestampProto(*p.PublishedAt)
+		}
+		if p.CheckedAt != nil {
+			res.Publication.CheckedAt, _ = ptypes.TimestampProto(*p.CheckedAt)
+		}
+	}
+	return res
+}
+
+func advertsToPB(adverts []advertservice.Advert, count uint) *pb.Adverts {
+	res := &pb.Adverts{Adverts: make([]*pb.Advert, len(adverts)), Count: uint32(count)}
+	for i, a := range adverts {
+		res.Adverts[i] = advertToPB(a)
+	}
+	return res
+}
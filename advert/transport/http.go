@@ -0,0 +1,246 @@
+package transport
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+
+	"microsrv/advert/endpoint"
+	"microsrv/advert/service"
+	"microsrv/apperr"
+	"microsrv/pb"
+
+	"github.com/go-kit/kit/log"
+	httptransport "github.com/go-kit/kit/transport/http"
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/gorilla/mux"
+)
+
+var (
+	// ErrBadRouting is returned when an expected path variable is missing.
+	ErrBadRouting = apperr.New(apperr.Internal, "inconsistent mapping between route and handler")
+)
+
+// badRequest reports a malformed request.
+func badRequest(err error) error {
+	return apperr.NewValidation(err.Error())
+}
+
+// NewHTTPHandler returns an HTTP handler that makes the advert and the task
+// endpoints available on predefined paths.
+func NewHTTPHandler(endpoints advertendpoint.Endpoints, tasks advertendpoint.TaskEndpoints, logger log.Logger) http.Handler {
+	m := mux.NewRouter()
+	options := []httptransport.ServerOption{
+		httptransport.ServerErrorEncoder(apperr.EncodeHTTPError),
+		httptransport.ServerErrorLogger(logger),
+	}
+
+	// GET    /health                             retrieves service heath information
+	// POST   /adverts                            adds another advert
+	// GET    /adverts?week&debtor_id             retrieves a page of adverts, of the
+	//        &responsible_id&project_id          week, debtor, responsible or project
+	//        &limit&from                         if given
+	// GET    /adverts/{id}                       retrieves the given advert by id
+	// PUT    /adverts/{id}                       updates the given advert
+	// DELETE /adverts/{id}                       removes the given advert
+	// POST   /adverts/{id}/publication           sends the advert to the kommersant
+	//                                            service, body {ad_num, schedule}
+	// POST   /adverts/{id}/publication/refresh   updates the publication of the
+	//                                            advert from the kommersant service
+
+	m.Methods("GET").Path("/health").Handler(httptransport.NewServer(
+		endpoints.HealthEndpoint,
+		DecodeHTTPHealthRequest,
+		EncodeHTTPGenericResponse,
+		options...,
+	))
+	m.Methods("POST").Path("/adverts").Handler(httptransport.NewServer(
+		endpoints.CreateAdvertEndpoint,
+		decodeHTTPCreateAdvertRequest,
+		encodeHTTPCreatedAdvertResponse,
+		options...,
+	))
+	m.Methods("GET").Path("/adverts").Handler(httptransport.NewServer(
+		endpoints.ListAdvertsEndpoint,
+		decodeHTTPListAdvertsRequest,
+		encodeHTTPAdvertsResponse,
+		options...,
+	))
+	m.Methods("GET").Path("/adverts/{id}").Handler(httptransport.NewServer(
+		endpoints.GetAdvertEndpoint,
+		decodeHTTPAdvertByIDRequest,
+		encodeHTTPAdvertResponse,
+		options...,
+	))
+	m.Methods("PUT").Path("/adverts/{id}").Handler(httptransport.NewServer(
+		endpoints.SaveAdvertEndpoint,
+		decodeHTTPSaveAdvertRequest,
+		encodeHTTPAdvertResponse,
+		options...,
+	))
+	m.Methods("DELETE").Path("/adverts/{id}").Handler(httptransport.NewServer(
+		endpoints.DeleteAdvertEndpoint,
+		decodeHTTPAdvertByIDRequest,
+		EncodeHTTPGenericResponse,
+		options...,
+	))
+	m.Methods("POST").Path("/adverts/{id}/publication").Handler(httptransport.NewServer(
+		endpoints.PublishAdvertEndpoint,
+		decodeHTTPPublishAdvertRequest,
+		encodeHTTPAdvertResponse,
+		options...,
+	))
+	m.Methods("POST").Path("/adverts/{id}/publication/refresh").Handler(httptransport.NewServer(
+		endpoints.RefreshPublicationEndpoint,
+		decodeHTTPAdvertByIDRequest,
+		encodeHTTPAdvertResponse,
+		options...,
+	))
+	addTaskRoutes(m, tasks, options)
+	return m
+}
+
+// DecodeHTTPHealthRequest method.
+func DecodeHTTPHealthRequest(_ context.Context, _ *http.Request) (interface{}, error) {
+	return advertendpoint.HealthRequest{}, nil
+}
+
+func decodeHTTPCreateAdvertRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	return decodeHTTPAdvert(r)
+}
+
+func decodeHTTPListAdvertsRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	q := r.URL.Query()
+	req := &pb.AdvertQuery{}
+	if s := q.Get("week"); s != "" {
+		w, err := strconv.ParseFloat(s, 32)
+		if err != nil {
+			return nil, badRequest(err)
+		}
+		req.Week = float32(w)
+	}
+	for name, v := range map[string]*uint32{"debtor_id": &req.DebtorID, "responsible_id": &req.ResponsibleID, "project_id": &req.ProjectID} {
+		if s := q.Get(name); s != "" {
+			n, err := strconv.ParseUint(s, 10, 32)
+			if err != nil {
+				return nil, badRequest(err)
+			}
+			*v = uint32(n)
+		}
+	}
+	for name, v := range map[string]*int64{"limit": &req.Limit, "from": &req.From} {
+		if s := q.Get(name); s != "" {
+			n, err := strconv.ParseInt(s, 10, 64)
+			if err != nil {
+				return nil, badRequest(err)
+			}
+			*v = n
+		}
+	}
+	return req, nil
+}
+
+func decodeHTTPAdvertByIDRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	id, err := advertID(r)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.AdvertByID{ID: uint32(id)}, nil
+}
+
+func decodeHTTPSaveAdvertRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	id, err := advertID(r)
+	if err != nil {
+		return nil, err
+	}
+	a, err := decodeHTTPAdvert(r)
+	if err != nil {
+		return nil, err
+	}
+	return advertendpoint.SaveRequest{ID: id, Advert: a}, nil
+}
+
+// decodeHTTPPublishAdvertRequest reads the optional {ad_num, schedule}
+// document of the request body.
+func decodeHTTPPublishAdvertRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	id, err := advertID(r)
+	if err != nil {
+		return nil, err
+	}
+	req := advertendpoint.PublishRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		return nil, badRequest(err)
+	}
+	req.ID = id
+	return req, nil
+}
+
+// decodeHTTPAdvert reads a pb.Advert JSON document from the request body.
+func decodeHTTPAdvert(r *http.Request) (advertservice.Advert, error) {
+	req := pb.Advert{}
+	u := jsonpb.Unmarshaler{AllowUnknownFields: true}
+	if err := u.Unmarshal(r.Body, &req); err != nil {
+		return advertservice.Advert{}, badRequest(err)
+	}
+	return advertFromPB(&req), nil
+}
+
+func advertID(r *http.Request) (uint, error) {
+	vars := mux.Vars(r)
+	id, ok := vars["id"]
+	if !ok {
+		return 0, ErrBadRouting
+	}
+	n, err := strconv.ParseUint(id, 10, 32)
+	if err != nil {
+		return 0, badRequest(err)
+	}
+	return uint(n), nil
+}
+
+func encodeHTTPCreatedAdvertResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	if f, ok := response.(advertendpoint.Failer); ok && f.Failed() != nil {
+		apperr.EncodeHTTPError(ctx, f.Failed(), w)
+		return nil
+	}
+	return encodeHTTPAdvert(w, http.StatusCreated, response.(advertendpoint.AdvertResponse).Advert)
+}
+
+func encodeHTTPAdvertResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	if f, ok := response.(advertendpoint.Failer); ok && f.Failed() != nil {
+		apperr.EncodeHTTPError(ctx, f.Failed(), w)
+		return nil
+	}
+	return encodeHTTPAdvert(w, http.StatusOK, response.(advertendpoint.AdvertResponse).Advert)
+}
+
+func encodeHTTPAdvert(w http.ResponseWriter, code int, a advertservice.Advert) error {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(code)
+	m := jsonpb.Marshaler{}
+	return m.Marshal(w, advertToPB(a))
+}
+
+func encodeHTTPAdvertsResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	if f, ok := response.(advertendpoint.Failer); ok && f.Failed() != nil {
+		apperr.EncodeHTTPError(ctx, f.Failed(), w)
+		return nil
+	}
+	page := response.(advertendpoint.AdvertsResponse).Page
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	m := jsonpb.Marshaler{EmitDefaults: true}
+	return m.Marshal(w, advertsToPB(page.Adverts, page.Count))
+}
+
+// EncodeHTTPGenericResponse is a transport/http.EncodeResponseFunc that encodes
+// the response as JSON to the response writer
+func EncodeHTTPGenericResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	if f, ok := response.(advertendpoint.Failer); ok && f.Failed() != nil {
+		apperr.EncodeHTTPError(ctx, f.Failed(), w)
+		return nil
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	return json.NewEncoder(w).Encode(response)
+}
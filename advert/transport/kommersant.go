@@ -0,0 +1,73 @@
+package transport
+
+import (
+	"context"
+
+	"microsrv/advert/service"
+	"microsrv/apperr"
+	"microsrv/pb"
+	"microsrv/schedule"
+
+	"github.com/golang/protobuf/ptypes"
+)
+
+// NewKommersantPublisher returns an advertservice.Publisher calling a
+// remote kommersant service.
+func NewKommersantPublisher(client pb.KommersantClient) advertservice.Publisher {
+	return kommersantPublisher{client}
+}
+
+type kommersantPublisher struct {
+	client pb.KommersantClient
+}
+
+// Publish implements advertservice.Publisher.
+func (p kommersantPublisher) Publish(ctx context.Context, adNum string, sch *schedule.Request) (advertservice.Publication, error) {
+	req := &pb.KommersantRequest{AdNum: adNum}
+	if sch != nil {
+		req.Schedule = &pb.Schedule{
+			Type:      pb.Type(sch.Type),
+			Intervals: sch.Intervals,
+			Max:       int32(sch.Max),
+		}
+		if sch.FirstRun != nil {
+			ts, err := ptypes.TimestampProto(*sch.FirstRun)
+			if err != nil {
+				return advertservice.Publication{}, apperr.NewValidation(err.Error(), apperr.Field{Field: "schedule.first_run", Description: "is out of range"})
+			}
+			req.Schedule.FirstRun = ts
+		}
+	}
+	res, err := p.client.Create(ctx, req)
+	if err != nil {
+		return advertservice.Publication{}, apperr.FromGRPC(err)
+	}
+	return publicationFromPB(adNum, res)
+}
+
+// Status implements advertservice.Publisher.
+func (p kommersantPublisher) Status(ctx context.Context, adNum string) (advertservice.Publication, error) {
+	res, err := p.client.Result(ctx, &pb.KommersantRequest{AdNum: adNum})
+	if err != nil {
+		return advertservice.Publication{}, apperr.FromGRPC(err)
+	}
+	return publicationFromPB(adNum, res)
+}
+
+func publicationFromPB(adNum string, res *pb.KommersantResponse) (advertservice.Publication, error) {
+	pub := advertservice.Publication{
+		AdNum:   adNum,
+		State:   res.State,
+		IssueNo: res.IssueNo,
+		Message: res.Message,
+		Job:     res.Job,
+	}
+	if res.PublishedAt != nil {
+		t, err := ptypes.Timestamp(res.PublishedAt)
+		if err != nil {
+			return pub, err
+		}
+		pub.PublishedAt = &t
+	}
+	return pub, nil
+}
@@ -0,0 +1,546 @@
+package transport
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"microsrv/advert/endpoint"
+	"microsrv/advert/workflow"
+	"microsrv/apperr"
+	"microsrv/pb"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/log"
+	grpctransport "github.com/go-kit/kit/transport/grpc"
+	httptransport "github.com/go-kit/kit/transport/http"
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/gorilla/mux"
+	oldcontext "golang.org/x/net/context"
+)
+
+type taskGRPCServer struct {
+	setTemplate      grpctransport.Handler
+	getTemplate      grpctransport.Handler
+	instantiateTasks grpctransport.Handler
+	listTasks        grpctransport.Handler
+	assignTask       grpctransport.Handler
+	completeTask     grpctransport.Handler
+	reopenTask       grpctransport.Handler
+	attachToTask     grpctransport.Handler
+	overdueTasks     grpctransport.Handler
+}
+
+// NewTaskGRPCServer makes a set of endpoints available as a gRPC
+// TaskSvcServer.
+func NewTaskGRPCServer(endpoints advertendpoint.TaskEndpoints, logger log.Logger) pb.TaskSvcServer {
+	options := []grpctransport.ServerOption{
+		grpctransport.ServerErrorLogger(logger),
+	}
+
+	return &taskGRPCServer{
+		setTemplate: grpctransport.NewServer(
+			endpoints.SetTemplateEndpoint,
+			decodeGRPCTaskTemplate,
+			encodeGRPCTemplateResponse,
+			options...,
+		),
+		getTemplate: grpctransport.NewServer(
+			endpoints.GetTemplateEndpoint,
+			decodeGRPCPassThrough,
+			encodeGRPCTemplateResponse,
+			options...,
+		),
+		instantiateTasks: grpctransport.NewServer(
+			endpoints.InstantiateTasksEndpoint,
+			decodeGRPCPassThrough,
+			encodeGRPCTasksResponse,
+			options...,
+		),
+		listTasks: grpctransport.NewServer(
+			endpoints.ListTasksEndpoint,
+			decodeGRPCPassThrough,
+			encodeGRPCTasksResponse,
+			options...,
+		),
+		assignTask: grpctransport.NewServer(
+			endpoints.AssignTaskEndpoint,
+			decodeGRPCTaskTransition,
+			encodeGRPCTaskResponse,
+			options...,
+		),
+		completeTask: grpctransport.NewServer(
+			endpoints.CompleteTaskEndpoint,
+			decodeGRPCTaskTransition,
+			encodeGRPCTaskResponse,
+			options...,
+		),
+		reopenTask: grpctransport.NewServer(
+			endpoints.ReopenTaskEndpoint,
+			decodeGRPCTaskTransition,
+			encodeGRPCTaskResponse,
+			options...,
+		),
+		attachToTask: grpctransport.NewServer(
+			endpoints.AttachToTaskEndpoint,
+			decodeGRPCAttachment,
+			encodeGRPCAttachmentResponse,
+			options...,
+		),
+		overdueTasks: grpctransport.NewServer(
+			endpoints.OverdueTasksEndpoint,
+			decodeGRPCOverdueTasks,
+			encodeGRPCTasksResponse,
+			options...,
+		),
+	}
+}
+
+// serve runs the handler and converts its error to a gRPC status.
+func serve(ctx oldcontext.Context, h grpctransport.Handler, req proto.Message) (interface{}, error) {
+	_, res, err := h.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, apperr.ToGRPC(err)
+	}
+	return res, nil
+}
+
+// SetTemplate implementation of the method of the TaskSvcServer interface.
+func (s *taskGRPCServer) SetTemplate(ctx oldcontext.Context, req *pb.TaskTemplate) (*pb.TaskTemplate, error) {
+	res, err := serve(ctx, s.setTemplate, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.TaskTemplate), nil
+}
+
+// GetTemplate implementation of the method of the TaskSvcServer interface.
+func (s *taskGRPCServer) GetTemplate(ctx oldcontext.Context, req *pb.TaskTemplateByAdvertType) (*pb.TaskTemplate, error) {
+	res, err := serve(ctx, s.getTemplate, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.TaskTemplate), nil
+}
+
+// InstantiateTasks implementation of the method of the TaskSvcServer
+// interface.
+func (s *taskGRPCServer) InstantiateTasks(ctx oldcontext.Context, req *pb.InstantiateTasksRequest) (*pb.TaskLists, error) {
+	res, err := serve(ctx, s.instantiateTasks, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.TaskLists), nil
+}
+
+// ListTasks implementation of the method of the TaskSvcServer interface.
+func (s *taskGRPCServer) ListTasks(ctx oldcontext.Context, req *pb.AdvertByID) (*pb.TaskLists, error) {
+	res, err := serve(ctx, s.listTasks, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.TaskLists), nil
+}
+
+// AssignTask implementation of the method of the TaskSvcServer interface.
+func (s *taskGRPCServer) AssignTask(ctx oldcontext.Context, req *pb.TaskTransition) (*pb.TaskList, error) {
+	res, err := serve(ctx, s.assignTask, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.TaskList), nil
+}
+
+// CompleteTask implementation of the method of the TaskSvcServer interface.
+func (s *taskGRPCServer) CompleteTask(ctx oldcontext.Context, req *pb.TaskTransition) (*pb.TaskList, error) {
+	res, err := serve(ctx, s.completeTask, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.TaskList), nil
+}
+
+// ReopenTask implementation of the method of the TaskSvcServer interface.
+func (s *taskGRPCServer) ReopenTask(ctx oldcontext.Context, req *pb.TaskTransition) (*pb.TaskList, error) {
+	res, err := serve(ctx, s.reopenTask, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.TaskList), nil
+}
+
+// AttachToTask implementation of the method of the TaskSvcServer interface.
+// The task is selected by the taskListID of the attachment.
+func (s *taskGRPCServer) AttachToTask(ctx oldcontext.Context, req *pb.Attachment) (*pb.Attachment, error) {
+	res, err := serve(ctx, s.attachToTask, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.Attachment), nil
+}
+
+// OverdueTasks implementation of the method of the TaskSvcServer interface.
+func (s *taskGRPCServer) OverdueTasks(ctx oldcontext.Context, req *pb.OverdueTasksRequest) (*pb.TaskLists, error) {
+	res, err := serve(ctx, s.overdueTasks, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.TaskLists), nil
+}
+
+func decodeGRPCPassThrough(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	return grpcReq, nil
+}
+
+func decodeGRPCTaskTemplate(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	return templateFromPB(grpcReq.(*pb.TaskTemplate)), nil
+}
+
+func decodeGRPCTaskTransition(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	return transitionFromPB(grpcReq.(*pb.TaskTransition)), nil
+}
+
+func decodeGRPCAttachment(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.Attachment)
+	return advertendpoint.AttachRequest{ID: uint(req.TaskListID), Attachment: attachmentFromPB(req)}, nil
+}
+
+func decodeGRPCOverdueTasks(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.OverdueTasksRequest)
+	res := advertendpoint.OverdueRequest{}
+	if req.At != nil {
+		at, err := ptypes.Timestamp(req.At)
+		if err != nil {
+			return nil, apperr.NewValidation(err.Error(), apperr.Field{Field: "at", Description: "is out of range"})
+		}
+		res.At = at
+	}
+	return res, nil
+}
+
+func encodeGRPCTemplateResponse(_ context.Context, response interface{}) (interface{}, error) {
+	result := response.(advertendpoint.TemplateResponse)
+	if result.Err != nil {
+		return nil, result.Err
+	}
+	return templateToPB(result.AdvertTypeID, result.Steps), nil
+}
+
+func encodeGRPCTasksResponse(_ context.Context, response interface{}) (interface{}, error) {
+	result := response.(advertendpoint.TasksResponse)
+	if result.Err != nil {
+		return nil, result.Err
+	}
+	return tasksToPB(result.Tasks), nil
+}
+
+func encodeGRPCTaskResponse(_ context.Context, response interface{}) (interface{}, error) {
+	result := response.(advertendpoint.TaskResponse)
+	if result.Err != nil {
+		return nil, result.Err
+	}
+	return taskToPB(result.Task), nil
+}
+
+func encodeGRPCAttachmentResponse(_ context.Context, response interface{}) (interface{}, error) {
+	result := response.(advertendpoint.AttachmentResponse)
+	if result.Err != nil {
+		return nil, result.Err
+	}
+	return attachmentToPB(result.Attachment), nil
+}
+
+func templateFromPB(t *pb.TaskTemplate) advertendpoint.TemplateRequest {
+	res := advertendpoint.TemplateRequest{AdvertTypeID: uint(t.AdvertTypeID), Steps: make([]workflow.Step, len(t.Steps))}
+	for i, st := range t.Steps {
+		res.Steps[i] = workflow.Step{
+			Sequence:         uint(st.Sequence),
+			Name:             st.Name,
+			Mandatory:        st.Mandatory,
+			IsBidCode:        st.Isbidcode,
+			AttachmentTypeID: uint(st.AttachmentTypeID),
+			DueDays:          uint(st.DueDays),
+		}
+	}
+	return res
+}
+
+func templateToPB(advertTypeID uint, steps []workflow.Step) *pb.TaskTemplate {
+	res := &pb.TaskTemplate{AdvertTypeID: uint32(advertTypeID), Steps: make([]*pb.TaskStep, len(steps))}
+	for i, st := range steps {
+		res.Steps[i] = &pb.TaskStep{
+			ID:               uint32(st.ID),
+			Sequence:         uint32(st.Sequence),
+			Name:             st.Name,
+			Mandatory:        st.Mandatory,
+			Isbidcode:        st.IsBidCode,
+			AttachmentTypeID: uint32(st.AttachmentTypeID),
+			DueDays:          uint32(st.DueDays),
+		}
+	}
+	return res
+}
+
+func transitionFromPB(t *pb.TaskTransition) advertendpoint.TransitionRequest {
+	return advertendpoint.TransitionRequest{
+		ID: uint(t.TaskID),
+		Transition: workflow.Transition{
+			UserID:  uint(t.UserID),
+			DoerID:  uint(t.DoerID),
+			Comment: t.Comment,
+		},
+	}
+}
+
+func tasksToPB(tasks []workflow.Task) *pb.TaskLists {
+	res := &pb.TaskLists{Tasks: make([]*pb.TaskList, len(tasks)), Complete: workflow.Done(tasks)}
+	for i, t := range tasks {
+		res.Tasks[i] = taskToPB(t)
+	}
+	return res
+}
+
+func taskToPB(t workflow.Task) *pb.TaskList {
+	res := &pb.TaskList{
+		ID:               uint32(t.ID),
+		OrganisationID:   uint32(t.OrganisationID),
+		Complete:         t.Complete,
+		Sequence:         uint32(t.Sequence),
+		Mandatory:        t.Mandatory,
+		Isbidcode:        t.IsBidCode,
+		Name:             t.Name,
+		AttachmentTypeID: uint32(t.AttachmentTypeID),
+		DoerID:           uint32(t.DoerID),
+		StepID:           uint32(t.StepID),
+		Attachment:       make([]*pb.Attachment, len(t.Attachments)),
+		Histories:        make([]*pb.TaskHistory, len(t.Histories)),
+	}
+	res.UpdatedAt, _ = ptypes.TimestampProto(t.UpdatedAt)
+	if t.DueAt != nil {
+		res.DueAt, _ = ptypes.TimestampProto(*t.DueAt)
+	}
+	for i, a := range t.Attachments {
+		res.Attachment[i] = attachmentToPB(a)
+	}
+	for i, h := range t.Histories {
+		res.Histories[i] = &pb.TaskHistory{
+			ID:         uint32(h.ID),
+			TaskListID: int32(h.TaskListID),
+			UserID:     uint32(h.UserID),
+			Comment:    h.Comment,
+			Complete:   h.Complete,
+			Mandatory:  h.Mandatory,
+			Action:     h.Action,
+		}
+		res.Histories[i].UpdatedAt, _ = ptypes.TimestampProto(h.UpdatedAt)
+	}
+	return res
+}
+
+func attachmentFromPB(a *pb.Attachment) workflow.Attachment {
+	return workflow.Attachment{
+		AttachmentTypeID: uint(a.AttachmentTypeID),
+		OriginalFileName: a.OriginalFileName,
+		File:             a.File,
+		Comment:          a.Comment,
+		CreatorID:        uint(a.CreatorID),
+	}
+}
+
+func attachmentToPB(a workflow.Attachment) *pb.Attachment {
+	res := &pb.Attachment{
+		ID:               uint32(a.ID),
+		OrganisationID:   uint32(a.OrganisationID),
+		TaskListID:       uint32(a.TaskListID),
+		AttachmentTypeID: uint32(a.AttachmentTypeID),
+		OriginalFileName: a.OriginalFileName,
+		File:             a.File,
+		Comment:          a.Comment,
+		CreatorID:        uint32(a.CreatorID),
+	}
+	res.UpdatedAt, _ = ptypes.TimestampProto(a.UpdatedAt)
+	return res
+}
+
+// addTaskRoutes makes the task endpoints available on m.
+func addTaskRoutes(m *mux.Router, endpoints advertendpoint.TaskEndpoints, options []httptransport.ServerOption) {
+	// GET    /task-templates/{advert_type_id} retrieves the task list template
+	//                                         of the advert type
+	// PUT    /task-templates/{advert_type_id} replaces the template, body
+	//                                         {steps}
+	// GET    /adverts/{id}/tasks              retrieves the task list of the
+	//                                         advert
+	// POST   /adverts/{id}/tasks              creates the task list of the
+	//                                         advert, body {advertTypeID}
+	// POST   /tasks/{id}/assign               assigns the task, body
+	//                                         {userID, doerID, comment}
+	// POST   /tasks/{id}/complete             completes the task, body
+	//                                         {userID, comment}
+	// POST   /tasks/{id}/reopen               reopens the task, body
+	//                                         {userID, comment}
+	// POST   /tasks/{id}/attachments          adds an attachment to the task
+	// GET    /tasks/overdue?at                retrieves the tasks overdue at
+	//                                         the RFC 3339 time, now by default
+
+	m.Methods("GET").Path("/task-templates/{advert_type_id}").Handler(httptransport.NewServer(
+		endpoints.GetTemplateEndpoint,
+		decodeHTTPTemplateByAdvertTypeRequest,
+		encodeHTTPProtoResponse(encodeGRPCTemplateResponse),
+		options...,
+	))
+	m.Methods("PUT").Path("/task-templates/{advert_type_id}").Handler(httptransport.NewServer(
+		endpoints.SetTemplateEndpoint,
+		decodeHTTPSetTemplateRequest,
+		encodeHTTPProtoResponse(encodeGRPCTemplateResponse),
+		options...,
+	))
+	m.Methods("GET").Path("/adverts/{id}/tasks").Handler(httptransport.NewServer(
+		endpoints.ListTasksEndpoint,
+		decodeHTTPAdvertByIDRequest,
+		encodeHTTPProtoResponse(encodeGRPCTasksResponse),
+		options...,
+	))
+	m.Methods("POST").Path("/adverts/{id}/tasks").Handler(httptransport.NewServer(
+		endpoints.InstantiateTasksEndpoint,
+		decodeHTTPInstantiateTasksRequest,
+		encodeHTTPProtoResponse(encodeGRPCTasksResponse),
+		options...,
+	))
+	for action, e := range map[string]endpoint.Endpoint{
+		"assign":   endpoints.AssignTaskEndpoint,
+		"complete": endpoints.CompleteTaskEndpoint,
+		"reopen":   endpoints.ReopenTaskEndpoint,
+	} {
+		m.Methods("POST").Path("/tasks/{id}/" + action).Handler(httptransport.NewServer(
+			e,
+			decodeHTTPTaskTransitionRequest,
+			encodeHTTPProtoResponse(encodeGRPCTaskResponse),
+			options...,
+		))
+	}
+	m.Methods("POST").Path("/tasks/{id}/attachments").Handler(httptransport.NewServer(
+		endpoints.AttachToTaskEndpoint,
+		decodeHTTPAttachmentRequest,
+		encodeHTTPProtoResponse(encodeGRPCAttachmentResponse),
+		options...,
+	))
+	m.Methods("GET").Path("/tasks/overdue").Handler(httptransport.NewServer(
+		endpoints.OverdueTasksEndpoint,
+		decodeHTTPOverdueTasksRequest,
+		encodeHTTPProtoResponse(encodeGRPCTasksResponse),
+		options...,
+	))
+}
+
+func decodeHTTPTemplateByAdvertTypeRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	id, err := pathID(r, "advert_type_id")
+	if err != nil {
+		return nil, err
+	}
+	return &pb.TaskTemplateByAdvertType{AdvertTypeID: uint32(id)}, nil
+}
+
+func decodeHTTPSetTemplateRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	id, err := pathID(r, "advert_type_id")
+	if err != nil {
+		return nil, err
+	}
+	req := &pb.TaskTemplate{}
+	if err := unmarshalHTTP(r, req); err != nil {
+		return nil, err
+	}
+	req.AdvertTypeID = uint32(id)
+	return templateFromPB(req), nil
+}
+
+func decodeHTTPInstantiateTasksRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	id, err := advertID(r)
+	if err != nil {
+		return nil, err
+	}
+	req := &pb.InstantiateTasksRequest{}
+	if err := unmarshalHTTP(r, req); err != nil {
+		return nil, err
+	}
+	req.AdvertID = uint32(id)
+	return req, nil
+}
+
+func decodeHTTPTaskTransitionRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		return nil, err
+	}
+	req := &pb.TaskTransition{}
+	if err := unmarshalHTTP(r, req); err != nil {
+		return nil, err
+	}
+	req.TaskID = uint32(id)
+	return transitionFromPB(req), nil
+}
+
+func decodeHTTPAttachmentRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		return nil, err
+	}
+	req := &pb.Attachment{}
+	if err := unmarshalHTTP(r, req); err != nil {
+		return nil, err
+	}
+	return advertendpoint.AttachRequest{ID: id, Attachment: attachmentFromPB(req)}, nil
+}
+
+func decodeHTTPOverdueTasksRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	req := advertendpoint.OverdueRequest{}
+	if s := r.URL.Query().Get("at"); s != "" {
+		at, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			return nil, badRequest(err)
+		}
+		req.At = at
+	}
+	return req, nil
+}
+
+// unmarshalHTTP reads a JSON document from the request body into m.
+func unmarshalHTTP(r *http.Request, m proto.Message) error {
+	u := jsonpb.Unmarshaler{AllowUnknownFields: true}
+	if err := u.Unmarshal(r.Body, m); err != nil {
+		return badRequest(err)
+	}
+	return nil
+}
+
+func pathID(r *http.Request, name string) (uint, error) {
+	id, ok := mux.Vars(r)[name]
+	if !ok {
+		return 0, ErrBadRouting
+	}
+	n, err := strconv.ParseUint(id, 10, 32)
+	if err != nil {
+		return 0, badRequest(err)
+	}
+	return uint(n), nil
+}
+
+// encodeHTTPProtoResponse returns a transport/http.EncodeResponseFunc
+// writing the message made by encode as JSON.
+func encodeHTTPProtoResponse(encode func(context.Context, interface{}) (interface{}, error)) httptransport.EncodeResponseFunc {
+	return func(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+		if f, ok := response.(advertendpoint.Failer); ok && f.Failed() != nil {
+			apperr.EncodeHTTPError(ctx, f.Failed(), w)
+			return nil
+		}
+		res, err := encode(ctx, response)
+		if err != nil {
+			return err
+		}
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		m := jsonpb.Marshaler{EmitDefaults: true}
+		return m.Marshal(w, res.(proto.Message))
+	}
+}
@@ -0,0 +1,159 @@
+package workflow
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/kit/log"
+)
+
+// Middleware describes a service (as opposed to endpoint) middleware.
+type Middleware func(Service) Service
+
+// LoggingMiddleware takes a logger as a dependency and returns a ServiceMiddleware.
+func LoggingMiddleware(logger log.Logger) Middleware {
+	return func(next Service) Service {
+		return loggingMiddleware{next, logger}
+	}
+}
+
+type loggingMiddleware struct {
+	next   Service
+	logger log.Logger
+}
+
+// Health func
+func (mw loggingMiddleware) Health() bool {
+	defer func(begin time.Time) {
+		mw.logger.Log(
+			"method", "Health",
+			"healthy", true,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return mw.next.Health()
+}
+
+// SetTemplate func
+func (mw loggingMiddleware) SetTemplate(ctx context.Context, advertTypeID uint, steps []Step) (res []Step, err error) {
+	defer func(begin time.Time) {
+		mw.logger.Log(
+			"method", "SetTemplate",
+			"AdvertType.ID", advertTypeID,
+			"steps", len(steps),
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return mw.next.SetTemplate(ctx, advertTypeID, steps)
+}
+
+// Template func
+func (mw loggingMiddleware) Template(ctx context.Context, advertTypeID uint) ([]Step, error) {
+	defer func(begin time.Time) {
+		mw.logger.Log(
+			"method", "Template",
+			"AdvertType.ID", advertTypeID,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return mw.next.Template(ctx, advertTypeID)
+}
+
+// Instantiate func
+func (mw loggingMiddleware) Instantiate(ctx context.Context, advertID, advertTypeID uint) (res []Task, err error) {
+	defer func(begin time.Time) {
+		mw.logger.Log(
+			"method", "Instantiate",
+			"Advert.ID", advertID,
+			"AdvertType.ID", advertTypeID,
+			"tasks", len(res),
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return mw.next.Instantiate(ctx, advertID, advertTypeID)
+}
+
+// Tasks func
+func (mw loggingMiddleware) Tasks(ctx context.Context, advertID uint) ([]Task, error) {
+	defer func(begin time.Time) {
+		mw.logger.Log(
+			"method", "Tasks",
+			"Advert.ID", advertID,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return mw.next.Tasks(ctx, advertID)
+}
+
+// Assign func
+func (mw loggingMiddleware) Assign(ctx context.Context, id uint, t Transition) (res Task, err error) {
+	defer func(begin time.Time) {
+		mw.logger.Log(
+			"method", "Assign",
+			"Task.ID", id,
+			"User.ID", t.UserID,
+			"Doer.ID", t.DoerID,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return mw.next.Assign(ctx, id, t)
+}
+
+// Complete func
+func (mw loggingMiddleware) Complete(ctx context.Context, id uint, t Transition) (res Task, err error) {
+	defer func(begin time.Time) {
+		mw.logger.Log(
+			"method", "Complete",
+			"Task.ID", id,
+			"User.ID", t.UserID,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return mw.next.Complete(ctx, id, t)
+}
+
+// Reopen func
+func (mw loggingMiddleware) Reopen(ctx context.Context, id uint, t Transition) (res Task, err error) {
+	defer func(begin time.Time) {
+		mw.logger.Log(
+			"method", "Reopen",
+			"Task.ID", id,
+			"User.ID", t.UserID,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return mw.next.Reopen(ctx, id, t)
+}
+
+// Attach func
+func (mw loggingMiddleware) Attach(ctx context.Context, id uint, a Attachment) (res Attachment, err error) {
+	defer func(begin time.Time) {
+		mw.logger.Log(
+			"method", "Attach",
+			"Task.ID", id,
+			"Attachment.ID", res.ID,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return mw.next.Attach(ctx, id, a)
+}
+
+// Overdue func
+func (mw loggingMiddleware) Overdue(ctx context.Context, at time.Time) (res []Task, err error) {
+	defer func(begin time.Time) {
+		mw.logger.Log(
+			"method", "Overdue",
+			"at", at,
+			"tasks", len(res),
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return mw.next.Overdue(ctx, at)
+}
@@ -0,0 +1,259 @@
+package workflow
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"microsrv/apperr"
+)
+
+// Service drives the task lists of the adverts.
+type Service interface {
+	Health() bool
+	// SetTemplate replaces the task list template of the advert type.
+	SetTemplate(ctx context.Context, advertTypeID uint, steps []Step) ([]Step, error)
+	Template(ctx context.Context, advertTypeID uint) ([]Step, error)
+	// Instantiate creates the task list of the advert from the template of
+	// its type.
+	Instantiate(ctx context.Context, advertID, advertTypeID uint) ([]Task, error)
+	// Tasks returns the task list of the advert, in sequence order.
+	Tasks(ctx context.Context, advertID uint) ([]Task, error)
+	Assign(ctx context.Context, id uint, t Transition) (Task, error)
+	// Complete completes the task once the mandatory tasks of the lower
+	// sequences are complete and the attachment it requires is added.
+	Complete(ctx context.Context, id uint, t Transition) (Task, error)
+	// Reopen reopens the task while no task of a greater sequence is
+	// complete.
+	Reopen(ctx context.Context, id uint, t Transition) (Task, error)
+	Attach(ctx context.Context, id uint, a Attachment) (Attachment, error)
+	// Overdue returns the tasks that are not complete at the given time
+	// after their due time, the oldest first.
+	Overdue(ctx context.Context, at time.Time) ([]Task, error)
+}
+
+// Transition of a task made by the user. DoerID is the user assigned to the
+// task by Assign.
+type Transition struct {
+	UserID  uint
+	DoerID  uint
+	Comment string
+}
+
+var (
+	// ErrTaskNotFound var
+	ErrTaskNotFound = apperr.NewNotFound("task not found")
+	// ErrNoTemplate var
+	ErrNoTemplate = apperr.NewNotFound("no task list template for the advert type")
+	// ErrTasksExist var
+	ErrTasksExist = apperr.NewConflict("advert already has a task list")
+	// ErrAlreadyComplete var
+	ErrAlreadyComplete = apperr.NewConflict("task is already complete")
+	// ErrNotComplete var
+	ErrNotComplete = apperr.NewConflict("task is not complete")
+	// ErrOutOfSequence var
+	ErrOutOfSequence = apperr.NewConflict("mandatory tasks of a lower sequence are not complete")
+	// ErrLaterComplete var
+	ErrLaterComplete = apperr.NewConflict("tasks of a greater sequence are complete")
+	// ErrAttachmentRequired var
+	ErrAttachmentRequired = apperr.NewConflict("task requires an attachment of its type")
+	// ErrNotDoer var
+	ErrNotDoer = apperr.NewPermission("task is assigned to another user")
+	// ErrNoUser var
+	ErrNoUser = apperr.NewValidation("user is required", apperr.Field{Field: "userID", Description: "is required"})
+)
+
+// NewBasicService returns a Service keeping the task lists in the store.
+func NewBasicService(store Store) Service {
+	return basicService{store}
+}
+
+type basicService struct {
+	store Store
+}
+
+// Health implementation of the Service.
+func (s basicService) Health() bool {
+	return s.store.Ping() == nil
+}
+
+func (s basicService) SetTemplate(ctx context.Context, advertTypeID uint, steps []Step) ([]Step, error) {
+	if err := ValidateTemplate(advertTypeID, steps); err != nil {
+		return nil, err
+	}
+	for i := range steps {
+		steps[i].ID = 0
+		steps[i].AdvertTypeID = advertTypeID
+	}
+	sort.SliceStable(steps, func(i, j int) bool { return steps[i].Sequence < steps[j].Sequence })
+	if err := s.store.SetTemplate(ctx, advertTypeID, steps); err != nil {
+		return nil, err
+	}
+	return s.store.Template(ctx, advertTypeID)
+}
+
+func (s basicService) Template(ctx context.Context, advertTypeID uint) ([]Step, error) {
+	steps, err := s.store.Template(ctx, advertTypeID)
+	if err == nil && len(steps) == 0 {
+		return nil, ErrNoTemplate
+	}
+	return steps, err
+}
+
+func (s basicService) Instantiate(ctx context.Context, advertID, advertTypeID uint) ([]Task, error) {
+	steps, err := s.Template(ctx, advertTypeID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	tasks := make([]Task, len(steps))
+	for i, st := range steps {
+		tasks[i] = Task{
+			OrganisationID:   advertID,
+			StepID:           st.ID,
+			Sequence:         st.Sequence,
+			Name:             st.Name,
+			Mandatory:        st.Mandatory,
+			IsBidCode:        st.IsBidCode,
+			AttachmentTypeID: st.AttachmentTypeID,
+		}
+		if st.DueDays > 0 {
+			due := now.AddDate(0, 0, int(st.DueDays))
+			tasks[i].DueAt = &due
+		}
+	}
+	if err := s.store.CreateTasks(ctx, advertID, tasks); err != nil {
+		return nil, err
+	}
+	return s.store.Tasks(ctx, advertID)
+}
+
+func (s basicService) Tasks(ctx context.Context, advertID uint) ([]Task, error) {
+	return s.store.Tasks(ctx, advertID)
+}
+
+func (s basicService) Assign(ctx context.Context, id uint, tr Transition) (Task, error) {
+	if tr.UserID == 0 {
+		return Task{}, ErrNoUser
+	}
+	return s.store.Update(ctx, id, func(t *Task, _ []Task) (History, error) {
+		if t.Complete {
+			return History{}, ErrAlreadyComplete
+		}
+		t.DoerID = tr.DoerID
+		return history(*t, tr, Assigned), nil
+	})
+}
+
+func (s basicService) Complete(ctx context.Context, id uint, tr Transition) (Task, error) {
+	if tr.UserID == 0 {
+		return Task{}, ErrNoUser
+	}
+	return s.store.Update(ctx, id, func(t *Task, siblings []Task) (History, error) {
+		switch {
+		case t.Complete:
+			return History{}, ErrAlreadyComplete
+		case t.DoerID != 0 && t.DoerID != tr.UserID:
+			return History{}, ErrNotDoer
+		case t.AttachmentTypeID != 0 && !t.hasAttachment():
+			return History{}, ErrAttachmentRequired
+		}
+		for _, o := range siblings {
+			if o.Mandatory && !o.Complete && o.Sequence < t.Sequence {
+				return History{}, ErrOutOfSequence
+			}
+		}
+		t.Complete = true
+		return history(*t, tr, Completed), nil
+	})
+}
+
+func (s basicService) Reopen(ctx context.Context, id uint, tr Transition) (Task, error) {
+	if tr.UserID == 0 {
+		return Task{}, ErrNoUser
+	}
+	return s.store.Update(ctx, id, func(t *Task, siblings []Task) (History, error) {
+		if !t.Complete {
+			return History{}, ErrNotComplete
+		}
+		for _, o := range siblings {
+			if o.Complete && o.Sequence > t.Sequence {
+				return History{}, ErrLaterComplete
+			}
+		}
+		t.Complete = false
+		return history(*t, tr, Reopened), nil
+	})
+}
+
+func (s basicService) Attach(ctx context.Context, id uint, a Attachment) (Attachment, error) {
+	if err := ValidateAttachment(a); err != nil {
+		return Attachment{}, err
+	}
+	t, err := s.store.Task(ctx, id)
+	if err != nil {
+		return Attachment{}, err
+	}
+	a.ID = 0
+	a.TaskListID = t.ID
+	a.OrganisationID = t.OrganisationID
+	if err := s.store.AddAttachment(ctx, &a); err != nil {
+		return Attachment{}, err
+	}
+	return a, nil
+}
+
+func (s basicService) Overdue(ctx context.Context, at time.Time) ([]Task, error) {
+	if at.IsZero() {
+		at = time.Now()
+	}
+	return s.store.Overdue(ctx, at)
+}
+
+func history(t Task, tr Transition, action string) History {
+	return History{
+		TaskListID: t.ID,
+		UserID:     tr.UserID,
+		Action:     action,
+		Comment:    tr.Comment,
+		Complete:   t.Complete,
+		Mandatory:  t.Mandatory,
+		UpdatedAt:  time.Now(),
+	}
+}
+
+// ValidateTemplate requires the advert type and a named step at least.
+func ValidateTemplate(advertTypeID uint, steps []Step) error {
+	fields := []apperr.Field{}
+	if advertTypeID == 0 {
+		fields = append(fields, apperr.Field{Field: "advertTypeID", Description: "is required"})
+	}
+	if len(steps) == 0 {
+		fields = append(fields, apperr.Field{Field: "steps", Description: "is required"})
+	}
+	for _, st := range steps {
+		if st.Name == "" {
+			fields = append(fields, apperr.Field{Field: "steps.name", Description: "is required"})
+			break
+		}
+	}
+	if len(fields) > 0 {
+		return apperr.NewValidation("invalid task list template", fields...)
+	}
+	return nil
+}
+
+// ValidateAttachment requires the type and the file of the attachment.
+func ValidateAttachment(a Attachment) error {
+	fields := []apperr.Field{}
+	if a.AttachmentTypeID == 0 {
+		fields = append(fields, apperr.Field{Field: "attachmentTypeID", Description: "is required"})
+	}
+	if a.File == "" {
+		fields = append(fields, apperr.Field{Field: "file", Description: "is required"})
+	}
+	if len(fields) > 0 {
+		return apperr.NewValidation("invalid attachment", fields...)
+	}
+	return nil
+}
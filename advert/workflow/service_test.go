@@ -0,0 +1,190 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// newTaskList instantiates the task list of advert 1 from a template given
+// out of sequence order: check (1), publish and notify (2), close (3).
+func newTaskList(t *testing.T) (Service, []Task) {
+	s := NewBasicService(NewMemStore())
+	ctx := context.Background()
+	steps := []Step{
+		{Sequence: 3, Name: "close", Mandatory: true, DueDays: 10},
+		{Sequence: 1, Name: "check", Mandatory: true},
+		{Sequence: 2, Name: "publish", Mandatory: true, AttachmentTypeID: 4, DueDays: 3},
+		{Sequence: 2, Name: "notify"},
+	}
+	if _, err := s.SetTemplate(ctx, 9, steps); err != nil {
+		t.Fatal(err)
+	}
+	tasks, err := s.Instantiate(ctx, 1, 9)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s, tasks
+}
+
+func names(tasks []Task) []string {
+	res := []string{}
+	for _, t := range tasks {
+		res = append(res, t.Name)
+	}
+	return res
+}
+
+func TestInstantiate(t *testing.T) {
+	s, tasks := newTaskList(t)
+	ctx := context.Background()
+	if got, want := names(tasks), "[check publish notify close]"; fmt.Sprint(got) != want {
+		t.Fatalf("tasks %v, want %s", got, want)
+	}
+	for _, task := range tasks {
+		if task.OrganisationID != 1 || task.StepID == 0 || task.Complete {
+			t.Errorf("task %+v", task)
+		}
+		if len(task.Histories) != 1 || task.Histories[0].Action != Created || task.Histories[0].Mandatory != task.Mandatory {
+			t.Errorf("%s histories %+v", task.Name, task.Histories)
+		}
+	}
+	if tasks[0].DueAt != nil || tasks[1].DueAt == nil || time.Until(*tasks[1].DueAt) < 71*time.Hour {
+		t.Errorf("due times %v, %v", tasks[0].DueAt, tasks[1].DueAt)
+	}
+	if _, err := s.Instantiate(ctx, 1, 9); err != ErrTasksExist {
+		t.Errorf("second Instantiate: %v, want ErrTasksExist", err)
+	}
+	if _, err := s.Instantiate(ctx, 2, 8); err != ErrNoTemplate {
+		t.Errorf("Instantiate without template: %v, want ErrNoTemplate", err)
+	}
+}
+
+func TestComplete(t *testing.T) {
+	s, tasks := newTaskList(t)
+	ctx := context.Background()
+	check, publish, notify, closing := tasks[0].ID, tasks[1].ID, tasks[2].ID, tasks[3].ID
+	user := Transition{UserID: 5, Comment: "done"}
+
+	if _, err := s.Complete(ctx, check, Transition{}); err != ErrNoUser {
+		t.Errorf("Complete without user: %v, want ErrNoUser", err)
+	}
+	if _, err := s.Complete(ctx, notify, user); err != ErrOutOfSequence {
+		t.Errorf("Complete before the mandatory check: %v, want ErrOutOfSequence", err)
+	}
+	if _, err := s.Assign(ctx, check, Transition{UserID: 5, DoerID: 6}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Complete(ctx, check, user); err != ErrNotDoer {
+		t.Errorf("Complete by another user: %v, want ErrNotDoer", err)
+	}
+	task, err := s.Complete(ctx, check, Transition{UserID: 6, Comment: "checked"})
+	if err != nil || !task.Complete {
+		t.Fatalf("Complete = %+v, %v", task, err)
+	}
+	last := task.Histories[len(task.Histories)-1]
+	if len(task.Histories) != 3 || last.Action != Completed || last.UserID != 6 || last.Comment != "checked" || !last.Complete || !last.Mandatory {
+		t.Errorf("histories %+v", task.Histories)
+	}
+	if _, err := s.Complete(ctx, check, Transition{UserID: 6}); err != ErrAlreadyComplete {
+		t.Errorf("Complete of a complete task: %v, want ErrAlreadyComplete", err)
+	}
+
+	// Tasks of the same sequence are done in any order, an optional one
+	// does not hold the next sequence.
+	if _, err := s.Complete(ctx, notify, user); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Complete(ctx, publish, user); err != ErrAttachmentRequired {
+		t.Errorf("Complete without attachment: %v, want ErrAttachmentRequired", err)
+	}
+	if _, err := s.Attach(ctx, publish, Attachment{AttachmentTypeID: 3, File: "other.pdf"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Complete(ctx, publish, user); err != ErrAttachmentRequired {
+		t.Errorf("Complete with an attachment of another type: %v, want ErrAttachmentRequired", err)
+	}
+	if _, err := s.Complete(ctx, closing, user); err != ErrOutOfSequence {
+		t.Errorf("Complete before the mandatory publish: %v, want ErrOutOfSequence", err)
+	}
+	a, err := s.Attach(ctx, publish, Attachment{AttachmentTypeID: 4, File: "notice.pdf"})
+	if err != nil || a.TaskListID != publish || a.OrganisationID != 1 {
+		t.Fatalf("Attach = %+v, %v", a, err)
+	}
+	if _, err := s.Complete(ctx, publish, user); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Complete(ctx, closing, user); err != nil {
+		t.Fatal(err)
+	}
+	if tasks, _ := s.Tasks(ctx, 1); !Done(tasks) {
+		t.Errorf("task list is not done: %+v", tasks)
+	}
+	if _, err := s.Complete(ctx, 100, user); err != ErrTaskNotFound {
+		t.Errorf("Complete of a missing task: %v, want ErrTaskNotFound", err)
+	}
+}
+
+func TestReopen(t *testing.T) {
+	s, tasks := newTaskList(t)
+	ctx := context.Background()
+	check, notify := tasks[0].ID, tasks[2].ID
+	user := Transition{UserID: 5}
+
+	if _, err := s.Reopen(ctx, check, user); err != ErrNotComplete {
+		t.Errorf("Reopen of an open task: %v, want ErrNotComplete", err)
+	}
+	for _, id := range []uint{check, notify} {
+		if _, err := s.Complete(ctx, id, user); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := s.Reopen(ctx, check, user); err != ErrLaterComplete {
+		t.Errorf("Reopen before a later task: %v, want ErrLaterComplete", err)
+	}
+	if _, err := s.Reopen(ctx, notify, Transition{UserID: 7, Comment: "wrong"}); err != nil {
+		t.Fatal(err)
+	}
+	task, err := s.Reopen(ctx, check, user)
+	if err != nil || task.Complete {
+		t.Fatalf("Reopen = %+v, %v", task, err)
+	}
+	actions := []string{}
+	for _, h := range task.Histories {
+		actions = append(actions, h.Action)
+	}
+	if fmt.Sprint(actions) != "[created completed reopened]" || task.Histories[2].Complete {
+		t.Errorf("histories %+v", task.Histories)
+	}
+}
+
+func TestOverdue(t *testing.T) {
+	s, tasks := newTaskList(t)
+	ctx := context.Background()
+	publish := tasks[1]
+
+	overdue, err := s.Overdue(ctx, time.Now())
+	if err != nil || len(overdue) != 0 {
+		t.Fatalf("Overdue now = %v, %v", names(overdue), err)
+	}
+	overdue, _ = s.Overdue(ctx, time.Now().AddDate(0, 0, 4))
+	if fmt.Sprint(names(overdue)) != "[publish]" {
+		t.Errorf("Overdue in 4 days = %v, want [publish]", names(overdue))
+	}
+	overdue, _ = s.Overdue(ctx, time.Now().AddDate(0, 0, 11))
+	if fmt.Sprint(names(overdue)) != "[publish close]" {
+		t.Errorf("Overdue in 11 days = %v, want [publish close]", names(overdue))
+	}
+
+	user := Transition{UserID: 5}
+	s.Complete(ctx, tasks[0].ID, user)
+	s.Attach(ctx, publish.ID, Attachment{AttachmentTypeID: 4, File: "notice.pdf"})
+	if _, err := s.Complete(ctx, publish.ID, user); err != nil {
+		t.Fatal(err)
+	}
+	overdue, _ = s.Overdue(ctx, time.Now().AddDate(0, 0, 11))
+	if fmt.Sprint(names(overdue)) != "[close]" {
+		t.Errorf("Overdue after publish = %v, want [close]", names(overdue))
+	}
+}
@@ -0,0 +1,363 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"microsrv/config"
+
+	"github.com/jinzhu/gorm"
+	_ "github.com/jinzhu/gorm/dialects/mysql"    // Mysql driver
+	_ "github.com/jinzhu/gorm/dialects/postgres" // Postgres driver
+)
+
+// Store persists the templates, the task lists and their attachments.
+type Store interface {
+	// SetTemplate replaces the steps of the advert type.
+	SetTemplate(ctx context.Context, advertTypeID uint, steps []Step) error
+	Template(ctx context.Context, advertTypeID uint) ([]Step, error)
+	// CreateTasks stores the task list of the advert and a Created history
+	// of each task, unless the advert has tasks.
+	CreateTasks(ctx context.Context, advertID uint, tasks []Task) error
+	Tasks(ctx context.Context, advertID uint) ([]Task, error)
+	Task(ctx context.Context, id uint) (Task, error)
+	// Update calls fn with the task and the other tasks of its advert, then
+	// stores the task and the history returned by fn. Concurrent updates of
+	// a task list are serialized.
+	Update(ctx context.Context, id uint, fn func(t *Task, siblings []Task) (History, error)) (Task, error)
+	AddAttachment(ctx context.Context, a *Attachment) error
+	Overdue(ctx context.Context, at time.Time) ([]Task, error)
+	Ping() error
+}
+
+// NewStore returns the Store selected by cfg.Driver. The tables are
+// created by the migrations of package migrate.
+func NewStore(cfg config.DB) (Store, error) {
+	switch cfg.Driver {
+	case "", "mysql":
+		return NewDB("mysql", cfg.DSN())
+	case "postgres":
+		return NewDB("postgres", cfg.DSN())
+	case "memory":
+		return NewMemStore(), nil
+	default:
+		return nil, fmt.Errorf("unknown database driver %q", cfg.Driver)
+	}
+}
+
+type databaseStore struct{ db *gorm.DB }
+
+// NewDB returns a Store backed by MySQL or PostgreSQL.
+func NewDB(dialect, DSN string) (Store, error) {
+	db, err := gorm.Open(dialect, DSN)
+	if err != nil {
+		return nil, err
+	}
+	return &databaseStore{db: db}, nil
+}
+
+func (ds *databaseStore) SetTemplate(ctx context.Context, advertTypeID uint, steps []Step) error {
+	return ds.inTx(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("advert_type_id = ?", advertTypeID).Delete(Step{}).Error; err != nil {
+			return err
+		}
+		for i := range steps {
+			if err := tx.Create(&steps[i]).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (ds *databaseStore) Template(ctx context.Context, advertTypeID uint) ([]Step, error) {
+	steps := []Step{}
+	err := ds.db.Where("advert_type_id = ?", advertTypeID).Order("sequence, id").Find(&steps).Error
+	return steps, err
+}
+
+func (ds *databaseStore) CreateTasks(ctx context.Context, advertID uint, tasks []Task) error {
+	return ds.inTx(func(tx *gorm.DB) error {
+		count := 0
+		if err := tx.Model(&Task{}).Where("organisation_id = ?", advertID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrTasksExist
+		}
+		for i := range tasks {
+			if err := tx.Create(&tasks[i]).Error; err != nil {
+				return err
+			}
+			h := History{TaskListID: tasks[i].ID, Action: Created, Mandatory: tasks[i].Mandatory}
+			if err := tx.Create(&h).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (ds *databaseStore) Tasks(ctx context.Context, advertID uint) ([]Task, error) {
+	tasks := []Task{}
+	err := ds.preload(ds.db).
+		Where("organisation_id = ?", advertID).
+		Order("sequence, id").
+		Find(&tasks).
+		Error
+	return tasks, err
+}
+
+func (ds *databaseStore) Task(ctx context.Context, id uint) (Task, error) {
+	t := Task{}
+	err := ds.preload(ds.db).First(&t, id).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return t, ErrTaskNotFound
+	}
+	return t, err
+}
+
+func (ds *databaseStore) Update(ctx context.Context, id uint, fn func(t *Task, siblings []Task) (History, error)) (Task, error) {
+	err := ds.inTx(func(tx *gorm.DB) error {
+		t := Task{}
+		err := tx.Set("gorm:query_option", "FOR UPDATE").First(&t, id).Error
+		if gorm.IsRecordNotFoundError(err) {
+			return ErrTaskNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(&t).Related(&t.Attachments, "TaskListID").Error; err != nil {
+			return err
+		}
+		siblings := []Task{}
+		err = tx.Set("gorm:query_option", "FOR UPDATE").
+			Where("organisation_id = ? AND id <> ?", t.OrganisationID, t.ID).
+			Find(&siblings).
+			Error
+		if err != nil {
+			return err
+		}
+		h, err := fn(&t, siblings)
+		if err != nil {
+			return err
+		}
+		err = tx.Model(&t).Updates(map[string]interface{}{
+			"complete": t.Complete,
+			"doer_id":  t.DoerID,
+		}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Create(&h).Error
+	})
+	if err != nil {
+		return Task{}, err
+	}
+	return ds.Task(ctx, id)
+}
+
+func (ds *databaseStore) AddAttachment(ctx context.Context, a *Attachment) error {
+	return ds.db.Create(a).Error
+}
+
+func (ds *databaseStore) Overdue(ctx context.Context, at time.Time) ([]Task, error) {
+	tasks := []Task{}
+	err := ds.db.
+		Where("complete = ? AND due_at IS NOT NULL AND due_at < ?", false, at).
+		Order("due_at, id").
+		Find(&tasks).
+		Error
+	return tasks, err
+}
+
+func (ds *databaseStore) Ping() error {
+	return ds.db.DB().Ping()
+}
+
+// preload adds the histories and the attachments of the tasks to q.
+func (ds *databaseStore) preload(q *gorm.DB) *gorm.DB {
+	byID := func(db *gorm.DB) *gorm.DB { return db.Order("id") }
+	return q.Preload("Histories", byID).Preload("Attachments", byID)
+}
+
+func (ds *databaseStore) inTx(fn func(tx *gorm.DB) error) error {
+	tx := ds.db.Begin()
+	if tx.Error != nil {
+		return tx.Error
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit().Error
+}
+
+type memStore struct {
+	mtx         sync.RWMutex
+	steps       map[uint][]Step
+	tasks       map[uint]Task
+	histories   []History
+	attachments []Attachment
+	nextID      uint
+}
+
+// NewMemStore returns an in-memory Store.
+func NewMemStore() Store {
+	return &memStore{steps: map[uint][]Step{}, tasks: map[uint]Task{}}
+}
+
+// id returns the next identifier. Must be called with mtx held.
+func (s *memStore) id() uint {
+	s.nextID++
+	return s.nextID
+}
+
+func (s *memStore) SetTemplate(ctx context.Context, advertTypeID uint, steps []Step) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	now := time.Now()
+	stored := make([]Step, len(steps))
+	for i, st := range steps {
+		st.ID = s.id()
+		st.CreatedAt, st.UpdatedAt = now, now
+		steps[i] = st
+		stored[i] = st
+	}
+	s.steps[advertTypeID] = stored
+	return nil
+}
+
+func (s *memStore) Template(ctx context.Context, advertTypeID uint) ([]Step, error) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	steps := append([]Step{}, s.steps[advertTypeID]...)
+	sort.SliceStable(steps, func(i, j int) bool { return steps[i].Sequence < steps[j].Sequence })
+	return steps, nil
+}
+
+func (s *memStore) CreateTasks(ctx context.Context, advertID uint, tasks []Task) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	for _, t := range s.tasks {
+		if t.OrganisationID == advertID {
+			return ErrTasksExist
+		}
+	}
+	now := time.Now()
+	for i := range tasks {
+		t := &tasks[i]
+		t.ID = s.id()
+		t.CreatedAt, t.UpdatedAt = now, now
+		s.tasks[t.ID] = *t
+		s.histories = append(s.histories, History{ID: s.id(), TaskListID: t.ID, Action: Created, Mandatory: t.Mandatory, UpdatedAt: now})
+	}
+	return nil
+}
+
+func (s *memStore) Tasks(ctx context.Context, advertID uint) ([]Task, error) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	tasks := []Task{}
+	for _, t := range s.tasks {
+		if t.OrganisationID == advertID {
+			tasks = append(tasks, s.preload(t))
+		}
+	}
+	sort.Slice(tasks, func(i, j int) bool {
+		if tasks[i].Sequence != tasks[j].Sequence {
+			return tasks[i].Sequence < tasks[j].Sequence
+		}
+		return tasks[i].ID < tasks[j].ID
+	})
+	return tasks, nil
+}
+
+func (s *memStore) Task(ctx context.Context, id uint) (Task, error) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	t, ok := s.tasks[id]
+	if !ok {
+		return Task{}, ErrTaskNotFound
+	}
+	return s.preload(t), nil
+}
+
+// preload adds the histories and the attachments to the task. Must be
+// called with mtx held.
+func (s *memStore) preload(t Task) Task {
+	t.Histories = []History{}
+	for _, h := range s.histories {
+		if h.TaskListID == t.ID {
+			t.Histories = append(t.Histories, h)
+		}
+	}
+	t.Attachments = []Attachment{}
+	for _, a := range s.attachments {
+		if a.TaskListID == t.ID {
+			t.Attachments = append(t.Attachments, a)
+		}
+	}
+	return t
+}
+
+func (s *memStore) Update(ctx context.Context, id uint, fn func(t *Task, siblings []Task) (History, error)) (Task, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	t, ok := s.tasks[id]
+	if !ok {
+		return Task{}, ErrTaskNotFound
+	}
+	t = s.preload(t)
+	siblings := []Task{}
+	for _, o := range s.tasks {
+		if o.OrganisationID == t.OrganisationID && o.ID != t.ID {
+			siblings = append(siblings, o)
+		}
+	}
+	h, err := fn(&t, siblings)
+	if err != nil {
+		return Task{}, err
+	}
+	t.UpdatedAt = time.Now()
+	t.Histories, t.Attachments = nil, nil
+	s.tasks[id] = t
+	h.ID = s.id()
+	s.histories = append(s.histories, h)
+	return s.preload(t), nil
+}
+
+func (s *memStore) AddAttachment(ctx context.Context, a *Attachment) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	now := time.Now()
+	a.ID = s.id()
+	a.CreatedAt, a.UpdatedAt = now, now
+	s.attachments = append(s.attachments, *a)
+	return nil
+}
+
+func (s *memStore) Overdue(ctx context.Context, at time.Time) ([]Task, error) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	tasks := []Task{}
+	for _, t := range s.tasks {
+		if t.Overdue(at) {
+			tasks = append(tasks, t)
+		}
+	}
+	sort.Slice(tasks, func(i, j int) bool {
+		if !tasks[i].DueAt.Equal(*tasks[j].DueAt) {
+			return tasks[i].DueAt.Before(*tasks[j].DueAt)
+		}
+		return tasks[i].ID < tasks[j].ID
+	})
+	return tasks, nil
+}
+
+func (s *memStore) Ping() error {
+	return nil
+}
@@ -0,0 +1,119 @@
+package workflow
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// Step of the task list template of an advert type. Steps with the same
+// sequence may be done in any order, a mandatory step must be complete
+// before the steps of a greater sequence. DueDays, when not zero, is the
+// number of days the task has from its creation.
+type Step struct {
+	gorm.Model
+	AdvertTypeID     uint
+	Sequence         uint
+	Name             string
+	Mandatory        bool
+	IsBidCode        bool
+	AttachmentTypeID uint
+	DueDays          uint
+}
+
+// TableName of the steps.
+func (Step) TableName() string {
+	return "task_templates"
+}
+
+// Task of the task list of an advert, made from a Step. The attachment
+// type, when not zero, requires an attachment of that type before the task
+// is complete.
+type Task struct {
+	gorm.Model
+	OrganisationID   uint // the advert
+	StepID           uint
+	Sequence         uint
+	Name             string
+	Mandatory        bool
+	IsBidCode        bool
+	AttachmentTypeID uint
+	Complete         bool
+	DoerID           uint
+	DueAt            *time.Time
+	Histories        []History    `gorm:"foreignkey:TaskListID"`
+	Attachments      []Attachment `gorm:"foreignkey:TaskListID"`
+}
+
+// TableName of the tasks.
+func (Task) TableName() string {
+	return "task_lists"
+}
+
+// Overdue reports whether the task is not complete after its due time.
+func (t Task) Overdue(at time.Time) bool {
+	return !t.Complete && t.DueAt != nil && t.DueAt.Before(at)
+}
+
+// hasAttachment reports whether the task has an attachment of its type.
+func (t Task) hasAttachment() bool {
+	for _, a := range t.Attachments {
+		if a.AttachmentTypeID == t.AttachmentTypeID {
+			return true
+		}
+	}
+	return false
+}
+
+// History records a transition of a task, Complete being its state after
+// the transition.
+type History struct {
+	ID         uint `gorm:"primary_key"`
+	TaskListID uint
+	UserID     uint
+	Action     string
+	Comment    string `gorm:"type:text"`
+	Complete   bool
+	Mandatory  bool
+	UpdatedAt  time.Time
+}
+
+// TableName of the histories.
+func (History) TableName() string {
+	return "task_histories"
+}
+
+// Actions of the histories.
+const (
+	Created   = "created"
+	Assigned  = "assigned"
+	Completed = "completed"
+	Reopened  = "reopened"
+)
+
+// Attachment of a task. File references the stored content.
+type Attachment struct {
+	gorm.Model
+	OrganisationID   uint // the advert
+	TaskListID       uint
+	AttachmentTypeID uint
+	OriginalFileName string
+	File             string
+	Comment          string `gorm:"type:text"`
+	CreatorID        uint
+}
+
+// TableName of the attachments.
+func (Attachment) TableName() string {
+	return "attachments"
+}
+
+// Done reports whether the mandatory tasks of a task list are complete.
+func Done(tasks []Task) bool {
+	for _, t := range tasks {
+		if t.Mandatory && !t.Complete {
+			return false
+		}
+	}
+	return true
+}
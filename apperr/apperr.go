@@ -0,0 +1,107 @@
+// Package apperr defines the typed errors shared by the services and their
+// translation to gRPC and HTTP responses.
+package apperr
+
+import "strings"
+
+// Kind classifies an error.
+type Kind int8
+
+const (
+	// Internal is an unexpected failure, it is the kind of any untyped error.
+	Internal = Kind(0)
+	// NotFound means the requested entity does not exist.
+	NotFound = Kind(1)
+	// Validation means the request is malformed or has invalid fields.
+	Validation = Kind(2)
+	// Conflict means the request contradicts the current state.
+	Conflict = Kind(3)
+	// Unavailable means a dependency is temporarily unavailable.
+	Unavailable = Kind(4)
+	// Permission means the caller is not allowed to make the request.
+	Permission = Kind(5)
+)
+
+// KindName map
+var KindName = map[Kind]string{
+	Internal:    "internal",
+	NotFound:    "not_found",
+	Validation:  "validation",
+	Conflict:    "conflict",
+	Unavailable: "unavailable",
+	Permission:  "permission",
+}
+
+func (k Kind) String() string {
+	return KindName[k]
+}
+
+// Field describes a problem with a single request field.
+type Field struct {
+	Field       string `json:"field"`
+	Description string `json:"description"`
+}
+
+// Error is a typed error.
+type Error struct {
+	Kind    Kind
+	Message string
+	Fields  []Field
+}
+
+func (e *Error) Error() string {
+	if len(e.Fields) == 0 {
+		return e.Message
+	}
+	fields := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		fields[i] = f.Field + ": " + f.Description
+	}
+	return e.Message + " (" + strings.Join(fields, "; ") + ")"
+}
+
+// New returns an error of the kind.
+func New(kind Kind, message string, fields ...Field) *Error {
+	return &Error{Kind: kind, Message: message, Fields: fields}
+}
+
+// NewNotFound returns a NotFound error.
+func NewNotFound(message string) *Error {
+	return New(NotFound, message)
+}
+
+// NewValidation returns a Validation error with field details.
+func NewValidation(message string, fields ...Field) *Error {
+	return New(Validation, message, fields...)
+}
+
+// NewConflict returns a Conflict error.
+func NewConflict(message string) *Error {
+	return New(Conflict, message)
+}
+
+// NewUnavailable returns an Unavailable error.
+func NewUnavailable(message string) *Error {
+	return New(Unavailable, message)
+}
+
+// NewPermission returns a Permission error.
+func NewPermission(message string) *Error {
+	return New(Permission, message)
+}
+
+// KindOf returns the kind of err, Internal for untyped errors.
+func KindOf(err error) Kind {
+	if e, ok := err.(*Error); ok {
+		return e.Kind
+	}
+	return Internal
+}
+
+// FieldsOf returns the field details of err.
+func FieldsOf(err error) []Field {
+	if e, ok := err.(*Error); ok {
+		return e.Fields
+	}
+	return nil
+}
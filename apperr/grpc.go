@@ -0,0 +1,81 @@
+package apperr
+
+import (
+	"context"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var kindCode = map[Kind]codes.Code{
+	Internal:    codes.Internal,
+	NotFound:    codes.NotFound,
+	Validation:  codes.InvalidArgument,
+	Conflict:    codes.FailedPrecondition,
+	Unavailable: codes.Unavailable,
+	Permission:  codes.PermissionDenied,
+}
+
+// GRPCCode returns the gRPC status code for err.
+func GRPCCode(err error) codes.Code {
+	return kindCode[KindOf(err)]
+}
+
+// ToGRPC converts err into a gRPC status error. Field details are attached
+// as errdetails.BadRequest. Errors that already are gRPC statuses are
+// returned unchanged, context errors get the Canceled and DeadlineExceeded
+// codes.
+func ToGRPC(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	switch err {
+	case context.Canceled:
+		return status.Error(codes.Canceled, err.Error())
+	case context.DeadlineExceeded:
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	}
+	st := status.New(GRPCCode(err), err.Error())
+	fields := FieldsOf(err)
+	if len(fields) == 0 {
+		return st.Err()
+	}
+	br := &errdetails.BadRequest{}
+	for _, f := range fields {
+		br.FieldViolations = append(br.FieldViolations, &errdetails.BadRequest_FieldViolation{
+			Field:       f.Field,
+			Description: f.Description,
+		})
+	}
+	if detailed, e := st.WithDetails(br); e == nil {
+		st = detailed
+	}
+	return st.Err()
+}
+
+// FromGRPC converts a gRPC status error received by a client back to Error.
+func FromGRPC(err error) error {
+	st, ok := status.FromError(err)
+	if !ok || err == nil {
+		return err
+	}
+	kind := Internal
+	for k, c := range kindCode {
+		if c == st.Code() {
+			kind = k
+		}
+	}
+	e := New(kind, st.Message())
+	for _, d := range st.Details() {
+		if br, ok := d.(*errdetails.BadRequest); ok {
+			for _, v := range br.FieldViolations {
+				e.Fields = append(e.Fields, Field{Field: v.Field, Description: v.Description})
+			}
+		}
+	}
+	return e
+}
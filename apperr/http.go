@@ -0,0 +1,57 @@
+package apperr
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+)
+
+var kindStatus = map[Kind]int{
+	Internal:    http.StatusInternalServerError,
+	NotFound:    http.StatusNotFound,
+	Validation:  http.StatusBadRequest,
+	Conflict:    http.StatusConflict,
+	Unavailable: http.StatusServiceUnavailable,
+	Permission:  http.StatusForbidden,
+}
+
+// HTTPStatus returns the HTTP status code for err.
+func HTTPStatus(err error) int {
+	return kindStatus[KindOf(err)]
+}
+
+// Problem is an RFC 7807 problem details body.
+type Problem struct {
+	Type   string  `json:"type"`
+	Title  string  `json:"title"`
+	Status int     `json:"status"`
+	Detail string  `json:"detail,omitempty"`
+	Kind   string  `json:"kind"`
+	Fields []Field `json:"fields,omitempty"`
+}
+
+// NewProblem describes err as a Problem.
+func NewProblem(err error) Problem {
+	code := HTTPStatus(err)
+	detail := err.Error()
+	if e, ok := err.(*Error); ok {
+		detail = e.Message
+	}
+	return Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(code),
+		Status: code,
+		Detail: detail,
+		Kind:   KindOf(err).String(),
+		Fields: FieldsOf(err),
+	}
+}
+
+// EncodeHTTPError is a transport/http.ErrorEncoder that writes err as an
+// application/problem+json response.
+func EncodeHTTPError(_ context.Context, err error, w http.ResponseWriter) {
+	p := NewProblem(err)
+	w.Header().Set("Content-Type", "application/problem+json; charset=utf-8")
+	w.WriteHeader(p.Status)
+	json.NewEncoder(w).Encode(p)
+}
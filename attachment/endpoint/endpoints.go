@@ -0,0 +1,137 @@
+package attachmentendpoint
+
+import (
+	"context"
+	"io"
+
+	"microsrv/attachment/service"
+	"microsrv/pb"
+
+	"github.com/go-kit/kit/endpoint"
+)
+
+// Endpoints struct
+type Endpoints struct {
+	HealthEndpoint     endpoint.Endpoint // used by Consul for the healthcheck
+	UploadEndpoint     endpoint.Endpoint
+	DownloadEndpoint   endpoint.Endpoint
+	GetFileEndpoint    endpoint.Endpoint
+	DeleteFileEndpoint endpoint.Endpoint
+}
+
+// MakeServerEndpoints func
+func MakeServerEndpoints(s attachmentservice.Service) Endpoints {
+	return Endpoints{
+		HealthEndpoint:     HealthEndpoint(s),
+		UploadEndpoint:     UploadEndpoint(s),
+		DownloadEndpoint:   DownloadEndpoint(s),
+		GetFileEndpoint:    GetFileEndpoint(s),
+		DeleteFileEndpoint: DeleteFileEndpoint(s),
+	}
+}
+
+// compile time assertions for our response types implementing endpoint.Failer.
+var (
+	_ endpoint.Failer = HealthResponse{}
+	_ endpoint.Failer = FileResponse{}
+	_ endpoint.Failer = DownloadResponse{}
+	_ endpoint.Failer = DeleteResponse{}
+)
+
+// HealthEndpoint constructs a Health endpoint wrapping the service.
+func HealthEndpoint(s attachmentservice.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		healthy := s.Health()
+		return HealthResponse{Healthy: healthy}, nil
+	}
+}
+
+// UploadEndpoint func
+func UploadEndpoint(s attachmentservice.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(UploadRequest)
+		res, e := s.Upload(ctx, req.File, req.Body)
+		return FileResponse{File: res, Err: e}, nil
+	}
+}
+
+// DownloadEndpoint func. The response body is to be closed by the
+// transport once sent.
+func DownloadEndpoint(s attachmentservice.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(*pb.FileByID)
+		f, body, e := s.Open(ctx, uint(req.ID))
+		return DownloadResponse{File: f, Body: body, Err: e}, nil
+	}
+}
+
+// GetFileEndpoint func
+func GetFileEndpoint(s attachmentservice.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(*pb.FileByID)
+		res, e := s.GetFile(ctx, uint(req.ID))
+		return FileResponse{File: res, Err: e}, nil
+	}
+}
+
+// DeleteFileEndpoint func
+func DeleteFileEndpoint(s attachmentservice.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(*pb.FileByID)
+		e := s.DeleteFile(ctx, uint(req.ID))
+		return DeleteResponse{Err: e}, nil
+	}
+}
+
+// Failer is an interface that should be implemented by response types.
+// Response encoders can check if responses are Failer, and if so if they've
+// failed, and if so encode them using a separate write path based on the error.
+type Failer interface {
+	Failed() error
+}
+
+// UploadRequest collects the request parameters for the Upload method.
+type UploadRequest struct {
+	File attachmentservice.File
+	Body io.Reader
+}
+
+// FileResponse collects the response values of the methods returning a
+// file.
+type FileResponse struct {
+	File attachmentservice.File `json:"file"`
+	Err  error                  `json:"err,omitempty"`
+}
+
+// Failed implements Failer.
+func (r FileResponse) Failed() error { return r.Err }
+
+// DownloadResponse collects the response values for the Open method.
+type DownloadResponse struct {
+	File attachmentservice.File
+	Body io.ReadCloser
+	Err  error `json:"err,omitempty"`
+}
+
+// Failed implements Failer.
+func (r DownloadResponse) Failed() error { return r.Err }
+
+// DeleteResponse collects the response values for the DeleteFile method.
+type DeleteResponse struct {
+	Err error `json:"err,omitempty"`
+}
+
+// Failed implements Failer.
+func (r DeleteResponse) Failed() error { return r.Err }
+
+// HealthRequest collects the request parameters for the Health method.
+type HealthRequest struct{}
+
+// HealthResponse collects the response values for the Health method.
+type HealthResponse struct {
+	Healthy bool  `json:"healthy,omitempty"`
+	Err     error `json:"err,omitempty"`
+}
+
+// Failed implements Failer.
+func (r HealthResponse) Failed() error { return r.Err }
@@ -0,0 +1,56 @@
+package attachmentservice
+
+import (
+	"mime"
+	"strings"
+
+	"github.com/jinzhu/gorm"
+)
+
+// File is an uploaded content. Contents are stored once under their hash,
+// the files with the same content share it.
+type File struct {
+	gorm.Model
+	Hash             string `gorm:"size:64"`
+	Size             int64
+	ContentType      string
+	OriginalFileName string
+	CreatorID        uint
+	// Duplicate reports that the content was already stored when the file
+	// was uploaded.
+	Duplicate bool `gorm:"-"`
+}
+
+// TableName of the files.
+func (File) TableName() string {
+	return "files"
+}
+
+// Limits of the uploads. Types are media types like application/pdf, or
+// prefixes like image/ that allow every media type they start; any type is
+// allowed when Types is empty. Uploads are checked against the type sniffed
+// by http.DetectContentType too, which reports office documents as
+// application/zip and text formats as text/plain. A zero MaxSize does not
+// limit the size.
+type Limits struct {
+	MaxSize int64
+	Types   []string
+}
+
+// Allowed reports whether the media type of the content type is allowed.
+func (l Limits) Allowed(contentType string) bool {
+	if len(l.Types) == 0 {
+		return true
+	}
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	for _, t := range l.Types {
+		t = strings.ToLower(strings.TrimSpace(t))
+		if t == mt || strings.HasSuffix(t, "/") && strings.HasPrefix(mt, t) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,90 @@
+package attachmentservice
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/go-kit/kit/log"
+)
+
+// Middleware describes a service (as opposed to endpoint) middleware.
+type Middleware func(Service) Service
+
+// LoggingMiddleware takes a logger as a dependency and returns a ServiceMiddleware.
+func LoggingMiddleware(logger log.Logger) Middleware {
+	return func(next Service) Service {
+		return loggingMiddleware{next, logger}
+	}
+}
+
+type loggingMiddleware struct {
+	next   Service
+	logger log.Logger
+}
+
+// Health func
+func (mw loggingMiddleware) Health() bool {
+	defer func(begin time.Time) {
+		mw.logger.Log(
+			"method", "Health",
+			"healthy", true,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return mw.next.Health()
+}
+
+// Upload func
+func (mw loggingMiddleware) Upload(ctx context.Context, f File, r io.Reader) (res File, err error) {
+	defer func(begin time.Time) {
+		mw.logger.Log(
+			"method", "Upload",
+			"File.ID", res.ID,
+			"name", f.OriginalFileName,
+			"size", res.Size,
+			"duplicate", res.Duplicate,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return mw.next.Upload(ctx, f, r)
+}
+
+// Open func
+func (mw loggingMiddleware) Open(ctx context.Context, id uint) (res File, rc io.ReadCloser, err error) {
+	defer func(begin time.Time) {
+		mw.logger.Log(
+			"method", "Open",
+			"File.ID", id,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return mw.next.Open(ctx, id)
+}
+
+// GetFile func
+func (mw loggingMiddleware) GetFile(ctx context.Context, id uint) (File, error) {
+	defer func(begin time.Time) {
+		mw.logger.Log(
+			"method", "GetFile",
+			"File.ID", id,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return mw.next.GetFile(ctx, id)
+}
+
+// DeleteFile func
+func (mw loggingMiddleware) DeleteFile(ctx context.Context, id uint) (err error) {
+	defer func(begin time.Time) {
+		mw.logger.Log(
+			"method", "DeleteFile",
+			"File.ID", id,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return mw.next.DeleteFile(ctx, id)
+}
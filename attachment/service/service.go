@@ -0,0 +1,170 @@
+package attachmentservice
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"microsrv/apperr"
+	"microsrv/blob"
+)
+
+// Service stores the uploaded files.
+type Service interface {
+	Health() bool
+	// Upload stores the content of r as the file f, of which the original
+	// name, the content type and the creator are kept. The content type is
+	// guessed from the name or the content when f has none. Both the type
+	// of f and the type sniffed from the content must be allowed.
+	Upload(ctx context.Context, f File, r io.Reader) (File, error)
+	// Open returns the file and its content, to be closed by the caller.
+	Open(ctx context.Context, id uint) (File, io.ReadCloser, error)
+	GetFile(ctx context.Context, id uint) (File, error)
+	// DeleteFile deletes the file, and its content unless another file has
+	// it.
+	DeleteFile(ctx context.Context, id uint) error
+}
+
+var (
+	// ErrNotFound var
+	ErrNotFound = apperr.NewNotFound("file not found")
+	// ErrEmpty var
+	ErrEmpty = apperr.NewValidation("empty file", apperr.Field{Field: "file", Description: "is required"})
+	// ErrTooLarge var
+	ErrTooLarge = apperr.NewValidation("file is too large", apperr.Field{Field: "file", Description: "exceeds the size limit"})
+	// ErrType var
+	ErrType = apperr.NewValidation("file type is not allowed", apperr.Field{Field: "contentType", Description: "is not allowed"})
+	// ErrContentMissing var
+	ErrContentMissing = apperr.NewUnavailable("file content is missing from the storage")
+	// ErrLocked var
+	ErrLocked = apperr.NewUnavailable("file content is locked by another upload or deletion")
+)
+
+// NewBasicService returns a Service keeping the files in the store and
+// their contents in blobs.
+func NewBasicService(store Store, blobs blob.Storage, limits Limits) Service {
+	return basicService{store: store, blobs: blobs, limits: limits}
+}
+
+type basicService struct {
+	store  Store
+	blobs  blob.Storage
+	limits Limits
+}
+
+// Health implementation of the Service.
+func (s basicService) Health() bool {
+	return s.store.Ping() == nil
+}
+
+// Upload spools the content to a temporary file while hashing it, so that
+// the limits are checked and a known content is not stored again.
+func (s basicService) Upload(ctx context.Context, f File, r io.Reader) (File, error) {
+	tmp, err := ioutil.TempFile("", "upload-")
+	if err != nil {
+		return File{}, err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	h := sha256.New()
+	if s.limits.MaxSize > 0 {
+		r = io.LimitReader(r, s.limits.MaxSize+1)
+	}
+	size, err := io.Copy(io.MultiWriter(tmp, h), r)
+	if err != nil {
+		return File{}, err
+	}
+	switch {
+	case size == 0:
+		return File{}, ErrEmpty
+	case s.limits.MaxSize > 0 && size > s.limits.MaxSize:
+		return File{}, ErrTooLarge
+	}
+	head := make([]byte, 512)
+	n, _ := tmp.ReadAt(head, 0)
+	sniffed := http.DetectContentType(head[:n])
+	if f.ContentType == "" {
+		f.ContentType = mime.TypeByExtension(filepath.Ext(f.OriginalFileName))
+	}
+	if f.ContentType == "" {
+		f.ContentType = sniffed
+	}
+	if !s.limits.Allowed(f.ContentType) || !s.limits.Allowed(sniffed) {
+		return File{}, ErrType
+	}
+
+	f.ID = 0
+	f.Hash = hex.EncodeToString(h.Sum(nil))
+	f.Size = size
+	duplicate := false
+	err = s.store.Lock(ctx, f.Hash, func(store Store) error {
+		exists, err := s.blobs.Exists(ctx, contentKey(f.Hash))
+		if err != nil {
+			return err
+		}
+		if !exists {
+			if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+				return err
+			}
+			if err := s.blobs.Put(ctx, contentKey(f.Hash), tmp, size, f.ContentType); err != nil {
+				return err
+			}
+		}
+		duplicate = exists
+		return store.Create(ctx, &f)
+	})
+	if err != nil {
+		return File{}, err
+	}
+	f.Duplicate = duplicate
+	return f, nil
+}
+
+func (s basicService) Open(ctx context.Context, id uint) (File, io.ReadCloser, error) {
+	f, err := s.store.Get(ctx, id)
+	if err != nil {
+		return f, nil, err
+	}
+	rc, err := s.blobs.Get(ctx, contentKey(f.Hash))
+	if err == blob.ErrNotFound {
+		return f, nil, ErrContentMissing
+	}
+	return f, rc, err
+}
+
+func (s basicService) GetFile(ctx context.Context, id uint) (File, error) {
+	return s.store.Get(ctx, id)
+}
+
+// DeleteFile deletes the file and counts the files left with its content
+// under the lock of the hash, so that an upload of the same content either
+// sees the content deleted or keeps it.
+func (s basicService) DeleteFile(ctx context.Context, id uint) error {
+	f, err := s.store.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	return s.store.Lock(ctx, f.Hash, func(store Store) error {
+		if err := store.Delete(ctx, id); err != nil {
+			return err
+		}
+		count, err := store.CountByHash(ctx, f.Hash)
+		if err != nil || count > 0 {
+			return err
+		}
+		return s.blobs.Delete(ctx, contentKey(f.Hash))
+	})
+}
+
+// contentKey is the blob key of the content with the hash, spread over
+// directories by its first byte.
+func contentKey(hash string) string {
+	return "sha256/" + hash[:2] + "/" + hash
+}
@@ -0,0 +1,201 @@
+package attachmentservice
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"microsrv/blob"
+)
+
+func newTestService(t *testing.T, limits Limits) (Service, string, func()) {
+	dir, err := ioutil.TempDir("", "attachment")
+	if err != nil {
+		t.Fatal(err)
+	}
+	blobs, err := blob.NewLocal(dir)
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatal(err)
+	}
+	return NewBasicService(NewMemStore(), blobs, limits), dir, func() { os.RemoveAll(dir) }
+}
+
+// contents returns the number of the contents stored in dir.
+func contents(t *testing.T, dir string) int {
+	n := 0
+	err := filepath.Walk(dir, func(p string, fi os.FileInfo, err error) error {
+		if err == nil && !fi.IsDir() {
+			n++
+		}
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func TestUploadDedup(t *testing.T) {
+	s, dir, done := newTestService(t, Limits{})
+	defer done()
+	ctx := context.Background()
+
+	a, err := s.Upload(ctx, File{OriginalFileName: "a.txt", CreatorID: 3}, strings.NewReader("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Duplicate || a.Size != 5 || a.CreatorID != 3 || !strings.HasPrefix(a.ContentType, "text/plain") {
+		t.Fatalf("first upload %+v", a)
+	}
+	b, err := s.Upload(ctx, File{OriginalFileName: "b.txt"}, strings.NewReader("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !b.Duplicate || b.Hash != a.Hash || b.ID == a.ID {
+		t.Fatalf("second upload %+v", b)
+	}
+	if n := contents(t, dir); n != 1 {
+		t.Fatalf("%d contents stored, want 1", n)
+	}
+
+	if err := s.DeleteFile(ctx, a.ID); err != nil {
+		t.Fatal(err)
+	}
+	if n := contents(t, dir); n != 1 {
+		t.Fatalf("%d contents after deleting a file sharing it, want 1", n)
+	}
+	_, rc, err := s.Open(ctx, b.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _ := ioutil.ReadAll(rc)
+	rc.Close()
+	if string(got) != "hello" {
+		t.Fatalf("content %q", got)
+	}
+	if err := s.DeleteFile(ctx, b.ID); err != nil {
+		t.Fatal(err)
+	}
+	if n := contents(t, dir); n != 0 {
+		t.Fatalf("%d contents after deleting every file, want 0", n)
+	}
+	if _, err := s.GetFile(ctx, b.ID); err != ErrNotFound {
+		t.Fatalf("GetFile of a deleted file: %v", err)
+	}
+}
+
+func TestUploadSizeLimit(t *testing.T) {
+	s, dir, done := newTestService(t, Limits{MaxSize: 10})
+	defer done()
+	ctx := context.Background()
+
+	if _, err := s.Upload(ctx, File{OriginalFileName: "a.txt"}, bytes.NewReader(make([]byte, 11))); err != ErrTooLarge {
+		t.Fatalf("11 bytes: %v, want ErrTooLarge", err)
+	}
+	if _, err := s.Upload(ctx, File{OriginalFileName: "a.txt"}, bytes.NewReader(make([]byte, 10))); err != nil {
+		t.Fatalf("10 bytes: %v", err)
+	}
+	if _, err := s.Upload(ctx, File{OriginalFileName: "a.txt"}, strings.NewReader("")); err != ErrEmpty {
+		t.Fatalf("empty: %v, want ErrEmpty", err)
+	}
+	if n := contents(t, dir); n != 1 {
+		t.Fatalf("%d contents stored, want 1", n)
+	}
+}
+
+func TestUploadTypeLimit(t *testing.T) {
+	s, _, done := newTestService(t, Limits{Types: []string{"application/pdf", "image/"}})
+	defer done()
+	ctx := context.Background()
+	pdf := "%PDF-1.4\n"
+	png := "\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"
+
+	cases := []struct {
+		name    string
+		file    File
+		content string
+		err     error
+		typ     string
+	}{
+		{"pdf", File{OriginalFileName: "a.pdf"}, pdf, nil, "application/pdf"},
+		{"png sniffed", File{}, png, nil, "image/png"},
+		{"declared png", File{OriginalFileName: "a", ContentType: "image/png"}, png, nil, "image/png"},
+		{"text", File{OriginalFileName: "a.txt"}, "hello", ErrType, ""},
+		{"text named pdf", File{OriginalFileName: "a.pdf"}, "hello", ErrType, ""},
+		{"text declared png", File{ContentType: "image/png"}, "hello", ErrType, ""},
+		{"html declared pdf", File{ContentType: "application/pdf"}, "<html><script>", ErrType, ""},
+	}
+	for _, c := range cases {
+		f, err := s.Upload(ctx, c.file, strings.NewReader(c.content))
+		if err != c.err {
+			t.Errorf("%s: %v, want %v", c.name, err, c.err)
+			continue
+		}
+		if err == nil && f.ContentType != c.typ {
+			t.Errorf("%s: type %s, want %s", c.name, f.ContentType, c.typ)
+		}
+	}
+}
+
+func TestLimitsAllowed(t *testing.T) {
+	l := Limits{Types: []string{"application/pdf", " Image/ "}}
+	for typ, want := range map[string]bool{
+		"application/pdf":           true,
+		"application/pdf; x=y":      true,
+		"image/png":                 true,
+		"text/plain; charset=utf-8": false,
+		"application/pdfx":          false,
+		"":                          false,
+	} {
+		if got := l.Allowed(typ); got != want {
+			t.Errorf("Allowed(%q) = %v, want %v", typ, got, want)
+		}
+	}
+	if !(Limits{}).Allowed("anything/at-all") {
+		t.Error("empty Limits rejects a type")
+	}
+}
+
+// TestUploadDeleteRace uploads a content while the last file having it is
+// deleted: the content must be kept for every file left.
+func TestUploadDeleteRace(t *testing.T) {
+	s, _, done := newTestService(t, Limits{})
+	defer done()
+	ctx := context.Background()
+
+	for i := 0; i < 50; i++ {
+		old, err := s.Upload(ctx, File{OriginalFileName: "a.txt"}, strings.NewReader("shared"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		wg := sync.WaitGroup{}
+		wg.Add(2)
+		var created File
+		go func() {
+			defer wg.Done()
+			created, err = s.Upload(ctx, File{OriginalFileName: "b.txt"}, strings.NewReader("shared"))
+		}()
+		go func() {
+			defer wg.Done()
+			s.DeleteFile(ctx, old.ID)
+		}()
+		wg.Wait()
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, rc, err := s.Open(ctx, created.ID)
+		if err != nil {
+			t.Fatalf("round %d: %v", i, err)
+		}
+		rc.Close()
+		if err := s.DeleteFile(ctx, created.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,202 @@
+package attachmentservice
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+
+	"microsrv/config"
+
+	"github.com/jinzhu/gorm"
+	_ "github.com/jinzhu/gorm/dialects/mysql"    // Mysql driver
+	_ "github.com/jinzhu/gorm/dialects/postgres" // Postgres driver
+)
+
+// Store persists the files, their contents are kept by a blob.Storage.
+type Store interface {
+	Create(ctx context.Context, f *File) error
+	Get(ctx context.Context, id uint) (File, error)
+	// CountByHash returns the number of the files with the content hash.
+	CountByHash(ctx context.Context, hash string) (int, error)
+	Delete(ctx context.Context, id uint) error
+	// Lock runs fn while holding a lock on the content hash, shared by
+	// every instance of the service. fn is given the Store to use under
+	// the lock.
+	Lock(ctx context.Context, hash string, fn func(Store) error) error
+	Ping() error
+}
+
+// NewStore returns the Store selected by cfg.Driver. The files table is
+// created by the migrations of package migrate.
+func NewStore(cfg config.DB) (Store, error) {
+	switch cfg.Driver {
+	case "", "mysql":
+		return NewDB("mysql", cfg.DSN())
+	case "postgres":
+		return NewDB("postgres", cfg.DSN())
+	case "memory":
+		return NewMemStore(), nil
+	default:
+		return nil, fmt.Errorf("unknown database driver %q", cfg.Driver)
+	}
+}
+
+type databaseStore struct {
+	db      *gorm.DB
+	dialect string
+}
+
+// NewDB returns a Store backed by MySQL or PostgreSQL.
+func NewDB(dialect, DSN string) (Store, error) {
+	db, err := gorm.Open(dialect, DSN)
+	if err != nil {
+		return nil, err
+	}
+	return &databaseStore{db: db, dialect: dialect}, nil
+}
+
+func (ds *databaseStore) Create(ctx context.Context, f *File) error {
+	return ds.db.Create(f).Error
+}
+
+func (ds *databaseStore) Get(ctx context.Context, id uint) (File, error) {
+	f := File{}
+	err := ds.db.First(&f, id).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return f, ErrNotFound
+	}
+	return f, err
+}
+
+func (ds *databaseStore) CountByHash(ctx context.Context, hash string) (int, error) {
+	count := 0
+	err := ds.db.Model(&File{}).Where("hash = ?", hash).Count(&count).Error
+	return count, err
+}
+
+func (ds *databaseStore) Delete(ctx context.Context, id uint) error {
+	q := ds.db.Delete(File{}, id)
+	if q.Error != nil {
+		return q.Error
+	}
+	if q.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Lock runs fn in a transaction while a connection of its own holds a
+// session lock on the hash, pg_advisory_lock on PostgreSQL and GET_LOCK on
+// MySQL. The lock is released once the transaction is committed, so the
+// next holder sees its changes.
+func (ds *databaseStore) Lock(ctx context.Context, hash string, fn func(Store) error) error {
+	conn, err := ds.db.DB().Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	name := "files:" + hash
+	if len(name) > 64 {
+		name = name[:64]
+	}
+	switch ds.dialect {
+	case "postgres":
+		if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock(hashtext($1))", name); err != nil {
+			return err
+		}
+		defer conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock(hashtext($1))", name)
+	default:
+		locked := sql.NullInt64{}
+		if err := conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, ?)", name, lockTimeout).Scan(&locked); err != nil {
+			return err
+		}
+		if locked.Int64 != 1 {
+			return ErrLocked
+		}
+		defer conn.ExecContext(context.Background(), "SELECT RELEASE_LOCK(?)", name)
+	}
+	tx := ds.db.Begin()
+	if tx.Error != nil {
+		return tx.Error
+	}
+	if err := fn(&databaseStore{db: tx, dialect: ds.dialect}); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit().Error
+}
+
+// lockTimeout is the number of seconds Lock waits for a MySQL lock.
+const lockTimeout = 30
+
+func (ds *databaseStore) Ping() error {
+	return ds.db.DB().Ping()
+}
+
+type memStore struct {
+	mtx    sync.RWMutex
+	files  map[uint]File
+	nextID uint
+	// lock serializes the functions run by Lock.
+	lock sync.Mutex
+}
+
+// NewMemStore returns an in-memory Store.
+func NewMemStore() Store {
+	return &memStore{files: map[uint]File{}}
+}
+
+func (s *memStore) Create(ctx context.Context, f *File) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.nextID++
+	f.ID = s.nextID
+	now := time.Now()
+	f.CreatedAt, f.UpdatedAt, f.DeletedAt = now, now, nil
+	s.files[f.ID] = *f
+	return nil
+}
+
+func (s *memStore) Get(ctx context.Context, id uint) (File, error) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	f, ok := s.files[id]
+	if !ok {
+		return File{}, ErrNotFound
+	}
+	return f, nil
+}
+
+func (s *memStore) CountByHash(ctx context.Context, hash string) (int, error) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	count := 0
+	for _, f := range s.files {
+		if f.Hash == hash {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (s *memStore) Delete(ctx context.Context, id uint) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if _, ok := s.files[id]; !ok {
+		return ErrNotFound
+	}
+	delete(s.files, id)
+	return nil
+}
+
+func (s *memStore) Lock(ctx context.Context, hash string, fn func(Store) error) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return fn(s)
+}
+
+func (s *memStore) Ping() error {
+	return nil
+}
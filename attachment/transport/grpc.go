@@ -0,0 +1,194 @@
+package transport
+
+import (
+	"context"
+	"io"
+
+	"microsrv/apperr"
+	"microsrv/attachment/endpoint"
+	"microsrv/attachment/service"
+	"microsrv/pb"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/log"
+	grpctransport "github.com/go-kit/kit/transport/grpc"
+	"github.com/golang/protobuf/ptypes"
+	oldcontext "golang.org/x/net/context"
+)
+
+// chunkSize is the size of the data of the chunks of a download.
+const chunkSize = 64 << 10
+
+type grpcServer struct {
+	getFile    grpctransport.Handler
+	deleteFile grpctransport.Handler
+	upload     endpoint.Endpoint
+	download   endpoint.Endpoint
+}
+
+// NewGRPCServer makes a set of endpoints available as a gRPC
+// AttachmentSvcServer.
+func NewGRPCServer(endpoints attachmentendpoint.Endpoints, logger log.Logger) pb.AttachmentSvcServer {
+	options := []grpctransport.ServerOption{
+		grpctransport.ServerErrorLogger(logger),
+	}
+
+	return &grpcServer{
+		getFile: grpctransport.NewServer(
+			endpoints.GetFileEndpoint,
+			decodeGRPCFileByID,
+			encodeGRPCFileResponse,
+			options...,
+		),
+		deleteFile: grpctransport.NewServer(
+			endpoints.DeleteFileEndpoint,
+			decodeGRPCFileByID,
+			encodeGRPCDeleteResponse,
+			options...,
+		),
+		upload:   endpoints.UploadEndpoint,
+		download: endpoints.DownloadEndpoint,
+	}
+}
+
+// Upload implementation of the method of the AttachmentSvcServer
+// interface. The chunks are piped to the service as they arrive.
+func (s *grpcServer) Upload(stream pb.AttachmentSvc_UploadServer) error {
+	first, err := stream.Recv()
+	if err == io.EOF {
+		return apperr.ToGRPC(attachmentservice.ErrEmpty)
+	}
+	if err != nil {
+		return err
+	}
+	pr, pw := io.Pipe()
+	defer pr.Close()
+	go func() {
+		chunk := first
+		for {
+			if _, err := pw.Write(chunk.Data); err != nil {
+				return
+			}
+			if chunk, err = stream.Recv(); err == io.EOF {
+				pw.Close()
+				return
+			} else if err != nil {
+				pw.CloseWithError(err)
+				return
+			}
+		}
+	}()
+	res, err := s.upload(stream.Context(), attachmentendpoint.UploadRequest{
+		File: fileFromPB(first.File),
+		Body: pr,
+	})
+	if err == nil {
+		err = res.(attachmentendpoint.FileResponse).Err
+	}
+	if err != nil {
+		return apperr.ToGRPC(err)
+	}
+	return stream.SendAndClose(fileToPB(res.(attachmentendpoint.FileResponse).File))
+}
+
+// Download implementation of the method of the AttachmentSvcServer
+// interface. The first chunk carries the file, the content follows in
+// chunks of 64 KiB.
+func (s *grpcServer) Download(req *pb.FileByID, stream pb.AttachmentSvc_DownloadServer) error {
+	res, err := s.download(stream.Context(), req)
+	if err == nil {
+		err = res.(attachmentendpoint.DownloadResponse).Err
+	}
+	if err != nil {
+		return apperr.ToGRPC(err)
+	}
+	dl := res.(attachmentendpoint.DownloadResponse)
+	defer dl.Body.Close()
+	chunk := &pb.FileChunk{File: fileToPB(dl.File)}
+	buf := make([]byte, chunkSize)
+	for {
+		n, err := io.ReadFull(dl.Body, buf)
+		if n > 0 || chunk.File != nil {
+			chunk.Data = buf[:n]
+			if err := stream.Send(chunk); err != nil {
+				return err
+			}
+			chunk = &pb.FileChunk{}
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil
+		}
+		if err != nil {
+			return apperr.ToGRPC(err)
+		}
+	}
+}
+
+// GetFile implementation of the method of the AttachmentSvcServer
+// interface.
+func (s *grpcServer) GetFile(ctx oldcontext.Context, req *pb.FileByID) (*pb.File, error) {
+	_, res, err := s.getFile.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, apperr.ToGRPC(err)
+	}
+	return res.(*pb.File), nil
+}
+
+// DeleteFile implementation of the method of the AttachmentSvcServer
+// interface.
+func (s *grpcServer) DeleteFile(ctx oldcontext.Context, req *pb.FileByID) (*pb.ErrorResponse, error) {
+	_, res, err := s.deleteFile.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, apperr.ToGRPC(err)
+	}
+	return res.(*pb.ErrorResponse), nil
+}
+
+func decodeGRPCFileByID(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	return grpcReq.(*pb.FileByID), nil
+}
+
+func encodeGRPCFileResponse(_ context.Context, response interface{}) (interface{}, error) {
+	result := response.(attachmentendpoint.FileResponse)
+	if result.Err != nil {
+		return nil, result.Err
+	}
+	return fileToPB(result.File), nil
+}
+
+func encodeGRPCDeleteResponse(_ context.Context, response interface{}) (interface{}, error) {
+	result := response.(attachmentendpoint.DeleteResponse)
+	if result.Err != nil {
+		return nil, result.Err
+	}
+	return &pb.ErrorResponse{}, nil
+}
+
+func fileToPB(f attachmentservice.File) *pb.File {
+	res := &pb.File{
+		ID:               uint32(f.ID),
+		Hash:             f.Hash,
+		Size:             f.Size,
+		ContentType:      f.ContentType,
+		OriginalFileName: f.OriginalFileName,
+		CreatorID:        uint32(f.CreatorID),
+		Duplicate:        f.Duplicate,
+	}
+	if !f.CreatedAt.IsZero() {
+		res.CreatedAt, _ = ptypes.TimestampProto(f.CreatedAt)
+	}
+	return res
+}
+
+// fileFromPB returns the upload parameters of the file, the rest is set by
+// the service.
+func fileFromPB(f *pb.File) attachmentservice.File {
+	if f == nil {
+		return attachmentservice.File{}
+	}
+	return attachmentservice.File{
+		ContentType:      f.ContentType,
+		OriginalFileName: f.OriginalFileName,
+		CreatorID:        uint(f.CreatorID),
+	}
+}
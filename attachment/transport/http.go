@@ -0,0 +1,212 @@
+package transport
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"microsrv/apperr"
+	"microsrv/attachment/endpoint"
+	"microsrv/pb"
+
+	"github.com/go-kit/kit/log"
+	httptransport "github.com/go-kit/kit/transport/http"
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/gorilla/mux"
+)
+
+var (
+	// ErrBadRouting is returned when an expected path variable is missing.
+	ErrBadRouting = apperr.New(apperr.Internal, "inconsistent mapping between route and handler")
+	// ErrNoFile is returned when a multipart upload has no file part.
+	ErrNoFile = apperr.NewValidation("no file in the upload", apperr.Field{Field: "file", Description: "is required"})
+)
+
+// badRequest reports a malformed request.
+func badRequest(err error) error {
+	return apperr.NewValidation(err.Error())
+}
+
+// NewHTTPHandler returns an HTTP handler that makes a set of endpoints
+// available on predefined paths.
+func NewHTTPHandler(endpoints attachmentendpoint.Endpoints, logger log.Logger) http.Handler {
+	m := mux.NewRouter()
+	options := []httptransport.ServerOption{
+		httptransport.ServerErrorEncoder(apperr.EncodeHTTPError),
+		httptransport.ServerErrorLogger(logger),
+	}
+
+	// GET    /health             retrieves service heath information
+	// POST   /files              uploads a file, multipart form with the
+	//                            content in the "file" part, preceded by an
+	//                            optional "creator_id" field
+	// GET    /files/{id}         downloads the content of the given file
+	// GET    /files/{id}/info    retrieves the given file by id
+	// DELETE /files/{id}         removes the given file
+
+	m.Methods("GET").Path("/health").Handler(httptransport.NewServer(
+		endpoints.HealthEndpoint,
+		DecodeHTTPHealthRequest,
+		EncodeHTTPGenericResponse,
+		options...,
+	))
+	m.Methods("POST").Path("/files").Handler(httptransport.NewServer(
+		endpoints.UploadEndpoint,
+		decodeHTTPUploadRequest,
+		encodeHTTPUploadResponse,
+		options...,
+	))
+	m.Methods("GET").Path("/files/{id}").Handler(httptransport.NewServer(
+		endpoints.DownloadEndpoint,
+		decodeHTTPFileByIDRequest,
+		encodeHTTPDownloadResponse,
+		options...,
+	))
+	m.Methods("GET").Path("/files/{id}/info").Handler(httptransport.NewServer(
+		endpoints.GetFileEndpoint,
+		decodeHTTPFileByIDRequest,
+		encodeHTTPFileResponse,
+		options...,
+	))
+	m.Methods("DELETE").Path("/files/{id}").Handler(httptransport.NewServer(
+		endpoints.DeleteFileEndpoint,
+		decodeHTTPFileByIDRequest,
+		EncodeHTTPGenericResponse,
+		options...,
+	))
+	return m
+}
+
+// DecodeHTTPHealthRequest method.
+func DecodeHTTPHealthRequest(_ context.Context, _ *http.Request) (interface{}, error) {
+	return attachmentendpoint.HealthRequest{}, nil
+}
+
+// decodeHTTPUploadRequest streams the "file" part of the multipart form
+// instead of buffering the whole form. The creator is read from the
+// "creator_id" field before the file, or the query. A declared
+// application/octet-stream type is left to the service to guess, any other
+// declared type is checked by the service against the content.
+func decodeHTTPUploadRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	mr, err := r.MultipartReader()
+	if err != nil {
+		return nil, badRequest(err)
+	}
+	req := attachmentendpoint.UploadRequest{}
+	creator := r.URL.Query().Get("creator_id")
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			return nil, ErrNoFile
+		}
+		if err != nil {
+			return nil, badRequest(err)
+		}
+		switch part.FormName() {
+		case "creator_id":
+			v, err := readField(part)
+			if err != nil {
+				return nil, err
+			}
+			creator = v
+		case "file":
+			if creator != "" {
+				n, err := strconv.ParseUint(creator, 10, 32)
+				if err != nil {
+					return nil, apperr.NewValidation(err.Error(), apperr.Field{Field: "creator_id", Description: "must be a number"})
+				}
+				req.File.CreatorID = uint(n)
+			}
+			req.File.OriginalFileName = part.FileName()
+			if ct := part.Header.Get("Content-Type"); ct != "" && ct != "application/octet-stream" {
+				req.File.ContentType = ct
+			}
+			req.Body = part
+			return req, nil
+		}
+	}
+}
+
+// readField reads the value of a form field, at most 1 KiB.
+func readField(r io.Reader) (string, error) {
+	b := make([]byte, 1024)
+	n, err := io.ReadFull(r, b)
+	if err == nil {
+		return "", badRequest(io.ErrShortBuffer)
+	}
+	if err != io.EOF && err != io.ErrUnexpectedEOF {
+		return "", badRequest(err)
+	}
+	return string(b[:n]), nil
+}
+
+func decodeHTTPFileByIDRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	vars := mux.Vars(r)
+	id, ok := vars["id"]
+	if !ok {
+		return nil, ErrBadRouting
+	}
+	n, err := strconv.ParseUint(id, 10, 32)
+	if err != nil {
+		return nil, badRequest(err)
+	}
+	return &pb.FileByID{ID: uint32(n)}, nil
+}
+
+func encodeHTTPUploadResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	if f, ok := response.(attachmentendpoint.Failer); ok && f.Failed() != nil {
+		apperr.EncodeHTTPError(ctx, f.Failed(), w)
+		return nil
+	}
+	return encodeHTTPFile(w, http.StatusCreated, response.(attachmentendpoint.FileResponse))
+}
+
+func encodeHTTPFileResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	if f, ok := response.(attachmentendpoint.Failer); ok && f.Failed() != nil {
+		apperr.EncodeHTTPError(ctx, f.Failed(), w)
+		return nil
+	}
+	return encodeHTTPFile(w, http.StatusOK, response.(attachmentendpoint.FileResponse))
+}
+
+func encodeHTTPFile(w http.ResponseWriter, code int, res attachmentendpoint.FileResponse) error {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(code)
+	m := jsonpb.Marshaler{EmitDefaults: true}
+	return m.Marshal(w, fileToPB(res.File))
+}
+
+// encodeHTTPDownloadResponse sends the content of the file under its
+// original name.
+func encodeHTTPDownloadResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	if f, ok := response.(attachmentendpoint.Failer); ok && f.Failed() != nil {
+		apperr.EncodeHTTPError(ctx, f.Failed(), w)
+		return nil
+	}
+	res := response.(attachmentendpoint.DownloadResponse)
+	defer res.Body.Close()
+	w.Header().Set("Content-Type", res.File.ContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Content-Length", strconv.FormatInt(res.File.Size, 10))
+	if res.File.OriginalFileName != "" {
+		w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": res.File.OriginalFileName}))
+	} else {
+		w.Header().Set("Content-Disposition", "attachment")
+	}
+	_, err := io.Copy(w, res.Body)
+	return err
+}
+
+// EncodeHTTPGenericResponse is a transport/http.EncodeResponseFunc that encodes
+// the response as JSON to the response writer
+func EncodeHTTPGenericResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	if f, ok := response.(attachmentendpoint.Failer); ok && f.Failed() != nil {
+		apperr.EncodeHTTPError(ctx, f.Failed(), w)
+		return nil
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	return json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,160 @@
+package biddingendpoint
+
+import (
+	"context"
+
+	"microsrv/model"
+	"microsrv/pb"
+
+	"microsrv/bidding/service"
+
+	"github.com/go-kit/kit/endpoint"
+)
+
+// Endpoints struct
+type Endpoints struct {
+	HealthEndpoint        endpoint.Endpoint // used by Consul for the healthcheck
+	CreateBiddingEndpoint endpoint.Endpoint
+	GetBiddingEndpoint    endpoint.Endpoint
+	ListBiddingsEndpoint  endpoint.Endpoint
+	SaveBiddingEndpoint   endpoint.Endpoint
+	DeleteBiddingEndpoint endpoint.Endpoint
+}
+
+// MakeServerEndpoints func
+func MakeServerEndpoints(s biddingservice.Service) Endpoints {
+	return Endpoints{
+		HealthEndpoint:        HealthEndpoint(s),
+		CreateBiddingEndpoint: CreateEndpoint(s),
+		GetBiddingEndpoint:    GetEndpoint(s),
+		ListBiddingsEndpoint:  ListEndpoint(s),
+		SaveBiddingEndpoint:   SaveEndpoint(s),
+		DeleteBiddingEndpoint: DeleteEndpoint(s),
+	}
+}
+
+// compile time assertions for our response types implementing endpoint.Failer.
+var (
+	_ endpoint.Failer = HealthResponse{}
+	_ endpoint.Failer = BiddingResponse{}
+	_ endpoint.Failer = BiddingsResponse{}
+	_ endpoint.Failer = DeleteResponse{}
+)
+
+// HealthEndpoint constructs a Health endpoint wrapping the service.
+func HealthEndpoint(s biddingservice.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		healthy := s.Health()
+		return HealthResponse{Healthy: healthy}, nil
+	}
+}
+
+// CreateEndpoint func
+func CreateEndpoint(s biddingservice.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(model.Bidding)
+		res, e := s.CreateBidding(ctx, req)
+		return BiddingResponse{Bidding: res, Err: e}, nil
+	}
+}
+
+// GetEndpoint func
+func GetEndpoint(s biddingservice.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(*pb.BiddingByID)
+		res, e := s.GetBidding(ctx, uint(req.ID))
+		return BiddingResponse{Bidding: res, Err: e}, nil
+	}
+}
+
+// ListEndpoint func
+func ListEndpoint(s biddingservice.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(*pb.BiddingQuery)
+		res, e := s.ListBiddings(ctx, QueryFromPB(req))
+		return BiddingsResponse{Page: res, Err: e}, nil
+	}
+}
+
+// SaveEndpoint func
+func SaveEndpoint(s biddingservice.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(SaveRequest)
+		res, e := s.SaveBidding(ctx, req.ID, req.Bidding)
+		return BiddingResponse{Bidding: res, Err: e}, nil
+	}
+}
+
+// DeleteEndpoint func
+func DeleteEndpoint(s biddingservice.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(*pb.BiddingByID)
+		e := s.DeleteBidding(ctx, uint(req.ID))
+		return DeleteResponse{Err: e}, nil
+	}
+}
+
+// QueryFromPB converts a listing request.
+func QueryFromPB(req *pb.BiddingQuery) biddingservice.Query {
+	return biddingservice.Query{
+		DebtorID:    uint(req.DebtorID),
+		InitiatorID: uint(req.InitiatorID),
+		Name:        req.Name,
+		Limit:       int(req.Limit),
+		From:        int(req.From),
+	}
+}
+
+// Failer is an interface that should be implemented by response types.
+// Response encoders can check if responses are Failer, and if so if they've
+// failed, and if so encode them using a separate write path based on the error.
+type Failer interface {
+	Failed() error
+}
+
+// SaveRequest collects the request parameters for the SaveBidding method.
+type SaveRequest struct {
+	ID      uint
+	Bidding model.Bidding
+}
+
+// BiddingResponse collects the response values of the methods returning
+// a bidding.
+type BiddingResponse struct {
+	Bidding model.Bidding `json:"bidding"`
+	Err     error         `json:"err,omitempty"`
+}
+
+// Failed implements Failer.
+func (r BiddingResponse) Failed() error { return r.Err }
+
+// BiddingsResponse collects the response values for the ListBiddings
+// method.
+type BiddingsResponse struct {
+	Page biddingservice.Page
+	Err  error `json:"err,omitempty"`
+}
+
+// Failed implements Failer.
+func (r BiddingsResponse) Failed() error { return r.Err }
+
+// DeleteResponse collects the response values for the DeleteBidding
+// method.
+type DeleteResponse struct {
+	Err error `json:"err,omitempty"`
+}
+
+// Failed implements Failer.
+func (r DeleteResponse) Failed() error { return r.Err }
+
+// HealthRequest collects the request parameters for the Health method.
+type HealthRequest struct{}
+
+// HealthResponse collects the response values for the Health method.
+type HealthResponse struct {
+	Healthy bool  `json:"healthy,omitempty"`
+	Err     error `json:"err,omitempty"`
+}
+
+// Failed implements Failer.
+func (r HealthResponse) Failed() error { return r.Err }
@@ -0,0 +1,125 @@
+package biddingservice
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"microsrv/model"
+)
+
+type memoryStore struct {
+	mtx      sync.RWMutex
+	biddings map[uint]model.Bidding
+	nextID   uint
+}
+
+// NewMemory returns a Service keeping biddings in memory. It is meant for
+// tests and for running the service without a database. Debtors are not
+// checked, and the initiator, adverts and trading codes are not loaded.
+func NewMemory() Service {
+	return &memoryStore{biddings: map[uint]model.Bidding{}}
+}
+
+// Health implementation of the Service.
+func (ms *memoryStore) Health() bool {
+	return true
+}
+
+func (ms *memoryStore) CreateBidding(ctx context.Context, b model.Bidding) (model.Bidding, error) {
+	ms.mtx.Lock()
+	defer ms.mtx.Unlock()
+	if b.ID != 0 {
+		if _, ok := ms.biddings[b.ID]; ok {
+			return model.Bidding{}, ErrAlreadyExists
+		}
+	} else {
+		ms.nextID++
+		b.ID = ms.nextID
+	}
+	if b.ID > ms.nextID {
+		ms.nextID = b.ID
+	}
+	now := time.Now()
+	b.CreatedAt, b.UpdatedAt, b.DeletedAt = now, now, nil
+	ms.biddings[b.ID] = b
+	return b, nil
+}
+
+func (ms *memoryStore) GetBidding(ctx context.Context, id uint) (model.Bidding, error) {
+	ms.mtx.RLock()
+	defer ms.mtx.RUnlock()
+	return ms.get(id)
+}
+
+// get returns a live bidding. Must be called with mtx held.
+func (ms *memoryStore) get(id uint) (model.Bidding, error) {
+	b, ok := ms.biddings[id]
+	if !ok || b.DeletedAt != nil {
+		return model.Bidding{}, ErrNotFound
+	}
+	return b, nil
+}
+
+func (ms *memoryStore) ListBiddings(ctx context.Context, p Query) (Page, error) {
+	ms.mtx.RLock()
+	defer ms.mtx.RUnlock()
+	if p.Limit == 0 {
+		p.Limit = 20
+	}
+	matched := []model.Bidding{}
+	for _, b := range ms.biddings {
+		switch {
+		case b.DeletedAt != nil:
+		case p.DebtorID != 0 && b.DebtorID != p.DebtorID:
+		case p.InitiatorID != 0 && b.InitiatorID != p.InitiatorID:
+		case p.Name != "" && !strings.Contains(strings.ToLower(b.Name), strings.ToLower(p.Name)):
+		default:
+			matched = append(matched, b)
+		}
+	}
+	sort.Slice(matched, func(i, j int) bool { return matched[i].ID < matched[j].ID })
+	res := Page{Biddings: []model.Bidding{}, Count: uint(len(matched))}
+	if p.From < len(matched) {
+		matched = matched[p.From:]
+		if len(matched) > p.Limit {
+			matched = matched[:p.Limit]
+		}
+		res.Biddings = matched
+	}
+	return res, nil
+}
+
+func (ms *memoryStore) SaveBidding(ctx context.Context, id uint, b model.Bidding) (model.Bidding, error) {
+	ms.mtx.Lock()
+	defer ms.mtx.Unlock()
+	if b.ID != 0 && b.ID != id {
+		return model.Bidding{}, ErrInconsistentIDs
+	}
+	bidding, err := ms.get(id)
+	if err != nil {
+		return bidding, err
+	}
+	bidding.Name = b.Name
+	bidding.Description = b.Description
+	bidding.DebtorID = b.DebtorID
+	bidding.InitiatorID = b.InitiatorID
+	bidding.UpdatedAt = time.Now()
+	ms.biddings[id] = bidding
+	return bidding, nil
+}
+
+func (ms *memoryStore) DeleteBidding(ctx context.Context, id uint) error {
+	ms.mtx.Lock()
+	defer ms.mtx.Unlock()
+	b, err := ms.get(id)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	b.DeletedAt = &now
+	ms.biddings[id] = b
+	return nil
+}
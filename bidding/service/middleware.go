@@ -0,0 +1,102 @@
+package biddingservice
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"microsrv/model"
+
+	"github.com/go-kit/kit/log"
+)
+
+// Middleware describes a service (as opposed to endpoint) middleware.
+type Middleware func(Service) Service
+
+// LoggingMiddleware takes a logger as a dependency and returns a ServiceMiddleware.
+func LoggingMiddleware(logger log.Logger) Middleware {
+	return func(next Service) Service {
+		return loggingMiddleware{next, logger}
+	}
+}
+
+type loggingMiddleware struct {
+	next   Service
+	logger log.Logger
+}
+
+// Health func
+func (mw loggingMiddleware) Health() bool {
+	defer func(begin time.Time) {
+		mw.logger.Log(
+			"method", "Health",
+			"healthy", true,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return mw.next.Health()
+}
+
+// CreateBidding func
+func (mw loggingMiddleware) CreateBidding(ctx context.Context, b model.Bidding) (res model.Bidding, err error) {
+	defer func(begin time.Time) {
+		mw.logger.Log(
+			"method", "CreateBidding",
+			"Bidding.ID", res.ID,
+			"Debtor.ID", b.DebtorID,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return mw.next.CreateBidding(ctx, b)
+}
+
+// GetBidding func
+func (mw loggingMiddleware) GetBidding(ctx context.Context, id uint) (model.Bidding, error) {
+	defer func(begin time.Time) {
+		mw.logger.Log(
+			"method", "GetBidding",
+			"Bidding.ID", id,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return mw.next.GetBidding(ctx, id)
+}
+
+// ListBiddings func
+func (mw loggingMiddleware) ListBiddings(ctx context.Context, q Query) (Page, error) {
+	defer func(begin time.Time) {
+		mw.logger.Log(
+			"method", "ListBiddings",
+			"Query", fmt.Sprintf("%+v", q),
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return mw.next.ListBiddings(ctx, q)
+}
+
+// SaveBidding func
+func (mw loggingMiddleware) SaveBidding(ctx context.Context, id uint, b model.Bidding) (res model.Bidding, err error) {
+	defer func(begin time.Time) {
+		mw.logger.Log(
+			"method", "SaveBidding",
+			"Bidding.ID", id,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return mw.next.SaveBidding(ctx, id, b)
+}
+
+// DeleteBidding func
+func (mw loggingMiddleware) DeleteBidding(ctx context.Context, id uint) (err error) {
+	defer func(begin time.Time) {
+		mw.logger.Log(
+			"method", "DeleteBidding",
+			"Bidding.ID", id,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return mw.next.DeleteBidding(ctx, id)
+}
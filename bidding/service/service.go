@@ -0,0 +1,214 @@
+package biddingservice
+
+import (
+	"context"
+	"fmt"
+
+	"microsrv/apperr"
+	"microsrv/config"
+	"microsrv/model"
+
+	"github.com/jinzhu/gorm"
+	_ "github.com/jinzhu/gorm/dialects/mysql"    // Mysql driver
+	_ "github.com/jinzhu/gorm/dialects/postgres" // Postgres driver
+)
+
+// Service interface
+type Service interface {
+	Health() bool
+	CreateBidding(ctx context.Context, b model.Bidding) (model.Bidding, error)
+	// GetBidding returns the bidding with its initiator, adverts and
+	// trading codes.
+	GetBidding(ctx context.Context, id uint) (model.Bidding, error)
+	// ListBiddings returns a page of the biddings matching the query, in
+	// id order.
+	ListBiddings(ctx context.Context, q Query) (Page, error)
+	// SaveBidding updates the name, description, debtor and initiator of
+	// the bidding, zero values included.
+	SaveBidding(ctx context.Context, id uint, b model.Bidding) (model.Bidding, error)
+	DeleteBidding(ctx context.Context, id uint) error
+}
+
+var (
+	// ErrInconsistentIDs var
+	ErrInconsistentIDs = apperr.NewValidation("inconsistent IDs", apperr.Field{Field: "id", Description: "differs from the bidding id"})
+	// ErrAlreadyExists var
+	ErrAlreadyExists = apperr.NewConflict("bidding already exists")
+	// ErrNotFound var
+	ErrNotFound = apperr.NewNotFound("bidding not found")
+	// ErrUnknownDebtor var
+	ErrUnknownDebtor = apperr.NewValidation("unknown debtor", apperr.Field{Field: "debtorID", Description: "must be an existing debtor"})
+)
+
+// Query selects the biddings of a debtor, of an initiator, or with a name
+// containing Name. Zero fields are not filtered on.
+type Query struct {
+	DebtorID    uint
+	InitiatorID uint
+	Name        string
+	Limit       int
+	From        int
+}
+
+// Page of biddings. Count is the number of biddings matching the query.
+type Page struct {
+	Biddings []model.Bidding
+	Count    uint
+}
+
+// New returns the Service backed by the storage selected by cfg.Driver.
+func New(cfg config.DB) (Service, error) {
+	switch cfg.Driver {
+	case "", "mysql":
+		return NewDB("mysql", cfg.DSN())
+	case "postgres":
+		return NewDB("postgres", cfg.DSN())
+	case "memory":
+		return NewMemory(), nil
+	default:
+		return nil, fmt.Errorf("unknown database driver %q", cfg.Driver)
+	}
+}
+
+type databaseStore struct {
+	db      *gorm.DB
+	dialect string
+}
+
+// NewDB returns a Service stored in a MySQL or PostgreSQL database. The
+// biddings table is created by the migrations of package migrate.
+func NewDB(dialect, DSN string) (Service, error) {
+	db, err := gorm.Open(dialect, DSN)
+	if err != nil {
+		return nil, err
+	}
+	if dialect == "mysql" {
+		db = db.Set("gorm:table_options", "ENGINE=InnoDB")
+	}
+	return &databaseStore{db: db, dialect: dialect}, nil
+}
+
+// like returns a case insensitive LIKE condition on the column.
+func (ds *databaseStore) like(column string) string {
+	if ds.dialect == "postgres" {
+		return column + " ILIKE ?"
+	}
+	return column + " COLLATE UTF8_GENERAL_CI LIKE ?"
+}
+
+// Health implementation of the Service.
+func (ds *databaseStore) Health() bool {
+	return ds.db.DB().Ping() == nil
+}
+
+// CreateBidding func
+func (ds *databaseStore) CreateBidding(ctx context.Context, b model.Bidding) (model.Bidding, error) {
+	if b.ID != 0 && !ds.db.Unscoped().First(&model.Bidding{}, b.ID).RecordNotFound() {
+		return model.Bidding{}, ErrAlreadyExists
+	}
+	if err := ds.debtorExists(b.DebtorID); err != nil {
+		return model.Bidding{}, err
+	}
+	if err := ds.db.Create(&b).Error; err != nil {
+		return model.Bidding{}, err
+	}
+	return ds.GetBidding(ctx, b.ID)
+}
+
+// debtorExists returns ErrUnknownDebtor unless the debtor with the id is
+// live.
+func (ds *databaseStore) debtorExists(id uint) error {
+	if ds.db.First(&model.Debtor{}, id).RecordNotFound() {
+		return ErrUnknownDebtor
+	}
+	return nil
+}
+
+// GetBidding func
+func (ds *databaseStore) GetBidding(ctx context.Context, id uint) (model.Bidding, error) {
+	return ds.get(ds.db.Preload("Initiator").Preload("Adverts").Preload("TradingCode"), id)
+}
+
+// get returns the live bidding with the id, loaded by q.
+func (ds *databaseStore) get(q *gorm.DB, id uint) (model.Bidding, error) {
+	b := model.Bidding{}
+	err := q.First(&b, id).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return b, ErrNotFound
+	}
+	return b, err
+}
+
+// ListBiddings func
+func (ds *databaseStore) ListBiddings(ctx context.Context, p Query) (Page, error) {
+	if p.Limit == 0 {
+		p.Limit = 20
+	}
+	res := Page{Biddings: []model.Bidding{}}
+	q := ds.db.Model(&model.Bidding{})
+	if p.DebtorID != 0 {
+		q = q.Where("debtor_id = ?", p.DebtorID)
+	}
+	if p.InitiatorID != 0 {
+		q = q.Where("initiator_id = ?", p.InitiatorID)
+	}
+	if p.Name != "" {
+		q = q.Where(ds.like("name"), fmt.Sprintf("%%%s%%", p.Name))
+	}
+	count := 0
+	if err := q.Count(&count).Error; err != nil {
+		return res, err
+	}
+	res.Count = uint(count)
+	err := q.
+		Order("id").
+		Limit(p.Limit).
+		Offset(p.From).
+		Find(&res.Biddings).
+		Error
+	return res, err
+}
+
+// SaveBidding func
+func (ds *databaseStore) SaveBidding(ctx context.Context, id uint, b model.Bidding) (model.Bidding, error) {
+	if b.ID != 0 && b.ID != id {
+		return model.Bidding{}, ErrInconsistentIDs
+	}
+	// Without the associations, which Updates would write back.
+	bidding, err := ds.get(ds.db, id)
+	if err != nil {
+		return bidding, err
+	}
+	if b.DebtorID != bidding.DebtorID {
+		if err := ds.debtorExists(b.DebtorID); err != nil {
+			return bidding, err
+		}
+	}
+	if err := ds.db.Model(&bidding).Updates(updates(b)).Error; err != nil {
+		return bidding, err
+	}
+	return ds.GetBidding(ctx, id)
+}
+
+// updates returns the editable fields of the bidding, zero values
+// included.
+func updates(b model.Bidding) map[string]interface{} {
+	return map[string]interface{}{
+		"Name":        b.Name,
+		"Description": b.Description,
+		"DebtorID":    b.DebtorID,
+		"InitiatorID": b.InitiatorID,
+	}
+}
+
+// DeleteBidding func
+func (ds *databaseStore) DeleteBidding(ctx context.Context, id uint) error {
+	q := ds.db.Delete(model.Bidding{}, id)
+	if q.Error != nil {
+		return q.Error
+	}
+	if q.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
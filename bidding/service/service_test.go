@@ -0,0 +1,138 @@
+package biddingservice
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+
+	"microsrv/model"
+)
+
+func TestMemoryBiddings(t *testing.T) {
+	s := NewMemory()
+	ctx := context.Background()
+	b, err := s.CreateBidding(ctx, model.Bidding{Name: "Auction", DebtorID: 1, InitiatorID: 2})
+	if err != nil || b.ID != 1 || b.CreatedAt.IsZero() {
+		t.Fatalf("CreateBidding = %+v, %v", b, err)
+	}
+	if _, err := s.CreateBidding(ctx, model.Bidding{Name: "Again", DebtorID: 1}); err != nil {
+		t.Fatal(err)
+	}
+	b.Name = "Duplicate"
+	if _, err := s.CreateBidding(ctx, b); err != ErrAlreadyExists {
+		t.Errorf("CreateBidding of an existing id: %v, want ErrAlreadyExists", err)
+	}
+
+	b, err = s.SaveBidding(ctx, 1, model.Bidding{Name: "Tender", DebtorID: 3})
+	if err != nil || b.Name != "Tender" || b.DebtorID != 3 || b.InitiatorID != 0 {
+		t.Fatalf("SaveBidding = %+v, %v", b, err)
+	}
+	if got, _ := s.GetBidding(ctx, 1); !reflect.DeepEqual(got, b) {
+		t.Errorf("GetBidding = %+v, want %+v", got, b)
+	}
+	mismatch := model.Bidding{Name: "x"}
+	mismatch.ID = 2
+	if _, err := s.SaveBidding(ctx, 1, mismatch); err != ErrInconsistentIDs {
+		t.Errorf("SaveBidding with another id: %v, want ErrInconsistentIDs", err)
+	}
+
+	if err := s.DeleteBidding(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.GetBidding(ctx, 1); err != ErrNotFound {
+		t.Errorf("GetBidding of a deleted bidding: %v, want ErrNotFound", err)
+	}
+	if _, err := s.SaveBidding(ctx, 1, model.Bidding{Name: "x"}); err != ErrNotFound {
+		t.Errorf("SaveBidding of a deleted bidding: %v, want ErrNotFound", err)
+	}
+	if err := s.DeleteBidding(ctx, 1); err != ErrNotFound {
+		t.Errorf("DeleteBidding of a deleted bidding: %v, want ErrNotFound", err)
+	}
+	if _, err := s.CreateBidding(ctx, b); err != ErrAlreadyExists {
+		t.Errorf("CreateBidding of a deleted id: %v, want ErrAlreadyExists", err)
+	}
+}
+
+func TestMemoryListBiddings(t *testing.T) {
+	s := NewMemory()
+	ctx := context.Background()
+	for _, b := range []model.Bidding{
+		{Name: "Auction of flats", DebtorID: 1, InitiatorID: 10},
+		{Name: "Public offer", DebtorID: 1, InitiatorID: 11},
+		{Name: "Auction of cars", DebtorID: 2, InitiatorID: 10},
+		{Name: "Deleted auction", DebtorID: 1, InitiatorID: 10},
+	} {
+		if _, err := s.CreateBidding(ctx, b); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.DeleteBidding(ctx, 4); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name  string
+		query Query
+		ids   []uint
+		count uint
+	}{
+		{"all", Query{}, []uint{1, 2, 3}, 3},
+		{"by debtor", Query{DebtorID: 1}, []uint{1, 2}, 2},
+		{"by initiator", Query{InitiatorID: 10}, []uint{1, 3}, 2},
+		{"by debtor and initiator", Query{DebtorID: 2, InitiatorID: 10}, []uint{3}, 1},
+		{"by name", Query{Name: "AUCTION"}, []uint{1, 3}, 2},
+		{"page", Query{Limit: 1, From: 1}, []uint{2}, 3},
+		{"past the end", Query{From: 5}, []uint{}, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, err := s.ListBiddings(ctx, tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ids := []uint{}
+			for _, b := range page.Biddings {
+				ids = append(ids, b.ID)
+			}
+			if !reflect.DeepEqual(ids, tt.ids) || page.Count != tt.count {
+				t.Errorf("ListBiddings = %v of %d, want %v of %d", ids, page.Count, tt.ids, tt.count)
+			}
+		})
+	}
+}
+
+func TestSaveKeepsAssociations(t *testing.T) {
+	b := model.Bidding{
+		Name:        "Auction",
+		Description: "Flats",
+		DebtorID:    1,
+		Adverts:     make([]model.Advert, 2),
+		TradingCode: make([]model.TradingCode, 1),
+	}
+	b.Initiator.INN = "7707083893"
+	fields := []string{}
+	for f := range updates(b) {
+		fields = append(fields, f)
+	}
+	sort.Strings(fields)
+	if want := []string{"DebtorID", "Description", "InitiatorID", "Name"}; !reflect.DeepEqual(fields, want) {
+		t.Errorf("updates fields %v, want %v", fields, want)
+	}
+
+	s := NewMemory()
+	ctx := context.Background()
+	created, err := s.CreateBidding(ctx, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved, err := s.SaveBidding(ctx, created.ID, model.Bidding{Name: "Tender", DebtorID: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.Name != "Tender" || saved.Description != "" {
+		t.Errorf("saved %q, %q", saved.Name, saved.Description)
+	}
+	if !reflect.DeepEqual(saved.Adverts, b.Adverts) || !reflect.DeepEqual(saved.TradingCode, b.TradingCode) || !reflect.DeepEqual(saved.Initiator, b.Initiator) {
+		t.Errorf("associations changed by SaveBidding: %+v", saved)
+	}
+}
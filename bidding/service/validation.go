@@ -0,0 +1,51 @@
+package biddingservice
+
+import (
+	"context"
+
+	"microsrv/apperr"
+	"microsrv/model"
+)
+
+// ValidationMiddleware returns a Middleware that rejects biddings without
+// a name or a debtor before they reach the next Service.
+func ValidationMiddleware() Middleware {
+	return func(next Service) Service {
+		return validationMiddleware{next}
+	}
+}
+
+type validationMiddleware struct {
+	Service
+}
+
+// CreateBidding func
+func (mw validationMiddleware) CreateBidding(ctx context.Context, b model.Bidding) (model.Bidding, error) {
+	if err := ValidateBidding(b); err != nil {
+		return model.Bidding{}, err
+	}
+	return mw.Service.CreateBidding(ctx, b)
+}
+
+// SaveBidding func
+func (mw validationMiddleware) SaveBidding(ctx context.Context, id uint, b model.Bidding) (model.Bidding, error) {
+	if err := ValidateBidding(b); err != nil {
+		return model.Bidding{}, err
+	}
+	return mw.Service.SaveBidding(ctx, id, b)
+}
+
+// ValidateBidding requires the name and the debtor of the bidding.
+func ValidateBidding(b model.Bidding) error {
+	fields := []apperr.Field{}
+	if b.Name == "" {
+		fields = append(fields, apperr.Field{Field: "name", Description: "is required"})
+	}
+	if b.DebtorID == 0 {
+		fields = append(fields, apperr.Field{Field: "debtorID", Description: "is required"})
+	}
+	if len(fields) > 0 {
+		return apperr.NewValidation("invalid bidding", fields...)
+	}
+	return nil
+}
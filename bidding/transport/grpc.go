@@ -0,0 +1,181 @@
+package transport
+
+import (
+	"context"
+
+	"microsrv/apperr"
+	"microsrv/bidding/endpoint"
+	"microsrv/model"
+	"microsrv/pb"
+
+	"github.com/go-kit/kit/log"
+	grpctransport "github.com/go-kit/kit/transport/grpc"
+	"github.com/jinzhu/copier"
+	oldcontext "golang.org/x/net/context"
+)
+
+type grpcServer struct {
+	createBidding grpctransport.Handler
+	getBidding    grpctransport.Handler
+	listBiddings  grpctransport.Handler
+	saveBidding   grpctransport.Handler
+	deleteBidding grpctransport.Handler
+}
+
+// NewGRPCServer makes a set of endpoints available as a gRPC BiddingSvcServer.
+func NewGRPCServer(endpoints biddingendpoint.Endpoints, logger log.Logger) pb.BiddingSvcServer {
+	options := []grpctransport.ServerOption{
+		grpctransport.ServerErrorLogger(logger),
+	}
+
+	return &grpcServer{
+		createBidding: grpctransport.NewServer(
+			endpoints.CreateBiddingEndpoint,
+			decodeGRPCBidding,
+			encodeGRPCBiddingResponse,
+			options...,
+		),
+		getBidding: grpctransport.NewServer(
+			endpoints.GetBiddingEndpoint,
+			decodeGRPCBiddingByID,
+			encodeGRPCBiddingResponse,
+			options...,
+		),
+		listBiddings: grpctransport.NewServer(
+			endpoints.ListBiddingsEndpoint,
+			decodeGRPCBiddingQuery,
+			encodeGRPCBiddingsResponse,
+			options...,
+		),
+		saveBidding: grpctransport.NewServer(
+			endpoints.SaveBiddingEndpoint,
+			decodeGRPCSaveBidding,
+			encodeGRPCBiddingResponse,
+			options...,
+		),
+		deleteBidding: grpctransport.NewServer(
+			endpoints.DeleteBiddingEndpoint,
+			decodeGRPCBiddingByID,
+			encodeGRPCDeleteResponse,
+			options...,
+		),
+	}
+}
+
+// CreateBidding implementation of the method of the BiddingSvcServer
+// interface.
+func (s *grpcServer) CreateBidding(ctx oldcontext.Context, req *pb.Bidding) (*pb.Bidding, error) {
+	_, res, err := s.createBidding.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, apperr.ToGRPC(err)
+	}
+	return res.(*pb.Bidding), nil
+}
+
+func decodeGRPCBidding(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	return biddingFromPB(grpcReq.(*pb.Bidding)), nil
+}
+
+func encodeGRPCBiddingResponse(_ context.Context, response interface{}) (interface{}, error) {
+	result := response.(biddingendpoint.BiddingResponse)
+	if result.Err != nil {
+		return nil, result.Err
+	}
+	return biddingToPB(result.Bidding), nil
+}
+
+// GetBidding implementation of the method of the BiddingSvcServer
+// interface.
+func (s *grpcServer) GetBidding(ctx oldcontext.Context, req *pb.BiddingByID) (*pb.Bidding, error) {
+	_, res, err := s.getBidding.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, apperr.ToGRPC(err)
+	}
+	return res.(*pb.Bidding), nil
+}
+
+func decodeGRPCBiddingByID(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	return grpcReq.(*pb.BiddingByID), nil
+}
+
+// ListBiddings implementation of the method of the BiddingSvcServer
+// interface.
+func (s *grpcServer) ListBiddings(ctx oldcontext.Context, req *pb.BiddingQuery) (*pb.Biddings, error) {
+	_, res, err := s.listBiddings.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, apperr.ToGRPC(err)
+	}
+	return res.(*pb.Biddings), nil
+}
+
+func decodeGRPCBiddingQuery(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	return grpcReq.(*pb.BiddingQuery), nil
+}
+
+func encodeGRPCBiddingsResponse(_ context.Context, response interface{}) (interface{}, error) {
+	result := response.(biddingendpoint.BiddingsResponse)
+	if result.Err != nil {
+		return nil, result.Err
+	}
+	return biddingsToPB(result.Page.Biddings, result.Page.Count), nil
+}
+
+// SaveBidding implementation of the method of the BiddingSvcServer
+// interface. The bidding is selected by its ID.
+func (s *grpcServer) SaveBidding(ctx oldcontext.Context, req *pb.Bidding) (*pb.Bidding, error) {
+	_, res, err := s.saveBidding.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, apperr.ToGRPC(err)
+	}
+	return res.(*pb.Bidding), nil
+}
+
+func decodeGRPCSaveBidding(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.Bidding)
+	return biddingendpoint.SaveRequest{ID: uint(req.ID), Bidding: biddingFromPB(req)}, nil
+}
+
+// DeleteBidding implementation of the method of the BiddingSvcServer
+// interface.
+func (s *grpcServer) DeleteBidding(ctx oldcontext.Context, req *pb.BiddingByID) (*pb.ErrorResponse, error) {
+	_, res, err := s.deleteBidding.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, apperr.ToGRPC(err)
+	}
+	return res.(*pb.ErrorResponse), nil
+}
+
+func encodeGRPCDeleteResponse(_ context.Context, response interface{}) (interface{}, error) {
+	result := response.(biddingendpoint.DeleteResponse)
+	if result.Err != nil {
+		return nil, result.Err
+	}
+	return &pb.ErrorResponse{}, nil
+}
+
+// biddingFromPB takes the editable fields of the bidding, its initiator,
+// adverts and trading codes are managed by their own services.
+func biddingFromPB(b *pb.Bidding) model.Bidding {
+	res := model.Bidding{
+		Name:        b.Name,
+		Description: b.Description,
+		DebtorID:    uint(b.DebtorID),
+		InitiatorID: uint(b.InitiatorID),
+	}
+	res.ID = uint(b.ID)
+	return res
+}
+
+func biddingToPB(b model.Bidding) *pb.Bidding {
+	res := &pb.Bidding{}
+	copier.Copy(res, b)
+	return res
+}
+
+func biddingsToPB(biddings []model.Bidding, count uint) *pb.Biddings {
+	res := &pb.Biddings{Biddings: make([]*pb.Bidding, len(biddings)), Count: uint32(count)}
+	for i, b := range biddings {
+		res.Biddings[i] = biddingToPB(b)
+	}
+	return res
+}
@@ -0,0 +1,205 @@
+package transport
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"microsrv/apperr"
+	"microsrv/bidding/endpoint"
+	"microsrv/model"
+	"microsrv/pb"
+
+	"github.com/go-kit/kit/log"
+	httptransport "github.com/go-kit/kit/transport/http"
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/gorilla/mux"
+)
+
+var (
+	// ErrBadRouting is returned when an expected path variable is missing.
+	ErrBadRouting = apperr.New(apperr.Internal, "inconsistent mapping between route and handler")
+)
+
+// badRequest reports a malformed request.
+func badRequest(err error) error {
+	return apperr.NewValidation(err.Error())
+}
+
+// NewHTTPHandler returns an HTTP handler that makes a set of endpoints
+// available on predefined paths.
+func NewHTTPHandler(endpoints biddingendpoint.Endpoints, logger log.Logger) http.Handler {
+	m := mux.NewRouter()
+	options := []httptransport.ServerOption{
+		httptransport.ServerErrorEncoder(apperr.EncodeHTTPError),
+		httptransport.ServerErrorLogger(logger),
+	}
+
+	// GET    /health                          retrieves service heath information
+	// POST   /biddings                        adds another bidding
+	// GET    /biddings?debtor_id&initiator_id retrieves a page of biddings, of
+	//        &name&limit&from                 the debtor or the initiator if given
+	// GET    /biddings/{id}                   retrieves the given bidding by id
+	// PUT    /biddings/{id}                   updates the given bidding
+	// DELETE /biddings/{id}                   removes the given bidding
+
+	m.Methods("GET").Path("/health").Handler(httptransport.NewServer(
+		endpoints.HealthEndpoint,
+		DecodeHTTPHealthRequest,
+		EncodeHTTPGenericResponse,
+		options...,
+	))
+	m.Methods("POST").Path("/biddings").Handler(httptransport.NewServer(
+		endpoints.CreateBiddingEndpoint,
+		decodeHTTPCreateBiddingRequest,
+		encodeHTTPCreatedBiddingResponse,
+		options...,
+	))
+	m.Methods("GET").Path("/biddings").Handler(httptransport.NewServer(
+		endpoints.ListBiddingsEndpoint,
+		decodeHTTPListBiddingsRequest,
+		encodeHTTPBiddingsResponse,
+		options...,
+	))
+	m.Methods("GET").Path("/biddings/{id}").Handler(httptransport.NewServer(
+		endpoints.GetBiddingEndpoint,
+		decodeHTTPBiddingByIDRequest,
+		encodeHTTPBiddingResponse,
+		options...,
+	))
+	m.Methods("PUT").Path("/biddings/{id}").Handler(httptransport.NewServer(
+		endpoints.SaveBiddingEndpoint,
+		decodeHTTPSaveBiddingRequest,
+		encodeHTTPBiddingResponse,
+		options...,
+	))
+	m.Methods("DELETE").Path("/biddings/{id}").Handler(httptransport.NewServer(
+		endpoints.DeleteBiddingEndpoint,
+		decodeHTTPBiddingByIDRequest,
+		EncodeHTTPGenericResponse,
+		options...,
+	))
+	return m
+}
+
+// DecodeHTTPHealthRequest method.
+func DecodeHTTPHealthRequest(_ context.Context, _ *http.Request) (interface{}, error) {
+	return biddingendpoint.HealthRequest{}, nil
+}
+
+func decodeHTTPCreateBiddingRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	return decodeHTTPBidding(r)
+}
+
+func decodeHTTPListBiddingsRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	q := r.URL.Query()
+	req := &pb.BiddingQuery{Name: q.Get("name")}
+	for name, v := range map[string]*uint32{"debtor_id": &req.DebtorID, "initiator_id": &req.InitiatorID} {
+		if s := q.Get(name); s != "" {
+			n, err := strconv.ParseUint(s, 10, 32)
+			if err != nil {
+				return nil, badRequest(err)
+			}
+			*v = uint32(n)
+		}
+	}
+	for name, v := range map[string]*int64{"limit": &req.Limit, "from": &req.From} {
+		if s := q.Get(name); s != "" {
+			n, err := strconv.ParseInt(s, 10, 64)
+			if err != nil {
+				return nil, badRequest(err)
+			}
+			*v = n
+		}
+	}
+	return req, nil
+}
+
+func decodeHTTPBiddingByIDRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	id, err := biddingID(r)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.BiddingByID{ID: uint32(id)}, nil
+}
+
+func decodeHTTPSaveBiddingRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	id, err := biddingID(r)
+	if err != nil {
+		return nil, err
+	}
+	b, err := decodeHTTPBidding(r)
+	if err != nil {
+		return nil, err
+	}
+	return biddingendpoint.SaveRequest{ID: id, Bidding: b}, nil
+}
+
+// decodeHTTPBidding reads a pb.Bidding JSON document from the request body.
+func decodeHTTPBidding(r *http.Request) (model.Bidding, error) {
+	req := pb.Bidding{}
+	u := jsonpb.Unmarshaler{AllowUnknownFields: true}
+	if err := u.Unmarshal(r.Body, &req); err != nil {
+		return model.Bidding{}, badRequest(err)
+	}
+	return biddingFromPB(&req), nil
+}
+
+func biddingID(r *http.Request) (uint, error) {
+	vars := mux.Vars(r)
+	id, ok := vars["id"]
+	if !ok {
+		return 0, ErrBadRouting
+	}
+	n, err := strconv.ParseUint(id, 10, 32)
+	if err != nil {
+		return 0, badRequest(err)
+	}
+	return uint(n), nil
+}
+
+func encodeHTTPCreatedBiddingResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	if f, ok := response.(biddingendpoint.Failer); ok && f.Failed() != nil {
+		apperr.EncodeHTTPError(ctx, f.Failed(), w)
+		return nil
+	}
+	return encodeHTTPBidding(w, http.StatusCreated, response.(biddingendpoint.BiddingResponse).Bidding)
+}
+
+func encodeHTTPBiddingResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	if f, ok := response.(biddingendpoint.Failer); ok && f.Failed() != nil {
+		apperr.EncodeHTTPError(ctx, f.Failed(), w)
+		return nil
+	}
+	return encodeHTTPBidding(w, http.StatusOK, response.(biddingendpoint.BiddingResponse).Bidding)
+}
+
+func encodeHTTPBidding(w http.ResponseWriter, code int, b model.Bidding) error {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(code)
+	m := jsonpb.Marshaler{}
+	return m.Marshal(w, biddingToPB(b))
+}
+
+func encodeHTTPBiddingsResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	if f, ok := response.(biddingendpoint.Failer); ok && f.Failed() != nil {
+		apperr.EncodeHTTPError(ctx, f.Failed(), w)
+		return nil
+	}
+	page := response.(biddingendpoint.BiddingsResponse).Page
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	m := jsonpb.Marshaler{EmitDefaults: true}
+	return m.Marshal(w, biddingsToPB(page.Biddings, page.Count))
+}
+
+// EncodeHTTPGenericResponse is a transport/http.EncodeResponseFunc that encodes
+// the response as JSON to the response writer
+func EncodeHTTPGenericResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	if f, ok := response.(biddingendpoint.Failer); ok && f.Failed() != nil {
+		apperr.EncodeHTTPError(ctx, f.Failed(), w)
+		return nil
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	return json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,44 @@
+// Package blob stores contents by key on a local file system or an S3
+// compatible object storage.
+package blob
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+)
+
+var (
+	// ErrNotFound var
+	ErrNotFound = errors.New("blob: not found")
+	// ErrKey var
+	ErrKey = errors.New("blob: invalid key")
+)
+
+// Storage of contents by key. Keys are slash separated paths without empty,
+// "." or ".." segments.
+type Storage interface {
+	// Put stores the size bytes of r under the key, replacing the content
+	// stored under it.
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Get returns the content stored under the key, ErrNotFound if none.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Exists(ctx context.Context, key string) (bool, error)
+	// Delete removes the content stored under the key, if any.
+	Delete(ctx context.Context, key string) error
+}
+
+// validKey reports whether the key is a relative path that stays below the
+// root of the storage.
+func validKey(key string) bool {
+	if key == "" || strings.ContainsAny(key, "\\\x00") {
+		return false
+	}
+	for _, s := range strings.Split(key, "/") {
+		if s == "" || s == "." || s == ".." {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,242 @@
+package blob
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeS3 is a MinIO style S3 server keeping the objects in memory. It
+// checks the Signature Version 4 of every request on its own.
+type fakeS3 struct {
+	mtx       sync.Mutex
+	accessKey string
+	secretKey string
+	objects   map[string][]byte
+	types     map[string]string
+}
+
+func newFakeS3() *fakeS3 {
+	return &fakeS3{
+		accessKey: "AKID",
+		secretKey: "SECRET",
+		objects:   map[string][]byte{},
+		types:     map[string]string{},
+	}
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := f.verify(r); err != "" {
+		http.Error(w, err, http.StatusForbidden)
+		return
+	}
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	switch r.Method {
+	case "PUT":
+		b, err := ioutil.ReadAll(r.Body)
+		if err != nil || int64(len(b)) != r.ContentLength {
+			http.Error(w, "IncompleteBody", http.StatusBadRequest)
+			return
+		}
+		f.objects[r.URL.Path] = b
+		f.types[r.URL.Path] = r.Header.Get("Content-Type")
+	case "GET", "HEAD":
+		b, ok := f.objects[r.URL.Path]
+		if !ok {
+			http.Error(w, "NoSuchKey", http.StatusNotFound)
+			return
+		}
+		if r.Method == "GET" {
+			w.Write(b)
+		}
+	case "DELETE":
+		delete(f.objects, r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// verify returns the error of the signature of the request, empty if it
+// is valid.
+func (f *fakeS3) verify(r *http.Request) string {
+	const prefix = "AWS4-HMAC-SHA256 "
+	auth := r.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, prefix) {
+		return "MissingSecurityHeader"
+	}
+	params := map[string]string{}
+	for _, p := range strings.Split(strings.TrimPrefix(auth, prefix), ", ") {
+		kv := strings.SplitN(p, "=", 2)
+		if len(kv) == 2 {
+			params[kv[0]] = kv[1]
+		}
+	}
+	cred := strings.SplitN(params["Credential"], "/", 2)
+	if len(cred) != 2 || cred[0] != f.accessKey {
+		return "InvalidAccessKeyId"
+	}
+	scope := strings.Split(cred[1], "/")
+	if len(scope) != 4 || scope[2] != "s3" || scope[3] != "aws4_request" {
+		return "AuthorizationHeaderMalformed"
+	}
+	date := r.Header.Get("X-Amz-Date")
+	if !strings.HasPrefix(date, scope[0]) {
+		return "AuthorizationHeaderMalformed"
+	}
+	payload := r.Header.Get("X-Amz-Content-Sha256")
+	canonical := r.Method + "\n" +
+		r.URL.EscapedPath() + "\n" +
+		r.URL.RawQuery + "\n" +
+		"host:" + r.Host + "\n" +
+		"x-amz-content-sha256:" + payload + "\n" +
+		"x-amz-date:" + date + "\n" +
+		"\n" +
+		params["SignedHeaders"] + "\n" +
+		payload
+	hash := sha256.Sum256([]byte(canonical))
+	toSign := "AWS4-HMAC-SHA256\n" + date + "\n" + cred[1] + "\n" + hex.EncodeToString(hash[:])
+	key := []byte("AWS4" + f.secretKey)
+	for _, s := range scope {
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(s))
+		key = mac.Sum(nil)
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(toSign))
+	if hex.EncodeToString(mac.Sum(nil)) != params["Signature"] {
+		return "SignatureDoesNotMatch"
+	}
+	return ""
+}
+
+func newTestS3(t *testing.T, f *fakeS3, secretKey string) (*S3, func()) {
+	srv := httptest.NewServer(f)
+	s, err := NewS3(S3Config{
+		Endpoint:  srv.URL,
+		Region:    "ru-central1",
+		Bucket:    "files",
+		AccessKey: f.accessKey,
+		SecretKey: secretKey,
+	}, nil)
+	if err != nil {
+		srv.Close()
+		t.Fatal(err)
+	}
+	return s, srv.Close
+}
+
+func TestS3PutGetExistsDelete(t *testing.T) {
+	f := newFakeS3()
+	s, done := newTestS3(t, f, f.secretKey)
+	defer done()
+	ctx := context.Background()
+	key := "sha256/ab/Отчёт 1.txt"
+
+	if ok, err := s.Exists(ctx, key); err != nil || ok {
+		t.Fatalf("Exists before Put = %v, %v", ok, err)
+	}
+	if _, err := s.Get(ctx, key); err != ErrNotFound {
+		t.Fatalf("Get before Put: %v, want ErrNotFound", err)
+	}
+	if err := s.Put(ctx, key, strings.NewReader("hello"), 5, "text/plain"); err != nil {
+		t.Fatal(err)
+	}
+	if b := f.objects["/files/"+key]; string(b) != "hello" {
+		t.Fatalf("stored %q", b)
+	}
+	if ct := f.types["/files/"+key]; ct != "text/plain" {
+		t.Fatalf("content type %q", ct)
+	}
+	if ok, err := s.Exists(ctx, key); err != nil || !ok {
+		t.Fatalf("Exists after Put = %v, %v", ok, err)
+	}
+	rc, err := s.Get(ctx, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadAll(rc)
+	rc.Close()
+	if err != nil || string(b) != "hello" {
+		t.Fatalf("Get = %q, %v", b, err)
+	}
+	if err := s.Put(ctx, "empty", strings.NewReader(""), 0, ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Delete(ctx, key); err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := s.Exists(ctx, key); ok {
+		t.Fatal("Exists after Delete")
+	}
+	if err := s.Delete(ctx, key); err != nil {
+		t.Fatalf("Delete of a missing object: %v", err)
+	}
+}
+
+func TestS3Signature(t *testing.T) {
+	f := newFakeS3()
+	s, done := newTestS3(t, f, "WRONG")
+	defer done()
+	_, err := s.Get(context.Background(), "a")
+	if err == nil || !strings.Contains(err.Error(), "SignatureDoesNotMatch") {
+		t.Fatalf("Get with a wrong secret: %v", err)
+	}
+}
+
+func TestS3SignHeaders(t *testing.T) {
+	s, err := NewS3(S3Config{
+		Endpoint:  "http://127.0.0.1:9000",
+		Region:    "us-east-1",
+		Bucket:    "files",
+		AccessKey: "AKID",
+		SecretKey: "SECRET",
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, err := s.request(context.Background(), "GET", "a b/c", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.sign(req, emptyHash, time.Date(2019, 1, 2, 3, 4, 5, 0, time.UTC))
+	if got := req.URL.EscapedPath(); got != "/files/a%20b/c" {
+		t.Errorf("path %s", got)
+	}
+	if got := req.Header.Get("X-Amz-Date"); got != "20190102T030405Z" {
+		t.Errorf("X-Amz-Date %s", got)
+	}
+	if got := req.Header.Get("X-Amz-Content-Sha256"); got != emptyHash {
+		t.Errorf("X-Amz-Content-Sha256 %s", got)
+	}
+	want := "AWS4-HMAC-SHA256 Credential=AKID/20190102/us-east-1/s3/aws4_request, " +
+		"SignedHeaders=host;x-amz-content-sha256;x-amz-date, Signature="
+	if got := req.Header.Get("Authorization"); !strings.HasPrefix(got, want) || len(got) != len(want)+64 {
+		t.Errorf("Authorization %s", got)
+	}
+	f := newFakeS3()
+	req.Host = req.URL.Host
+	if err := f.verify(req); err != "" {
+		t.Errorf("verify: %s", err)
+	}
+}
+
+func TestKeys(t *testing.T) {
+	for _, key := range []string{"", "/a", "a/", "a//b", "../a", "a/./b", "a\\b"} {
+		if validKey(key) {
+			t.Errorf("validKey(%q)", key)
+		}
+	}
+	for _, key := range []string{"a", "sha256/ab/abcd", "a.b/c"} {
+		if !validKey(key) {
+			t.Errorf("!validKey(%q)", key)
+		}
+	}
+}
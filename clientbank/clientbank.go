@@ -0,0 +1,293 @@
+// Package clientbank reads account statements in the 1CClientBankExchange
+// text format banks export for the 1C accounting software.
+package clientbank
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+const header = "1CClientBankExchange"
+
+// dosEncoding is the Кодировка line of a CP866 file.
+var dosEncoding, _ = charmap.CodePage866.NewEncoder().Bytes([]byte("\nКодировка=DOS"))
+
+var (
+	// ErrFormat is returned for a file without the 1CClientBankExchange
+	// header.
+	ErrFormat = errors.New("not a 1CClientBankExchange file")
+	// ErrUnterminated is returned for a section without its end line.
+	ErrUnterminated = errors.New("unterminated section")
+)
+
+// ParseError reports the line of a malformed value.
+type ParseError struct {
+	Line int
+	Err  error
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+// File is a parsed exchange file.
+type File struct {
+	Version   string
+	Sender    string
+	Receiver  string
+	Start     time.Time
+	End       time.Time
+	Accounts  []Account
+	Documents []Document
+}
+
+// Account is a СекцияРасчСчет, the balance of an account for the period.
+// Amounts are in kopecks.
+type Account struct {
+	Account  string
+	Start    time.Time
+	End      time.Time
+	Opening  int64
+	Income   int64
+	WriteOff int64
+	Closing  int64
+}
+
+// Document is a СекцияДокумент, a payment document. The amount is in
+// kopecks. Fields keeps all the values of the section by key.
+type Document struct {
+	Kind         string
+	Number       string
+	Date         time.Time
+	Amount       int64
+	PayerAccount string
+	PayerBIK     string
+	PayerINN     string
+	Payer        string
+	PayeeAccount string
+	PayeeBIK     string
+	PayeeINN     string
+	Payee        string
+	Purpose      string
+	Written      time.Time
+	Received     time.Time
+	Fields       map[string]string
+	Line         int
+}
+
+// Key identifies a payment document across files.
+func (d Document) Key() string {
+	return strings.Join([]string{d.Number, d.Date.Format("2006-01-02"), strconv.FormatInt(d.Amount, 10), d.PayerAccount, d.PayeeAccount}, "|")
+}
+
+// Parse reads an exchange file encoded in UTF-8, Windows-1251 or, when its
+// Кодировка is DOS, CP866.
+func Parse(r io.Reader) (*File, error) {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	b = bytes.TrimPrefix(b, []byte("\xef\xbb\xbf"))
+	if !bytes.HasPrefix(b, []byte(header)) {
+		return nil, ErrFormat
+	}
+	text := string(b)
+	if !utf8.Valid(b) {
+		// The encoding is told by the Кодировка line, which is itself
+		// encoded, so it is looked up in the raw bytes.
+		dec := charmap.Windows1251.NewDecoder()
+		if bytes.Contains(b, dosEncoding) {
+			dec = charmap.CodePage866.NewDecoder()
+		}
+		if text, err = dec.String(text); err != nil {
+			return nil, err
+		}
+	}
+	return parse(strings.Split(text, "\n"))
+}
+
+func parse(lines []string) (*File, error) {
+	f := &File{}
+	var (
+		account *Account
+		doc     *Document
+	)
+	for i, line := range lines {
+		n := i + 1
+		line = strings.TrimRight(line, "\r")
+		key, value := line, ""
+		if eq := strings.Index(line, "="); eq >= 0 {
+			key, value = strings.TrimSpace(line[:eq]), strings.TrimSpace(line[eq+1:])
+		}
+		var err error
+		switch {
+		case key == "СекцияРасчСчет":
+			account = &Account{}
+		case key == "КонецРасчСчет" && account != nil:
+			f.Accounts = append(f.Accounts, *account)
+			account = nil
+		case key == "СекцияДокумент":
+			doc = &Document{Kind: value, Fields: map[string]string{}, Line: n}
+		case key == "КонецДокумента" && doc != nil:
+			f.Documents = append(f.Documents, *doc)
+			doc = nil
+		case key == "КонецФайла":
+			return f, nil
+		case account != nil:
+			err = account.set(key, value)
+		case doc != nil:
+			err = doc.set(key, value)
+		default:
+			err = f.set(key, value)
+		}
+		if err != nil {
+			return nil, &ParseError{Line: n, Err: err}
+		}
+	}
+	if account != nil || doc != nil {
+		return nil, &ParseError{Line: len(lines), Err: ErrUnterminated}
+	}
+	return f, nil
+}
+
+func (f *File) set(key, value string) (err error) {
+	switch key {
+	case "ВерсияФормата":
+		f.Version = value
+	case "Отправитель":
+		f.Sender = value
+	case "Получатель":
+		f.Receiver = value
+	case "ДатаНачала":
+		f.Start, err = parseDate(value)
+	case "ДатаКонца":
+		f.End, err = parseDate(value)
+	}
+	return err
+}
+
+func (a *Account) set(key, value string) (err error) {
+	switch key {
+	case "РасчСчет":
+		a.Account = value
+	case "ДатаНачала":
+		a.Start, err = parseDate(value)
+	case "ДатаКонца":
+		a.End, err = parseDate(value)
+	case "НачальныйОстаток":
+		a.Opening, err = parseAmount(value)
+	case "ВсегоПоступило":
+		a.Income, err = parseAmount(value)
+	case "ВсегоСписано":
+		a.WriteOff, err = parseAmount(value)
+	case "КонечныйОстаток":
+		a.Closing, err = parseAmount(value)
+	}
+	return err
+}
+
+func (d *Document) set(key, value string) (err error) {
+	d.Fields[key] = value
+	switch key {
+	case "Номер":
+		d.Number = value
+	case "Дата":
+		d.Date, err = parseDate(value)
+	case "Сумма":
+		d.Amount, err = parseAmount(value)
+	case "ПлательщикСчет":
+		d.PayerAccount = value
+	case "ПлательщикРасчСчет":
+		if d.PayerAccount == "" {
+			d.PayerAccount = value
+		}
+	case "ПлательщикБИК":
+		d.PayerBIK = value
+	case "ПлательщикИНН":
+		d.PayerINN = value
+	case "Плательщик", "Плательщик1":
+		if d.Payer == "" {
+			d.Payer = value
+		}
+	case "ПолучательСчет":
+		d.PayeeAccount = value
+	case "ПолучательРасчСчет":
+		if d.PayeeAccount == "" {
+			d.PayeeAccount = value
+		}
+	case "ПолучательБИК":
+		d.PayeeBIK = value
+	case "ПолучательИНН":
+		d.PayeeINN = value
+	case "Получатель", "Получатель1":
+		if d.Payee == "" {
+			d.Payee = value
+		}
+	case "НазначениеПлатежа":
+		d.Purpose = value
+	case "ДатаСписано":
+		d.Written, err = parseDate(value)
+	case "ДатаПоступило":
+		d.Received, err = parseDate(value)
+	}
+	return err
+}
+
+func parseDate(v string) (time.Time, error) {
+	if v == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse("02.01.2006", v)
+}
+
+// parseAmount parses roubles with up to two decimals into kopecks.
+func parseAmount(v string) (int64, error) {
+	if v == "" {
+		return 0, nil
+	}
+	v = strings.Replace(v, ",", ".", 1)
+	neg := strings.HasPrefix(v, "-")
+	parts := strings.SplitN(strings.TrimPrefix(v, "-"), ".", 2)
+	frac := ""
+	if len(parts) == 2 {
+		frac = parts[1]
+	}
+	if len(frac) > 2 || parts[0] == "" {
+		return 0, fmt.Errorf("invalid amount %q", v)
+	}
+	if !digits(parts[0]) || !digits(frac) {
+		return 0, fmt.Errorf("invalid amount %q", v)
+	}
+	rub, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil || rub > (math.MaxInt64-99)/100 {
+		return 0, fmt.Errorf("invalid amount %q", v)
+	}
+	kop := int64(0)
+	if frac != "" {
+		kop, _ = strconv.ParseInt((frac + "0")[:2], 10, 64)
+	}
+	amount := rub*100 + kop
+	if neg {
+		amount = -amount
+	}
+	return amount, nil
+}
+
+func digits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,123 @@
+package clientbank
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+const statement = `1CClientBankExchange
+ВерсияФормата=1.03
+Кодировка=%s
+Отправитель=Бухгалтерия
+ДатаНачала=01.03.2019
+ДатаКонца=31.03.2019
+СекцияРасчСчет
+РасчСчет=40702810900000000001
+НачальныйОстаток=1000
+ВсегоПоступило=2500,5
+ВсегоСписано=0.05
+КонечныйОстаток=3500.45
+КонецРасчСчет
+СекцияДокумент=Платежное поручение
+Номер=17
+Дата=05.03.2019
+Сумма=2500.50
+ПлательщикСчет=40702810100000000002
+ПлательщикИНН=7707083893
+Плательщик=ООО "Ромашка"
+ПолучательСчет=40702810900000000001
+Получатель1=ООО "Лютик"
+НазначениеПлатежа=Оплата по договору №1
+ДатаПоступило=05.03.2019
+КонецДокумента
+КонецФайла
+`
+
+func TestParseEncodings(t *testing.T) {
+	cases := []struct {
+		name string
+		data func(string) []byte
+		enc  string
+	}{
+		{"utf-8", func(s string) []byte { return []byte(s) }, "Windows"},
+		{"utf-8 with a bom", func(s string) []byte { return []byte("\xef\xbb\xbf" + s) }, "Windows"},
+		{"windows-1251", func(s string) []byte {
+			b, _ := charmap.Windows1251.NewEncoder().Bytes([]byte(s))
+			return b
+		}, "Windows"},
+		{"cp866", func(s string) []byte {
+			b, _ := charmap.CodePage866.NewEncoder().Bytes([]byte(strings.Replace(s, "\n", "\r\n", -1)))
+			return b
+		}, "DOS"},
+	}
+	for _, c := range cases {
+		f, err := Parse(bytes.NewReader(c.data(strings.Replace(statement, "%s", c.enc, 1))))
+		if err != nil {
+			t.Errorf("%s: %v", c.name, err)
+			continue
+		}
+		if f.Sender != "Бухгалтерия" || !f.End.Equal(time.Date(2019, 3, 31, 0, 0, 0, 0, time.UTC)) {
+			t.Errorf("%s: file %+v", c.name, f)
+		}
+		if len(f.Accounts) != 1 || f.Accounts[0].Opening != 100000 || f.Accounts[0].Income != 250050 ||
+			f.Accounts[0].WriteOff != 5 || f.Accounts[0].Closing != 350045 {
+			t.Errorf("%s: accounts %+v", c.name, f.Accounts)
+		}
+		if len(f.Documents) != 1 {
+			t.Errorf("%s: %d documents", c.name, len(f.Documents))
+			continue
+		}
+		d := f.Documents[0]
+		if d.Kind != "Платежное поручение" || d.Amount != 250050 || d.Payer != `ООО "Ромашка"` ||
+			d.Payee != `ООО "Лютик"` || d.Purpose != "Оплата по договору №1" || d.Line != 14 {
+			t.Errorf("%s: document %+v", c.name, d)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	if _, err := Parse(strings.NewReader("ВерсияФормата=1.03\n")); err != ErrFormat {
+		t.Errorf("Parse without the header: %v", err)
+	}
+	if _, err := Parse(strings.NewReader(header + "\nСекцияДокумент=Платежное поручение\nНомер=1\n")); err == nil || err.(*ParseError).Err != ErrUnterminated {
+		t.Errorf("Parse of an unterminated section: %v", err)
+	}
+	_, err := Parse(strings.NewReader(header + "\nДатаНачала=2019-03-01\n"))
+	if pe, ok := err.(*ParseError); !ok || pe.Line != 2 {
+		t.Errorf("Parse of a bad date: %v", err)
+	}
+}
+
+func TestParseAmount(t *testing.T) {
+	cases := []struct {
+		in   string
+		want int64
+		ok   bool
+	}{
+		{"", 0, true},
+		{"0", 0, true},
+		{"15", 1500, true},
+		{"15.", 1500, true},
+		{"15.5", 1550, true},
+		{"15,05", 1505, true},
+		{"-15.05", -1505, true},
+		{"92233720368547758", 0, false},
+		{"15.055", 0, false},
+		{".5", 0, false},
+		{"--5", 0, false},
+		{"+5", 0, false},
+		{"5.-1", 0, false},
+		{"1 000", 0, false},
+		{"abc", 0, false},
+	}
+	for _, c := range cases {
+		got, err := parseAmount(c.in)
+		if (err == nil) != c.ok || got != c.want {
+			t.Errorf("parseAmount(%q) = %d, %v", c.in, got, err)
+		}
+	}
+}
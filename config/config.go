@@ -2,14 +2,18 @@ package config
 
 import (
 	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
 	"strconv"
+	"strings"
 
+	"github.com/lib/pq"
 	ini "gopkg.in/ini.v1"
 )
 
 // Save func
 func (r Parameters) Save(file string) error {
-	fmt.Println(file)
 	cfg := ini.Empty()
 	sect := cfg.Section("service")
 	sect.Key("debug_port").SetValue(strconv.Itoa(int(r.Service.DebugPort)))
@@ -19,13 +23,60 @@ func (r Parameters) Save(file string) error {
 	sect.Key("consul_port").SetValue(strconv.Itoa(int(r.Service.ConsulPort)))
 	sect.Key("consul_addr").SetValue(r.Service.ConsulAddr)
 	sect = cfg.Section("DB")
+	sect.Key("driver").SetValue(r.DB.Driver)
+	sect.Key("db_host").SetValue(r.DB.DbHost)
+	sect.Key("db_port").SetValue(strconv.Itoa(int(r.DB.DbPort)))
 	sect.Key("database").SetValue(r.DB.DB)
 	sect.Key("db_user").SetValue(r.DB.DbUser)
 	sect.Key("db_password").SetValue(r.DB.DbPassword)
+	sect.Key("cursor_secret").SetValue(r.DB.CursorSecret)
+	sect = cfg.Section("purge")
+	sect.Key("retention_days").SetValue(strconv.Itoa(int(r.Purge.RetentionDays)))
+	sect.Key("interval_hours").SetValue(strconv.Itoa(int(r.Purge.IntervalHours)))
+	sect = cfg.Section("kommersant")
+	sect.Key("addr").SetValue(r.Kommersant.Addr)
+	sect.Key("refresh_minutes").SetValue(strconv.Itoa(int(r.Kommersant.RefreshMinutes)))
+	sect = cfg.Section("storage")
+	sect.Key("backend").SetValue(r.Storage.Backend)
+	sect.Key("dir").SetValue(r.Storage.Dir)
+	sect.Key("s3_endpoint").SetValue(r.Storage.S3Endpoint)
+	sect.Key("s3_region").SetValue(r.Storage.S3Region)
+	sect.Key("s3_bucket").SetValue(r.Storage.S3Bucket)
+	sect.Key("s3_access_key").SetValue(r.Storage.S3AccessKey)
+	sect.Key("s3_secret_key").SetValue(r.Storage.S3SecretKey)
+	sect.Key("max_size_mb").SetValue(strconv.Itoa(int(r.Storage.MaxSizeMB)))
+	sect.Key("allowed_types").SetValue(strings.Join(r.Storage.AllowedTypes, ","))
+	sect = cfg.Section("ledger")
+	sect.Key("pdf_font").SetValue(r.Ledger.PDFFont)
 	cfg.SaveTo(file)
 	return nil
 }
 
+// File returns the config file named by the -cfg flag of args, or the
+// <executable>.ini of the working directory without it. It is called
+// before the flags are parsed, because their defaults come from the file.
+func File(args []string) string {
+	for i, arg := range args {
+		if arg == "--" {
+			break
+		}
+		if !strings.HasPrefix(arg, "-") {
+			continue
+		}
+		name := strings.TrimLeft(arg, "-")
+		if name == "cfg" && i+1 < len(args) {
+			return args[i+1]
+		}
+		if strings.HasPrefix(name, "cfg=") {
+			return strings.TrimPrefix(name, "cfg=")
+		}
+	}
+	pwd, _ := os.Getwd()
+	ex, _ := os.Executable()
+	ex = filepath.Base(ex)
+	return filepath.Join(pwd, strings.TrimSuffix(ex, filepath.Ext(ex))+".ini")
+}
+
 // Read func
 func (r *Parameters) Read(file string) error {
 	if r.Service.DebugPort == 0 {
@@ -40,6 +91,27 @@ func (r *Parameters) Read(file string) error {
 	if r.Service.GrpcPort == 0 {
 		r.Service.GrpcPort = 9120
 	}
+	if r.DB.Driver == "" {
+		r.DB.Driver = "mysql"
+	}
+	if r.Purge.IntervalHours == 0 {
+		r.Purge.IntervalHours = 24
+	}
+	if r.Kommersant.RefreshMinutes == 0 {
+		r.Kommersant.RefreshMinutes = 60
+	}
+	if r.Storage.Backend == "" {
+		r.Storage.Backend = "local"
+	}
+	if r.Storage.Dir == "" {
+		r.Storage.Dir = "files"
+	}
+	if r.Storage.MaxSizeMB == 0 {
+		r.Storage.MaxSizeMB = 20
+	}
+	if r.Ledger.PDFFont == "" {
+		r.Ledger.PDFFont = "/usr/share/fonts/truetype/dejavu/DejaVuSans.ttf"
+	}
 	cfg, _ := ini.LooseLoad(file)
 	return cfg.MapTo(&r)
 }
@@ -52,8 +124,12 @@ type Config interface {
 
 // Parameters struct for store service params
 type Parameters struct {
-	Service Service `ini:"service,omitempty"`
-	DB      DB      `ini:"DB,omitempty"`
+	Service    Service    `ini:"service,omitempty"`
+	DB         DB         `ini:"DB,omitempty"`
+	Purge      Purge      `ini:"purge,omitempty"`
+	Kommersant Kommersant `ini:"kommersant,omitempty"`
+	Storage    Storage    `ini:"storage,omitempty"`
+	Ledger     Ledger     `ini:"ledger,omitempty"`
 }
 
 // Service struct
@@ -66,9 +142,90 @@ type Service struct {
 	ConsulPort uint16 `ini:"consul_port,omitempty"`
 }
 
-// DB struct
+// DB struct. Driver is one of "mysql", "postgres" or "memory".
+// CursorSecret signs the pagination cursors, a random one is used when
+// it is empty.
 type DB struct {
-	DB         string `ini:"database,omitempty"`
-	DbUser     string `ini:"db_user,omitempty"`
-	DbPassword string `ini:"db_password,omitempty"`
+	Driver       string `ini:"driver,omitempty"`
+	DbHost       string `ini:"db_host,omitempty"`
+	DbPort       uint16 `ini:"db_port,omitempty"`
+	DB           string `ini:"database,omitempty"`
+	DbUser       string `ini:"db_user,omitempty"`
+	DbPassword   string `ini:"db_password,omitempty"`
+	CursorSecret string `ini:"cursor_secret,omitempty"`
+}
+
+// Purge struct. Soft deleted records older than RetentionDays are purged
+// every IntervalHours, a zero RetentionDays disables purging.
+type Purge struct {
+	RetentionDays uint16 `ini:"retention_days,omitempty"`
+	IntervalHours uint16 `ini:"interval_hours,omitempty"`
+}
+
+// Kommersant struct. Addr is the gRPC address of the kommersant service,
+// it is required. The pending publications are refreshed every
+// RefreshMinutes.
+type Kommersant struct {
+	Addr           string `ini:"addr,omitempty"`
+	RefreshMinutes uint16 `ini:"refresh_minutes,omitempty"`
+}
+
+// Storage struct. Backend is "local", keeping the files in Dir, or "s3"
+// for an S3 compatible object storage. Uploads are limited to MaxSizeMB
+// and to the AllowedTypes, media types or prefixes like "image/", any type
+// is allowed when it is empty.
+type Storage struct {
+	Backend      string   `ini:"backend,omitempty"`
+	Dir          string   `ini:"dir,omitempty"`
+	S3Endpoint   string   `ini:"s3_endpoint,omitempty"`
+	S3Region     string   `ini:"s3_region,omitempty"`
+	S3Bucket     string   `ini:"s3_bucket,omitempty"`
+	S3AccessKey  string   `ini:"s3_access_key,omitempty"`
+	S3SecretKey  string   `ini:"s3_secret_key,omitempty"`
+	MaxSizeMB    uint16   `ini:"max_size_mb,omitempty"`
+	AllowedTypes []string `ini:"allowed_types,omitempty" delim:","`
+}
+
+// Ledger struct. PDFFont is the TrueType font of the PDF statements, the
+// service does not start when it cannot be loaded.
+type Ledger struct {
+	PDFFont string `ini:"pdf_font,omitempty"`
+}
+
+// DSN returns the connection string for the driver.
+func (d DB) DSN() string {
+	switch d.Driver {
+	case "postgres":
+		// The driver quotes the values of the URL, a password may hold
+		// spaces and quotes.
+		u := url.URL{
+			Scheme:   "postgres",
+			User:     url.UserPassword(d.DbUser, d.DbPassword),
+			Host:     d.DbHost,
+			Path:     "/" + d.DB,
+			RawQuery: "sslmode=disable",
+		}
+		if d.DbPort != 0 {
+			u.Host = fmt.Sprintf("%s:%d", d.DbHost, d.DbPort)
+		}
+		dsn, err := pq.ParseURL(u.String())
+		if err != nil {
+			return ""
+		}
+		return dsn
+	default:
+		addr := ""
+		if d.DbHost != "" || d.DbPort != 0 {
+			host := d.DbHost
+			if host == "" {
+				host = "127.0.0.1"
+			}
+			port := d.DbPort
+			if port == 0 {
+				port = 3306
+			}
+			addr = fmt.Sprintf("tcp(%s:%d)", host, port)
+		}
+		return fmt.Sprintf("%s:%s@%s/%s?charset=utf8&parseTime=True&loc=Local", d.DbUser, d.DbPassword, addr, d.DB)
+	}
 }
@@ -0,0 +1,28 @@
+package config
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestFile(t *testing.T) {
+	cases := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"-cfg", "a.ini"}, "a.ini"},
+		{[]string{"--cfg=a.ini"}, "a.ini"},
+		{[]string{"-http.port", "80", "-cfg", "a.ini", "-db.host", "db"}, "a.ini"},
+		{[]string{"-http.port", "cfg"}, ""},
+		{[]string{"--", "-cfg", "a.ini"}, ""},
+	}
+	for _, c := range cases {
+		got := File(c.args)
+		if c.want == "" && filepath.Ext(got) == ".ini" && filepath.IsAbs(got) {
+			continue
+		}
+		if got != c.want {
+			t.Errorf("File(%q) = %q, want %q", c.args, got, c.want)
+		}
+	}
+}
@@ -4,7 +4,13 @@ import (
 	"context"
 	"flag"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
 	"os"
+	"os/signal"
+	"strconv"
 	"text/tabwriter"
 
 	gokitservice "microsrv/pb"
@@ -15,11 +21,16 @@ func main() {
 	fs := flag.NewFlagSet("debtorclient", flag.ExitOnError)
 	var (
 		serviceAddr = fs.String("sercice.addr", "127.0.0.1:9120", "The Go Kit greeter service address")
-		// ID          = fs.String("ID", "1", "The Name to greet")
+		httpAddr    = fs.String("http.addr", "127.0.0.1:9110", "The debtor service HTTP address, used by export")
+		format      = fs.String("format", "csv", "Export format: csv, xlsx or jsonl")
+		output      = fs.String("o", "", "Export file, standard output by default")
+		inn         = fs.String("inn", "", "Stream or export only the debtors with the INN")
+		ogrn        = fs.String("ogrn", "", "Stream or export only the debtors with the OGRN")
+		caseNo      = fs.String("case", "", "Stream or export only the debtors with the case number")
+		managerID   = fs.Uint("manager", 0, "Stream or export only the debtors of the bankruptcy manager")
+		biddings    = fs.Bool("biddings", false, "Stream or export only the debtors with active biddings")
 	)
-	// id, _ := strconv.Atoi(*ID)
-	// uid := uint32(id)
-	fs.Usage = usageFor(fs, os.Args[0]+" [flags]")
+	fs.Usage = usageFor(fs, os.Args[0]+" [flags] [list|stream|export]")
 	fs.Parse(os.Args[1:])
 
 	conn, err := grpc.Dial(*serviceAddr, grpc.WithInsecure())
@@ -35,12 +46,104 @@ func main() {
 	}()
 
 	client := gokitservice.NewDebtorSvcClient(conn)
-	serviceResponse, err := client.GetAll(context.Background(), &gokitservice.Pagination{})
+	switch fs.Arg(0) {
+	case "", "list":
+		serviceResponse, err := client.GetAll(context.Background(), &gokitservice.Pagination{})
+		if err != nil {
+			fmt.Println("goKitServiceErr", err)
+			return
+		}
+		fmt.Printf("goKitResponse: %+v", serviceResponse.Debtors)
+	case "stream":
+		filter := &gokitservice.DebtorFilter{
+			INN:                 *inn,
+			OGRN:                *ogrn,
+			CaseNo:              *caseNo,
+			BankruptcyManagerID: uint32(*managerID),
+			HasActiveBiddings:   *biddings,
+		}
+		if err := stream(client, filter); err != nil {
+			fmt.Println("goKitServiceErr", err)
+			os.Exit(1)
+		}
+	case "export":
+		q := url.Values{}
+		q.Set("format", *format)
+		q.Set("inn", *inn)
+		q.Set("ogrn", *ogrn)
+		q.Set("case_no", *caseNo)
+		if *managerID != 0 {
+			q.Set("manager_id", strconv.FormatUint(uint64(*managerID), 10))
+		}
+		if *biddings {
+			q.Set("has_active_biddings", "true")
+		}
+		if err := export("http://"+*httpAddr+"/debtors/export?"+q.Encode(), *output); err != nil {
+			fmt.Println("exportErr", err)
+			os.Exit(1)
+		}
+	default:
+		fs.Usage()
+		os.Exit(2)
+	}
+}
+
+// export downloads the export to the file, or to the standard output when
+// file is empty. An incomplete file is removed.
+func export(u, file string) error {
+	resp, err := http.Get(u)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		b, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("%s: %s", resp.Status, b)
+	}
+	if file == "" {
+		_, err = io.Copy(os.Stdout, resp.Body)
+		return err
+	}
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(f, resp.Body); err == nil {
+		err = f.Close()
+	} else {
+		f.Close()
+	}
 	if err != nil {
-		fmt.Println("goKitServiceErr", err)
-		return
+		os.Remove(file)
+	}
+	return err
+}
+
+// stream prints the debtors tab separated, one per line as they arrive. Interrupting the
+// client cancels the stream on the server.
+func stream(client gokitservice.DebtorSvcClient, filter *gokitservice.DebtorFilter) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, os.Interrupt)
+	go func() {
+		<-c
+		cancel()
+	}()
+	s, err := client.StreamDebtors(ctx, filter)
+	if err != nil {
+		return err
+	}
+	for {
+		d, err := s.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%d\t%s\t%s\t%s\t%s\n", d.ID, d.INN, d.OGRN, d.CaseNo, d.Name)
 	}
-	fmt.Printf("goKitResponse: %+v", serviceResponse.Debtors)
 }
 
 func usageFor(fs *flag.FlagSet, short string) func() {
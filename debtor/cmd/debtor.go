@@ -1,19 +1,20 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"net"
 	"net/http"
 	"os"
 	"os/signal"
-	"path/filepath"
 	"strconv"
-	"strings"
 	"syscall"
 	"text/tabwriter"
+	"time"
 
 	"microsrv/config"
+	"microsrv/schedule"
 
 	"github.com/go-kit/kit/log"
 	kitgrpc "github.com/go-kit/kit/transport/grpc"
@@ -28,22 +29,13 @@ import (
 
 func main() {
 	cfg := config.Parameters{}
-	pwd, _ := os.Getwd()
-	ex, _ := os.Executable()
-	ex = filepath.Base(ex)
-	iniFile := ""
 	fs := flag.NewFlagSet("debtor", flag.ExitOnError)
-	configFile := fs.String("cfg", "", "Location of config file")
-	if *configFile == "" {
-		iniFile = filepath.Join(pwd, strings.TrimSuffix(ex, filepath.Ext(ex))+".ini")
-	} else {
-		iniFile = *configFile
-	}
+	iniFile := config.File(os.Args[1:])
+	fs.String("cfg", iniFile, "Location of config file")
 	err := cfg.Read(iniFile)
 	if err != nil {
 		fmt.Println(err)
 	}
-	cfg.Save(iniFile)
 	var (
 		debugPort  = fs.String("debug.port", fmt.Sprintf(":%d", cfg.Service.DebugPort), "Debug and metrics listen address")
 		grpcPort   = fs.String("grpc.port", fmt.Sprintf("%d", cfg.Service.GrpcPort), "gRPC listen address")
@@ -51,13 +43,31 @@ func main() {
 		httpPort   = fs.String("http.port", fmt.Sprintf("%d", cfg.Service.HTTPPort), "HTTP Listen Port")
 		consulAddr = fs.String("consul.addr", fmt.Sprintf("%s", cfg.Service.ConsulAddr), "Consul Address")
 		consulPort = fs.String("consul.port", fmt.Sprintf("%d", cfg.Service.ConsulPort), "Consul Port")
+		driver     = fs.String("db.driver", fmt.Sprintf("%s", cfg.DB.Driver), "Database driver: mysql, postgres or memory")
+		dbHost     = fs.String("db.host", fmt.Sprintf("%s", cfg.DB.DbHost), "Database host")
+		dbPort     = fs.Uint("db.port", uint(cfg.DB.DbPort), "Database port")
 		db         = fs.String("db.database", fmt.Sprintf("%s", cfg.DB.DB), "Database name")
 		user       = fs.String("db.user", fmt.Sprintf("%s", cfg.DB.DbUser), "Database user")
 		password   = fs.String("db.password", fmt.Sprintf("%s", cfg.DB.DbPassword), "Database password")
+		retention  = fs.Uint("purge.retention", uint(cfg.Purge.RetentionDays), "Days deleted debtors are kept before they are purged, 0 never purges")
+		interval   = fs.Uint("purge.interval", uint(cfg.Purge.IntervalHours), "Hours between purges of deleted debtors")
 	)
-	dsn := fmt.Sprintf("%s:%s@/%s?charset=utf8&parseTime=True&loc=Local", *user, *password, *db)
-	fs.Usage = usageFor(fs, os.Args[0]+" [flags]")
-	fs.Parse(os.Args[1:])
+	fs.Usage = usageFor(fs, os.Args[0]+" [migrate] [flags] [up|down|status]")
+	args := os.Args[1:]
+	migrateCmd := len(args) > 0 && args[0] == "migrate"
+	if migrateCmd {
+		args = args[1:]
+	}
+	fs.Parse(args)
+	dbConfig := config.DB{
+		Driver:       *driver,
+		DbHost:       *dbHost,
+		DbPort:       uint16(*dbPort),
+		DB:           *db,
+		DbUser:       *user,
+		DbPassword:   *password,
+		CursorSecret: cfg.DB.CursorSecret,
+	}
 
 	iGrpcPort, _ := strconv.Atoi(*grpcPort)
 
@@ -67,11 +77,29 @@ func main() {
 		logger = log.With(logger, "ts", log.DefaultTimestampUTC)
 		logger = log.With(logger, "caller", log.DefaultCaller)
 	}
+	if migrateCmd {
+		os.Exit(runMigrate(dbConfig, fs.Arg(0), logger))
+	}
 	var service debtorservice.Service
 	{
-		service = debtorservice.NewDB(dsn)
+		service, err = debtorservice.New(dbConfig)
+		if err != nil {
+			logger.Log("during", "New", "err", err)
+			os.Exit(1)
+		}
+		service = debtorservice.ValidationMiddleware()(service)
+		service = debtorservice.ActorMiddleware()(service)
 		service = debtorservice.LoggingMiddleware(logger)(service)
 	}
+	scheduler := schedule.New(schedule.NewMemStore(), log.With(logger, "component", "scheduler"))
+	if *retention > 0 {
+		day := 24 * time.Hour
+		_, err := debtorservice.SchedulePurge(service, scheduler, time.Duration(*retention)*day, time.Duration(*interval)*time.Hour)
+		if err != nil {
+			logger.Log("during", "SchedulePurge", "err", err)
+			os.Exit(1)
+		}
+	}
 
 	var (
 		endpoints   = debtorendpoint.MakeServerEndpoints(service)
@@ -88,6 +116,8 @@ func main() {
 			logger.Log("transport", "debug/HTTP", "during", "Listen", "err", err)
 			os.Exit(1)
 		}
+		http.Handle("/jobs", schedule.NewHTTPHandler(scheduler))
+		http.Handle("/jobs/", schedule.NewHTTPHandler(scheduler))
 		g.Add(func() error {
 			logger.Log("transport", "debug/HTTP", "addr", *debugPort)
 			return http.Serve(debugListener, http.DefaultServeMux)
@@ -122,6 +152,15 @@ func main() {
 			grpcListener.Close()
 		})
 	}
+	{
+		// The scheduler runs the purges of deleted debtors.
+		ctx, cancel := context.WithCancel(context.Background())
+		g.Add(func() error {
+			return scheduler.Run(ctx)
+		}, func(error) {
+			cancel()
+		})
+	}
 	{
 		// This function just sits and waits for ctrl-C.
 		cancelInterrupt := make(chan struct{})
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"microsrv/config"
+	"microsrv/migrate"
+
+	"github.com/go-kit/kit/log"
+	"github.com/jinzhu/gorm"
+)
+
+// runMigrate executes the `debtor migrate up|down|status` subcommand and
+// returns the exit code.
+func runMigrate(cfg config.DB, action string, logger log.Logger) int {
+	if cfg.Driver == "memory" {
+		logger.Log("migrate", action, "err", "the memory driver has no schema")
+		return 1
+	}
+	db, err := gorm.Open(cfg.Driver, cfg.DSN())
+	if err != nil {
+		logger.Log("migrate", action, "during", "Open", "err", err)
+		return 1
+	}
+	defer db.Close()
+	m := migrate.New(db, migrate.Migrations)
+	switch action {
+	case "up":
+		done, err := m.Up()
+		for _, mg := range done {
+			logger.Log("migrate", "up", "version", mg.Version, "name", mg.Name)
+		}
+		if err != nil {
+			logger.Log("migrate", "up", "err", err)
+			return 1
+		}
+		if len(done) == 0 {
+			logger.Log("migrate", "up", "result", "schema is up to date")
+		}
+	case "down":
+		mg, err := m.Down()
+		if err != nil {
+			logger.Log("migrate", "down", "err", err)
+			return 1
+		}
+		logger.Log("migrate", "down", "version", mg.Version, "name", mg.Name)
+	case "status":
+		status, err := m.Status()
+		if err != nil {
+			logger.Log("migrate", "status", "err", err)
+			return 1
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 2, 2, ' ', 0)
+		fmt.Fprintf(w, "VERSION\tNAME\tAPPLIED\n")
+		for _, s := range status {
+			applied := "pending"
+			if s.Applied {
+				applied = s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\n", s.Version, s.Name, applied)
+		}
+		w.Flush()
+	default:
+		logger.Log("migrate", action, "err", "expected up, down or status")
+		return 2
+	}
+	return 0
+}
@@ -2,7 +2,12 @@ package debtorendpoint
 
 import (
 	"context"
+	"io"
+	"math"
+	"time"
 
+	"microsrv/apperr"
+	"microsrv/clientbank"
 	"microsrv/pb"
 
 	"microsrv/model"
@@ -10,28 +15,59 @@ import (
 	"microsrv/debtor/service"
 
 	"github.com/go-kit/kit/endpoint"
+	"github.com/golang/protobuf/ptypes"
 	"github.com/jinzhu/copier"
 )
 
 // Endpoints struct
 type Endpoints struct {
-	HealthEndpoint        endpoint.Endpoint // used by Consul for the healthcheck
-	CreateDebtorEndpoint  endpoint.Endpoint
-	GetDebtorEndpoint     endpoint.Endpoint
-	GetAllDebtorsEndpoint endpoint.Endpoint
-	SaveDebtorEndpoint    endpoint.Endpoint
-	DeleteDebtorEndpoint  endpoint.Endpoint
+	HealthEndpoint           endpoint.Endpoint // used by Consul for the healthcheck
+	CreateDebtorEndpoint     endpoint.Endpoint
+	GetDebtorEndpoint        endpoint.Endpoint
+	GetAllDebtorsEndpoint    endpoint.Endpoint
+	SaveDebtorEndpoint       endpoint.Endpoint
+	DeleteDebtorEndpoint     endpoint.Endpoint
+	StreamDebtorsEndpoint    endpoint.Endpoint
+	ImportDebtorsEndpoint    endpoint.Endpoint
+	ExportDebtorsEndpoint    endpoint.Endpoint
+	HistoryEndpoint          endpoint.Endpoint
+	ListDeletedEndpoint      endpoint.Endpoint
+	RestoreEndpoint          endpoint.Endpoint
+	PurgeEndpoint            endpoint.Endpoint
+	ListBankDetailsEndpoint  endpoint.Endpoint
+	AddBankDetailEndpoint    endpoint.Endpoint
+	SaveBankDetailEndpoint   endpoint.Endpoint
+	CloseBankDetailEndpoint  endpoint.Endpoint
+	PostStatementEndpoint    endpoint.Endpoint
+	ListStatementsEndpoint   endpoint.Endpoint
+	CashFlowEndpoint         endpoint.Endpoint
+	ImportStatementsEndpoint endpoint.Endpoint
 }
 
 // MakeServerEndpoints func
 func MakeServerEndpoints(s debtorservice.Service) Endpoints {
 	return Endpoints{
-		HealthEndpoint:        HealthEndpoint(s),
-		CreateDebtorEndpoint:  CreateEndpoint(s),
-		GetDebtorEndpoint:     GetEndpoint(s),
-		GetAllDebtorsEndpoint: GetAllEndpoint(s),
-		SaveDebtorEndpoint:    SaveEndpoint(s),
-		DeleteDebtorEndpoint:  DeleteEndpoint(s),
+		HealthEndpoint:           HealthEndpoint(s),
+		CreateDebtorEndpoint:     CreateEndpoint(s),
+		GetDebtorEndpoint:        GetEndpoint(s),
+		GetAllDebtorsEndpoint:    GetAllEndpoint(s),
+		SaveDebtorEndpoint:       SaveEndpoint(s),
+		DeleteDebtorEndpoint:     DeleteEndpoint(s),
+		StreamDebtorsEndpoint:    StreamEndpoint(s),
+		ImportDebtorsEndpoint:    ImportEndpoint(s),
+		ExportDebtorsEndpoint:    ExportEndpoint(s),
+		HistoryEndpoint:          HistoryEndpoint(s),
+		ListDeletedEndpoint:      ListDeletedEndpoint(s),
+		RestoreEndpoint:          RestoreEndpoint(s),
+		PurgeEndpoint:            PurgeEndpoint(s),
+		ListBankDetailsEndpoint:  ListBankDetailsEndpoint(s),
+		AddBankDetailEndpoint:    AddBankDetailEndpoint(s),
+		SaveBankDetailEndpoint:   SaveBankDetailEndpoint(s),
+		CloseBankDetailEndpoint:  CloseBankDetailEndpoint(s),
+		PostStatementEndpoint:    PostStatementEndpoint(s),
+		ListStatementsEndpoint:   ListStatementsEndpoint(s),
+		CashFlowEndpoint:         CashFlowEndpoint(s),
+		ImportStatementsEndpoint: ImportStatementsEndpoint(s),
 	}
 }
 
@@ -40,6 +76,18 @@ var (
 	_ endpoint.Failer = HealthResponse{}
 	_ endpoint.Failer = model.DebtorsResponse{}
 	_ endpoint.Failer = model.DebtorResponse{}
+	_ endpoint.Failer = DeleteResponse{}
+	_ endpoint.Failer = StreamResponse{}
+	_ endpoint.Failer = ImportResponse{}
+	_ endpoint.Failer = ExportResponse{}
+	_ endpoint.Failer = HistoryResponse{}
+	_ endpoint.Failer = PurgeResponse{}
+	_ endpoint.Failer = BankDetailsResponse{}
+	_ endpoint.Failer = BankDetailResponse{}
+	_ endpoint.Failer = StatementResponse{}
+	_ endpoint.Failer = StatementsResponse{}
+	_ endpoint.Failer = CashFlowResponse{}
+	_ endpoint.Failer = ImportStatementsResponse{}
 )
 
 // HealthEndpoint constructs a Health endpoint wrapping the service.
@@ -54,15 +102,17 @@ func HealthEndpoint(s debtorservice.Service) endpoint.Endpoint {
 func CreateEndpoint(s debtorservice.Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
 		req := request.(model.Debtor)
-		return s.CreateDebtor(ctx, req)
+		res, e := s.CreateDebtor(ctx, req)
+		return model.DebtorResponse{Debtor: res, Err: e}, nil
 	}
 }
 
 // SaveEndpoint func
 func SaveEndpoint(s debtorservice.Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
-		req := response.(map[string]interface{})
-		return s.Save(ctx, req["debtor"].(model.Debtor), req["ID"].(uint))
+		req := request.(SaveRequest)
+		res, e := s.Save(ctx, req.Debtor, req.ID, req.Version, req.Mask)
+		return model.DebtorResponse{Debtor: res, Err: e}, nil
 	}
 }
 
@@ -82,10 +132,11 @@ func GetEndpoint(s debtorservice.Service) endpoint.Endpoint {
 func GetAllEndpoint(s debtorservice.Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
 		req := request.(*pb.Pagination)
-		page := model.Pagination{}
-		copier.Copy(&page, req)
-		resp := model.DebtorsResponse{}
-		resp, e := s.GetAll(ctx, page)
+		query, err := QueryFromPB(req)
+		if err != nil {
+			return debtorservice.Page{DebtorsResponse: model.DebtorsResponse{Err: err}}, nil
+		}
+		resp, e := s.GetAll(ctx, query)
 		resp.Err = e
 		return resp, nil
 	}
@@ -95,10 +146,312 @@ func GetAllEndpoint(s debtorservice.Service) endpoint.Endpoint {
 func DeleteEndpoint(s debtorservice.Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
 		req := request.(*pb.DebtorByID)
-		return nil, s.Delete(ctx, uint(req.ID))
+		e := s.Delete(ctx, uint(req.ID))
+		return DeleteResponse{Err: e}, nil
 	}
 }
 
+// StreamEndpoint func. The request carries the Send callback of the
+// transport stream.
+func StreamEndpoint(s debtorservice.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(StreamRequest)
+		e := s.StreamDebtors(ctx, req.Filter, req.Send)
+		return StreamResponse{Err: e}, nil
+	}
+}
+
+// ImportEndpoint func
+func ImportEndpoint(s debtorservice.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(ImportRequest)
+		rows, e := debtorservice.NewRowReader(req.Format, req.Body)
+		if e != nil {
+			return ImportResponse{Err: e}, nil
+		}
+		report, e := debtorservice.Import(ctx, s, rows, req.Mapping)
+		return ImportResponse{Report: report, Err: e}, nil
+	}
+}
+
+// ExportEndpoint func. The export is written by the response encoder, so
+// that it is streamed to the client.
+func ExportEndpoint(s debtorservice.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(ExportRequest)
+		if _, ok := debtorservice.ExportContentTypes[req.Format]; !ok {
+			return ExportResponse{Err: debtorservice.ErrExportFormat}, nil
+		}
+		return ExportResponse{
+			Format: req.Format,
+			Write: func(w io.Writer) error {
+				return debtorservice.Export(ctx, s, req.Filter, req.Format, w)
+			},
+		}, nil
+	}
+}
+
+// HistoryEndpoint func
+func HistoryEndpoint(s debtorservice.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(*pb.DebtorByID)
+		records, e := s.GetDebtorHistory(ctx, uint(req.ID))
+		return HistoryResponse{Records: records, Err: e}, nil
+	}
+}
+
+// ListDeletedEndpoint func
+func ListDeletedEndpoint(s debtorservice.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(*pb.Pagination)
+		p := model.Pagination{}
+		copier.Copy(&p, req)
+		resp, e := s.ListDeleted(ctx, p)
+		resp.Err = e
+		return resp, nil
+	}
+}
+
+// RestoreEndpoint func
+func RestoreEndpoint(s debtorservice.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(*pb.DebtorByID)
+		d, e := s.Restore(ctx, uint(req.ID))
+		return model.DebtorResponse{Debtor: d, Err: e}, nil
+	}
+}
+
+// PurgeEndpoint func
+func PurgeEndpoint(s debtorservice.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(*pb.PurgeRequest)
+		before := time.Time{}
+		if req.DeletedBefore != nil {
+			if before, err = ptypes.Timestamp(req.DeletedBefore); err != nil {
+				return PurgeResponse{Err: apperr.NewValidation(err.Error(), apperr.Field{Field: "deletedBefore", Description: "invalid timestamp"})}, nil
+			}
+		}
+		purged, e := s.Purge(ctx, uint(req.ID), before)
+		return PurgeResponse{Purged: purged, Err: e}, nil
+	}
+}
+
+// ListBankDetailsEndpoint func
+func ListBankDetailsEndpoint(s debtorservice.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(*pb.BankDetailRequest)
+		res, e := s.ListBankDetails(ctx, bankDetailScope(req))
+		return BankDetailsResponse{BankDetails: res, Err: e}, nil
+	}
+}
+
+// AddBankDetailEndpoint func
+func AddBankDetailEndpoint(s debtorservice.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(*pb.BankDetailRequest)
+		res, e := s.AddBankDetail(ctx, bankDetailScope(req), bankDetailFromPB(req.BankDetail))
+		return BankDetailResponse{BankDetail: res, Err: e}, nil
+	}
+}
+
+// SaveBankDetailEndpoint func
+func SaveBankDetailEndpoint(s debtorservice.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(*pb.BankDetailRequest)
+		res, e := s.SaveBankDetail(ctx, bankDetailScope(req), uint(req.ID), bankDetailFromPB(req.BankDetail))
+		return BankDetailResponse{BankDetail: res, Err: e}, nil
+	}
+}
+
+// CloseBankDetailEndpoint func
+func CloseBankDetailEndpoint(s debtorservice.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(*pb.BankDetailRequest)
+		e := s.CloseBankDetail(ctx, bankDetailScope(req), uint(req.ID))
+		return DeleteResponse{Err: e}, nil
+	}
+}
+
+func bankDetailScope(req *pb.BankDetailRequest) debtorservice.BankDetailScope {
+	return debtorservice.BankDetailScope{DebtorID: uint(req.DebtorID), InitiatorID: uint(req.InitiatorID)}
+}
+
+func bankDetailFromPB(b *pb.BankDetail) model.BankDetail {
+	res := model.BankDetail{}
+	if b != nil {
+		copier.Copy(&res, b)
+	}
+	return res
+}
+
+// PostStatementEndpoint func
+func PostStatementEndpoint(s debtorservice.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(*pb.StatementRequest)
+		st, e := StatementFromPB(req.Statement)
+		if e != nil {
+			return StatementResponse{Err: e}, nil
+		}
+		scope := debtorservice.BankDetailScope{DebtorID: uint(req.DebtorID), InitiatorID: uint(req.InitiatorID)}
+		res, e := s.PostStatement(ctx, scope, uint(req.BankDetailID), st)
+		return StatementResponse{Statement: res, Err: e}, nil
+	}
+}
+
+// ListStatementsEndpoint func
+func ListStatementsEndpoint(s debtorservice.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(*pb.BankDetailRequest)
+		res, e := s.ListStatements(ctx, bankDetailScope(req), uint(req.ID))
+		return StatementsResponse{Statements: res, Err: e}, nil
+	}
+}
+
+// CashFlowEndpoint func
+func CashFlowEndpoint(s debtorservice.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(*pb.CashFlowRequest)
+		if req.From == nil || req.To == nil {
+			return CashFlowResponse{Err: apperr.NewValidation("report period is required", apperr.Field{Field: "from", Description: "from and to are required"})}, nil
+		}
+		from, e := ptypes.Timestamp(req.From)
+		if e != nil {
+			return CashFlowResponse{Err: apperr.NewValidation(e.Error(), apperr.Field{Field: "from", Description: "invalid timestamp"})}, nil
+		}
+		to, e := ptypes.Timestamp(req.To)
+		if e != nil {
+			return CashFlowResponse{Err: apperr.NewValidation(e.Error(), apperr.Field{Field: "to", Description: "invalid timestamp"})}, nil
+		}
+		res, e := s.CashFlow(ctx, uint(req.DebtorID), from, to)
+		return CashFlowResponse{Report: res, Err: e}, nil
+	}
+}
+
+// ImportStatementsEndpoint func
+func ImportStatementsEndpoint(s debtorservice.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(ImportStatementsRequest)
+		f, e := clientbank.Parse(req.Body)
+		if _, ok := e.(*clientbank.ParseError); ok || e == clientbank.ErrFormat {
+			e = apperr.NewValidation(e.Error(), apperr.Field{Field: "file", Description: "must be a 1CClientBankExchange file"})
+		}
+		if e != nil {
+			return ImportStatementsResponse{Err: e}, nil
+		}
+		report, e := debtorservice.ImportStatements(ctx, s, f, packDocument)
+		return ImportStatementsResponse{Report: report, Err: e}, nil
+	}
+}
+
+// packDocument packs a payment document as a pb.PaymentDocument.
+func packDocument(d clientbank.Document) (debtorservice.Document, error) {
+	doc := &pb.PaymentDocument{
+		Kind:         d.Kind,
+		Number:       d.Number,
+		AmountKop:    d.Amount,
+		PayerAccount: d.PayerAccount,
+		PayerBIK:     d.PayerBIK,
+		PayerINN:     d.PayerINN,
+		Payer:        d.Payer,
+		PayeeAccount: d.PayeeAccount,
+		PayeeBIK:     d.PayeeBIK,
+		PayeeINN:     d.PayeeINN,
+		Payee:        d.Payee,
+		Purpose:      d.Purpose,
+	}
+	if !d.Date.IsZero() {
+		doc.Date, _ = ptypes.TimestampProto(d.Date)
+	}
+	if !d.Written.IsZero() {
+		doc.Written, _ = ptypes.TimestampProto(d.Written)
+	}
+	if !d.Received.IsZero() {
+		doc.Received, _ = ptypes.TimestampProto(d.Received)
+	}
+	a, err := ptypes.MarshalAny(doc)
+	if err != nil {
+		return debtorservice.Document{}, err
+	}
+	return debtorservice.Document{TypeURL: a.TypeUrl, Value: a.Value}, nil
+}
+
+// StatementFromPB converts a gRPC statement. The amounts in kopecks are
+// used when any is set, the ones in roubles otherwise.
+func StatementFromPB(r *pb.Remaining) (debtorservice.Statement, error) {
+	st := debtorservice.Statement{}
+	if r == nil {
+		return st, apperr.NewValidation("statement is required", apperr.Field{Field: "statement", Description: "is required"})
+	}
+	var err error
+	if r.StartDate == nil || r.EndDate == nil {
+		return st, apperr.NewValidation("statement period is required", apperr.Field{Field: "startDate", Description: "startDate and endDate are required"})
+	}
+	if st.StartDate, err = ptypes.Timestamp(r.StartDate); err != nil {
+		return st, apperr.NewValidation(err.Error(), apperr.Field{Field: "startDate", Description: "invalid timestamp"})
+	}
+	if st.EndDate, err = ptypes.Timestamp(r.EndDate); err != nil {
+		return st, apperr.NewValidation(err.Error(), apperr.Field{Field: "endDate", Description: "invalid timestamp"})
+	}
+	st.Account = r.Account
+	st.CreatorID = uint(r.Creator_ID)
+	st.InitialBalance, st.Income, st.WriteOff, st.FinalBalance = r.InitialBalanceKop, r.IncomeKop, r.WriteOffKop, r.FinalBalanceKop
+	if st.InitialBalance == 0 && st.Income == 0 && st.WriteOff == 0 && st.FinalBalance == 0 {
+		st.InitialBalance, st.Income = kopecks(r.InitialBalance), kopecks(r.Income)
+		st.WriteOff, st.FinalBalance = kopecks(r.WriteOff), kopecks(r.FinalBalance)
+	}
+	for _, d := range r.PaymentDocuments {
+		st.PaymentDocuments = append(st.PaymentDocuments, debtorservice.Document{TypeURL: d.TypeUrl, Value: d.Value})
+	}
+	return st, nil
+}
+
+func kopecks(roubles float32) int64 {
+	return int64(math.Round(float64(roubles) * 100))
+}
+
+// QueryFromPB converts the gRPC pagination and filter to a service query.
+func QueryFromPB(req *pb.Pagination) (debtorservice.Query, error) {
+	query := debtorservice.Query{}
+	copier.Copy(&query.Pagination, req)
+	query.Cursor = req.Cursor
+	for _, k := range req.Order {
+		query.Order = append(query.Order, debtorservice.SortKey{Field: k.Field, Desc: k.Desc})
+	}
+	filter, err := FilterFromPB(req.Filter)
+	query.Filter = filter
+	return query, err
+}
+
+// FilterFromPB converts the gRPC debtor filter to a service filter.
+func FilterFromPB(f *pb.DebtorFilter) (debtorservice.Filter, error) {
+	if f == nil {
+		return debtorservice.Filter{}, nil
+	}
+	filter := debtorservice.Filter{
+		INN:                 f.INN,
+		OGRN:                f.OGRN,
+		CaseNo:              f.CaseNo,
+		ArbitrationID:       f.ArbitrationID,
+		BankruptcyManagerID: uint(f.BankruptcyManagerID),
+		HasActiveBiddings:   f.HasActiveBiddings,
+	}
+	if f.DecisionDateFrom != nil {
+		t, err := ptypes.Timestamp(f.DecisionDateFrom)
+		if err != nil {
+			return filter, apperr.NewValidation(err.Error(), apperr.Field{Field: "filter.decisionDateFrom", Description: "invalid timestamp"})
+		}
+		filter.DecisionDateFrom = &t
+	}
+	if f.DecisionDateTo != nil {
+		t, err := ptypes.Timestamp(f.DecisionDateTo)
+		if err != nil {
+			return filter, apperr.NewValidation(err.Error(), apperr.Field{Field: "filter.decisionDateTo", Description: "invalid timestamp"})
+		}
+		filter.DecisionDateTo = &t
+	}
+	return filter, nil
+}
+
 // Failer is an interface that should be implemented by response types.
 // Response encoders can check if responses are Failer, and if so if they've
 // failed, and if so encode them using a separate write path based on the error.
@@ -106,6 +459,157 @@ type Failer interface {
 	Failed() error
 }
 
+// SaveRequest collects the request parameters for the Save method.
+type SaveRequest struct {
+	ID      uint
+	Debtor  model.Debtor
+	Version string
+	Mask    debtorservice.FieldMask
+}
+
+// DeleteResponse collects the response values for the Delete method.
+type DeleteResponse struct {
+	Err error `json:"err,omitempty"`
+}
+
+// Failed implements Failer.
+func (r DeleteResponse) Failed() error { return r.Err }
+
+// StreamRequest collects the request parameters for the StreamDebtors
+// method. Send delivers a debtor to the client and blocks while the
+// client is not ready to receive it.
+type StreamRequest struct {
+	Filter debtorservice.Filter
+	Send   func(model.Debtor) error
+}
+
+// StreamResponse collects the response values for the StreamDebtors method.
+type StreamResponse struct {
+	Err error `json:"err,omitempty"`
+}
+
+// Failed implements Failer.
+func (r StreamResponse) Failed() error { return r.Err }
+
+// ImportStatementsRequest collects the request parameters for the
+// ImportStatements method. Body is a 1CClientBankExchange file.
+type ImportStatementsRequest struct {
+	Body io.Reader
+}
+
+// ImportStatementsResponse collects the response values for the
+// ImportStatements method.
+type ImportStatementsResponse struct {
+	Report debtorservice.StatementImportReport
+	Err    error `json:"err,omitempty"`
+}
+
+// Failed implements Failer.
+func (r ImportStatementsResponse) Failed() error { return r.Err }
+
+// ImportRequest collects the request parameters for the ImportDebtors
+// method. Body is a csv or xlsx file.
+type ImportRequest struct {
+	Format  string
+	Mapping debtorservice.Mapping
+	Body    io.Reader
+}
+
+// ImportResponse collects the response values for the ImportDebtors method.
+type ImportResponse struct {
+	Report debtorservice.ImportReport
+	Err    error `json:"err,omitempty"`
+}
+
+// Failed implements Failer.
+func (r ImportResponse) Failed() error { return r.Err }
+
+// ExportRequest collects the request parameters for the ExportDebtors
+// method.
+type ExportRequest struct {
+	Filter debtorservice.Filter
+	Format string
+}
+
+// ExportResponse collects the response values for the ExportDebtors
+// method. Write writes the export in Format.
+type ExportResponse struct {
+	Format string
+	Write  func(w io.Writer) error
+	Err    error `json:"err,omitempty"`
+}
+
+// Failed implements Failer.
+func (r ExportResponse) Failed() error { return r.Err }
+
+// HistoryResponse collects the response values for the GetDebtorHistory
+// method.
+type HistoryResponse struct {
+	Records []debtorservice.AuditRecord `json:"records"`
+	Err     error                       `json:"err,omitempty"`
+}
+
+// Failed implements Failer.
+func (r HistoryResponse) Failed() error { return r.Err }
+
+// PurgeResponse collects the response values for the Purge method.
+type PurgeResponse struct {
+	Purged uint  `json:"purged"`
+	Err    error `json:"err,omitempty"`
+}
+
+// Failed implements Failer.
+func (r PurgeResponse) Failed() error { return r.Err }
+
+// BankDetailsResponse collects the response values for the ListBankDetails
+// method.
+type BankDetailsResponse struct {
+	BankDetails []model.BankDetail `json:"bankDetails"`
+	Err         error              `json:"err,omitempty"`
+}
+
+// Failed implements Failer.
+func (r BankDetailsResponse) Failed() error { return r.Err }
+
+// BankDetailResponse collects the response values for the AddBankDetail
+// and SaveBankDetail methods.
+type BankDetailResponse struct {
+	BankDetail model.BankDetail `json:"bankDetail"`
+	Err        error            `json:"err,omitempty"`
+}
+
+// Failed implements Failer.
+func (r BankDetailResponse) Failed() error { return r.Err }
+
+// StatementResponse collects the response values for the PostStatement
+// method.
+type StatementResponse struct {
+	Statement debtorservice.Statement `json:"statement"`
+	Err       error                   `json:"err,omitempty"`
+}
+
+// Failed implements Failer.
+func (r StatementResponse) Failed() error { return r.Err }
+
+// StatementsResponse collects the response values for the ListStatements
+// method.
+type StatementsResponse struct {
+	Statements []debtorservice.Statement `json:"statements"`
+	Err        error                     `json:"err,omitempty"`
+}
+
+// Failed implements Failer.
+func (r StatementsResponse) Failed() error { return r.Err }
+
+// CashFlowResponse collects the response values for the CashFlow method.
+type CashFlowResponse struct {
+	Report debtorservice.CashFlowReport `json:"report"`
+	Err    error                        `json:"err,omitempty"`
+}
+
+// Failed implements Failer.
+func (r CashFlowResponse) Failed() error { return r.Err }
+
 // HealthRequest collects the request parameters for the Health method.
 type HealthRequest struct{}
 
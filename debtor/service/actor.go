@@ -0,0 +1,108 @@
+package debtorservice
+
+import (
+	"context"
+	"time"
+
+	"microsrv/model"
+)
+
+// ActorMiddleware returns a Middleware that rejects the mutations without
+// an actor in the context, so that every entry of the audit trail names
+// the user who made it.
+func ActorMiddleware() Middleware {
+	return func(next Service) Service {
+		return actorMiddleware{next}
+	}
+}
+
+type actorMiddleware struct {
+	Service
+}
+
+func requireActor(ctx context.Context) error {
+	if Actor(ctx) == "" {
+		return ErrNoActor
+	}
+	return nil
+}
+
+// CreateDebtor func
+func (mw actorMiddleware) CreateDebtor(ctx context.Context, d model.Debtor) (model.Debtor, error) {
+	if err := requireActor(ctx); err != nil {
+		return model.Debtor{}, err
+	}
+	return mw.Service.CreateDebtor(ctx, d)
+}
+
+// Save func
+func (mw actorMiddleware) Save(ctx context.Context, d model.Debtor, id uint, version string, mask FieldMask) (model.Debtor, error) {
+	if err := requireActor(ctx); err != nil {
+		return model.Debtor{}, err
+	}
+	return mw.Service.Save(ctx, d, id, version, mask)
+}
+
+// Delete func
+func (mw actorMiddleware) Delete(ctx context.Context, id uint) error {
+	if err := requireActor(ctx); err != nil {
+		return err
+	}
+	return mw.Service.Delete(ctx, id)
+}
+
+// UpsertDebtors func
+func (mw actorMiddleware) UpsertDebtors(ctx context.Context, debtors []model.Debtor) ([]Upserted, error) {
+	if err := requireActor(ctx); err != nil {
+		return nil, err
+	}
+	return mw.Service.UpsertDebtors(ctx, debtors)
+}
+
+// Restore func
+func (mw actorMiddleware) Restore(ctx context.Context, id uint) (model.Debtor, error) {
+	if err := requireActor(ctx); err != nil {
+		return model.Debtor{}, err
+	}
+	return mw.Service.Restore(ctx, id)
+}
+
+// Purge func
+func (mw actorMiddleware) Purge(ctx context.Context, id uint, deletedBefore time.Time) (uint, error) {
+	if err := requireActor(ctx); err != nil {
+		return 0, err
+	}
+	return mw.Service.Purge(ctx, id, deletedBefore)
+}
+
+// AddBankDetail func
+func (mw actorMiddleware) AddBankDetail(ctx context.Context, scope BankDetailScope, b model.BankDetail) (model.BankDetail, error) {
+	if err := requireActor(ctx); err != nil {
+		return model.BankDetail{}, err
+	}
+	return mw.Service.AddBankDetail(ctx, scope, b)
+}
+
+// SaveBankDetail func
+func (mw actorMiddleware) SaveBankDetail(ctx context.Context, scope BankDetailScope, id uint, b model.BankDetail) (model.BankDetail, error) {
+	if err := requireActor(ctx); err != nil {
+		return model.BankDetail{}, err
+	}
+	return mw.Service.SaveBankDetail(ctx, scope, id, b)
+}
+
+// CloseBankDetail func
+func (mw actorMiddleware) CloseBankDetail(ctx context.Context, scope BankDetailScope, id uint) error {
+	if err := requireActor(ctx); err != nil {
+		return err
+	}
+	return mw.Service.CloseBankDetail(ctx, scope, id)
+}
+
+// PostStatement func
+func (mw actorMiddleware) PostStatement(ctx context.Context, scope BankDetailScope, bankDetailID uint, st Statement) (Statement, error) {
+	if err := requireActor(ctx); err != nil {
+		return Statement{}, err
+	}
+	return mw.Service.PostStatement(ctx, scope, bankDetailID, st)
+}
@@ -0,0 +1,32 @@
+package debtorservice
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"microsrv/model"
+)
+
+func TestActorMiddleware(t *testing.T) {
+	s := ActorMiddleware()(NewMemory())
+	ctx := context.Background()
+
+	if _, err := s.CreateDebtor(ctx, model.Debtor{Name: "A"}); err != ErrNoActor {
+		t.Fatalf("CreateDebtor without an actor: %v", err)
+	}
+	rows, _ := NewRowReader("csv", strings.NewReader("ИНН;Наименование\n7707083893;Сбербанк\n"))
+	if _, err := Import(ctx, s, rows, nil); err != ErrNoActor {
+		t.Fatalf("Import without an actor: %v", err)
+	}
+
+	ctx = WithActor(ctx, "ivanov")
+	d, err := s.CreateDebtor(ctx, model.Debtor{Name: "A"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	history, err := s.GetDebtorHistory(ctx, d.ID)
+	if err != nil || len(history) != 1 || history[0].Actor != "ivanov" {
+		t.Fatalf("history %+v, %v", history, err)
+	}
+}
@@ -0,0 +1,196 @@
+package debtorservice
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"microsrv/apperr"
+	"microsrv/model"
+)
+
+// Audited operations.
+const (
+	OpCreate  = "create"
+	OpUpdate  = "update"
+	OpDelete  = "delete"
+	OpImport  = "import"
+	OpRestore = "restore"
+	OpPurge   = "purge"
+
+	OpAddBankDetail   = "add bank detail"
+	OpEditBankDetail  = "edit bank detail"
+	OpCloseBankDetail = "close bank detail"
+)
+
+// ErrNoActor is returned by the ActorMiddleware for a mutation without the
+// user performing it.
+var ErrNoActor = apperr.NewPermission("the user performing the request is not known")
+
+// AuditRecord is an entry of the debtor audit trail. Records are only ever
+// inserted, together with the mutation they describe.
+type AuditRecord struct {
+	ID        uint      `gorm:"primary_key" json:"id"`
+	DebtorID  uint      `gorm:"index" json:"debtorId"`
+	Actor     string    `json:"actor"`
+	Operation string    `json:"operation"`
+	At        time.Time `json:"at"`
+	Changes   Changes   `gorm:"type:text" json:"changes"`
+}
+
+// TableName of the audit trail.
+func (AuditRecord) TableName() string {
+	return "debtor_audit"
+}
+
+// Change is the value of a debtor field before and after a mutation.
+type Change struct {
+	Field  string `json:"field"`
+	Before string `json:"before"`
+	After  string `json:"after"`
+}
+
+// Changes are stored as a JSON array.
+type Changes []Change
+
+// Value implements driver.Valuer.
+func (c Changes) Value() (driver.Value, error) {
+	b, err := json.Marshal(c)
+	return string(b), err
+}
+
+// Scan implements sql.Scanner.
+func (c *Changes) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*c = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(v, c)
+	case string:
+		return json.Unmarshal([]byte(v), c)
+	default:
+		return errors.New("unsupported audit changes type")
+	}
+}
+
+type actorKey struct{}
+
+// WithActor returns a context carrying the user that performs the request.
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// Actor returns the user carried by the context, empty if none.
+func Actor(ctx context.Context) string {
+	a, _ := ctx.Value(actorKey{}).(string)
+	return a
+}
+
+// audit returns the record of a mutation of the debtor from before to
+// after.
+func audit(ctx context.Context, op string, before, after model.Debtor) AuditRecord {
+	id := after.ID
+	if id == 0 {
+		id = before.ID
+	}
+	return AuditRecord{
+		DebtorID:  id,
+		Actor:     Actor(ctx),
+		Operation: op,
+		At:        time.Now(),
+		Changes:   diff(before, after),
+	}
+}
+
+// diff compares the own fields of the debtors, the deletion time and the
+// bank details. The other associations are audited by their id fields.
+func diff(before, after model.Debtor) Changes {
+	changes := Changes{}
+	if b, a := auditValue(reflect.ValueOf(before.DeletedAt)), auditValue(reflect.ValueOf(after.DeletedAt)); b != a {
+		changes = append(changes, Change{Field: "DeletedAt", Before: b, After: a})
+	}
+	changes = append(changes, fieldChanges("", reflect.ValueOf(before), reflect.ValueOf(after))...)
+	return append(changes, bankDetailChanges(before.BankDetails, after.BankDetails)...)
+}
+
+// bankDetailChanges compares the bank details of the same id. A removed or
+// an added bank detail is compared with an empty one.
+func bankDetailChanges(before, after []model.BankDetail) Changes {
+	changes := Changes{}
+	removed := map[uint]model.BankDetail{}
+	for _, b := range before {
+		removed[b.ID] = b
+	}
+	for _, a := range after {
+		b := removed[a.ID]
+		delete(removed, a.ID)
+		changes = append(changes, fieldChanges(fmt.Sprintf("BankDetails[%d].", a.ID), reflect.ValueOf(b), reflect.ValueOf(a))...)
+	}
+	for _, b := range before {
+		if _, ok := removed[b.ID]; ok {
+			changes = append(changes, fieldChanges(fmt.Sprintf("BankDetails[%d].", b.ID), reflect.ValueOf(b), reflect.ValueOf(model.BankDetail{}))...)
+		}
+	}
+	return changes
+}
+
+// auditBankDetail returns the record of a mutation of a bank detail of the
+// debtor from before to after. The fields are named after the bank detail.
+func auditBankDetail(ctx context.Context, op string, before, after model.BankDetail) AuditRecord {
+	b := after
+	if b.ID == 0 {
+		b = before
+	}
+	return AuditRecord{
+		DebtorID:  b.DebtorID,
+		Actor:     Actor(ctx),
+		Operation: op,
+		At:        time.Now(),
+		Changes:   fieldChanges(fmt.Sprintf("BankDetails[%d].", b.ID), reflect.ValueOf(before), reflect.ValueOf(after)),
+	}
+}
+
+// fieldChanges compares the own fields of two structs of a type, the
+// field names prefixed.
+func fieldChanges(prefix string, bv, av reflect.Value) Changes {
+	changes := Changes{}
+	t := bv.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		switch f.Type.Kind() {
+		case reflect.Struct, reflect.Slice:
+			if f.Type != reflect.TypeOf(time.Time{}) {
+				continue
+			}
+		}
+		if f.Anonymous {
+			continue
+		}
+		b, a := auditValue(bv.Field(i)), auditValue(av.Field(i))
+		if b != a {
+			changes = append(changes, Change{Field: prefix + f.Name, Before: b, After: a})
+		}
+	}
+	return changes
+}
+
+func auditValue(v reflect.Value) string {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return ""
+		}
+		v = v.Elem()
+	}
+	if t, ok := v.Interface().(time.Time); ok {
+		if t.IsZero() {
+			return ""
+		}
+		return t.Format(time.RFC3339)
+	}
+	return fmt.Sprint(v.Interface())
+}
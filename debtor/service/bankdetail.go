@@ -0,0 +1,61 @@
+package debtorservice
+
+import (
+	"microsrv/apperr"
+	"microsrv/model"
+)
+
+var (
+	// ErrBankDetailNotFound var
+	ErrBankDetailNotFound = apperr.NewNotFound("bank detail not found")
+	// ErrBankAccountExists var
+	ErrBankAccountExists = apperr.NewConflict("bank account already exists in the bank")
+)
+
+// BankDetailScope selects the bank details of a debtor, only those of the
+// initiator when InitiatorID is not zero.
+type BankDetailScope struct {
+	DebtorID    uint
+	InitiatorID uint
+}
+
+func (s BankDetailScope) has(b model.BankDetail) bool {
+	return b.DebtorID == s.DebtorID && (s.InitiatorID == 0 || b.InitiatorID == s.InitiatorID)
+}
+
+// ValidateBankDetail requires the BIK and the account number and checks
+// their control keys.
+func ValidateBankDetail(b model.BankDetail) error {
+	v := validator{}
+	if b.BIK == "" {
+		v.fields = append(v.fields, apperr.Field{Field: "BIK", Description: "is required"})
+	}
+	if b.BankAccount == "" {
+		v.fields = append(v.fields, apperr.Field{Field: "bankAccount", Description: "is required"})
+	}
+	v.bankDetail("", b)
+	return v.err()
+}
+
+// bankDetailUpdates returns the editable fields of the bank detail, zero
+// values included.
+func bankDetailUpdates(b model.BankDetail) map[string]interface{} {
+	return map[string]interface{}{
+		"MarketPlaceID": b.MarketPlaceID,
+		"Name":          b.Name,
+		"BankAccount":   b.BankAccount,
+		"Bank":          b.Bank,
+		"CorrAccount":   b.CorrAccount,
+		"BIK":           b.BIK,
+	}
+}
+
+// editBankDetail copies the editable fields of src to dst.
+func editBankDetail(dst *model.BankDetail, src model.BankDetail) {
+	dst.MarketPlaceID = src.MarketPlaceID
+	dst.Name = src.Name
+	dst.BankAccount = src.BankAccount
+	dst.Bank = src.Bank
+	dst.CorrAccount = src.CorrAccount
+	dst.BIK = src.BIK
+}
@@ -0,0 +1,117 @@
+package debtorservice
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"microsrv/model"
+)
+
+func TestBankAccountUnique(t *testing.T) {
+	s := NewMemory()
+	ctx := context.Background()
+	account := model.BankDetail{BIK: "044525225", BankAccount: "40702810938000000001"}
+	a, err := s.CreateDebtor(ctx, model.Debtor{Name: "A", BankDetails: []model.BankDetail{account}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := s.CreateDebtor(ctx, model.Debtor{Name: "B"})
+
+	if _, err := s.CreateDebtor(ctx, model.Debtor{Name: "C", BankDetails: []model.BankDetail{account}}); err != ErrBankAccountExists {
+		t.Errorf("CreateDebtor with a taken account: %v", err)
+	}
+	if _, err := s.AddBankDetail(ctx, BankDetailScope{DebtorID: b.ID}, account); err != ErrBankAccountExists {
+		t.Errorf("AddBankDetail with a taken account: %v", err)
+	}
+	b, _ = s.GetDebtor(ctx, uint32(b.ID))
+	if _, err := s.Save(ctx, model.Debtor{BankDetails: []model.BankDetail{account}}, b.ID, Version(b), FieldMask{"BankDetails"}); err != ErrBankAccountExists {
+		t.Errorf("Save with a taken account: %v", err)
+	}
+	twice := []model.BankDetail{{BIK: "044525225", BankAccount: "40702810938000000002"}, {BIK: "044525225", BankAccount: "40702810938000000002"}}
+	if _, err := s.Save(ctx, model.Debtor{BankDetails: twice}, b.ID, Version(b), FieldMask{"BankDetails"}); err != ErrBankAccountExists {
+		t.Errorf("Save with an account twice: %v", err)
+	}
+	_, err = s.UpsertDebtors(ctx, []model.Debtor{
+		{Name: "D", INN: "7707083893"},
+		{Name: "E", INN: "7736050003", BankDetails: []model.BankDetail{account}},
+	})
+	if err != ErrBankAccountExists {
+		t.Errorf("UpsertDebtors with a taken account: %v", err)
+	}
+	if p, _ := s.GetAll(ctx, Query{}); p.Count != 2 {
+		t.Errorf("%d debtors after a failed upsert, want 2", p.Count)
+	}
+
+	// A bank detail of another debtor cannot be taken over by its id.
+	a, _ = s.GetDebtor(ctx, uint32(a.ID))
+	b, _ = s.GetDebtor(ctx, uint32(b.ID))
+	if _, err := s.Save(ctx, model.Debtor{BankDetails: a.BankDetails}, b.ID, Version(b), FieldMask{"BankDetails"}); err != ErrBankDetailNotFound {
+		t.Errorf("Save with a bank detail of another debtor: %v", err)
+	}
+	if _, err := s.Save(ctx, model.Debtor{Name: "B2", BankDetails: a.BankDetails}, b.ID, Version(b), nil); err != ErrBankDetailNotFound {
+		t.Errorf("Save without a mask with a bank detail of another debtor: %v", err)
+	}
+
+	// The account of a replaced bank detail can be taken.
+	a, _ = s.GetDebtor(ctx, uint32(a.ID))
+	if _, err := s.Save(ctx, model.Debtor{BankDetails: []model.BankDetail{}}, a.ID, Version(a), FieldMask{"BankDetails"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.AddBankDetail(ctx, BankDetailScope{DebtorID: b.ID}, account); err != nil {
+		t.Errorf("AddBankDetail with a released account: %v", err)
+	}
+}
+
+func TestBankDetailAudit(t *testing.T) {
+	s := NewMemory()
+	ctx := WithActor(context.Background(), "ivanov")
+	d, _ := s.CreateDebtor(ctx, model.Debtor{Name: "A"})
+	scope := BankDetailScope{DebtorID: d.ID}
+
+	b, err := s.AddBankDetail(ctx, scope, model.BankDetail{BIK: "044525225", BankAccount: "40702810938000000001"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.SaveBankDetail(ctx, scope, b.ID, model.BankDetail{BIK: "044525225", BankAccount: "40702810938000000002"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.CloseBankDetail(ctx, scope, b.ID); err != nil {
+		t.Fatal(err)
+	}
+	history, _ := s.GetDebtorHistory(ctx, d.ID)
+	if len(history) != 4 {
+		t.Fatalf("%d records, want 4", len(history))
+	}
+	for i, op := range []string{OpAddBankDetail, OpEditBankDetail, OpCloseBankDetail} {
+		if r := history[i+1]; r.Operation != op || r.Actor != "ivanov" {
+			t.Errorf("record %d %+v, want %s", i+1, r, op)
+		}
+	}
+	edit := history[2].Changes
+	if len(edit) != 1 || edit[0].Field != "BankDetails[1].BankAccount" || edit[0].After != "40702810938000000002" {
+		t.Errorf("edit changes %+v", edit)
+	}
+}
+
+func TestSaveAuditsBankDetails(t *testing.T) {
+	s := NewMemory()
+	ctx := WithActor(context.Background(), "ivanov")
+	d, _ := s.CreateDebtor(ctx, model.Debtor{Name: "A", BankDetails: []model.BankDetail{{BIK: "044525225", BankAccount: "40702810938000000001"}}})
+	replaced := []model.BankDetail{{BIK: "044525225", BankAccount: "40702810938000000002"}}
+	if _, err := s.Save(ctx, model.Debtor{BankDetails: replaced}, d.ID, Version(d), FieldMask{"BankDetails"}); err != nil {
+		t.Fatal(err)
+	}
+	history, _ := s.GetDebtorHistory(ctx, d.ID)
+	changes := map[string]Change{}
+	for _, c := range history[1].Changes {
+		changes[c.Field] = c
+	}
+	old := d.BankDetails[0].ID
+	if c := changes[fmt.Sprintf("BankDetails[%d].BankAccount", old)]; c.Before != "40702810938000000001" || c.After != "" {
+		t.Errorf("removed bank detail %+v", c)
+	}
+	if c := changes[fmt.Sprintf("BankDetails[%d].BankAccount", old+1)]; c.Before != "" || c.After != "40702810938000000002" {
+		t.Errorf("added bank detail %+v in %+v", c, history[1].Changes)
+	}
+}
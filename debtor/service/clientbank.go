@@ -0,0 +1,181 @@
+package debtorservice
+
+import (
+	"context"
+	"time"
+
+	"microsrv/apperr"
+	"microsrv/clientbank"
+	"microsrv/model"
+)
+
+// Statement import statuses, besides Failed.
+const (
+	Imported  = "imported"
+	Unmatched = "unmatched"
+)
+
+var (
+	// ErrUnmatchedAccount var
+	ErrUnmatchedAccount = apperr.NewNotFound("no open bank detail with the account and BIK")
+	// ErrAmbiguousAccount var
+	ErrAmbiguousAccount = apperr.NewConflict("several bank details have the account, the file has no BIK for it")
+)
+
+// AccountImport reports the outcome of an account section of a file.
+type AccountImport struct {
+	Account      string
+	BIK          string
+	BankDetailID uint
+	StatementID  uint
+	Status       string
+	Documents    int
+	Err          error
+}
+
+// DuplicateDocument is a payment document already posted to the account,
+// or met before in the file. It is not imported again.
+type DuplicateDocument struct {
+	Account string
+	Number  string
+	Date    time.Time
+	Amount  int64
+}
+
+// StatementImportReport lists the outcome of every account of the file.
+type StatementImportReport struct {
+	Accounts   []AccountImport
+	Duplicates []DuplicateDocument
+}
+
+// PackDocument serializes a payment document of a file into a statement
+// document.
+type PackDocument func(clientbank.Document) (Document, error)
+
+// ImportStatements posts a statement for each account of the file to the
+// open bank detail with the account number and the BIK the documents of
+// the file give for it. The documents paid from or to the account are
+// attached to its statement, except the duplicates. Failed and unmatched
+// accounts do not stop the import, unless the Service refuses the user.
+func ImportStatements(ctx context.Context, s Service, f *clientbank.File, pack PackDocument) (StatementImportReport, error) {
+	report := StatementImportReport{Accounts: []AccountImport{}, Duplicates: []DuplicateDocument{}}
+	for _, a := range f.Accounts {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+		docs, bik := accountDocuments(f, a.Account)
+		res := AccountImport{Account: a.Account, BIK: bik, Status: Failed}
+		b, err := matchBankDetail(ctx, s, a.Account, bik)
+		if err != nil {
+			if apperr.KindOf(err) != apperr.Internal {
+				res.Status = Unmatched
+			}
+			res.Err = err
+			report.Accounts = append(report.Accounts, res)
+			continue
+		}
+		res.BankDetailID = b.ID
+		scope := BankDetailScope{DebtorID: b.DebtorID}
+		st := Statement{
+			Account:        a.Account,
+			StartDate:      a.Start,
+			EndDate:        a.End,
+			InitialBalance: a.Opening,
+			Income:         a.Income,
+			WriteOff:       a.WriteOff,
+			FinalBalance:   a.Closing,
+		}
+		seen, err := postedDocuments(ctx, s, scope, b.ID)
+		for _, d := range docs {
+			if err != nil {
+				break
+			}
+			key := d.Key()
+			if seen[key] {
+				report.Duplicates = append(report.Duplicates, DuplicateDocument{Account: a.Account, Number: d.Number, Date: d.Date, Amount: d.Amount})
+				continue
+			}
+			seen[key] = true
+			var doc Document
+			if doc, err = pack(d); err == nil {
+				doc.Key = key
+				st.PaymentDocuments = append(st.PaymentDocuments, doc)
+			}
+		}
+		if err == nil {
+			st, err = s.PostStatement(ctx, scope, b.ID, st)
+		}
+		if apperr.KindOf(err) == apperr.Permission {
+			return report, err
+		}
+		if err != nil {
+			res.Err = err
+		} else {
+			res.Status, res.StatementID, res.Documents = Imported, st.ID, len(st.PaymentDocuments)
+		}
+		report.Accounts = append(report.Accounts, res)
+	}
+	return report, nil
+}
+
+// accountDocuments returns the documents of the file paid from or to the
+// account, and the BIK of the account they give.
+func accountDocuments(f *clientbank.File, account string) ([]clientbank.Document, string) {
+	docs := []clientbank.Document{}
+	bik := ""
+	for _, d := range f.Documents {
+		switch account {
+		case d.PayerAccount:
+			if bik == "" {
+				bik = d.PayerBIK
+			}
+		case d.PayeeAccount:
+			if bik == "" {
+				bik = d.PayeeBIK
+			}
+		default:
+			continue
+		}
+		docs = append(docs, d)
+	}
+	return docs, bik
+}
+
+func matchBankDetail(ctx context.Context, s Service, account, bik string) (model.BankDetail, error) {
+	details, err := s.FindBankDetails(ctx, account)
+	if err != nil {
+		return model.BankDetail{}, err
+	}
+	matched := []model.BankDetail{}
+	for _, b := range details {
+		if bik == "" || b.BIK == bik {
+			matched = append(matched, b)
+		}
+	}
+	switch len(matched) {
+	case 0:
+		return model.BankDetail{}, ErrUnmatchedAccount
+	case 1:
+		return matched[0], nil
+	default:
+		return model.BankDetail{}, ErrAmbiguousAccount
+	}
+}
+
+// postedDocuments returns the keys of the documents of the statements of
+// the bank detail.
+func postedDocuments(ctx context.Context, s Service, scope BankDetailScope, id uint) (map[string]bool, error) {
+	statements, err := s.ListStatements(ctx, scope, id)
+	if err != nil {
+		return nil, err
+	}
+	keys := map[string]bool{}
+	for _, st := range statements {
+		for _, d := range st.PaymentDocuments {
+			if d.Key != "" {
+				keys[d.Key] = true
+			}
+		}
+	}
+	return keys, nil
+}
@@ -0,0 +1,180 @@
+package debtorservice
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"microsrv/apperr"
+	"microsrv/model"
+)
+
+// ErrInvalidCursor is returned for a cursor that is malformed, signed with
+// another secret or issued for another sort order or filter.
+var ErrInvalidCursor = apperr.NewValidation("invalid cursor", apperr.Field{Field: "cursor", Description: "is malformed, expired or issued for another order or filter"})
+
+// Page is a page of debtors. NextCursor is set when more rows may follow.
+type Page struct {
+	model.DebtorsResponse
+	NextCursor string `json:"nextCursor,omitempty"`
+}
+
+// cursor is the payload of a cursor token: the sort order and the digest
+// of the filter it was issued for and the sort key values of the last row
+// of the page.
+type cursor struct {
+	Order  string   `json:"o"`
+	Filter string   `json:"f"`
+	Values []string `json:"v"`
+}
+
+// cursorCodec signs cursors with HMAC-SHA256, so that clients cannot forge
+// them.
+type cursorCodec struct{ secret []byte }
+
+// newCursorCodec returns a codec for the secret. Without a secret a random
+// one is used and cursors do not survive a restart.
+func newCursorCodec(secret string) (*cursorCodec, error) {
+	key := []byte(secret)
+	if len(key) == 0 {
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return nil, err
+		}
+	}
+	return &cursorCodec{secret: key}, nil
+}
+
+// encode returns the cursor of the query positioned after the debtor.
+func (c *cursorCodec) encode(p Query, order []SortKey, d model.Debtor) string {
+	cur := cursor{Order: orderSignature(order), Filter: filterSignature(p)}
+	for _, k := range order {
+		cur.Values = append(cur.Values, formatSortValue(sortValue(d, k.Field)))
+	}
+	payload, _ := json.Marshal(cur)
+	enc := base64.RawURLEncoding
+	return enc.EncodeToString(payload) + "." + enc.EncodeToString(c.sign(payload))
+}
+
+// decode checks that the token was issued for the query and returns the
+// typed sort key values.
+func (c *cursorCodec) decode(token string, p Query, order []SortKey) ([]interface{}, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 2 {
+		return nil, ErrInvalidCursor
+	}
+	enc := base64.RawURLEncoding
+	payload, err := enc.DecodeString(parts[0])
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	sig, err := enc.DecodeString(parts[1])
+	if err != nil || !hmac.Equal(sig, c.sign(payload)) {
+		return nil, ErrInvalidCursor
+	}
+	cur := cursor{}
+	if err := json.Unmarshal(payload, &cur); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	if cur.Order != orderSignature(order) || cur.Filter != filterSignature(p) || len(cur.Values) != len(order) {
+		return nil, ErrInvalidCursor
+	}
+	values := make([]interface{}, len(order))
+	for i, k := range order {
+		v, err := parseSortValue(sortValue(model.Debtor{}, k.Field), cur.Values[i])
+		if err != nil {
+			return nil, ErrInvalidCursor
+		}
+		values[i] = v
+	}
+	return values, nil
+}
+
+func (c *cursorCodec) sign(payload []byte) []byte {
+	mac := hmac.New(sha256.New, c.secret)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+func orderSignature(order []SortKey) string {
+	keys := make([]string, len(order))
+	for i, k := range order {
+		keys[i] = k.Field
+		if k.Desc {
+			keys[i] = "-" + k.Field
+		}
+	}
+	return strings.Join(keys, ",")
+}
+
+// filterSignature digests the name search and the filter of the query.
+func filterSignature(p Query) string {
+	b, _ := json.Marshal(struct {
+		Name   string
+		Filter Filter
+	}{p.Name, p.Filter})
+	sum := sha256.Sum256(b)
+	return base64.RawURLEncoding.EncodeToString(sum[:16])
+}
+
+func formatSortValue(v interface{}) string {
+	switch x := v.(type) {
+	case uint:
+		return strconv.FormatUint(uint64(x), 10)
+	case time.Time:
+		return x.UTC().Format(time.RFC3339Nano)
+	default:
+		return x.(string)
+	}
+}
+
+func parseSortValue(kind interface{}, s string) (interface{}, error) {
+	switch kind.(type) {
+	case uint:
+		n, err := strconv.ParseUint(s, 10, 64)
+		return uint(n), err
+	case time.Time:
+		return time.Parse(time.RFC3339Nano, s)
+	default:
+		return s, nil
+	}
+}
+
+// after reports whether the debtor follows the cursor values in the order.
+func after(d model.Debtor, order []SortKey, values []interface{}) bool {
+	for i, k := range order {
+		c := compare(sortValue(d, k.Field), values[i])
+		if c == 0 {
+			continue
+		}
+		return (c > 0) != k.Desc
+	}
+	return false
+}
+
+// keyset returns the SQL condition selecting the rows after the cursor
+// values: (a > ?) OR (a = ? AND b > ?) OR ... with < for descending keys.
+func keyset(order []SortKey, values []interface{}, expr func(column string) string) (string, []interface{}) {
+	or := make([]string, len(order))
+	args := []interface{}{}
+	for i := range order {
+		and := []string{}
+		for j := 0; j < i; j++ {
+			and = append(and, expr(order[j].Field)+" = ?")
+			args = append(args, values[j])
+		}
+		op := " > ?"
+		if order[i].Desc {
+			op = " < ?"
+		}
+		and = append(and, expr(order[i].Field)+op)
+		args = append(args, values[i])
+		or[i] = "(" + strings.Join(and, " AND ") + ")"
+	}
+	return "(" + strings.Join(or, " OR ") + ")", args
+}
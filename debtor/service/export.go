@@ -0,0 +1,192 @@
+package debtorservice
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"microsrv/apperr"
+	"microsrv/model"
+	"microsrv/xlsx"
+)
+
+// exportFlush is the number of rows written between flushes, so that the
+// client receives the export while it is produced.
+const exportFlush = 100
+
+// ErrExportFormat var
+var ErrExportFormat = apperr.NewValidation("unsupported export format", apperr.Field{Field: "format", Description: "must be csv, xlsx or jsonl"})
+
+// ExportColumns are the columns of an export. Associations are flattened
+// into dotted columns, the values of several bank details are joined with
+// "; ".
+var ExportColumns = []string{
+	"id", "name", "fullName", "INN", "KPP", "OGRN", "address", "postAddress",
+	"caseNo", "decisionDate",
+	"arbitration.id", "arbitration.name",
+	"bankruptcyManager.id", "bankruptcyManager.name", "bankruptcyManager.INN", "bankruptcyManager.SNILS",
+	"bankDetails.bank", "bankDetails.BIK", "bankDetails.bankAccount", "bankDetails.corrAccount",
+	"contacts", "createdAt", "updatedAt",
+}
+
+// ExportContentTypes maps the export formats to their media types.
+var ExportContentTypes = map[string]string{
+	"csv":   "text/csv; charset=utf-8",
+	"xlsx":  "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	"jsonl": "application/x-ndjson",
+}
+
+// Export writes the debtors matching the filter to w in the format as they
+// are streamed by the Service. Nothing is written before the first debtor,
+// so that an error of the Service up to then can still be reported instead
+// of the export.
+func Export(ctx context.Context, s Service, f Filter, format string, w io.Writer) error {
+	if _, ok := ExportContentTypes[format]; !ok {
+		return ErrExportFormat
+	}
+	var ew exportWriter
+	start := func() (err error) {
+		if ew == nil {
+			ew, err = newExportWriter(format, w)
+		}
+		return err
+	}
+	n := 0
+	err := s.StreamDebtors(ctx, f, func(d model.Debtor) error {
+		if err := start(); err != nil {
+			return err
+		}
+		if err := ew.write(exportRecord(d)); err != nil {
+			return err
+		}
+		if n++; n%exportFlush == 0 {
+			return ew.flush()
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if err := start(); err != nil {
+		return err
+	}
+	return ew.close()
+}
+
+type exportWriter interface {
+	write(record []string) error
+	flush() error
+	close() error
+}
+
+func newExportWriter(format string, w io.Writer) (exportWriter, error) {
+	switch format {
+	case "csv":
+		// The byte order mark lets spreadsheet applications detect UTF-8.
+		if _, err := io.WriteString(w, "\xef\xbb\xbf"); err != nil {
+			return nil, err
+		}
+		cw := &csvExport{csv.NewWriter(w)}
+		return cw, cw.write(ExportColumns)
+	case "xlsx":
+		xw, err := xlsx.NewWriter(w, "Debtors")
+		if err != nil {
+			return nil, err
+		}
+		return &xlsxExport{xw}, xw.WriteRow(ExportColumns)
+	case "jsonl":
+		return &jsonlExport{bufio.NewWriter(w)}, nil
+	default:
+		return nil, ErrExportFormat
+	}
+}
+
+type csvExport struct{ w *csv.Writer }
+
+func (e *csvExport) write(record []string) error { return e.w.Write(record) }
+
+func (e *csvExport) flush() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+func (e *csvExport) close() error { return e.flush() }
+
+type xlsxExport struct{ w *xlsx.Writer }
+
+func (e *xlsxExport) write(record []string) error { return e.w.WriteRow(record) }
+
+func (e *xlsxExport) flush() error { return e.w.Flush() }
+
+func (e *xlsxExport) close() error { return e.w.Close() }
+
+// jsonlExport writes a JSON object per line with the keys in the order of
+// ExportColumns. Empty values are omitted.
+type jsonlExport struct{ w *bufio.Writer }
+
+func (e *jsonlExport) write(record []string) error {
+	e.w.WriteByte('{')
+	first := true
+	for i, v := range record {
+		if v == "" {
+			continue
+		}
+		if !first {
+			e.w.WriteByte(',')
+		}
+		first = false
+		k, _ := json.Marshal(ExportColumns[i])
+		val, _ := json.Marshal(v)
+		e.w.Write(k)
+		e.w.WriteByte(':')
+		e.w.Write(val)
+	}
+	_, err := e.w.WriteString("}\n")
+	return err
+}
+
+func (e *jsonlExport) flush() error { return e.w.Flush() }
+
+func (e *jsonlExport) close() error { return e.w.Flush() }
+
+// exportRecord flattens the debtor into the values of ExportColumns.
+func exportRecord(d model.Debtor) []string {
+	date := ""
+	if t := d.DecisionDate; t != nil {
+		date = t.Format("2006-01-02")
+	}
+	m := d.BankruptcyManager
+	managerID := ""
+	if m.ID != 0 {
+		managerID = strconv.FormatUint(uint64(m.ID), 10)
+	}
+	var banks, biks, accounts, corrs []string
+	for _, b := range d.BankDetails {
+		banks = append(banks, b.Bank)
+		biks = append(biks, b.BIK)
+		accounts = append(accounts, b.BankAccount)
+		corrs = append(corrs, b.CorrAccount)
+	}
+	return []string{
+		strconv.FormatUint(uint64(d.ID), 10), d.Name, d.FullName, d.INN, d.KPP, d.OGRN, d.Address, d.PostAddress,
+		d.CaseNo, date,
+		d.Arbitration.ID, d.Arbitration.Name,
+		managerID, strings.Join(strings.Fields(m.Surname+" "+m.Name+" "+m.Patronymicname), " "), m.INN, m.SNILS,
+		joinValues(banks), joinValues(biks), joinValues(accounts), joinValues(corrs),
+		d.Contacts, d.CreatedAt.Format(time.RFC3339), d.UpdatedAt.Format(time.RFC3339),
+	}
+}
+
+// joinValues joins the values with "; ", values that are all empty give an
+// empty cell.
+func joinValues(values []string) string {
+	if blank(values) {
+		return ""
+	}
+	return strings.Join(values, "; ")
+}
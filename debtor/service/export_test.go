@@ -0,0 +1,51 @@
+package debtorservice
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"microsrv/model"
+)
+
+// failingService fails StreamDebtors before the first debtor.
+type failingService struct{ Service }
+
+var errStream = errors.New("database is down")
+
+func (s failingService) StreamDebtors(ctx context.Context, f Filter, fn func(model.Debtor) error) error {
+	return errStream
+}
+
+func TestExport(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemory()
+	buf := &bytes.Buffer{}
+	if err := Export(ctx, s, Filter{}, "csv", buf); err != nil {
+		t.Fatal(err)
+	}
+	if want := "\ufeff" + strings.Join(ExportColumns, ",") + "\n"; buf.String() != want {
+		t.Fatalf("empty export %q, want %q", buf.String(), want)
+	}
+
+	s.CreateDebtor(ctx, model.Debtor{Name: "Ромашка", INN: "7707083893"})
+	buf.Reset()
+	if err := Export(ctx, s, Filter{}, "jsonl", buf); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(buf.String(), `{"id":"1","name":"Ромашка","INN":"7707083893",`) {
+		t.Fatalf("jsonl export %q", buf.String())
+	}
+
+	if err := Export(ctx, s, Filter{}, "pdf", buf); err != ErrExportFormat {
+		t.Fatalf("Export to pdf: %v", err)
+	}
+	for _, format := range []string{"csv", "xlsx", "jsonl"} {
+		buf.Reset()
+		if err := Export(ctx, failingService{}, Filter{}, format, buf); err != errStream || buf.Len() != 0 {
+			t.Errorf("%s: failed export wrote %d bytes, %v", format, buf.Len(), err)
+		}
+	}
+}
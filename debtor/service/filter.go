@@ -0,0 +1,185 @@
+package debtorservice
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"microsrv/apperr"
+	"microsrv/model"
+)
+
+// Query collects the parameters of GetAll. Pagination.Name and
+// Pagination.Sort keep their meaning: a name substring and a descending
+// sort by name when Order is empty. A Cursor from a previous Page replaces
+// Pagination.From and must be used with the same order.
+type Query struct {
+	model.Pagination
+	Filter Filter
+	Order  []SortKey
+	Cursor string
+}
+
+// Filter narrows down the debtors returned by GetAll. Zero fields do not
+// filter.
+type Filter struct {
+	INN                 string
+	OGRN                string
+	CaseNo              string
+	ArbitrationID       string
+	BankruptcyManagerID uint
+	DecisionDateFrom    *time.Time
+	DecisionDateTo      *time.Time
+	HasActiveBiddings   bool
+}
+
+// SortKey orders debtors by a field.
+type SortKey struct {
+	Field string
+	Desc  bool
+}
+
+// sortColumns maps the sortable fields to indexed columns. Both the JSON
+// and the column names are accepted.
+var sortColumns = map[string]string{
+	"id":                    "id",
+	"name":                  "name",
+	"INN":                   "inn",
+	"inn":                   "inn",
+	"OGRN":                  "ogrn",
+	"ogrn":                  "ogrn",
+	"caseNo":                "case_no",
+	"case_no":               "case_no",
+	"decisionDate":          "decision_date",
+	"decision_date":         "decision_date",
+	"arbitrationId":         "arbitration_id",
+	"arbitration_id":        "arbitration_id",
+	"bankruptcyManagerId":   "bankruptcy_manager_id",
+	"bankruptcy_manager_id": "bankruptcy_manager_id",
+	"createdAt":             "created_at",
+	"created_at":            "created_at",
+	"updatedAt":             "updated_at",
+	"updated_at":            "updated_at",
+}
+
+// order returns the sort keys of the query with the fields resolved to
+// columns. The id is always the last key, so that the order is total.
+func (q Query) order() ([]SortKey, error) {
+	keys := q.Order
+	if len(keys) == 0 {
+		keys = []SortKey{{Field: "name", Desc: q.Sort != ""}}
+	}
+	res := make([]SortKey, 0, len(keys)+1)
+	fields := []apperr.Field{}
+	hasID := false
+	for i, k := range keys {
+		column, ok := sortColumns[k.Field]
+		if !ok {
+			fields = append(fields, apperr.Field{Field: "order[" + strconv.Itoa(i) + "].field", Description: "cannot sort by " + k.Field})
+			continue
+		}
+		hasID = hasID || column == "id"
+		res = append(res, SortKey{Field: column, Desc: k.Desc})
+	}
+	if len(fields) > 0 {
+		return nil, apperr.NewValidation("invalid sort order", fields...)
+	}
+	if !hasID {
+		res = append(res, SortKey{Field: "id"})
+	}
+	return res, nil
+}
+
+// match reports whether the debtor passes the query filters. It is used by
+// the memory store, the database store builds the same conditions in SQL.
+func (q Query) match(d model.Debtor, activeBiddings bool) bool {
+	f := q.Filter
+	switch {
+	case q.Name != "" && !strings.Contains(strings.ToLower(d.Name), strings.ToLower(q.Name)):
+		return false
+	case f.INN != "" && d.INN != f.INN:
+		return false
+	case f.OGRN != "" && d.OGRN != f.OGRN:
+		return false
+	case f.CaseNo != "" && d.CaseNo != f.CaseNo:
+		return false
+	case f.ArbitrationID != "" && d.ArbitrationID != f.ArbitrationID:
+		return false
+	case f.BankruptcyManagerID != 0 && d.BankruptcyManagerID != f.BankruptcyManagerID:
+		return false
+	case f.HasActiveBiddings && !activeBiddings:
+		return false
+	}
+	if f.DecisionDateFrom != nil || f.DecisionDateTo != nil {
+		date := d.DecisionDate
+		if date == nil ||
+			(f.DecisionDateFrom != nil && date.Before(*f.DecisionDateFrom)) ||
+			(f.DecisionDateTo != nil && date.After(*f.DecisionDateTo)) {
+			return false
+		}
+	}
+	return true
+}
+
+// less compares two debtors by the resolved sort keys.
+func less(a, b model.Debtor, keys []SortKey) bool {
+	for _, k := range keys {
+		c := compare(sortValue(a, k.Field), sortValue(b, k.Field))
+		if c == 0 {
+			continue
+		}
+		return (c < 0) != k.Desc
+	}
+	return false
+}
+
+func sortValue(d model.Debtor, column string) interface{} {
+	switch column {
+	case "name":
+		return d.Name
+	case "inn":
+		return d.INN
+	case "ogrn":
+		return d.OGRN
+	case "case_no":
+		return d.CaseNo
+	case "arbitration_id":
+		return d.ArbitrationID
+	case "bankruptcy_manager_id":
+		return d.BankruptcyManagerID
+	case "decision_date":
+		if t := d.DecisionDate; t != nil {
+			return *t
+		}
+		return time.Time{}
+	case "created_at":
+		return d.CreatedAt
+	case "updated_at":
+		return d.UpdatedAt
+	default:
+		return d.ID
+	}
+}
+
+func compare(a, b interface{}) int {
+	switch x := a.(type) {
+	case string:
+		// Case-insensitive, as the default collation of the database.
+		return strings.Compare(strings.ToLower(x), strings.ToLower(b.(string)))
+	case uint:
+		y := b.(uint)
+		if x < y {
+			return -1
+		} else if x > y {
+			return 1
+		}
+	case time.Time:
+		y := b.(time.Time)
+		if x.Before(y) {
+			return -1
+		} else if x.After(y) {
+			return 1
+		}
+	}
+	return 0
+}
@@ -0,0 +1,371 @@
+package debtorservice
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"io"
+	"io/ioutil"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"microsrv/apperr"
+	"microsrv/model"
+	"microsrv/xlsx"
+)
+
+// ImportBatch is the number of rows upserted in one transaction.
+const ImportBatch = 100
+
+// MaxXLSXSize bounds an xlsx import file, which is read whole.
+const MaxXLSXSize = 32 << 20
+
+// Import row statuses.
+const (
+	Created = "created"
+	Updated = "updated"
+	Failed  = "failed"
+)
+
+var (
+	// ErrImportFormat var
+	ErrImportFormat = apperr.NewValidation("unsupported import format", apperr.Field{Field: "format", Description: "must be csv or xlsx"})
+	// ErrImportHeader var
+	ErrImportHeader = apperr.NewValidation("no INN or OGRN column", apperr.Field{Field: "mapping", Description: "must map a column to INN or OGRN"})
+	// ErrNoKey var
+	ErrNoKey = apperr.NewValidation("no INN or OGRN", apperr.Field{Field: "INN", Description: "INN or OGRN is required to import a debtor"})
+	// ErrImportTooLarge var
+	ErrImportTooLarge = apperr.NewValidation("import file is too large", apperr.Field{Field: "file", Description: "must not exceed 32 MB"})
+)
+
+// Mapping maps the column headers of an import file to debtor fields,
+// named as in JSON: name, fullName, INN, KPP, OGRN, address, postAddress,
+// arbitrationId, caseNo, decisionDate, bankruptcyManagerId and contacts.
+// Headers are compared case insensitively.
+type Mapping map[string]string
+
+// DefaultMapping accepts the field names and the column headers of the
+// EFRSB debtor exports.
+var DefaultMapping = Mapping{
+	"Наименование":        "name",
+	"ФИО":                 "name",
+	"Полное наименование": "fullName",
+	"ИНН":                 "INN",
+	"КПП":                 "KPP",
+	"ОГРН":                "OGRN",
+	"ОГРНИП":              "OGRN",
+	"Адрес":               "address",
+	"Почтовый адрес":      "postAddress",
+	"Номер дела":          "caseNo",
+	"Дата решения":        "decisionDate",
+	"Контакты":            "contacts",
+}
+
+// Upserted is the outcome of upserting one debtor.
+type Upserted struct {
+	ID      uint
+	Created bool
+}
+
+// ImportRow reports the outcome of one row of the file. Row is the number
+// of the record, the header being 1. Empty csv lines are not counted.
+type ImportRow struct {
+	Row    int
+	Status string
+	ID     uint
+	Err    error
+}
+
+// ImportReport lists the outcome of every data row of the file in the
+// order of the file.
+type ImportReport struct {
+	Created int
+	Updated int
+	Failed  int
+	Rows    []ImportRow
+}
+
+func (r *ImportReport) add(row ImportRow) {
+	switch row.Status {
+	case Created:
+		r.Created++
+	case Updated:
+		r.Updated++
+	default:
+		r.Failed++
+	}
+	r.Rows = append(r.Rows, row)
+}
+
+// RowReader reads the rows of an import file. encoding/csv.Reader is one.
+type RowReader interface {
+	Read() ([]string, error)
+}
+
+// NewRowReader returns a RowReader of a csv or xlsx file. The csv
+// delimiter is a semicolon, as in EFRSB exports, when the header has more
+// semicolons than commas. An xlsx file is read whole, up to MaxXLSXSize,
+// its first sheet is imported.
+func NewRowReader(format string, r io.Reader) (RowReader, error) {
+	switch strings.ToLower(format) {
+	case "csv":
+		br := bufio.NewReader(r)
+		if bom, err := br.Peek(3); err == nil && bytes.Equal(bom, []byte("\xef\xbb\xbf")) {
+			br.Discard(3)
+		}
+		cr := csv.NewReader(br)
+		cr.FieldsPerRecord = -1
+		header, _ := br.Peek(br.Buffered())
+		if line := strings.SplitN(string(header), "\n", 2)[0]; strings.Count(line, ";") > strings.Count(line, ",") {
+			cr.Comma = ';'
+		}
+		return cr, nil
+	case "xlsx":
+		b, err := ioutil.ReadAll(io.LimitReader(r, MaxXLSXSize+1))
+		if err != nil {
+			return nil, err
+		}
+		if len(b) > MaxXLSXSize {
+			return nil, ErrImportTooLarge
+		}
+		rows, err := xlsx.ReadRows(bytes.NewReader(b), int64(len(b)))
+		if err != nil {
+			return nil, apperr.NewValidation(err.Error(), apperr.Field{Field: "file", Description: "is not a valid xlsx file"})
+		}
+		return &sliceReader{rows: rows}, nil
+	default:
+		return nil, ErrImportFormat
+	}
+}
+
+type sliceReader struct {
+	rows [][]string
+}
+
+func (r *sliceReader) Read() ([]string, error) {
+	if len(r.rows) == 0 {
+		return nil, io.EOF
+	}
+	row := r.rows[0]
+	r.rows = r.rows[1:]
+	return row, nil
+}
+
+// Import reads debtors from the rows, the first non blank one being the
+// header, validates them and upserts them by INN, or OGRN without an INN,
+// in batches of ImportBatch. The rows of a failed batch are retried one by
+// one. Invalid and failed rows are reported and do not stop the import,
+// unless the Service refuses the user.
+func Import(ctx context.Context, s Service, rows RowReader, mapping Mapping) (ImportReport, error) {
+	report := ImportReport{}
+	header, err := rows.Read()
+	n := 1
+	for err == nil && blank(header) {
+		header, err = rows.Read()
+		n++
+	}
+	if err == io.EOF {
+		return report, nil
+	}
+	if err != nil {
+		return report, apperr.NewValidation(err.Error(), apperr.Field{Field: "file", Description: "cannot be parsed"})
+	}
+	fields, err := columns(header, mapping)
+	if err != nil {
+		return report, err
+	}
+	batch := []model.Debtor{}
+	numbers := []int{}
+	add := func(n int, u Upserted, err error) {
+		row := ImportRow{Row: n, Status: Failed, Err: err}
+		if err == nil {
+			row = ImportRow{Row: n, Status: Updated, ID: u.ID}
+			if u.Created {
+				row.Status = Created
+			}
+		}
+		report.add(row)
+	}
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		defer func() { batch, numbers = batch[:0], numbers[:0] }()
+		res, err := s.UpsertDebtors(ctx, batch)
+		switch {
+		case err == nil:
+			for i, n := range numbers {
+				add(n, res[i], nil)
+			}
+		case apperr.KindOf(err) == apperr.Permission:
+			return err
+		default:
+			// One failing row fails the whole batch, the rows are retried
+			// alone so that only the failing ones are reported.
+			for i, d := range batch {
+				res, err := s.UpsertDebtors(ctx, []model.Debtor{d})
+				if apperr.KindOf(err) == apperr.Permission {
+					return err
+				}
+				u := Upserted{}
+				if err == nil {
+					u = res[0]
+				}
+				add(numbers[i], u, err)
+			}
+		}
+		return nil
+	}
+	for n++; ; n++ {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+		values, err := rows.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			if _, ok := err.(*csv.ParseError); !ok {
+				return report, err
+			}
+			report.add(ImportRow{Row: n, Status: Failed, Err: apperr.NewValidation(err.Error())})
+			continue
+		}
+		if blank(values) {
+			continue
+		}
+		d, err := rowDebtor(fields, values)
+		if err == nil {
+			err = ValidateDebtor(d)
+		}
+		if err != nil {
+			report.add(ImportRow{Row: n, Status: Failed, Err: err})
+			continue
+		}
+		batch = append(batch, d)
+		numbers = append(numbers, n)
+		if len(batch) == ImportBatch {
+			if err := flush(); err != nil {
+				return report, err
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		return report, err
+	}
+	sort.Slice(report.Rows, func(i, j int) bool {
+		return report.Rows[i].Row < report.Rows[j].Row
+	})
+	return report, nil
+}
+
+// columns resolves the header to the debtor field of each column, "" for
+// the columns not imported.
+func columns(header []string, mapping Mapping) ([]string, error) {
+	lower := map[string]string{}
+	for h, f := range DefaultMapping {
+		lower[strings.ToLower(h)] = f
+	}
+	for h, f := range mapping {
+		lower[strings.ToLower(h)] = f
+	}
+	fields := make([]string, len(header))
+	key := false
+	for i, h := range header {
+		h = strings.ToLower(strings.TrimSpace(h))
+		f, ok := lower[h]
+		if !ok {
+			for _, name := range importFields {
+				if strings.ToLower(name) == h {
+					f = name
+				}
+			}
+		}
+		fields[i] = f
+		key = key || f == "INN" || f == "OGRN"
+	}
+	if !key {
+		return nil, ErrImportHeader
+	}
+	return fields, nil
+}
+
+var importFields = []string{"name", "fullName", "INN", "KPP", "OGRN", "address", "postAddress", "arbitrationId", "caseNo", "decisionDate", "bankruptcyManagerId", "contacts"}
+
+// rowDebtor fills a debtor from the values of a row.
+func rowDebtor(fields, values []string) (model.Debtor, error) {
+	d := model.Debtor{}
+	for i, v := range values {
+		if i >= len(fields) {
+			break
+		}
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+		switch fields[i] {
+		case "name":
+			d.Name = v
+		case "fullName":
+			d.FullName = v
+		case "INN":
+			d.INN = v
+		case "KPP":
+			d.KPP = v
+		case "OGRN":
+			d.OGRN = v
+		case "address":
+			d.Address = v
+		case "postAddress":
+			d.PostAddress = v
+		case "arbitrationId":
+			d.ArbitrationID = v
+		case "caseNo":
+			d.CaseNo = v
+		case "contacts":
+			d.Contacts = v
+		case "bankruptcyManagerId":
+			id, err := strconv.ParseUint(v, 10, 32)
+			if err != nil {
+				return d, apperr.NewValidation(err.Error(), apperr.Field{Field: "bankruptcyManagerId", Description: "must be a number"})
+			}
+			d.BankruptcyManagerID = uint(id)
+		case "decisionDate":
+			t, err := parseDate(v)
+			if err != nil {
+				return d, apperr.NewValidation(err.Error(), apperr.Field{Field: "decisionDate", Description: "must be a date"})
+			}
+			d.DecisionDate = &t
+		}
+	}
+	if d.INN == "" && d.OGRN == "" {
+		return d, ErrNoKey
+	}
+	return d, nil
+}
+
+// parseDate accepts 02.01.2006, 2006-01-02 and the serial day numbers
+// xlsx stores dates as.
+func parseDate(v string) (time.Time, error) {
+	if t, err := time.Parse("02.01.2006", v); err == nil {
+		return t, nil
+	}
+	if f, err := strconv.ParseFloat(v, 64); err == nil {
+		days := math.Floor(f)
+		return time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC).AddDate(0, 0, int(days)), nil
+	}
+	return time.Parse("2006-01-02", v)
+}
+
+func blank(values []string) bool {
+	for _, v := range values {
+		if strings.TrimSpace(v) != "" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,85 @@
+package debtorservice
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"microsrv/model"
+	"microsrv/xlsx"
+)
+
+func TestImportRowNumbers(t *testing.T) {
+	s := NewMemory()
+	rows, err := NewRowReader("csv", strings.NewReader("\ufeff;;\nИНН;Наименование\n7707083893;Сбербанк\n;\n1234567890;Ошибка\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	report, err := Import(context.Background(), s, rows, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Created != 1 || report.Failed != 1 || len(report.Rows) != 2 {
+		t.Fatalf("report %+v", report)
+	}
+	if report.Rows[0].Row != 3 || report.Rows[0].Status != Created || report.Rows[1].Row != 5 || report.Rows[1].Status != Failed {
+		t.Fatalf("rows %+v", report.Rows)
+	}
+}
+
+func TestImportXLSX(t *testing.T) {
+	buf := &bytes.Buffer{}
+	w, _ := xlsx.NewWriter(buf, "Должники")
+	w.WriteRow([]string{"ИНН", "Наименование"})
+	w.WriteRow([]string{"7707083893", "Сбербанк"})
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := NewRowReader("xlsx", buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	report, err := Import(context.Background(), NewMemory(), rows, nil)
+	if err != nil || report.Created != 1 {
+		t.Fatalf("Import = %+v, %v", report, err)
+	}
+
+	large := io.MultiReader(bytes.NewReader(make([]byte, MaxXLSXSize)), strings.NewReader("x"))
+	if _, err := NewRowReader("xlsx", large); err != ErrImportTooLarge {
+		t.Fatalf("NewRowReader of a large file: %v", err)
+	}
+}
+
+// rejectingService fails the upserts of debtors named "Ошибка".
+type rejectingService struct{ Service }
+
+var errRejected = errors.New("rejected")
+
+func (s rejectingService) UpsertDebtors(ctx context.Context, debtors []model.Debtor) ([]Upserted, error) {
+	for _, d := range debtors {
+		if d.Name == "Ошибка" {
+			return nil, errRejected
+		}
+	}
+	return s.Service.UpsertDebtors(ctx, debtors)
+}
+
+func TestImportRetriesFailedBatch(t *testing.T) {
+	rows, _ := NewRowReader("csv", strings.NewReader("ИНН;Наименование;Дата решения\n7707083893;Сбербанк;01.03.2019\n7736050003;Ошибка;\n"))
+	s := NewMemory()
+	report, err := Import(context.Background(), rejectingService{s}, rows, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Created != 1 || report.Failed != 1 || report.Rows[0].Status != Created || report.Rows[1].Err != errRejected {
+		t.Fatalf("report %+v", report)
+	}
+	d, _ := s.GetDebtor(context.Background(), uint32(report.Rows[0].ID))
+	if d.DecisionDate == nil || !d.DecisionDate.Equal(time.Date(2019, 3, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("decision date %v", d.DecisionDate)
+	}
+}
@@ -0,0 +1,102 @@
+package debtorservice
+
+import (
+	"reflect"
+	"strings"
+
+	"microsrv/apperr"
+	"microsrv/model"
+
+	"github.com/jinzhu/gorm"
+)
+
+// FieldMask lists the debtor fields an update writes, zero values
+// included. The fields are named as in pb.Debtor, in its JSON or proto
+// form: name, fullName, INN, KPP, OGRN, address, postAddress,
+// postAddressMatch, arbitrationId, caseNo, decisionDate,
+// bankruptcyManagerId, contacts and bankDetails. bankDetails replaces all
+// the bank details of the debtor. An empty mask writes the non zero fields.
+type FieldMask []string
+
+// maskFields maps the normalized names of FieldMask to the fields of
+// model.Debtor.
+var maskFields = map[string]string{
+	"name":                "Name",
+	"fullname":            "FullName",
+	"inn":                 "INN",
+	"kpp":                 "KPP",
+	"ogrn":                "OGRN",
+	"address":             "Address",
+	"postaddress":         "PostAddress",
+	"postaddressmatch":    "PostAddressMatch",
+	"arbitrationid":       "ArbitrationID",
+	"caseno":              "CaseNo",
+	"decisiondate":        "DecisionDate",
+	"bankruptcymanagerid": "BankruptcyManagerID",
+	"contacts":            "Contacts",
+	"bankdetails":         "BankDetails",
+}
+
+func maskField(name string) string {
+	return maskFields[strings.ToLower(strings.Replace(name, "_", "", -1))]
+}
+
+// Validate reports the fields of the mask that cannot be updated.
+func (m FieldMask) Validate() error {
+	fields := []apperr.Field{}
+	for _, name := range m {
+		if maskField(name) == "" {
+			fields = append(fields, apperr.Field{Field: name, Description: "cannot be updated"})
+		}
+	}
+	if len(fields) > 0 {
+		return apperr.NewValidation("invalid field mask", fields...)
+	}
+	return nil
+}
+
+func (m FieldMask) has(field string) bool {
+	for _, name := range m {
+		if maskField(name) == field {
+			return true
+		}
+	}
+	return false
+}
+
+// nonZero returns the mask of the non zero fields of the debtor, those an
+// update without a mask writes. Non nil bank details replace those of the
+// debtor.
+func nonZero(d model.Debtor) FieldMask {
+	v := reflect.ValueOf(d)
+	mask := FieldMask{}
+	for _, f := range maskFields {
+		if !v.FieldByName(f).IsZero() {
+			mask = append(mask, f)
+		}
+	}
+	return mask
+}
+
+// updates returns the masked fields of the debtor by field name, the bank
+// details excepted, with the update time so that the version changes.
+func (m FieldMask) updates(d model.Debtor) map[string]interface{} {
+	v := reflect.ValueOf(d)
+	res := map[string]interface{}{"UpdatedAt": gorm.NowFunc()}
+	for _, name := range m {
+		if f := maskField(name); f != "" && f != "BankDetails" {
+			res[f] = v.FieldByName(f).Interface()
+		}
+	}
+	return res
+}
+
+// apply copies the masked fields of src to dst.
+func (m FieldMask) apply(dst *model.Debtor, src model.Debtor) {
+	dv, sv := reflect.ValueOf(dst).Elem(), reflect.ValueOf(src)
+	for _, name := range m {
+		if f := maskField(name); f != "" {
+			dv.FieldByName(f).Set(sv.FieldByName(f))
+		}
+	}
+}
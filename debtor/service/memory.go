@@ -0,0 +1,595 @@
+package debtorservice
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"microsrv/model"
+)
+
+type memoryStore struct {
+	mtx     sync.RWMutex
+	debtors map[uint]model.Debtor
+	nextID  uint
+	bankID  uint
+	cursors *cursorCodec
+	audit   []AuditRecord
+	// statements are kept in the order they are posted.
+	statements  []Statement
+	statementID uint
+}
+
+// NewMemory returns a Service keeping debtors in memory. It is meant for
+// tests and for running the service without a database.
+func NewMemory() Service {
+	cursors, err := newCursorCodec("")
+	if err != nil {
+		// Without a source of randomness no cursor can be signed.
+		panic(err)
+	}
+	return &memoryStore{debtors: map[uint]model.Debtor{}, cursors: cursors}
+}
+
+// Health implementation of the Service.
+func (ms *memoryStore) Health() bool {
+	return true
+}
+
+func (ms *memoryStore) CreateDebtor(ctx context.Context, d model.Debtor) (model.Debtor, error) {
+	ms.mtx.Lock()
+	defer ms.mtx.Unlock()
+	if d.ID != 0 {
+		if _, ok := ms.debtors[d.ID]; ok {
+			return model.Debtor{}, ErrAlreadyExists
+		}
+	} else {
+		ms.nextID++
+		d.ID = ms.nextID
+	}
+	if d.ID > ms.nextID {
+		ms.nextID = d.ID
+	}
+	if err := ms.accountsUnique(d); err != nil {
+		return model.Debtor{}, err
+	}
+	now := time.Now()
+	d.CreatedAt, d.UpdatedAt, d.DeletedAt = now, now, nil
+	ms.numberBankDetails(&d)
+	ms.debtors[d.ID] = d
+	ms.record(audit(ctx, OpCreate, model.Debtor{}, d))
+	return d, nil
+}
+
+// record appends to the audit trail. Must be called with mtx held.
+func (ms *memoryStore) record(r AuditRecord) {
+	r.ID = uint(len(ms.audit) + 1)
+	ms.audit = append(ms.audit, r)
+}
+
+func (ms *memoryStore) GetDebtor(ctx context.Context, id uint32) (model.Debtor, error) {
+	ms.mtx.RLock()
+	defer ms.mtx.RUnlock()
+	return ms.get(uint(id))
+}
+
+// get returns a live debtor. Must be called with mtx held.
+func (ms *memoryStore) get(id uint) (model.Debtor, error) {
+	d, ok := ms.debtors[id]
+	if !ok || d.DeletedAt != nil {
+		return model.Debtor{}, ErrNotFound
+	}
+	return d, nil
+}
+
+func (ms *memoryStore) GetAll(ctx context.Context, p Query) (Page, error) {
+	if p.Limit == 0 {
+		p.Limit = 20
+	}
+	order, err := p.order()
+	if err != nil {
+		return Page{}, err
+	}
+	var values []interface{}
+	if p.Cursor != "" {
+		if values, err = ms.cursors.decode(p.Cursor, p, order); err != nil {
+			return Page{}, err
+		}
+		p.From = 0
+	}
+	ms.mtx.RLock()
+	defer ms.mtx.RUnlock()
+	debtors := model.Debtors{}
+	count := 0
+	for _, d := range ms.debtors {
+		if d.DeletedAt != nil || !p.match(d, hasActiveBiddings(d)) {
+			continue
+		}
+		count++
+		if values != nil && !after(d, order, values) {
+			continue
+		}
+		debtors = append(debtors, d)
+	}
+	sort.Slice(debtors, func(i, j int) bool {
+		return less(debtors[i], debtors[j], order)
+	})
+	res := Page{}
+	res.Count = uint(count)
+	res.Debtors = page(debtors, int(p.From), int(p.Limit))
+	if len(res.Debtors) == int(p.Limit) {
+		res.NextCursor = ms.cursors.encode(p, order, res.Debtors[len(res.Debtors)-1])
+	}
+	return res, nil
+}
+
+func (ms *memoryStore) Save(ctx context.Context, debtor model.Debtor, id uint, version string, mask FieldMask) (model.Debtor, error) {
+	if debtor.ID != 0 && debtor.ID != id {
+		return model.Debtor{}, ErrInconsistentIDs
+	}
+	if err := mask.Validate(); err != nil {
+		return model.Debtor{}, err
+	}
+	ms.mtx.Lock()
+	defer ms.mtx.Unlock()
+	d, err := ms.get(id)
+	if err != nil {
+		return d, err
+	}
+	if err := checkVersion(d, version); err != nil {
+		return model.Debtor{}, err
+	}
+	before := d
+	if len(mask) == 0 {
+		mask = nonZero(debtor)
+	}
+	mask.apply(&d, debtor)
+	if err := ms.accountsUnique(d); err != nil {
+		return model.Debtor{}, err
+	}
+	ms.numberBankDetails(&d)
+	d.UpdatedAt = time.Now()
+	ms.debtors[id] = d
+	ms.record(audit(ctx, OpUpdate, before, d))
+	return d, nil
+}
+
+func (ms *memoryStore) Delete(ctx context.Context, id uint) error {
+	ms.mtx.Lock()
+	defer ms.mtx.Unlock()
+	d, err := ms.get(id)
+	if err != nil {
+		return err
+	}
+	before := d
+	now := time.Now()
+	d.DeletedAt = &now
+	ms.debtors[id] = d
+	ms.record(audit(ctx, OpDelete, before, d))
+	return nil
+}
+
+func (ms *memoryStore) GetDebtorHistory(ctx context.Context, id uint) ([]AuditRecord, error) {
+	ms.mtx.RLock()
+	defer ms.mtx.RUnlock()
+	records := []AuditRecord{}
+	for _, r := range ms.audit {
+		if r.DebtorID == id {
+			records = append(records, r)
+		}
+	}
+	if len(records) == 0 {
+		return nil, ErrNotFound
+	}
+	return records, nil
+}
+
+func (ms *memoryStore) StreamDebtors(ctx context.Context, f Filter, send func(model.Debtor) error) error {
+	p := Query{Filter: f}
+	ms.mtx.RLock()
+	debtors := model.Debtors{}
+	for _, d := range ms.debtors {
+		if d.DeletedAt == nil && p.match(d, hasActiveBiddings(d)) {
+			debtors = append(debtors, d)
+		}
+	}
+	ms.mtx.RUnlock()
+	sort.Slice(debtors, func(i, j int) bool {
+		return debtors[i].ID < debtors[j].ID
+	})
+	for _, d := range debtors {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := send(d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (ms *memoryStore) UpsertDebtors(ctx context.Context, debtors []model.Debtor) ([]Upserted, error) {
+	ms.mtx.Lock()
+	defer ms.mtx.Unlock()
+	// All or none of the debtors are upserted, the store is restored on
+	// an error.
+	saved := make(map[uint]model.Debtor, len(ms.debtors))
+	for id, d := range ms.debtors {
+		saved[id] = d
+	}
+	nextID, bankID, records := ms.nextID, ms.bankID, len(ms.audit)
+	fail := func(err error) ([]Upserted, error) {
+		ms.debtors, ms.nextID, ms.bankID, ms.audit = saved, nextID, bankID, ms.audit[:records]
+		return nil, err
+	}
+	res := make([]Upserted, len(debtors))
+	now := time.Now()
+	for i, d := range debtors {
+		existing, ok := ms.find(d.INN, d.OGRN)
+		if !ok {
+			ms.nextID++
+			d.ID = ms.nextID
+			if err := ms.accountsUnique(d); err != nil {
+				return fail(err)
+			}
+			d.CreatedAt, d.UpdatedAt, d.DeletedAt = now, now, nil
+			ms.numberBankDetails(&d)
+			ms.debtors[d.ID] = d
+			ms.record(audit(ctx, OpImport, model.Debtor{}, d))
+			res[i] = Upserted{ID: d.ID, Created: true}
+			continue
+		}
+		before := existing
+		nonZero(d).apply(&existing, d)
+		if err := ms.accountsUnique(existing); err != nil {
+			return fail(err)
+		}
+		ms.numberBankDetails(&existing)
+		existing.UpdatedAt = now
+		ms.debtors[existing.ID] = existing
+		ms.record(audit(ctx, OpImport, before, existing))
+		res[i] = Upserted{ID: existing.ID}
+	}
+	return res, nil
+}
+
+// find returns the live debtor with the INN, or with the OGRN when the INN
+// is empty. Must be called with mtx held.
+func (ms *memoryStore) find(inn, ogrn string) (model.Debtor, bool) {
+	for _, d := range ms.debtors {
+		if d.DeletedAt != nil {
+			continue
+		}
+		if (inn != "" && d.INN == inn) || (inn == "" && d.OGRN == ogrn) {
+			return d, true
+		}
+	}
+	return model.Debtor{}, false
+}
+
+func (ms *memoryStore) ListDeleted(ctx context.Context, p model.Pagination) (model.DebtorsResponse, error) {
+	if p.Limit == 0 {
+		p.Limit = 20
+	}
+	ms.mtx.RLock()
+	defer ms.mtx.RUnlock()
+	debtors := model.Debtors{}
+	for _, d := range ms.debtors {
+		if d.DeletedAt != nil {
+			debtors = append(debtors, d)
+		}
+	}
+	sort.Slice(debtors, func(i, j int) bool {
+		a, b := debtors[i], debtors[j]
+		if !a.DeletedAt.Equal(*b.DeletedAt) {
+			return a.DeletedAt.After(*b.DeletedAt)
+		}
+		return a.ID < b.ID
+	})
+	res := model.DebtorsResponse{Count: uint(len(debtors))}
+	res.Debtors = page(debtors, int(p.From), int(p.Limit))
+	return res, nil
+}
+
+func (ms *memoryStore) Restore(ctx context.Context, id uint) (model.Debtor, error) {
+	ms.mtx.Lock()
+	defer ms.mtx.Unlock()
+	d, ok := ms.debtors[id]
+	if !ok {
+		return model.Debtor{}, ErrNotFound
+	}
+	if d.DeletedAt == nil {
+		return model.Debtor{}, ErrNotDeleted
+	}
+	before := d
+	d.DeletedAt = nil
+	ms.debtors[id] = d
+	ms.record(audit(ctx, OpRestore, before, d))
+	return d, nil
+}
+
+func (ms *memoryStore) Purge(ctx context.Context, id uint, deletedBefore time.Time) (uint, error) {
+	ms.mtx.Lock()
+	defer ms.mtx.Unlock()
+	purged := uint(0)
+	for _, d := range ms.debtors {
+		if id != 0 && d.ID != id {
+			continue
+		}
+		switch {
+		case d.DeletedAt == nil:
+			if id != 0 {
+				return 0, ErrNotDeleted
+			}
+		case !deletedBefore.IsZero() && !d.DeletedAt.Before(deletedBefore):
+			if id != 0 {
+				return 0, ErrRetained
+			}
+		case len(d.Biddings) > 0:
+			if id != 0 {
+				return 0, ErrHasBiddings
+			}
+		default:
+			delete(ms.debtors, d.ID)
+			ms.purgeStatements(d.ID)
+			purged++
+			gone := model.Debtor{}
+			gone.ID = d.ID
+			ms.record(audit(ctx, OpPurge, gone, model.Debtor{}))
+		}
+	}
+	if id != 0 && purged == 0 {
+		return 0, ErrNotFound
+	}
+	return purged, nil
+}
+
+func hasActiveBiddings(d model.Debtor) bool {
+	for _, b := range d.Biddings {
+		if b.DeletedAt == nil {
+			return true
+		}
+	}
+	return false
+}
+
+func page(debtors model.Debtors, from, limit int) model.Debtors {
+	if from >= len(debtors) {
+		return model.Debtors{}
+	}
+	debtors = debtors[from:]
+	if limit > 0 && limit < len(debtors) {
+		debtors = debtors[:limit]
+	}
+	return debtors
+}
+
+func (ms *memoryStore) ListBankDetails(ctx context.Context, scope BankDetailScope) ([]model.BankDetail, error) {
+	ms.mtx.RLock()
+	defer ms.mtx.RUnlock()
+	d, err := ms.get(scope.DebtorID)
+	if err != nil {
+		return nil, err
+	}
+	res := []model.BankDetail{}
+	for _, b := range d.BankDetails {
+		if scope.has(b) {
+			res = append(res, b)
+		}
+	}
+	return res, nil
+}
+
+func (ms *memoryStore) AddBankDetail(ctx context.Context, scope BankDetailScope, b model.BankDetail) (model.BankDetail, error) {
+	ms.mtx.Lock()
+	defer ms.mtx.Unlock()
+	d, err := ms.get(scope.DebtorID)
+	if err != nil {
+		return model.BankDetail{}, err
+	}
+	b.ID = 0
+	d.BankDetails = append(append([]model.BankDetail(nil), d.BankDetails...), b)
+	if err := ms.accountsUnique(d); err != nil {
+		return model.BankDetail{}, err
+	}
+	ms.bankID++
+	now := time.Now()
+	b.ID, b.DebtorID, b.CreatedAt, b.UpdatedAt, b.DeletedAt = ms.bankID, d.ID, now, now, nil
+	if scope.InitiatorID != 0 {
+		b.InitiatorID = scope.InitiatorID
+	}
+	d.BankDetails[len(d.BankDetails)-1] = b
+	ms.debtors[d.ID] = d
+	ms.record(auditBankDetail(ctx, OpAddBankDetail, model.BankDetail{}, b))
+	return b, nil
+}
+
+func (ms *memoryStore) SaveBankDetail(ctx context.Context, scope BankDetailScope, id uint, b model.BankDetail) (model.BankDetail, error) {
+	ms.mtx.Lock()
+	defer ms.mtx.Unlock()
+	d, i, err := ms.bankDetail(scope, id)
+	if err != nil {
+		return model.BankDetail{}, err
+	}
+	before := d.BankDetails[i]
+	edited := before
+	editBankDetail(&edited, b)
+	edited.UpdatedAt = time.Now()
+	d.BankDetails = append([]model.BankDetail(nil), d.BankDetails...)
+	d.BankDetails[i] = edited
+	if err := ms.accountsUnique(d); err != nil {
+		return model.BankDetail{}, err
+	}
+	ms.debtors[d.ID] = d
+	ms.record(auditBankDetail(ctx, OpEditBankDetail, before, edited))
+	return edited, nil
+}
+
+func (ms *memoryStore) CloseBankDetail(ctx context.Context, scope BankDetailScope, id uint) error {
+	ms.mtx.Lock()
+	defer ms.mtx.Unlock()
+	d, i, err := ms.bankDetail(scope, id)
+	if err != nil {
+		return err
+	}
+	closed := d.BankDetails[i]
+	details := append([]model.BankDetail(nil), d.BankDetails[:i]...)
+	d.BankDetails = append(details, d.BankDetails[i+1:]...)
+	ms.debtors[d.ID] = d
+	ms.record(auditBankDetail(ctx, OpCloseBankDetail, closed, model.BankDetail{}))
+	return nil
+}
+
+func (ms *memoryStore) FindBankDetails(ctx context.Context, account string) ([]model.BankDetail, error) {
+	ms.mtx.RLock()
+	defer ms.mtx.RUnlock()
+	res := []model.BankDetail{}
+	for _, d := range ms.debtors {
+		if d.DeletedAt != nil {
+			continue
+		}
+		for _, b := range d.BankDetails {
+			if b.BankAccount == account {
+				res = append(res, b)
+			}
+		}
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].ID < res[j].ID })
+	return res, nil
+}
+
+// numberBankDetails links the bank details to the debtor and gives an id
+// to the new ones. Must be called with mtx held.
+func (ms *memoryStore) numberBankDetails(d *model.Debtor) {
+	d.BankDetails = append([]model.BankDetail(nil), d.BankDetails...)
+	for i := range d.BankDetails {
+		b := &d.BankDetails[i]
+		b.DebtorID = d.ID
+		if b.ID == 0 {
+			ms.bankID++
+			b.ID = ms.bankID
+		}
+	}
+}
+
+// bankDetail returns the debtor of the scope and the index of its bank
+// detail with the id. Must be called with mtx held.
+func (ms *memoryStore) bankDetail(scope BankDetailScope, id uint) (model.Debtor, int, error) {
+	d, err := ms.get(scope.DebtorID)
+	if err != nil {
+		return d, 0, err
+	}
+	for i, b := range d.BankDetails {
+		if b.ID == id && id != 0 && scope.has(b) {
+			return d, i, nil
+		}
+	}
+	return d, 0, ErrBankDetailNotFound
+}
+
+// accountsUnique checks that the bank details of the debtor with an id are
+// those it has stored, and that their account numbers are unique in their
+// banks, among them and the bank details of the other debtors. Must be
+// called with mtx held.
+func (ms *memoryStore) accountsUnique(d model.Debtor) error {
+	owned := map[uint]bool{}
+	for _, b := range ms.debtors[d.ID].BankDetails {
+		owned[b.ID] = true
+	}
+	seen := map[[2]string]bool{}
+	for _, b := range d.BankDetails {
+		if b.ID != 0 && !owned[b.ID] {
+			return ErrBankDetailNotFound
+		}
+		k := [2]string{b.BIK, b.BankAccount}
+		if seen[k] {
+			return ErrBankAccountExists
+		}
+		seen[k] = true
+	}
+	for _, other := range ms.debtors {
+		if other.ID == d.ID {
+			continue
+		}
+		for _, b := range other.BankDetails {
+			if seen[[2]string{b.BIK, b.BankAccount}] {
+				return ErrBankAccountExists
+			}
+		}
+	}
+	return nil
+}
+
+func (ms *memoryStore) PostStatement(ctx context.Context, scope BankDetailScope, bankDetailID uint, st Statement) (Statement, error) {
+	ms.mtx.Lock()
+	defer ms.mtx.Unlock()
+	d, i, err := ms.bankDetail(scope, bankDetailID)
+	if err != nil {
+		return Statement{}, err
+	}
+	var last *Statement
+	for j := range ms.statements {
+		if ms.statements[j].BankDetailID == bankDetailID {
+			last = &ms.statements[j]
+		}
+	}
+	if st, err = newStatement(st, d.BankDetails[i], last); err != nil {
+		return Statement{}, err
+	}
+	ms.statementID++
+	st.ID = ms.statementID
+	st.CreatedAt = time.Now()
+	ms.statements = append(ms.statements, st)
+	return st, nil
+}
+
+// purgeStatements removes the statements of the debtor. Must be called
+// with mtx held.
+func (ms *memoryStore) purgeStatements(debtorID uint) {
+	kept := ms.statements[:0]
+	for _, st := range ms.statements {
+		if st.DebtorID != debtorID {
+			kept = append(kept, st)
+		}
+	}
+	ms.statements = kept
+}
+
+func (ms *memoryStore) ListStatements(ctx context.Context, scope BankDetailScope, bankDetailID uint) ([]Statement, error) {
+	ms.mtx.RLock()
+	defer ms.mtx.RUnlock()
+	if _, _, err := ms.bankDetail(scope, bankDetailID); err != nil {
+		return nil, err
+	}
+	res := []Statement{}
+	for _, st := range ms.statements {
+		if st.BankDetailID == bankDetailID {
+			res = append(res, st)
+		}
+	}
+	return res, nil
+}
+
+func (ms *memoryStore) CashFlow(ctx context.Context, debtorID uint, from, to time.Time) (CashFlowReport, error) {
+	from, to = day(from), day(to)
+	if to.Before(from) {
+		return CashFlowReport{}, ErrReportPeriod
+	}
+	ms.mtx.RLock()
+	defer ms.mtx.RUnlock()
+	d, err := ms.get(debtorID)
+	if err != nil {
+		return CashFlowReport{}, err
+	}
+	statements := []Statement{}
+	for _, st := range ms.statements {
+		if st.DebtorID == debtorID && !st.EndDate.Before(from) && !st.StartDate.After(to) {
+			statements = append(statements, st)
+		}
+	}
+	sort.SliceStable(statements, func(i, j int) bool {
+		return statements[i].BankDetailID < statements[j].BankDetailID
+	})
+	return cashFlow(debtorID, from, to, d.BankDetails, statements), nil
+}
@@ -0,0 +1,237 @@
+package debtorservice
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"microsrv/model"
+)
+
+func TestMemoryCreateSave(t *testing.T) {
+	s := NewMemory()
+	ctx := context.Background()
+
+	d, err := s.CreateDebtor(ctx, model.Debtor{Name: "Ромашка", INN: "7707083893"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.ID != 1 || d.CreatedAt.IsZero() {
+		t.Fatalf("created %+v", d)
+	}
+	if _, err := s.CreateDebtor(ctx, model.Debtor{Model: d.Model}); err != ErrAlreadyExists {
+		t.Fatalf("create with a taken id: %v", err)
+	}
+	got, err := s.GetDebtor(ctx, uint32(d.ID))
+	if err != nil || got.Name != "Ромашка" {
+		t.Fatalf("GetDebtor = %+v, %v", got, err)
+	}
+
+	if _, err := s.Save(ctx, model.Debtor{Name: "Лютик"}, d.ID, "", nil); err != ErrVersionRequired {
+		t.Fatalf("Save without a version: %v", err)
+	}
+	version := Version(got)
+	saved, err := s.Save(ctx, model.Debtor{Name: "Лютик"}, d.ID, version, nil)
+	if err != nil || saved.Name != "Лютик" || saved.INN != "7707083893" {
+		t.Fatalf("Save = %+v, %v", saved, err)
+	}
+	if _, err := s.Save(ctx, model.Debtor{Name: "Василёк"}, d.ID, version, nil); err != ErrStaleVersion {
+		t.Fatalf("Save with a stale version: %v", err)
+	}
+	masked, err := s.Save(ctx, model.Debtor{}, d.ID, Version(saved), FieldMask{"INN"})
+	if err != nil || masked.INN != "" || masked.Name != "Лютик" {
+		t.Fatalf("masked Save = %+v, %v", masked, err)
+	}
+	if _, err := s.Save(ctx, model.Debtor{}, 9, "1", nil); err != ErrNotFound {
+		t.Fatalf("Save of a missing debtor: %v", err)
+	}
+
+	history, err := s.GetDebtorHistory(ctx, d.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ops := []string{}
+	for _, r := range history {
+		ops = append(ops, r.Operation)
+	}
+	if len(ops) != 3 || ops[0] != OpCreate || ops[1] != OpUpdate || ops[2] != OpUpdate {
+		t.Fatalf("history %v", ops)
+	}
+}
+
+func TestMemoryGetAll(t *testing.T) {
+	s := NewMemory()
+	ctx := context.Background()
+	for _, name := range []string{"Берёза", "Арбуз", "Вишня", "Абрикос"} {
+		if _, err := s.CreateDebtor(ctx, model.Debtor{Name: name, CaseNo: "А40-" + name}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	p, err := s.GetAll(ctx, Query{Pagination: model.Pagination{Name: "а", Limit: 2}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Count != 3 || len(p.Debtors) != 2 || p.Debtors[0].Name != "Абрикос" || p.Debtors[1].Name != "Арбуз" {
+		t.Fatalf("first page %d %+v", p.Count, p.Debtors)
+	}
+	next, err := s.GetAll(ctx, Query{Pagination: model.Pagination{Name: "а", Limit: 2}, Cursor: p.NextCursor})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(next.Debtors) != 1 || next.Debtors[0].Name != "Берёза" || next.NextCursor != "" {
+		t.Fatalf("second page %+v", next.Debtors)
+	}
+	if _, err := s.GetAll(ctx, Query{Pagination: model.Pagination{Name: "б", Limit: 2}, Cursor: p.NextCursor}); err != ErrInvalidCursor {
+		t.Fatalf("cursor of another search: %v", err)
+	}
+	if _, err := s.GetAll(ctx, Query{Pagination: model.Pagination{Name: "а", Limit: 2}, Filter: Filter{INN: "7707083893"}, Cursor: p.NextCursor}); err != ErrInvalidCursor {
+		t.Fatalf("cursor of another filter: %v", err)
+	}
+
+	p, err = s.GetAll(ctx, Query{Filter: Filter{CaseNo: "А40-Вишня"}})
+	if err != nil || p.Count != 1 || p.Debtors[0].Name != "Вишня" {
+		t.Fatalf("filtered %+v, %v", p, err)
+	}
+	if _, err := s.GetAll(ctx, Query{Order: []SortKey{{Field: "contacts"}}}); err == nil {
+		t.Fatal("sort by an unknown field")
+	}
+}
+
+func TestMemoryDeleteRestorePurge(t *testing.T) {
+	s := NewMemory()
+	ctx := context.Background()
+	a, _ := s.CreateDebtor(ctx, model.Debtor{Name: "A"})
+	b, _ := s.CreateDebtor(ctx, model.Debtor{Name: "B", Biddings: []model.Bidding{{Name: "lot"}}})
+
+	if err := s.Delete(ctx, a.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.GetDebtor(ctx, uint32(a.ID)); err != ErrNotFound {
+		t.Fatalf("GetDebtor of a deleted debtor: %v", err)
+	}
+	if err := s.Delete(ctx, a.ID); err != ErrNotFound {
+		t.Fatalf("second Delete: %v", err)
+	}
+	deleted, err := s.ListDeleted(ctx, model.Pagination{})
+	if err != nil || deleted.Count != 1 || deleted.Debtors[0].ID != a.ID {
+		t.Fatalf("ListDeleted = %+v, %v", deleted, err)
+	}
+	if _, err := s.Restore(ctx, b.ID); err != ErrNotDeleted {
+		t.Fatalf("Restore of a live debtor: %v", err)
+	}
+	if _, err := s.Restore(ctx, a.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Purge(ctx, a.ID, time.Time{}); err != ErrNotDeleted {
+		t.Fatalf("Purge of a live debtor: %v", err)
+	}
+
+	s.Delete(ctx, a.ID)
+	s.Delete(ctx, b.ID)
+	if _, err := s.Purge(ctx, a.ID, time.Now().Add(-time.Hour)); err != ErrRetained {
+		t.Fatalf("Purge of a recently deleted debtor: %v", err)
+	}
+	if _, err := s.Purge(ctx, b.ID, time.Time{}); err != ErrHasBiddings {
+		t.Fatalf("Purge of a debtor with biddings: %v", err)
+	}
+	n, err := s.Purge(ctx, 0, time.Time{})
+	if err != nil || n != 1 {
+		t.Fatalf("Purge = %d, %v", n, err)
+	}
+	if _, err := s.Restore(ctx, a.ID); err != ErrNotFound {
+		t.Fatalf("Restore of a purged debtor: %v", err)
+	}
+	history, _ := s.GetDebtorHistory(ctx, a.ID)
+	if last := history[len(history)-1]; last.Operation != OpPurge {
+		t.Fatalf("last operation %s, want purge", last.Operation)
+	}
+}
+
+func TestMemoryUpsertStream(t *testing.T) {
+	s := NewMemory()
+	ctx := context.Background()
+	a, _ := s.CreateDebtor(ctx, model.Debtor{Name: "A", INN: "7707083893", Address: "Москва"})
+
+	res, err := s.UpsertDebtors(ctx, []model.Debtor{
+		{Name: "A2", INN: "7707083893"},
+		{Name: "B", OGRN: "1027700132195"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res) != 2 || res[0].ID != a.ID || res[0].Created || !res[1].Created {
+		t.Fatalf("UpsertDebtors = %+v", res)
+	}
+	got, _ := s.GetDebtor(ctx, uint32(a.ID))
+	if got.Name != "A2" || got.Address != "Москва" {
+		t.Fatalf("updated %+v", got)
+	}
+
+	names := []string{}
+	err = s.StreamDebtors(ctx, Filter{}, func(d model.Debtor) error {
+		names = append(names, d.Name)
+		return nil
+	})
+	if err != nil || len(names) != 2 || names[0] != "A2" || names[1] != "B" {
+		t.Fatalf("StreamDebtors = %v, %v", names, err)
+	}
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if err := s.StreamDebtors(cancelled, Filter{}, func(model.Debtor) error { return nil }); err != context.Canceled {
+		t.Fatalf("StreamDebtors with a cancelled context: %v", err)
+	}
+}
+
+func TestMemoryPurgeStatements(t *testing.T) {
+	s := NewMemory()
+	ctx := context.Background()
+	day := time.Date(2019, 3, 1, 0, 0, 0, 0, time.UTC)
+	ids := []uint{}
+	for i, account := range []string{"40702810938000000001", "40702810938000000002"} {
+		d, _ := s.CreateDebtor(ctx, model.Debtor{Name: account, BankDetails: []model.BankDetail{{BIK: "044525225", BankAccount: account}}})
+		st, err := s.PostStatement(ctx, BankDetailScope{DebtorID: d.ID}, d.BankDetails[0].ID, Statement{StartDate: day, EndDate: day, Income: int64(i + 1), FinalBalance: int64(i + 1)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, d.ID, st.ID)
+	}
+	s.Delete(ctx, ids[0])
+	if n, err := s.Purge(ctx, ids[0], time.Time{}); err != nil || n != 1 {
+		t.Fatalf("Purge = %d, %v", n, err)
+	}
+	statements := s.(*memoryStore).statements
+	if len(statements) != 1 || statements[0].ID != ids[3] {
+		t.Fatalf("statements after the purge %+v", statements)
+	}
+}
+
+func TestMemorySortIgnoresCase(t *testing.T) {
+	s := NewMemory()
+	ctx := context.Background()
+	for _, name := range []string{"берёза", "Арбуз", "вишня", "абрикос"} {
+		s.CreateDebtor(ctx, model.Debtor{Name: name})
+	}
+	p, err := s.GetAll(ctx, Query{Order: []SortKey{{Field: "name"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := []string{}
+	for _, d := range p.Debtors {
+		names = append(names, d.Name)
+	}
+	if strings.Join(names, ",") != "абрикос,Арбуз,берёза,вишня" {
+		t.Fatalf("sorted %v", names)
+	}
+}
+
+func TestNonZeroMask(t *testing.T) {
+	mask := nonZero(model.Debtor{Name: "A", PostAddressMatch: true, Biddings: []model.Bidding{{}}})
+	if len(mask) != 2 || !mask.has("Name") || !mask.has("PostAddressMatch") {
+		t.Errorf("mask %v", mask)
+	}
+	if m := nonZero(model.Debtor{BankDetails: []model.BankDetail{}}); !m.has("BankDetails") {
+		t.Errorf("empty bank details are not masked: %v", m)
+	}
+}
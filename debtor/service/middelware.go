@@ -3,6 +3,7 @@ package debtorservice
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"microsrv/model"
@@ -62,23 +63,25 @@ func (mw loggingMiddleware) GetDebtor(ctx context.Context, id uint32) (model.Deb
 }
 
 // Save func
-func (mw loggingMiddleware) Save(ctx context.Context, d model.Debtor, id uint) (model.Debtor, error) {
+func (mw loggingMiddleware) Save(ctx context.Context, d model.Debtor, id uint, version string, mask FieldMask) (model.Debtor, error) {
 	defer func(begin time.Time) {
 		mw.logger.Log(
 			"method", "Save",
 			"Debtor.ID", id,
+			"version", version,
+			"mask", strings.Join(mask, ","),
 			"took", time.Since(begin),
 		)
 	}(time.Now())
-	return mw.next.Save(ctx, d, id)
+	return mw.next.Save(ctx, d, id, version, mask)
 }
 
 // GetAll func
-func (mw loggingMiddleware) GetAll(ctx context.Context, p model.Pagination) (model.DebtorsResponse, error) {
+func (mw loggingMiddleware) GetAll(ctx context.Context, p Query) (Page, error) {
 	defer func(begin time.Time) {
 		mw.logger.Log(
 			"method", "GetAll",
-			"Pagination", fmt.Sprintf("%+v", p),
+			"Query", fmt.Sprintf("%+v", p),
 			"took", time.Since(begin),
 		)
 	}(time.Now())
@@ -96,3 +99,195 @@ func (mw loggingMiddleware) Delete(ctx context.Context, id uint) error {
 	}(time.Now())
 	return mw.next.Delete(ctx, id)
 }
+
+// StreamDebtors func
+func (mw loggingMiddleware) StreamDebtors(ctx context.Context, f Filter, send func(model.Debtor) error) (err error) {
+	sent := 0
+	defer func(begin time.Time) {
+		mw.logger.Log(
+			"method", "StreamDebtors",
+			"Filter", fmt.Sprintf("%+v", f),
+			"sent", sent,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return mw.next.StreamDebtors(ctx, f, func(d model.Debtor) error {
+		sent++
+		return send(d)
+	})
+}
+
+// UpsertDebtors func
+func (mw loggingMiddleware) UpsertDebtors(ctx context.Context, debtors []model.Debtor) (res []Upserted, err error) {
+	defer func(begin time.Time) {
+		mw.logger.Log(
+			"method", "UpsertDebtors",
+			"debtors", len(debtors),
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return mw.next.UpsertDebtors(ctx, debtors)
+}
+
+// GetDebtorHistory func
+func (mw loggingMiddleware) GetDebtorHistory(ctx context.Context, id uint) ([]AuditRecord, error) {
+	defer func(begin time.Time) {
+		mw.logger.Log(
+			"method", "GetDebtorHistory",
+			"Debtor.ID", id,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return mw.next.GetDebtorHistory(ctx, id)
+}
+
+// ListDeleted func
+func (mw loggingMiddleware) ListDeleted(ctx context.Context, p model.Pagination) (model.DebtorsResponse, error) {
+	defer func(begin time.Time) {
+		mw.logger.Log(
+			"method", "ListDeleted",
+			"Pagination", fmt.Sprintf("%+v", p),
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return mw.next.ListDeleted(ctx, p)
+}
+
+// Restore func
+func (mw loggingMiddleware) Restore(ctx context.Context, id uint) (model.Debtor, error) {
+	defer func(begin time.Time) {
+		mw.logger.Log(
+			"method", "Restore",
+			"Debtor.ID", id,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return mw.next.Restore(ctx, id)
+}
+
+// Purge func
+func (mw loggingMiddleware) Purge(ctx context.Context, id uint, deletedBefore time.Time) (purged uint, err error) {
+	defer func(begin time.Time) {
+		mw.logger.Log(
+			"method", "Purge",
+			"Debtor.ID", id,
+			"deletedBefore", deletedBefore,
+			"purged", purged,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return mw.next.Purge(ctx, id, deletedBefore)
+}
+
+// ListBankDetails func
+func (mw loggingMiddleware) ListBankDetails(ctx context.Context, scope BankDetailScope) ([]model.BankDetail, error) {
+	defer func(begin time.Time) {
+		mw.logger.Log(
+			"method", "ListBankDetails",
+			"Debtor.ID", scope.DebtorID,
+			"InitiatorID", scope.InitiatorID,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return mw.next.ListBankDetails(ctx, scope)
+}
+
+// AddBankDetail func
+func (mw loggingMiddleware) AddBankDetail(ctx context.Context, scope BankDetailScope, b model.BankDetail) (res model.BankDetail, err error) {
+	defer func(begin time.Time) {
+		mw.logger.Log(
+			"method", "AddBankDetail",
+			"Debtor.ID", scope.DebtorID,
+			"InitiatorID", scope.InitiatorID,
+			"BankDetail.ID", res.ID,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return mw.next.AddBankDetail(ctx, scope, b)
+}
+
+// SaveBankDetail func
+func (mw loggingMiddleware) SaveBankDetail(ctx context.Context, scope BankDetailScope, id uint, b model.BankDetail) (model.BankDetail, error) {
+	defer func(begin time.Time) {
+		mw.logger.Log(
+			"method", "SaveBankDetail",
+			"Debtor.ID", scope.DebtorID,
+			"InitiatorID", scope.InitiatorID,
+			"BankDetail.ID", id,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return mw.next.SaveBankDetail(ctx, scope, id, b)
+}
+
+// CloseBankDetail func
+func (mw loggingMiddleware) CloseBankDetail(ctx context.Context, scope BankDetailScope, id uint) error {
+	defer func(begin time.Time) {
+		mw.logger.Log(
+			"method", "CloseBankDetail",
+			"Debtor.ID", scope.DebtorID,
+			"InitiatorID", scope.InitiatorID,
+			"BankDetail.ID", id,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return mw.next.CloseBankDetail(ctx, scope, id)
+}
+
+// FindBankDetails func
+func (mw loggingMiddleware) FindBankDetails(ctx context.Context, account string) ([]model.BankDetail, error) {
+	defer func(begin time.Time) {
+		mw.logger.Log(
+			"method", "FindBankDetails",
+			"account", account,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return mw.next.FindBankDetails(ctx, account)
+}
+
+// PostStatement func
+func (mw loggingMiddleware) PostStatement(ctx context.Context, scope BankDetailScope, bankDetailID uint, st Statement) (res Statement, err error) {
+	defer func(begin time.Time) {
+		mw.logger.Log(
+			"method", "PostStatement",
+			"Debtor.ID", scope.DebtorID,
+			"BankDetail.ID", bankDetailID,
+			"Statement.ID", res.ID,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return mw.next.PostStatement(ctx, scope, bankDetailID, st)
+}
+
+// ListStatements func
+func (mw loggingMiddleware) ListStatements(ctx context.Context, scope BankDetailScope, bankDetailID uint) ([]Statement, error) {
+	defer func(begin time.Time) {
+		mw.logger.Log(
+			"method", "ListStatements",
+			"Debtor.ID", scope.DebtorID,
+			"BankDetail.ID", bankDetailID,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return mw.next.ListStatements(ctx, scope, bankDetailID)
+}
+
+// CashFlow func
+func (mw loggingMiddleware) CashFlow(ctx context.Context, debtorID uint, from, to time.Time) (CashFlowReport, error) {
+	defer func(begin time.Time) {
+		mw.logger.Log(
+			"method", "CashFlow",
+			"Debtor.ID", debtorID,
+			"from", from,
+			"to", to,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return mw.next.CashFlow(ctx, debtorID, from, to)
+}
@@ -0,0 +1,48 @@
+package debtorservice
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"microsrv/apperr"
+	"microsrv/schedule"
+)
+
+// PurgeJob is the name of the scheduler handler that purges the debtors
+// deleted more than a retention ago, the payload being the retention in
+// seconds.
+const PurgeJob = "debtor.purge"
+
+// PurgeActor is recorded in the audit trail for the purges of PurgeJob.
+const PurgeActor = "purge job"
+
+var (
+	// ErrNotDeleted var
+	ErrNotDeleted = apperr.NewConflict("debtor is not deleted")
+	// ErrHasBiddings var
+	ErrHasBiddings = apperr.NewConflict("debtor has biddings")
+	// ErrRetained var
+	ErrRetained = apperr.NewConflict("debtor was deleted after the given time")
+	// ErrInvalidRetention var
+	ErrInvalidRetention = apperr.NewValidation("invalid retention", apperr.Field{Field: "retention", Description: "must be positive"})
+)
+
+// SchedulePurge registers PurgeJob and adds a job running it every
+// interval, starting now.
+func SchedulePurge(s Service, scheduler *schedule.Scheduler, retention, interval time.Duration) (schedule.Job, error) {
+	if retention <= 0 {
+		return schedule.Job{}, ErrInvalidRetention
+	}
+	scheduler.Register(PurgeJob, func(ctx context.Context, payload []byte) error {
+		seconds, err := strconv.ParseInt(string(payload), 10, 64)
+		if err != nil || seconds <= 0 {
+			return ErrInvalidRetention
+		}
+		ctx = WithActor(ctx, PurgeActor)
+		_, err = s.Purge(ctx, 0, time.Now().Add(-time.Duration(seconds)*time.Second))
+		return err
+	})
+	req := schedule.Request{Type: schedule.PERIODICALY, Intervals: int32(interval / time.Second)}
+	return scheduler.Add(req, PurgeJob, []byte(strconv.FormatInt(int64(retention/time.Second), 10)))
+}
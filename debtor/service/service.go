@@ -2,14 +2,17 @@ package debtorservice
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"log"
+	"time"
 
+	"microsrv/apperr"
+	"microsrv/config"
 	"microsrv/model"
 
 	"github.com/jinzhu/gorm"
-	_ "github.com/jinzhu/gorm/dialects/mysql" // Mysql driver
+	_ "github.com/jinzhu/gorm/dialects/mysql"    // Mysql driver
+	_ "github.com/jinzhu/gorm/dialects/postgres" // Postgres driver
 )
 
 // Service interface
@@ -17,33 +20,131 @@ type Service interface {
 	Health() bool
 	CreateDebtor(ctx context.Context, d model.Debtor) (model.Debtor, error)
 	GetDebtor(ctx context.Context, id uint32) (model.Debtor, error)
-	GetAll(ctx context.Context, p model.Pagination) (model.DebtorsResponse, error)
-	Save(ctx context.Context, debtor model.Debtor, id uint) (model.Debtor, error)
+	GetAll(ctx context.Context, p Query) (Page, error)
+	// Save updates the debtor when version is the Version of the stored
+	// debtor, so that concurrent updates do not overwrite each other. Only
+	// the fields of the mask are written, the non zero ones without a mask.
+	Save(ctx context.Context, debtor model.Debtor, id uint, version string, mask FieldMask) (model.Debtor, error)
 	Delete(ctx context.Context, id uint) error
+	// StreamDebtors passes the debtors matching the filter to send in id
+	// order. It stops at the first send error or when ctx is done.
+	StreamDebtors(ctx context.Context, f Filter, send func(model.Debtor) error) error
+	// UpsertDebtors creates or updates the debtors matched by INN, or by
+	// OGRN when the INN is empty, all or none of them.
+	UpsertDebtors(ctx context.Context, debtors []model.Debtor) ([]Upserted, error)
+	// GetDebtorHistory returns the audit trail of the debtor, oldest first,
+	// deleted debtors included.
+	GetDebtorHistory(ctx context.Context, id uint) ([]AuditRecord, error)
+	// ListDeleted returns a page of the soft deleted debtors, the last
+	// deleted first.
+	ListDeleted(ctx context.Context, p model.Pagination) (model.DebtorsResponse, error)
+	// Restore undeletes a soft deleted debtor.
+	Restore(ctx context.Context, id uint) (model.Debtor, error)
+	// Purge permanently removes the soft deleted debtors, with their bank
+	// details and statements, deleted before deletedBefore, or the one with the id when
+	// id is not zero. Debtors with biddings are kept. The audit trail of
+	// purged debtors is kept. It returns the number of purged debtors.
+	Purge(ctx context.Context, id uint, deletedBefore time.Time) (uint, error)
+	// ListBankDetails returns the open bank details of the scope.
+	ListBankDetails(ctx context.Context, scope BankDetailScope) ([]model.BankDetail, error)
+	// AddBankDetail opens a bank detail of the debtor of the scope, on
+	// behalf of its initiator if any. An account number is unique in a bank.
+	AddBankDetail(ctx context.Context, scope BankDetailScope, b model.BankDetail) (model.BankDetail, error)
+	// SaveBankDetail edits the bank detail of the scope with the id.
+	SaveBankDetail(ctx context.Context, scope BankDetailScope, id uint, b model.BankDetail) (model.BankDetail, error)
+	// CloseBankDetail closes the bank detail of the scope with the id.
+	CloseBankDetail(ctx context.Context, scope BankDetailScope, id uint) error
+	// FindBankDetails returns the open bank details with the account
+	// number, of any debtor.
+	FindBankDetails(ctx context.Context, account string) ([]model.BankDetail, error)
+	// PostStatement appends a statement to the open bank detail of the
+	// scope with the id. The statement must balance and follow the last
+	// one of the account without a gap.
+	PostStatement(ctx context.Context, scope BankDetailScope, bankDetailID uint, st Statement) (Statement, error)
+	// ListStatements returns the statements of the bank detail of the scope
+	// with the id, oldest first.
+	ListStatements(ctx context.Context, scope BankDetailScope, bankDetailID uint) ([]Statement, error)
+	// CashFlow reports the statements of the accounts of the debtor that
+	// overlap the days from and to.
+	CashFlow(ctx context.Context, debtorID uint, from, to time.Time) (CashFlowReport, error)
 }
 
 var (
 	// ErrInconsistentIDs var
-	ErrInconsistentIDs = errors.New("inconsistent IDs")
+	ErrInconsistentIDs = apperr.NewValidation("inconsistent IDs", apperr.Field{Field: "id", Description: "differs from the debtor id"})
 	// ErrAlreadyExists var
-	ErrAlreadyExists = errors.New("already exists")
+	ErrAlreadyExists = apperr.NewConflict("debtor already exists")
 	// ErrNotFound var
-	ErrNotFound = errors.New("not found")
+	ErrNotFound = apperr.NewNotFound("debtor not found")
 )
 
-type databaseStore struct{ db *gorm.DB }
+// New returns the Service backed by the storage selected by cfg.Driver.
+func New(cfg config.DB) (Service, error) {
+	switch cfg.Driver {
+	case "", "mysql":
+		return NewDB("mysql", cfg.DSN(), cfg.CursorSecret)
+	case "postgres":
+		return NewDB("postgres", cfg.DSN(), cfg.CursorSecret)
+	case "memory":
+		return NewMemory(), nil
+	default:
+		return nil, fmt.Errorf("unknown database driver %q", cfg.Driver)
+	}
+}
+
+type databaseStore struct {
+	db      *gorm.DB
+	dialect string
+	cursors *cursorCodec
+}
 
-// NewDB func
-func NewDB(DSN string) Service {
-	db, err := gorm.Open("mysql", DSN)
+// NewDB returns a Service stored in a MySQL or PostgreSQL database.
+// Pagination cursors are signed with the secret.
+func NewDB(dialect, DSN, secret string) (Service, error) {
+	db, err := gorm.Open(dialect, DSN)
 	if err != nil {
-		fmt.Println(err.Error())
-		panic("failed to connect database")
+		return nil, err
+	}
+	if dialect == "mysql" {
+		db = db.Set("gorm:table_options", "ENGINE=InnoDB")
+		db.Set("sql_mode", "")
 	}
-	//defer db.Close()
-	db = db.Set("gorm:table_options", "ENGINE=InnoDB")
-	db.Set("sql_mode", "")
-	return &databaseStore{db: db}
+	cursors, err := newCursorCodec(secret)
+	if err != nil {
+		return nil, err
+	}
+	return &databaseStore{db: db, dialect: dialect, cursors: cursors}, nil
+}
+
+// like returns a case insensitive LIKE condition on the column.
+func (ds *databaseStore) like(column string) string {
+	if ds.dialect == "postgres" {
+		return column + " ILIKE ?"
+	}
+	return column + " COLLATE UTF8_GENERAL_CI LIKE ?"
+}
+
+// sortExpr returns the expression the column is sorted by. A missing
+// decision date sorts as the earliest one, as in the memory store.
+func (ds *databaseStore) sortExpr(column string) string {
+	if column != "decision_date" {
+		return column
+	}
+	if ds.dialect == "postgres" {
+		return "COALESCE(decision_date, '0001-01-01 00:00:00+00'::timestamptz)"
+	}
+	return "COALESCE(decision_date, CAST('1000-01-01 00:00:00' AS DATETIME))"
+}
+
+// keysetValues replaces the zero decision date of a cursor with the one
+// used by sortExpr.
+func (ds *databaseStore) keysetValues(order []SortKey, values []interface{}) []interface{} {
+	for i, k := range order {
+		if t, ok := values[i].(time.Time); ok && k.Field == "decision_date" && t.IsZero() && ds.dialect != "postgres" {
+			values[i] = time.Date(1000, 1, 1, 0, 0, 0, 0, time.Local)
+		}
+	}
+	return values
 }
 
 // Health implementation of the Service.
@@ -53,7 +154,15 @@ func (ds *databaseStore) Health() bool {
 
 func (ds *databaseStore) CreateDebtor(ctx context.Context, d model.Debtor) (model.Debtor, error) {
 	debtor := model.Debtor{}
-	err := ds.db.Create(&d).Error
+	if d.ID != 0 && !ds.db.First(&model.Debtor{}, d.ID).RecordNotFound() {
+		return debtor, ErrAlreadyExists
+	}
+	err := ds.inTx(func(tx *gorm.DB) error {
+		if err := createDebtor(tx, &d); err != nil {
+			return err
+		}
+		return tx.Create(ptr(audit(ctx, OpCreate, model.Debtor{}, d))).Error
+	})
 	if err != nil {
 		return debtor, err
 	}
@@ -76,36 +185,50 @@ func (ds *databaseStore) GetDebtor(ctx context.Context, id uint32) (model.Debtor
 		Preload("BankDetails").
 		First(&debtor, id).
 		Error
+	if gorm.IsRecordNotFoundError(err) {
+		return debtor, ErrNotFound
+	}
 	if err != nil {
 		return debtor, err
 	}
 	return debtor, nil
 }
 
-func (ds *databaseStore) GetAll(ctx context.Context, p model.Pagination) (model.DebtorsResponse, error) {
+func (ds *databaseStore) GetAll(ctx context.Context, p Query) (Page, error) {
 	if p.Limit == 0 {
 		p.Limit = 20
 	}
-	res := model.DebtorsResponse{}
+	res := Page{}
 	debtors := model.Debtors{}
 	count := 0
-	q := ds.db
-	if p.Name != "" {
-		q = q.Where("name COLLATE UTF8_GENERAL_CI LIKE ?", fmt.Sprintf("%%%s%%", p.Name))
+	order, err := p.order()
+	if err != nil {
+		return res, err
 	}
-	q1 := q.Table("debtors")
-	err := q1.Where("deleted_at IS NULL").Count(&count).Error
+	q := ds.filter(ds.db, p)
+	err = q.Model(&model.Debtor{}).Count(&count).Error
 	if err != nil {
 		return res, err
 	}
 	res.Count = uint(count)
-	if p.Sort == "" {
-		q = q.Order("name")
-	} else {
-		q = q.Order("name desc")
+	if p.Cursor != "" {
+		values, err := ds.cursors.decode(p.Cursor, p, order)
+		if err != nil {
+			return res, err
+		}
+		cond, args := keyset(order, ds.keysetValues(order, values), ds.sortExpr)
+		q = q.Where(cond, args...)
+		p.From = 0
+	}
+	for _, k := range order {
+		if k.Desc {
+			q = q.Order(ds.sortExpr(k.Field) + " desc")
+		} else {
+			q = q.Order(ds.sortExpr(k.Field))
+		}
 	}
 	err = q.
-		Select("id, name, inn, ogrn, address, arbitration_id, case_no, decision_date, bankruptcy_manager_id").
+		Select("id, name, inn, ogrn, address, arbitration_id, case_no, decision_date, bankruptcy_manager_id, created_at, updated_at").
 		Preload("Biddings").
 		Preload("BankruptcyManager").
 		Preload("Arbitration").
@@ -117,25 +240,271 @@ func (ds *databaseStore) GetAll(ctx context.Context, p model.Pagination) (model.
 		return res, err
 	}
 	res.Debtors = debtors
+	if len(debtors) == p.Limit {
+		res.NextCursor = ds.cursors.encode(p, order, debtors[len(debtors)-1])
+	}
+	return res, nil
+}
+
+// streamBatch is the number of debtors StreamDebtors loads per query.
+const streamBatch = 500
+
+// StreamDebtors reads the ids one by one from a database cursor, so a slow
+// receiver holds back the query instead of buffering the table. The
+// debtors are loaded with their arbitration, bankruptcy manager and bank
+// details for every streamBatch ids.
+func (ds *databaseStore) StreamDebtors(ctx context.Context, f Filter, send func(model.Debtor) error) error {
+	rows, err := ds.filter(ds.db.Model(&model.Debtor{}), Query{Filter: f}).Select("id").Order("id").Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	ids := make([]uint, 0, streamBatch)
+	flush := func() error {
+		if len(ids) == 0 {
+			return nil
+		}
+		debtors := model.Debtors{}
+		err := ds.db.
+			Where("id IN (?)", ids).
+			Order("id").
+			Preload("Arbitration").
+			Preload("BankruptcyManager").
+			Preload("BankDetails").
+			Find(&debtors).
+			Error
+		if err != nil {
+			return err
+		}
+		ids = ids[:0]
+		for _, d := range debtors {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if err := send(d); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for rows.Next() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		var id uint
+		if err := rows.Scan(&id); err != nil {
+			return err
+		}
+		if ids = append(ids, id); len(ids) == streamBatch {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	return flush()
+}
+
+// UpsertDebtors func
+func (ds *databaseStore) UpsertDebtors(ctx context.Context, debtors []model.Debtor) ([]Upserted, error) {
+	res := make([]Upserted, len(debtors))
+	err := ds.inTx(func(tx *gorm.DB) error {
+		for i, d := range debtors {
+			existing := model.Debtor{}
+			q := tx.Where("inn = ?", d.INN)
+			if d.INN == "" {
+				q = tx.Where("ogrn = ?", d.OGRN)
+			}
+			err := q.First(&existing).Error
+			switch {
+			case gorm.IsRecordNotFoundError(err):
+				d.ID = 0
+				if err = createDebtor(tx, &d); err == nil {
+					err = tx.Create(ptr(audit(ctx, OpImport, model.Debtor{}, d))).Error
+				}
+				res[i] = Upserted{ID: d.ID, Created: true}
+			case err == nil:
+				before := existing
+				d.ID = existing.ID
+				err = tx.Where("debtor_id = ?", existing.ID).Order("id").Find(&before.BankDetails).Error
+				if err == nil {
+					err = updateDebtor(tx, &existing, d, nonZero(d))
+				}
+				if err == nil {
+					err = tx.Create(ptr(audit(ctx, OpImport, before, existing))).Error
+				}
+				res[i] = Upserted{ID: existing.ID}
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
 	return res, nil
 }
 
+// createDebtor creates the debtor and its bank details, which are then
+// reloaded. The other associations are referenced by their ids and are not
+// saved with the debtor.
+func createDebtor(tx *gorm.DB, d *model.Debtor) error {
+	details := d.BankDetails
+	if err := tx.Set("gorm:save_associations", false).Create(d).Error; err != nil {
+		return err
+	}
+	if err := replaceBankDetails(tx, d.ID, details); err != nil {
+		return err
+	}
+	return tx.Where("debtor_id = ?", d.ID).Order("id").Find(&d.BankDetails).Error
+}
+
+// updateDebtor writes the masked fields of src to the debtor d, replacing
+// its bank details when they are masked, and reloads the bank details of
+// d. The associations are not saved with the debtor.
+func updateDebtor(tx *gorm.DB, d *model.Debtor, src model.Debtor, mask FieldMask) error {
+	if err := tx.Set("gorm:save_associations", false).Model(d).Updates(mask.updates(src)).Error; err != nil {
+		return err
+	}
+	if mask.has("BankDetails") {
+		if err := replaceBankDetails(tx, d.ID, src.BankDetails); err != nil {
+			return err
+		}
+	}
+	return tx.Where("debtor_id = ?", d.ID).Order("id").Find(&d.BankDetails).Error
+}
+
+// replaceBankDetails makes details the bank details of the debtor. The
+// missing ones are deleted, then the details with an id update the
+// debtor's ones and the others are created.
+func replaceBankDetails(tx *gorm.DB, id uint, details []model.BankDetail) error {
+	keep := []uint{}
+	for _, b := range details {
+		if b.ID != 0 {
+			keep = append(keep, b.ID)
+		}
+	}
+	q := tx.Where("debtor_id = ?", id)
+	if len(keep) > 0 {
+		q = q.Where("id NOT IN (?)", keep)
+	}
+	if err := q.Delete(&model.BankDetail{}).Error; err != nil {
+		return err
+	}
+	if err := accountsUnique(tx, id, details); err != nil {
+		return err
+	}
+	for _, b := range details {
+		b.DebtorID = id
+		var err error
+		if b.ID == 0 {
+			err = tx.Create(&b).Error
+		} else {
+			err = tx.Omit("created_at").Save(&b).Error
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// inTx runs fn in a transaction, committed when fn returns nil.
+func (ds *databaseStore) inTx(fn func(tx *gorm.DB) error) error {
+	tx := ds.db.Begin()
+	if tx.Error != nil {
+		return tx.Error
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit().Error
+}
+
+func ptr(r AuditRecord) *AuditRecord {
+	return &r
+}
+
+// filter adds the conditions of the query to q.
+func (ds *databaseStore) filter(q *gorm.DB, p Query) *gorm.DB {
+	f := p.Filter
+	if p.Name != "" {
+		q = q.Where(ds.like("name"), fmt.Sprintf("%%%s%%", p.Name))
+	}
+	if f.INN != "" {
+		q = q.Where("inn = ?", f.INN)
+	}
+	if f.OGRN != "" {
+		q = q.Where("ogrn = ?", f.OGRN)
+	}
+	if f.CaseNo != "" {
+		q = q.Where("case_no = ?", f.CaseNo)
+	}
+	if f.ArbitrationID != "" {
+		q = q.Where("arbitration_id = ?", f.ArbitrationID)
+	}
+	if f.BankruptcyManagerID != 0 {
+		q = q.Where("bankruptcy_manager_id = ?", f.BankruptcyManagerID)
+	}
+	if f.DecisionDateFrom != nil {
+		q = q.Where("decision_date >= ?", *f.DecisionDateFrom)
+	}
+	if f.DecisionDateTo != nil {
+		q = q.Where("decision_date <= ?", *f.DecisionDateTo)
+	}
+	if f.HasActiveBiddings {
+		q = q.Where("EXISTS (SELECT 1 FROM biddings WHERE biddings.debtor_id = debtors.id AND biddings.deleted_at IS NULL)")
+	}
+	return q
+}
+
 // Save func
-func (ds *databaseStore) Save(ctx context.Context, debtor model.Debtor, id uint) (model.Debtor, error) {
+func (ds *databaseStore) Save(ctx context.Context, debtor model.Debtor, id uint, version string, mask FieldMask) (model.Debtor, error) {
 	dbtr := model.Debtor{}
-	err := ds.db.
-		Preload("BankDetails").
-		Preload("Arbitration").
-		Preload("Biddings").
-		Find(&dbtr, id).
-		Error
-	if err != nil {
-		log.Println(err)
+	if debtor.ID != 0 && debtor.ID != id {
+		return dbtr, ErrInconsistentIDs
+	}
+	if err := mask.Validate(); err != nil {
 		return dbtr, err
 	}
-	debtor.ID = dbtr.ID
-	err = ds.db.Model(&dbtr).Updates(debtor).Error
+	if version == "" {
+		return dbtr, ErrVersionRequired
+	}
+	if len(mask) == 0 {
+		mask = nonZero(debtor)
+	}
+	debtor.ID = id
+	err := ds.inTx(func(tx *gorm.DB) error {
+		// The row stays locked until the update is committed, so that a
+		// concurrent Save with the same version waits and then fails.
+		err := tx.Set("gorm:query_option", "FOR UPDATE").First(&dbtr, id).Error
+		if gorm.IsRecordNotFoundError(err) {
+			return ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if err := checkVersion(dbtr, version); err != nil {
+			return err
+		}
+		before := dbtr
+		if err := tx.Where("debtor_id = ?", id).Order("id").Find(&before.BankDetails).Error; err != nil {
+			return err
+		}
+		if err := updateDebtor(tx, &dbtr, debtor, mask); err != nil {
+			return err
+		}
+		return tx.Create(ptr(audit(ctx, OpUpdate, before, dbtr))).Error
+	})
 	if err != nil {
+		if apperr.KindOf(err) == apperr.Internal {
+			log.Println(err)
+		}
 		return dbtr, err
 	}
 	err = ds.db.
@@ -152,5 +521,384 @@ func (ds *databaseStore) Save(ctx context.Context, debtor model.Debtor, id uint)
 
 // Delete func
 func (ds *databaseStore) Delete(ctx context.Context, id uint) error {
-	return ds.db.Delete(model.Debtor{}, id).Error
+	return ds.inTx(func(tx *gorm.DB) error {
+		before := model.Debtor{}
+		err := tx.First(&before, id).Error
+		if gorm.IsRecordNotFoundError(err) {
+			return ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+		q := tx.Delete(model.Debtor{}, id)
+		if q.Error != nil {
+			return q.Error
+		}
+		if q.RowsAffected == 0 {
+			return ErrNotFound
+		}
+		now := time.Now()
+		after := before
+		after.DeletedAt = &now
+		return tx.Create(ptr(audit(ctx, OpDelete, before, after))).Error
+	})
+}
+
+// ListDeleted func
+func (ds *databaseStore) ListDeleted(ctx context.Context, p model.Pagination) (model.DebtorsResponse, error) {
+	if p.Limit == 0 {
+		p.Limit = 20
+	}
+	res := model.DebtorsResponse{}
+	debtors := model.Debtors{}
+	count := 0
+	q := ds.db.Unscoped().Model(&model.Debtor{}).Where("deleted_at IS NOT NULL")
+	if err := q.Count(&count).Error; err != nil {
+		return res, err
+	}
+	res.Count = uint(count)
+	err := q.
+		Order("deleted_at desc").
+		Order("id").
+		Limit(p.Limit).
+		Offset(p.From).
+		Find(&debtors).
+		Error
+	if err != nil {
+		return res, err
+	}
+	res.Debtors = debtors
+	return res, nil
+}
+
+// Restore func
+func (ds *databaseStore) Restore(ctx context.Context, id uint) (model.Debtor, error) {
+	err := ds.inTx(func(tx *gorm.DB) error {
+		before := model.Debtor{}
+		err := tx.Unscoped().First(&before, id).Error
+		if gorm.IsRecordNotFoundError(err) {
+			return ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if before.DeletedAt == nil {
+			return ErrNotDeleted
+		}
+		err = tx.Unscoped().Model(&model.Debtor{}).Where("id = ?", id).UpdateColumn("deleted_at", gorm.Expr("NULL")).Error
+		if err != nil {
+			return err
+		}
+		after := before
+		after.DeletedAt = nil
+		return tx.Create(ptr(audit(ctx, OpRestore, before, after))).Error
+	})
+	if err != nil {
+		return model.Debtor{}, err
+	}
+	return ds.GetDebtor(ctx, uint32(id))
+}
+
+// purgeBatch is the number of debtors Purge removes per statement.
+const purgeBatch = 500
+
+// Purge func
+func (ds *databaseStore) Purge(ctx context.Context, id uint, deletedBefore time.Time) (uint, error) {
+	q := ds.db.Unscoped().Model(&model.Debtor{}).
+		Where("deleted_at IS NOT NULL").
+		Where("NOT EXISTS (SELECT 1 FROM biddings WHERE biddings.debtor_id = debtors.id)")
+	if id != 0 {
+		q = q.Where("id = ?", id)
+	}
+	if !deletedBefore.IsZero() {
+		q = q.Where("deleted_at < ?", deletedBefore)
+	}
+	ids := []uint{}
+	if err := q.Pluck("id", &ids).Error; err != nil {
+		return 0, err
+	}
+	if id != 0 && len(ids) == 0 {
+		return 0, ds.purgeError(id, deletedBefore)
+	}
+	purged := uint(0)
+	for len(ids) > 0 {
+		if err := ctx.Err(); err != nil {
+			return purged, err
+		}
+		batch := ids
+		if len(batch) > purgeBatch {
+			batch = batch[:purgeBatch]
+		}
+		ids = ids[len(batch):]
+		err := ds.inTx(func(tx *gorm.DB) error {
+			if err := tx.Where("debtor_id IN (?)", batch).Delete(&Statement{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Unscoped().Where("debtor_id IN (?)", batch).Delete(&model.BankDetail{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Unscoped().Where("id IN (?)", batch).Delete(&model.Debtor{}).Error; err != nil {
+				return err
+			}
+			for _, id := range batch {
+				d := model.Debtor{}
+				d.ID = id
+				if err := tx.Create(ptr(audit(ctx, OpPurge, d, model.Debtor{}))).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return purged, err
+		}
+		purged += uint(len(batch))
+	}
+	return purged, nil
+}
+
+// purgeError tells why the debtor cannot be purged.
+func (ds *databaseStore) purgeError(id uint, deletedBefore time.Time) error {
+	d := model.Debtor{}
+	err := ds.db.Unscoped().First(&d, id).Error
+	switch {
+	case gorm.IsRecordNotFoundError(err):
+		return ErrNotFound
+	case err != nil:
+		return err
+	case d.DeletedAt == nil:
+		return ErrNotDeleted
+	case !deletedBefore.IsZero() && !d.DeletedAt.Before(deletedBefore):
+		return ErrRetained
+	default:
+		return ErrHasBiddings
+	}
+}
+
+// GetDebtorHistory func
+func (ds *databaseStore) GetDebtorHistory(ctx context.Context, id uint) ([]AuditRecord, error) {
+	records := []AuditRecord{}
+	err := ds.db.Where("debtor_id = ?", id).Order("id").Find(&records).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, ErrNotFound
+	}
+	return records, nil
+}
+
+// ListBankDetails func
+func (ds *databaseStore) ListBankDetails(ctx context.Context, scope BankDetailScope) ([]model.BankDetail, error) {
+	res := []model.BankDetail{}
+	if err := debtorExists(ds.db, scope.DebtorID); err != nil {
+		return res, err
+	}
+	err := bankDetailsOf(ds.db, scope).Order("id").Find(&res).Error
+	return res, err
+}
+
+// AddBankDetail func
+func (ds *databaseStore) AddBankDetail(ctx context.Context, scope BankDetailScope, b model.BankDetail) (model.BankDetail, error) {
+	b.ID = 0
+	b.DebtorID = scope.DebtorID
+	if scope.InitiatorID != 0 {
+		b.InitiatorID = scope.InitiatorID
+	}
+	err := ds.inTx(func(tx *gorm.DB) error {
+		if err := debtorExists(tx, scope.DebtorID); err != nil {
+			return err
+		}
+		if err := accountsUnique(tx, scope.DebtorID, []model.BankDetail{b}); err != nil {
+			return err
+		}
+		if err := tx.Create(&b).Error; err != nil {
+			return err
+		}
+		return tx.Create(ptr(auditBankDetail(ctx, OpAddBankDetail, model.BankDetail{}, b))).Error
+	})
+	return b, err
+}
+
+// SaveBankDetail func
+func (ds *databaseStore) SaveBankDetail(ctx context.Context, scope BankDetailScope, id uint, b model.BankDetail) (model.BankDetail, error) {
+	existing := model.BankDetail{}
+	err := ds.inTx(func(tx *gorm.DB) error {
+		err := bankDetailsOf(tx, scope).First(&existing, id).Error
+		if gorm.IsRecordNotFoundError(err) {
+			return ErrBankDetailNotFound
+		}
+		if err != nil {
+			return err
+		}
+		before := existing
+		editBankDetail(&existing, b)
+		if err := accountsUnique(tx, existing.DebtorID, []model.BankDetail{existing}); err != nil {
+			return err
+		}
+		if err := tx.Model(&existing).Updates(bankDetailUpdates(b)).Error; err != nil {
+			return err
+		}
+		return tx.Create(ptr(auditBankDetail(ctx, OpEditBankDetail, before, existing))).Error
+	})
+	return existing, err
+}
+
+// CloseBankDetail func
+func (ds *databaseStore) CloseBankDetail(ctx context.Context, scope BankDetailScope, id uint) error {
+	return ds.inTx(func(tx *gorm.DB) error {
+		b := model.BankDetail{}
+		err := bankDetailsOf(tx, scope).First(&b, id).Error
+		if gorm.IsRecordNotFoundError(err) {
+			return ErrBankDetailNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if err := tx.Delete(&b).Error; err != nil {
+			return err
+		}
+		return tx.Create(ptr(auditBankDetail(ctx, OpCloseBankDetail, b, model.BankDetail{}))).Error
+	})
+}
+
+// FindBankDetails func
+func (ds *databaseStore) FindBankDetails(ctx context.Context, account string) ([]model.BankDetail, error) {
+	res := []model.BankDetail{}
+	err := ds.db.
+		Where("bank_account = ?", account).
+		Where("debtor_id IN (?)", ds.db.Table("debtors").Select("id").Where("deleted_at IS NULL").QueryExpr()).
+		Order("id").
+		Find(&res).
+		Error
+	return res, err
+}
+
+func bankDetailsOf(q *gorm.DB, scope BankDetailScope) *gorm.DB {
+	q = q.Where("debtor_id = ?", scope.DebtorID)
+	if scope.InitiatorID != 0 {
+		q = q.Where("initiator_id = ?", scope.InitiatorID)
+	}
+	return q
+}
+
+func debtorExists(q *gorm.DB, id uint) error {
+	err := q.Select("id").First(&model.Debtor{}, id).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return ErrNotFound
+	}
+	return err
+}
+
+// accountsUnique checks that the bank details with an id are those of the
+// debtor, and that the account numbers of the details are unique in their
+// banks, among the details and the other open ones. The closed bank
+// details are soft deleted, gorm leaves them out. Every write of bank
+// details goes through it.
+func accountsUnique(q *gorm.DB, debtorID uint, details []model.BankDetail) error {
+	seen := map[[2]string]bool{}
+	ids := []uint{0}
+	for _, b := range details {
+		k := [2]string{b.BIK, b.BankAccount}
+		if seen[k] {
+			return ErrBankAccountExists
+		}
+		seen[k] = true
+		if b.ID != 0 {
+			ids = append(ids, b.ID)
+		}
+	}
+	if len(ids) > 1 {
+		count := 0
+		err := q.Model(&model.BankDetail{}).
+			Where("debtor_id = ? AND id IN (?)", debtorID, ids[1:]).
+			Count(&count).
+			Error
+		if err != nil {
+			return err
+		}
+		if count != len(ids)-1 {
+			return ErrBankDetailNotFound
+		}
+	}
+	for _, b := range details {
+		count := 0
+		err := q.Model(&model.BankDetail{}).
+			Where("bik = ? AND bank_account = ? AND id NOT IN (?)", b.BIK, b.BankAccount, ids).
+			Count(&count).
+			Error
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrBankAccountExists
+		}
+	}
+	return nil
+}
+
+// PostStatement func
+func (ds *databaseStore) PostStatement(ctx context.Context, scope BankDetailScope, bankDetailID uint, st Statement) (Statement, error) {
+	err := ds.inTx(func(tx *gorm.DB) error {
+		// Locking the bank detail serializes the statements of the account.
+		b := model.BankDetail{}
+		err := bankDetailsOf(tx.Set("gorm:query_option", "FOR UPDATE"), scope).First(&b, bankDetailID).Error
+		if gorm.IsRecordNotFoundError(err) {
+			return ErrBankDetailNotFound
+		}
+		if err != nil {
+			return err
+		}
+		last := &Statement{}
+		err = tx.Where("bank_detail_id = ?", b.ID).Order("end_date DESC").First(last).Error
+		if gorm.IsRecordNotFoundError(err) {
+			last = nil
+		} else if err != nil {
+			return err
+		}
+		if st, err = newStatement(st, b, last); err != nil {
+			return err
+		}
+		return tx.Create(&st).Error
+	})
+	return st, err
+}
+
+// ListStatements func
+func (ds *databaseStore) ListStatements(ctx context.Context, scope BankDetailScope, bankDetailID uint) ([]Statement, error) {
+	res := []Statement{}
+	err := bankDetailsOf(ds.db.Unscoped(), scope).First(&model.BankDetail{}, bankDetailID).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return res, ErrBankDetailNotFound
+	}
+	if err != nil {
+		return res, err
+	}
+	err = ds.db.Where("bank_detail_id = ?", bankDetailID).Order("start_date").Find(&res).Error
+	return res, err
+}
+
+// CashFlow func
+func (ds *databaseStore) CashFlow(ctx context.Context, debtorID uint, from, to time.Time) (CashFlowReport, error) {
+	from, to = day(from), day(to)
+	if to.Before(from) {
+		return CashFlowReport{}, ErrReportPeriod
+	}
+	if err := debtorExists(ds.db, debtorID); err != nil {
+		return CashFlowReport{}, err
+	}
+	details := []model.BankDetail{}
+	if err := ds.db.Unscoped().Where("debtor_id = ?", debtorID).Find(&details).Error; err != nil {
+		return CashFlowReport{}, err
+	}
+	statements := []Statement{}
+	err := ds.db.
+		Where("debtor_id = ? AND end_date >= ? AND start_date <= ?", debtorID, from, to).
+		Order("bank_detail_id, start_date").
+		Find(&statements).
+		Error
+	if err != nil {
+		return CashFlowReport{}, err
+	}
+	return cashFlow(debtorID, from, to, details, statements), nil
 }
@@ -0,0 +1,192 @@
+package debtorservice
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"microsrv/apperr"
+	"microsrv/model"
+)
+
+var (
+	// ErrStatementPeriod var
+	ErrStatementPeriod = apperr.NewValidation("invalid statement period", apperr.Field{Field: "endDate", Description: "must not be before startDate"})
+	// ErrStatementAmount var
+	ErrStatementAmount = apperr.NewValidation("invalid statement amounts", apperr.Field{Field: "income", Description: "income and write-off must not be negative"})
+	// ErrStatementBalance var
+	ErrStatementBalance = apperr.NewValidation("statement does not balance", apperr.Field{Field: "finalBalance", Description: "must be initialBalance + income - writeOff"})
+	// ErrStatementGap var
+	ErrStatementGap = apperr.NewConflict("statement period does not follow the last statement of the account")
+	// ErrStatementOpening var
+	ErrStatementOpening = apperr.NewConflict("statement initial balance differs from the final balance of the last statement")
+	// ErrReportPeriod var
+	ErrReportPeriod = apperr.NewValidation("invalid report period", apperr.Field{Field: "to", Description: "must not be before from"})
+)
+
+// Statement is the balance of a bank account for a period of whole days,
+// from StartDate to EndDate included. Amounts are in kopecks. It is the
+// stored form of a pb.Remaining, which cannot be stored as it is: its
+// amounts are float roubles, its dates protobuf timestamps and its payment
+// documents Any messages, kept here as Documents.
+type Statement struct {
+	ID               uint      `gorm:"primary_key" json:"id"`
+	BankDetailID     uint      `gorm:"index" json:"bankDetailId"`
+	DebtorID         uint      `gorm:"index" json:"debtorId"`
+	Account          string    `json:"account"`
+	StartDate        time.Time `json:"startDate"`
+	EndDate          time.Time `json:"endDate"`
+	InitialBalance   int64     `json:"initialBalance"`
+	Income           int64     `json:"income"`
+	WriteOff         int64     `json:"writeOff"`
+	FinalBalance     int64     `json:"finalBalance"`
+	PaymentDocuments Documents `gorm:"type:text" json:"paymentDocuments"`
+	CreatorID        uint      `json:"creatorId"`
+	CreatedAt        time.Time `json:"createdAt"`
+}
+
+// TableName of the statements.
+func (Statement) TableName() string {
+	return "bank_statements"
+}
+
+// Document is a payment document of a statement, a serialized protobuf
+// message of the type. Key identifies imported documents.
+type Document struct {
+	TypeURL string `json:"typeUrl"`
+	Value   []byte `json:"value"`
+	Key     string `json:"key,omitempty"`
+}
+
+// Documents are stored as a JSON array.
+type Documents []Document
+
+// Value implements driver.Valuer.
+func (d Documents) Value() (driver.Value, error) {
+	b, err := json.Marshal(d)
+	return string(b), err
+}
+
+// Scan implements sql.Scanner.
+func (d *Documents) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*d = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(v, d)
+	case string:
+		return json.Unmarshal([]byte(v), d)
+	default:
+		return errors.New("unsupported payment documents type")
+	}
+}
+
+// Flow is the movement of money over a period, in kopecks.
+type Flow struct {
+	Opening  int64 `json:"opening"`
+	Income   int64 `json:"income"`
+	WriteOff int64 `json:"writeOff"`
+	Closing  int64 `json:"closing"`
+}
+
+// AccountFlow is the flow of a bank account over the statements
+// overlapping the report period, From and To being the period they cover.
+type AccountFlow struct {
+	Flow
+	BankDetailID uint      `json:"bankDetailId"`
+	Account      string    `json:"account"`
+	Bank         string    `json:"bank"`
+	From         time.Time `json:"from"`
+	To           time.Time `json:"to"`
+}
+
+// CashFlowReport is the flow of all the accounts of a debtor, closed ones
+// included, and their total.
+type CashFlowReport struct {
+	Flow
+	DebtorID uint          `json:"debtorId"`
+	From     time.Time     `json:"from"`
+	To       time.Time     `json:"to"`
+	Accounts []AccountFlow `json:"accounts"`
+}
+
+// day truncates t to its date.
+func day(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// ValidateStatement checks the period and that the statement balances.
+func ValidateStatement(st Statement) error {
+	if day(st.EndDate).Before(day(st.StartDate)) {
+		return ErrStatementPeriod
+	}
+	if st.Income < 0 || st.WriteOff < 0 {
+		return ErrStatementAmount
+	}
+	if st.InitialBalance+st.Income-st.WriteOff != st.FinalBalance {
+		return ErrStatementBalance
+	}
+	return nil
+}
+
+// newStatement prepares st to be posted after last, the latest statement
+// of the bank detail if any: its period starts the day after last and its
+// initial balance is the final balance of last.
+func newStatement(st Statement, b model.BankDetail, last *Statement) (Statement, error) {
+	if err := ValidateStatement(st); err != nil {
+		return st, err
+	}
+	st.ID = 0
+	st.BankDetailID, st.DebtorID = b.ID, b.DebtorID
+	if st.Account == "" {
+		st.Account = b.BankAccount
+	}
+	st.StartDate, st.EndDate = day(st.StartDate), day(st.EndDate)
+	if last != nil {
+		if !st.StartDate.Equal(last.EndDate.AddDate(0, 0, 1)) {
+			return st, ErrStatementGap
+		}
+		if st.InitialBalance != last.FinalBalance {
+			return st, ErrStatementOpening
+		}
+	}
+	return st, nil
+}
+
+// cashFlow sums the statements, ordered by bank detail and period, of the
+// bank details.
+func cashFlow(debtorID uint, from, to time.Time, details []model.BankDetail, statements []Statement) CashFlowReport {
+	report := CashFlowReport{DebtorID: debtorID, From: from, To: to, Accounts: []AccountFlow{}}
+	banks := map[uint]model.BankDetail{}
+	for _, b := range details {
+		banks[b.ID] = b
+	}
+	for _, st := range statements {
+		n := len(report.Accounts)
+		if n == 0 || report.Accounts[n-1].BankDetailID != st.BankDetailID {
+			report.Accounts = append(report.Accounts, AccountFlow{
+				Flow:         Flow{Opening: st.InitialBalance},
+				BankDetailID: st.BankDetailID,
+				Account:      st.Account,
+				Bank:         banks[st.BankDetailID].Bank,
+				From:         st.StartDate,
+			})
+			n++
+		}
+		a := &report.Accounts[n-1]
+		a.Income += st.Income
+		a.WriteOff += st.WriteOff
+		a.Closing = st.FinalBalance
+		a.To = st.EndDate
+	}
+	for _, a := range report.Accounts {
+		report.Opening += a.Opening
+		report.Income += a.Income
+		report.WriteOff += a.WriteOff
+		report.Closing += a.Closing
+	}
+	return report
+}
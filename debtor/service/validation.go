@@ -0,0 +1,114 @@
+package debtorservice
+
+import (
+	"context"
+	"fmt"
+
+	"microsrv/apperr"
+	"microsrv/model"
+	"microsrv/requisites"
+)
+
+// ValidationMiddleware returns a Middleware that rejects debtors with
+// malformed identifiers before they reach the next Service. Empty fields
+// are not checked.
+func ValidationMiddleware() Middleware {
+	return func(next Service) Service {
+		return validationMiddleware{next}
+	}
+}
+
+type validationMiddleware struct {
+	Service
+}
+
+// CreateDebtor func
+func (mw validationMiddleware) CreateDebtor(ctx context.Context, d model.Debtor) (model.Debtor, error) {
+	if err := ValidateDebtor(d); err != nil {
+		return model.Debtor{}, err
+	}
+	return mw.Service.CreateDebtor(ctx, d)
+}
+
+// Save func
+func (mw validationMiddleware) Save(ctx context.Context, d model.Debtor, id uint, version string, mask FieldMask) (model.Debtor, error) {
+	if err := ValidateDebtor(d); err != nil {
+		return model.Debtor{}, err
+	}
+	return mw.Service.Save(ctx, d, id, version, mask)
+}
+
+// AddBankDetail func
+func (mw validationMiddleware) AddBankDetail(ctx context.Context, scope BankDetailScope, b model.BankDetail) (model.BankDetail, error) {
+	if err := ValidateBankDetail(b); err != nil {
+		return model.BankDetail{}, err
+	}
+	return mw.Service.AddBankDetail(ctx, scope, b)
+}
+
+// SaveBankDetail func
+func (mw validationMiddleware) SaveBankDetail(ctx context.Context, scope BankDetailScope, id uint, b model.BankDetail) (model.BankDetail, error) {
+	if err := ValidateBankDetail(b); err != nil {
+		return model.BankDetail{}, err
+	}
+	return mw.Service.SaveBankDetail(ctx, scope, id, b)
+}
+
+// ValidateDebtor checks the identifiers of the debtor, its bankruptcy
+// manager, bank details and the initiators of its biddings. The returned
+// error lists every invalid field.
+func ValidateDebtor(d model.Debtor) error {
+	v := validator{}
+	v.check("INN", d.INN, requisites.INN)
+	v.check("KPP", d.KPP, requisites.KPP)
+	v.check("OGRN", d.OGRN, requisites.OGRN)
+	v.check("bankruptcyManager.INN", d.BankruptcyManager.INN, requisites.PersonINN)
+	v.check("bankruptcyManager.SNILS", d.BankruptcyManager.SNILS, requisites.SNILS)
+	for i, b := range d.BankDetails {
+		v.bankDetail(fmt.Sprintf("bankDetails[%d].", i), b)
+	}
+	for i, b := range d.Biddings {
+		v.initiator(fmt.Sprintf("biddings[%d].initiator.", i), b.Initiator)
+	}
+	return v.err()
+}
+
+type validator struct {
+	fields []apperr.Field
+}
+
+func (v *validator) check(field, value string, fn func(string) error) {
+	if value == "" {
+		return
+	}
+	if err := fn(value); err != nil {
+		v.fields = append(v.fields, apperr.Field{Field: field, Description: err.Error()})
+	}
+}
+
+func (v *validator) bankDetail(prefix string, b model.BankDetail) {
+	v.check(prefix+"BIK", b.BIK, requisites.BIK)
+	v.check(prefix+"bankAccount", b.BankAccount, func(s string) error {
+		return requisites.Account(s, b.BIK)
+	})
+	v.check(prefix+"corrAccount", b.CorrAccount, func(s string) error {
+		return requisites.CorrAccount(s, b.BIK)
+	})
+}
+
+func (v *validator) initiator(prefix string, i model.Initiator) {
+	v.check(prefix+"INN", i.INN, requisites.INN)
+	v.check(prefix+"KPP", i.KPP, requisites.KPP)
+	v.check(prefix+"OGRN", i.OGRN, requisites.OGRN)
+	v.check(prefix+"SNILS", i.SNILS, requisites.SNILS)
+	for j, b := range i.BankDetails {
+		v.bankDetail(fmt.Sprintf("%sbankDetails[%d].", prefix, j), b)
+	}
+}
+
+func (v *validator) err() error {
+	if len(v.fields) == 0 {
+		return nil
+	}
+	return apperr.NewValidation("invalid identifiers", v.fields...)
+}
@@ -0,0 +1,56 @@
+package debtorservice
+
+import (
+	"testing"
+
+	"microsrv/apperr"
+	"microsrv/model"
+)
+
+func TestValidateDebtor(t *testing.T) {
+	valid := model.Debtor{
+		INN:         "7707083893",
+		KPP:         "773601001",
+		OGRN:        "1027700132195",
+		BankDetails: []model.BankDetail{{BIK: "044525225", BankAccount: "40702810380000000031", CorrAccount: "30101810400000000225"}},
+		Biddings: []model.Bidding{{Initiator: model.Initiator{
+			INN:         "500100732259",
+			OGRN:        "304500116000157",
+			SNILS:       "112-233-445 95",
+			BankDetails: []model.BankDetail{{BIK: "044525225", BankAccount: "40702810380000000031"}},
+		}}},
+	}
+	if err := ValidateDebtor(valid); err != nil {
+		t.Fatal(err)
+	}
+
+	invalid := model.Debtor{
+		INN: "7707083894",
+		Biddings: []model.Bidding{{}, {Initiator: model.Initiator{
+			INN:         "500100732258",
+			KPP:         "77A601001",
+			SNILS:       "112-233-445 96",
+			BankDetails: []model.BankDetail{{BIK: "044525225", BankAccount: "40702810380000000032"}},
+		}}},
+	}
+	err := ValidateDebtor(invalid)
+	if apperr.KindOf(err) != apperr.Validation {
+		t.Fatalf("ValidateDebtor = %v", err)
+	}
+	want := []string{
+		"INN",
+		"biddings[1].initiator.INN",
+		"biddings[1].initiator.KPP",
+		"biddings[1].initiator.SNILS",
+		"biddings[1].initiator.bankDetails[0].bankAccount",
+	}
+	fields := apperr.FieldsOf(err)
+	if len(fields) != len(want) {
+		t.Fatalf("fields %+v", fields)
+	}
+	for i, f := range fields {
+		if f.Field != want[i] {
+			t.Errorf("field %d %q, want %q", i, f.Field, want[i])
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package debtorservice
+
+import (
+	"strconv"
+
+	"microsrv/apperr"
+	"microsrv/model"
+)
+
+var (
+	// ErrVersionRequired var
+	ErrVersionRequired = apperr.NewValidation("version is required", apperr.Field{Field: "version", Description: "must be the version of the debtor being updated"})
+	// ErrStaleVersion var
+	ErrStaleVersion = apperr.NewConflict("debtor was modified since it was read")
+)
+
+// Version returns the token of the state of the debtor that Save expects.
+// It changes with every update, as it is the update time in microseconds,
+// the precision the databases keep.
+func Version(d model.Debtor) string {
+	return strconv.FormatInt(d.UpdatedAt.UnixNano()/1000, 10)
+}
+
+// checkVersion tells whether the debtor is still in the state of version.
+func checkVersion(d model.Debtor, version string) error {
+	if version == "" {
+		return ErrVersionRequired
+	}
+	if version != Version(d) {
+		return ErrStaleVersion
+	}
+	return nil
+}
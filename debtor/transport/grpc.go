@@ -2,29 +2,67 @@ package transport
 
 import (
 	"context"
+	"io"
 
+	"microsrv/apperr"
 	"microsrv/model"
 
 	"microsrv/debtor/endpoint"
+	"microsrv/debtor/service"
 	"microsrv/pb"
 
+	"github.com/go-kit/kit/endpoint"
 	"github.com/go-kit/kit/log"
 	grpctransport "github.com/go-kit/kit/transport/grpc"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/any"
 	"github.com/jinzhu/copier"
 	oldcontext "golang.org/x/net/context"
+	"google.golang.org/grpc/metadata"
 )
 
 type grpcServer struct {
-	createDebtor grpctransport.Handler
-	getDebtor    grpctransport.Handler
-	getAll       grpctransport.Handler
-	save         grpctransport.Handler
-	delete       grpctransport.Handler
+	createDebtor    grpctransport.Handler
+	getDebtor       grpctransport.Handler
+	getAll          grpctransport.Handler
+	save            grpctransport.Handler
+	delete          grpctransport.Handler
+	history         grpctransport.Handler
+	listDeleted     grpctransport.Handler
+	restore         grpctransport.Handler
+	purge           grpctransport.Handler
+	listBankDetails grpctransport.Handler
+	addBankDetail   grpctransport.Handler
+	saveBankDetail  grpctransport.Handler
+	closeBankDetail grpctransport.Handler
+	postStatement   grpctransport.Handler
+	listStatements  grpctransport.Handler
+	cashFlow        grpctransport.Handler
+	// go-kit has no streaming gRPC transport, the endpoints are called
+	// directly by StreamDebtors, ImportDebtors and ImportStatements.
+	streamDebtors    endpoint.Endpoint
+	importDebtors    endpoint.Endpoint
+	importStatements endpoint.Endpoint
+}
+
+// ActorMetadata is the metadata key of the user performing the request,
+// recorded in the audit trail. Like ActorHeader, it is set by the gateway
+// authenticating the users.
+const ActorMetadata = "x-user"
+
+func actorFromGRPC(ctx context.Context, md metadata.MD) context.Context {
+	if v := md.Get(ActorMetadata); len(v) > 0 {
+		return debtorservice.WithActor(ctx, v[0])
+	}
+	return ctx
 }
 
 // NewGRPCServer makes a set of endpoints available as a gRPC DebtorServer.
 func NewGRPCServer(endpoints debtorendpoint.Endpoints, logger log.Logger) pb.DebtorSvcServer {
-	options := []grpctransport.ServerOption{grpctransport.ServerErrorLogger(logger)}
+	options := []grpctransport.ServerOption{
+		grpctransport.ServerErrorLogger(logger),
+		grpctransport.ServerBefore(actorFromGRPC),
+	}
 
 	return &grpcServer{
 		createDebtor: grpctransport.NewServer(
@@ -57,6 +95,75 @@ func NewGRPCServer(endpoints debtorendpoint.Endpoints, logger log.Logger) pb.Deb
 			encodeGRPCDeleteDebtor,
 			options...,
 		),
+		history: grpctransport.NewServer(
+			endpoints.HistoryEndpoint,
+			decodeGRPCGetDebtor,
+			encodeGRPCHistory,
+			options...,
+		),
+		listDeleted: grpctransport.NewServer(
+			endpoints.ListDeletedEndpoint,
+			decodeGRPCGetAll,
+			encodeGRPCListDeleted,
+			options...,
+		),
+		restore: grpctransport.NewServer(
+			endpoints.RestoreEndpoint,
+			decodeGRPCGetDebtor,
+			encodeGRPCDebtorResponse,
+			options...,
+		),
+		purge: grpctransport.NewServer(
+			endpoints.PurgeEndpoint,
+			decodeGRPCPurge,
+			encodeGRPCPurge,
+			options...,
+		),
+		listBankDetails: grpctransport.NewServer(
+			endpoints.ListBankDetailsEndpoint,
+			decodeGRPCBankDetailRequest,
+			encodeGRPCBankDetails,
+			options...,
+		),
+		addBankDetail: grpctransport.NewServer(
+			endpoints.AddBankDetailEndpoint,
+			decodeGRPCBankDetailRequest,
+			encodeGRPCBankDetail,
+			options...,
+		),
+		saveBankDetail: grpctransport.NewServer(
+			endpoints.SaveBankDetailEndpoint,
+			decodeGRPCBankDetailRequest,
+			encodeGRPCBankDetail,
+			options...,
+		),
+		closeBankDetail: grpctransport.NewServer(
+			endpoints.CloseBankDetailEndpoint,
+			decodeGRPCBankDetailRequest,
+			encodeGRPCDeleteDebtor,
+			options...,
+		),
+		postStatement: grpctransport.NewServer(
+			endpoints.PostStatementEndpoint,
+			decodeGRPCStatementRequest,
+			encodeGRPCStatement,
+			options...,
+		),
+		listStatements: grpctransport.NewServer(
+			endpoints.ListStatementsEndpoint,
+			decodeGRPCBankDetailRequest,
+			encodeGRPCStatements,
+			options...,
+		),
+		cashFlow: grpctransport.NewServer(
+			endpoints.CashFlowEndpoint,
+			decodeGRPCCashFlowRequest,
+			encodeGRPCCashFlow,
+			options...,
+		),
+		streamDebtors:    endpoints.StreamDebtorsEndpoint,
+		importDebtors:    endpoints.ImportDebtorsEndpoint,
+		importStatements: endpoints.ImportStatementsEndpoint,
 	}
 }
 
@@ -64,7 +171,7 @@ func NewGRPCServer(endpoints debtorendpoint.Endpoints, logger log.Logger) pb.Deb
 func (s *grpcServer) CreateDebtor(ctx oldcontext.Context, req *pb.Debtor) (*pb.DebtorResponse, error) {
 	_, res, err := s.createDebtor.ServeGRPC(ctx, req)
 	if err != nil {
-		return nil, err
+		return nil, apperr.ToGRPC(err)
 	}
 	return res.(*pb.DebtorResponse), nil
 }
@@ -82,9 +189,10 @@ func decodeGRPCCreateDebtor(_ context.Context, grpcReq interface{}) (interface{}
 // a user-domain greeting response to a gRPC greeting response.
 func encodeGRPCDebtorResponse(_ context.Context, response interface{}) (interface{}, error) {
 	result := response.(model.DebtorResponse)
-	res := pb.DebtorResponse{}
-	copier.Copy(&res.Debtor, result.Debtor)
-	res.Error = result.Err.Error()
+	if result.Err != nil {
+		return nil, result.Err
+	}
+	res := pb.DebtorResponse{Debtor: debtorToPB(result.Debtor)}
 	return &res, nil
 }
 
@@ -92,7 +200,7 @@ func encodeGRPCDebtorResponse(_ context.Context, response interface{}) (interfac
 func (s *grpcServer) GetDebtor(ctx oldcontext.Context, req *pb.DebtorByID) (*pb.DebtorResponse, error) {
 	_, res, err := s.getDebtor.ServeGRPC(ctx, req)
 	if err != nil {
-		return nil, err
+		return nil, apperr.ToGRPC(err)
 	}
 	return res.(*pb.DebtorResponse), nil
 }
@@ -108,7 +216,7 @@ func decodeGRPCGetDebtor(_ context.Context, grpcReq interface{}) (interface{}, e
 func (s *grpcServer) GetAll(ctx oldcontext.Context, req *pb.Pagination) (*pb.DebtorsResponse, error) {
 	_, response, err := s.getAll.ServeGRPC(ctx, req)
 	if err != nil {
-		return nil, err
+		return nil, apperr.ToGRPC(err)
 	}
 	res := response.(pb.DebtorsResponse)
 	return &res, nil
@@ -120,10 +228,12 @@ func decodeGRPCGetAll(_ context.Context, grpcReq interface{}) (interface{}, erro
 }
 
 func encodeGRPCGetAll(_ context.Context, response interface{}) (interface{}, error) {
-	result := response.(model.DebtorsResponse)
-	res := pb.DebtorsResponse{}
-	copier.Copy(&res.Debtors, result.Debtors)
-	// res.Error = result.Err.Error()
+	result := response.(debtorservice.Page)
+	if result.Err != nil {
+		return nil, result.Err
+	}
+	res := pb.DebtorsResponse{Count: uint32(result.Count), NextCursor: result.NextCursor}
+	res.Debtors = debtorsToPB(result.Debtors)
 	return res, nil
 }
 
@@ -131,7 +241,7 @@ func encodeGRPCGetAll(_ context.Context, response interface{}) (interface{}, err
 func (s *grpcServer) Save(ctx oldcontext.Context, req *pb.UpadateDebtor) (*pb.DebtorResponse, error) {
 	_, res, err := s.save.ServeGRPC(ctx, req)
 	if err != nil {
-		return nil, err
+		return nil, apperr.ToGRPC(err)
 	}
 	return res.(*pb.DebtorResponse), nil
 }
@@ -140,20 +250,475 @@ func (s *grpcServer) Save(ctx oldcontext.Context, req *pb.UpadateDebtor) (*pb.De
 // a gRPC greeting request to a user-domain greeting request.
 func decodeGRPCSaveDebtor(_ context.Context, grpReq interface{}) (interface{}, error) {
 	req := grpReq.(*pb.UpadateDebtor)
-	res := map[string]interface{}{}
-	res["debtor"] = req.Update
-	res["ID"] = req.ID
+	res := debtorendpoint.SaveRequest{ID: uint(req.ID), Mask: req.Mask.GetPaths()}
+	copier.Copy(&res.Debtor, req.Update)
+	if req.Update != nil {
+		res.Version = req.Update.Version
+	}
 	return res, nil
 }
 
 // Delete implementation of the method of the DebtorServer interface.
 func (s *grpcServer) Delete(ctx oldcontext.Context, req *pb.DebtorByID) (*pb.ErrorResponse, error) {
 	_, res, err := s.delete.ServeGRPC(ctx, req)
-	result := res.(*pb.ErrorResponse)
-	return result, err
+	if err != nil {
+		return nil, apperr.ToGRPC(err)
+	}
+	return res.(*pb.ErrorResponse), nil
 }
 
 func encodeGRPCDeleteDebtor(_ context.Context, response interface{}) (interface{}, error) {
-	res := response.(model.DebtorResponse)
-	return res, nil
+	result := response.(debtorendpoint.DeleteResponse)
+	if result.Err != nil {
+		return nil, result.Err
+	}
+	return &pb.ErrorResponse{}, nil
+}
+
+// StreamDebtors implementation of the method of the DebtorServer interface.
+// Each debtor is sent as soon as it is read, stream.Send blocks while the
+// client's flow control window is full.
+func (s *grpcServer) StreamDebtors(req *pb.DebtorFilter, stream pb.DebtorSvc_StreamDebtorsServer) error {
+	filter, err := debtorendpoint.FilterFromPB(req)
+	if err != nil {
+		return apperr.ToGRPC(err)
+	}
+	res, err := s.streamDebtors(stream.Context(), debtorendpoint.StreamRequest{
+		Filter: filter,
+		Send: func(d model.Debtor) error {
+			return stream.Send(debtorToPB(d))
+		},
+	})
+	if err == nil {
+		err = res.(debtorendpoint.StreamResponse).Err
+	}
+	return apperr.ToGRPC(err)
+}
+
+// ImportDebtors implementation of the method of the DebtorServer interface.
+// The chunks are piped to the importer as they arrive.
+func (s *grpcServer) ImportDebtors(stream pb.DebtorSvc_ImportDebtorsServer) error {
+	first, pr, err := recvUpload(stream)
+	if err != nil {
+		return err
+	}
+	defer pr.Close()
+	ctx := stream.Context()
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		ctx = actorFromGRPC(ctx, md)
+	}
+	res, err := s.importDebtors(ctx, debtorendpoint.ImportRequest{
+		Format:  first.Format,
+		Mapping: first.Mapping,
+		Body:    pr,
+	})
+	if err == nil {
+		err = res.(debtorendpoint.ImportResponse).Err
+	}
+	if err != nil {
+		return apperr.ToGRPC(err)
+	}
+	return stream.SendAndClose(importReportToPB(res.(debtorendpoint.ImportResponse).Report))
+}
+
+// ImportStatements implementation of the method of the DebtorServer
+// interface.
+func (s *grpcServer) ImportStatements(stream pb.DebtorSvc_ImportStatementsServer) error {
+	_, pr, err := recvUpload(stream)
+	if err != nil {
+		return err
+	}
+	defer pr.Close()
+	ctx := stream.Context()
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		ctx = actorFromGRPC(ctx, md)
+	}
+	res, err := s.importStatements(ctx, debtorendpoint.ImportStatementsRequest{Body: pr})
+	if err == nil {
+		err = res.(debtorendpoint.ImportStatementsResponse).Err
+	}
+	if err != nil {
+		return apperr.ToGRPC(err)
+	}
+	return stream.SendAndClose(statementImportReportToPB(res.(debtorendpoint.ImportStatementsResponse).Report))
+}
+
+// recvUpload returns the first chunk of an upload and a reader of the data
+// of all the chunks, piped as they arrive.
+func recvUpload(stream interface {
+	Recv() (*pb.ImportChunk, error)
+}) (*pb.ImportChunk, *io.PipeReader, error) {
+	first, err := stream.Recv()
+	if err == io.EOF {
+		return nil, nil, apperr.ToGRPC(apperr.NewValidation("empty upload", apperr.Field{Field: "data", Description: "is required"}))
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	pr, pw := io.Pipe()
+	go func() {
+		chunk := first
+		for {
+			if _, err := pw.Write(chunk.Data); err != nil {
+				return
+			}
+			if chunk, err = stream.Recv(); err == io.EOF {
+				pw.Close()
+				return
+			} else if err != nil {
+				pw.CloseWithError(err)
+				return
+			}
+		}
+	}()
+	return first, pr, nil
+}
+
+func statementImportReportToPB(r debtorservice.StatementImportReport) *pb.StatementImportReport {
+	res := &pb.StatementImportReport{}
+	for _, a := range r.Accounts {
+		m := &pb.AccountImport{
+			Account:      a.Account,
+			BIK:          a.BIK,
+			BankDetailID: uint32(a.BankDetailID),
+			StatementID:  uint32(a.StatementID),
+			Status:       a.Status,
+			Documents:    uint32(a.Documents),
+		}
+		if a.Err != nil {
+			m.Error = a.Err.Error()
+		}
+		res.Accounts = append(res.Accounts, m)
+	}
+	for _, d := range r.Duplicates {
+		date, _ := ptypes.TimestampProto(d.Date)
+		res.Duplicates = append(res.Duplicates, &pb.DuplicateDocument{Account: d.Account, Number: d.Number, Date: date, AmountKop: d.Amount})
+	}
+	return res
+}
+
+func importReportToPB(r debtorservice.ImportReport) *pb.ImportReport {
+	res := &pb.ImportReport{
+		Created: uint32(r.Created),
+		Updated: uint32(r.Updated),
+		Failed:  uint32(r.Failed),
+	}
+	for _, row := range r.Rows {
+		m := &pb.ImportRow{Row: uint32(row.Row), Status: row.Status, ID: uint32(row.ID)}
+		if row.Err != nil {
+			m.Error = row.Err.Error()
+			for _, f := range apperr.FieldsOf(row.Err) {
+				m.Fields = append(m.Fields, &pb.FieldViolation{Field: f.Field, Description: f.Description})
+			}
+		}
+		res.Rows = append(res.Rows, m)
+	}
+	return res
+}
+
+// GetDebtorHistory implementation of the method of the DebtorServer
+// interface.
+func (s *grpcServer) GetDebtorHistory(ctx oldcontext.Context, req *pb.DebtorByID) (*pb.DebtorHistory, error) {
+	_, res, err := s.history.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, apperr.ToGRPC(err)
+	}
+	return res.(*pb.DebtorHistory), nil
+}
+
+func encodeGRPCHistory(_ context.Context, response interface{}) (interface{}, error) {
+	result := response.(debtorendpoint.HistoryResponse)
+	if result.Err != nil {
+		return nil, result.Err
+	}
+	return historyToPB(result.Records), nil
+}
+
+func historyToPB(records []debtorservice.AuditRecord) *pb.DebtorHistory {
+	res := &pb.DebtorHistory{}
+	for _, r := range records {
+		at, _ := ptypes.TimestampProto(r.At)
+		m := &pb.AuditRecord{
+			ID:        uint32(r.ID),
+			DebtorID:  uint32(r.DebtorID),
+			Actor:     r.Actor,
+			Operation: r.Operation,
+			At:        at,
+		}
+		for _, c := range r.Changes {
+			m.Changes = append(m.Changes, &pb.FieldChange{Field: c.Field, Before: c.Before, After: c.After})
+		}
+		res.Records = append(res.Records, m)
+	}
+	return res
+}
+
+// ListDeleted implementation of the method of the DebtorServer interface.
+func (s *grpcServer) ListDeleted(ctx oldcontext.Context, req *pb.Pagination) (*pb.DebtorsResponse, error) {
+	_, res, err := s.listDeleted.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, apperr.ToGRPC(err)
+	}
+	return res.(*pb.DebtorsResponse), nil
+}
+
+func encodeGRPCListDeleted(_ context.Context, response interface{}) (interface{}, error) {
+	result := response.(model.DebtorsResponse)
+	if result.Err != nil {
+		return nil, result.Err
+	}
+	return deletedToPB(result), nil
+}
+
+// debtorToPB converts the debtor with its version.
+func debtorToPB(d model.Debtor) *pb.Debtor {
+	res := &pb.Debtor{}
+	copier.Copy(res, d)
+	res.Version = debtorservice.Version(d)
+	return res
+}
+
+func debtorsToPB(debtors []model.Debtor) []*pb.Debtor {
+	res := make([]*pb.Debtor, len(debtors))
+	for i, d := range debtors {
+		res[i] = debtorToPB(d)
+	}
+	return res
+}
+
+// deletedToPB converts a page of deleted debtors, with their deletion time.
+func deletedToPB(r model.DebtorsResponse) *pb.DebtorsResponse {
+	res := &pb.DebtorsResponse{Count: uint32(r.Count), Debtors: debtorsToPB(r.Debtors)}
+	for i, d := range r.Debtors {
+		if i < len(res.Debtors) && d.DeletedAt != nil {
+			res.Debtors[i].DeletedAt, _ = ptypes.TimestampProto(*d.DeletedAt)
+		}
+	}
+	return res
+}
+
+// Restore implementation of the method of the DebtorServer interface.
+func (s *grpcServer) Restore(ctx oldcontext.Context, req *pb.DebtorByID) (*pb.DebtorResponse, error) {
+	_, res, err := s.restore.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, apperr.ToGRPC(err)
+	}
+	return res.(*pb.DebtorResponse), nil
+}
+
+// Purge implementation of the method of the DebtorServer interface.
+func (s *grpcServer) Purge(ctx oldcontext.Context, req *pb.PurgeRequest) (*pb.PurgeResponse, error) {
+	_, res, err := s.purge.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, apperr.ToGRPC(err)
+	}
+	return res.(*pb.PurgeResponse), nil
+}
+
+func decodeGRPCPurge(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.PurgeRequest)
+	return req, nil
+}
+
+func encodeGRPCPurge(_ context.Context, response interface{}) (interface{}, error) {
+	result := response.(debtorendpoint.PurgeResponse)
+	if result.Err != nil {
+		return nil, result.Err
+	}
+	return &pb.PurgeResponse{Purged: uint32(result.Purged)}, nil
+}
+
+// ListBankDetails implementation of the method of the DebtorServer interface.
+func (s *grpcServer) ListBankDetails(ctx oldcontext.Context, req *pb.BankDetailRequest) (*pb.BankDetails, error) {
+	_, res, err := s.listBankDetails.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, apperr.ToGRPC(err)
+	}
+	return res.(*pb.BankDetails), nil
+}
+
+// AddBankDetail implementation of the method of the DebtorServer interface.
+func (s *grpcServer) AddBankDetail(ctx oldcontext.Context, req *pb.BankDetailRequest) (*pb.BankDetail, error) {
+	_, res, err := s.addBankDetail.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, apperr.ToGRPC(err)
+	}
+	return res.(*pb.BankDetail), nil
+}
+
+// SaveBankDetail implementation of the method of the DebtorServer interface.
+func (s *grpcServer) SaveBankDetail(ctx oldcontext.Context, req *pb.BankDetailRequest) (*pb.BankDetail, error) {
+	_, res, err := s.saveBankDetail.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, apperr.ToGRPC(err)
+	}
+	return res.(*pb.BankDetail), nil
+}
+
+// CloseBankDetail implementation of the method of the DebtorServer interface.
+func (s *grpcServer) CloseBankDetail(ctx oldcontext.Context, req *pb.BankDetailRequest) (*pb.ErrorResponse, error) {
+	_, res, err := s.closeBankDetail.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, apperr.ToGRPC(err)
+	}
+	return res.(*pb.ErrorResponse), nil
+}
+
+func decodeGRPCBankDetailRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.BankDetailRequest)
+	return req, nil
+}
+
+func encodeGRPCBankDetails(_ context.Context, response interface{}) (interface{}, error) {
+	result := response.(debtorendpoint.BankDetailsResponse)
+	if result.Err != nil {
+		return nil, result.Err
+	}
+	return bankDetailsToPB(result.BankDetails), nil
+}
+
+func encodeGRPCBankDetail(_ context.Context, response interface{}) (interface{}, error) {
+	result := response.(debtorendpoint.BankDetailResponse)
+	if result.Err != nil {
+		return nil, result.Err
+	}
+	return bankDetailToPB(result.BankDetail), nil
+}
+
+func bankDetailToPB(b model.BankDetail) *pb.BankDetail {
+	res := &pb.BankDetail{}
+	copier.Copy(res, b)
+	res.CreatedAt, _ = ptypes.TimestampProto(b.CreatedAt)
+	return res
+}
+
+func bankDetailsToPB(details []model.BankDetail) *pb.BankDetails {
+	res := &pb.BankDetails{BankDetails: make([]*pb.BankDetail, len(details))}
+	for i, b := range details {
+		res.BankDetails[i] = bankDetailToPB(b)
+	}
+	return res
+}
+
+// PostStatement implementation of the method of the DebtorServer interface.
+func (s *grpcServer) PostStatement(ctx oldcontext.Context, req *pb.StatementRequest) (*pb.Remaining, error) {
+	_, res, err := s.postStatement.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, apperr.ToGRPC(err)
+	}
+	return res.(*pb.Remaining), nil
+}
+
+// ListStatements implementation of the method of the DebtorServer interface.
+func (s *grpcServer) ListStatements(ctx oldcontext.Context, req *pb.BankDetailRequest) (*pb.Remainings, error) {
+	_, res, err := s.listStatements.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, apperr.ToGRPC(err)
+	}
+	return res.(*pb.Remainings), nil
+}
+
+// CashFlow implementation of the method of the DebtorServer interface.
+func (s *grpcServer) CashFlow(ctx oldcontext.Context, req *pb.CashFlowRequest) (*pb.CashFlowReport, error) {
+	_, res, err := s.cashFlow.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, apperr.ToGRPC(err)
+	}
+	return res.(*pb.CashFlowReport), nil
+}
+
+func decodeGRPCStatementRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.StatementRequest)
+	return req, nil
+}
+
+func decodeGRPCCashFlowRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.CashFlowRequest)
+	return req, nil
+}
+
+func encodeGRPCStatement(_ context.Context, response interface{}) (interface{}, error) {
+	result := response.(debtorendpoint.StatementResponse)
+	if result.Err != nil {
+		return nil, result.Err
+	}
+	return statementToPB(result.Statement), nil
+}
+
+func encodeGRPCStatements(_ context.Context, response interface{}) (interface{}, error) {
+	result := response.(debtorendpoint.StatementsResponse)
+	if result.Err != nil {
+		return nil, result.Err
+	}
+	return statementsToPB(result.Statements), nil
+}
+
+func encodeGRPCCashFlow(_ context.Context, response interface{}) (interface{}, error) {
+	result := response.(debtorendpoint.CashFlowResponse)
+	if result.Err != nil {
+		return nil, result.Err
+	}
+	return cashFlowToPB(result.Report), nil
+}
+
+func statementToPB(st debtorservice.Statement) *pb.Remaining {
+	res := &pb.Remaining{
+		ID:                uint32(st.ID),
+		Account:           st.Account,
+		InitialBalance:    roubles(st.InitialBalance),
+		Income:            roubles(st.Income),
+		WriteOff:          roubles(st.WriteOff),
+		FinalBalance:      roubles(st.FinalBalance),
+		Creator_ID:        uint32(st.CreatorID),
+		InitialBalanceKop: st.InitialBalance,
+		IncomeKop:         st.Income,
+		WriteOffKop:       st.WriteOff,
+		FinalBalanceKop:   st.FinalBalance,
+	}
+	res.StartDate, _ = ptypes.TimestampProto(st.StartDate)
+	res.EndDate, _ = ptypes.TimestampProto(st.EndDate)
+	for _, d := range st.PaymentDocuments {
+		res.PaymentDocuments = append(res.PaymentDocuments, &any.Any{TypeUrl: d.TypeURL, Value: d.Value})
+	}
+	return res
+}
+
+func statementsToPB(statements []debtorservice.Statement) *pb.Remainings {
+	res := &pb.Remainings{Remainings: make([]*pb.Remaining, len(statements))}
+	for i, st := range statements {
+		res.Remainings[i] = statementToPB(st)
+	}
+	return res
+}
+
+func roubles(kopecks int64) float32 {
+	return float32(kopecks) / 100
+}
+
+func cashFlowToPB(r debtorservice.CashFlowReport) *pb.CashFlowReport {
+	res := &pb.CashFlowReport{
+		DebtorID: uint32(r.DebtorID),
+		Accounts: make([]*pb.AccountFlow, len(r.Accounts)),
+		Opening:  r.Opening,
+		Income:   r.Income,
+		WriteOff: r.WriteOff,
+		Closing:  r.Closing,
+	}
+	res.From, _ = ptypes.TimestampProto(r.From)
+	res.To, _ = ptypes.TimestampProto(r.To)
+	for i, a := range r.Accounts {
+		f := &pb.AccountFlow{
+			BankDetailID: uint32(a.BankDetailID),
+			Account:      a.Account,
+			Bank:         a.Bank,
+			Opening:      a.Opening,
+			Income:       a.Income,
+			WriteOff:     a.WriteOff,
+			Closing:      a.Closing,
+		}
+		f.From, _ = ptypes.TimestampProto(a.From)
+		f.To, _ = ptypes.TimestampProto(a.To)
+		res.Accounts[i] = f
+	}
+	return res
 }
@@ -1,27 +1,101 @@
 package transport
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"io"
+	"io/ioutil"
+	"mime"
 	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"microsrv/apperr"
+	"microsrv/model"
 
 	"github.com/go-kit/kit/log"
 	httptransport "github.com/go-kit/kit/transport/http"
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/timestamp"
 	"github.com/gorilla/mux"
+	"github.com/jinzhu/copier"
 	"microsrv/debtor/endpoint"
+	"microsrv/debtor/service"
+	"microsrv/pb"
+)
+
+var (
+	// ErrBadRouting is returned when an expected path variable is missing.
+	ErrBadRouting = apperr.New(apperr.Internal, "inconsistent mapping between route and handler")
 )
 
+// MergePatchType is the media type of a JSON Merge Patch of a debtor.
+const MergePatchType = "application/merge-patch+json"
+
+// ActorHeader carries the user performing the request, recorded in the
+// audit trail. It is set by the gateway authenticating the users, the
+// mutations of a request without it are refused.
+const ActorHeader = "X-User"
+
+func actorFromHTTP(ctx context.Context, r *http.Request) context.Context {
+	return debtorservice.WithActor(ctx, r.Header.Get(ActorHeader))
+}
+
+// badRequest reports a malformed request.
+func badRequest(err error) error {
+	return apperr.NewValidation(err.Error())
+}
+
 // NewHTTPHandler returns an HTTP handler that makes a set of endpoints
 // available on predefined paths.
 func NewHTTPHandler(endpoints debtorendpoint.Endpoints, logger log.Logger) http.Handler {
 	m := mux.NewRouter()
 	options := []httptransport.ServerOption{
-		httptransport.ServerErrorEncoder(encodeError),
+		httptransport.ServerErrorEncoder(apperr.EncodeHTTPError),
 		httptransport.ServerErrorLogger(logger),
+		httptransport.ServerBefore(actorFromHTTP),
 	}
 
-	// GET /health         retrieves service heath information
-	// GET /greeting?name  retrieves greeting
+	// GET    /health                          retrieves service heath information
+	// POST   /debtors                         adds another debtor
+	// GET    /debtors?limit&from&name&sort    retrieves a page of debtors, also
+	//        &inn&ogrn&case_no&arbitration_id&manager_id&decided_from
+	//        &decided_to&has_active_biddings&order&cursor
+	// POST   /debtors/import                  imports a csv or xlsx file
+	// POST   /debtors/statements/import       imports a 1CClientBankExchange file
+	// GET    /debtors/export?format           exports the debtors, filtered as
+	//                                         GET /debtors
+	// GET    /debtors/deleted?limit&from      retrieves a page of deleted debtors
+	// POST   /debtors/purge?deleted_before    purges the debtors deleted before
+	//                                         the date
+	// GET    /debtors/{id}                    retrieves the given debtor by id
+	// GET    /debtors/{id}/history            retrieves the audit trail of the debtor
+	// POST   /debtors/{id}/restore            restores the given deleted debtor
+	// POST   /debtors/{id}/purge              purges the given deleted debtor
+	// GET    /debtors/{id}/bank-details       retrieves the open bank details of
+	//        ?initiator_id                    the debtor, of the initiator if given
+	// POST   /debtors/{id}/bank-details       opens a bank detail of the debtor
+	// PUT    /debtors/{id}/bank-details/{bid} edits the given bank detail
+	// DELETE /debtors/{id}/bank-details/{bid} closes the given bank detail
+	// GET    /debtors/{id}/bank-details/{bid}/statements
+	//                                         retrieves the statements of the
+	//                                         given bank detail
+	// POST   /debtors/{id}/bank-details/{bid}/statements
+	//                                         posts the next statement of the
+	//                                         given bank detail
+	// GET    /debtors/{id}/cash-flow?from&to  reports the cash flow of the debtor
+	// PUT    /debtors/{id}                    updates the given debtor, the
+	// PATCH  /debtors/{id}                    version of the debtor read is
+	//                                         sent in If-Match or in the body,
+	//                                         application/merge-patch+json
+	//                                         writes exactly the members sent
+	// DELETE /debtors/{id}                    removes the given debtor
 
 	m.Methods("GET").Path("/health").Handler(httptransport.NewServer(
 		endpoints.HealthEndpoint,
@@ -29,6 +103,126 @@ func NewHTTPHandler(endpoints debtorendpoint.Endpoints, logger log.Logger) http.
 		EncodeHTTPGenericResponse,
 		options...,
 	))
+	m.Methods("POST").Path("/debtors").Handler(httptransport.NewServer(
+		endpoints.CreateDebtorEndpoint,
+		decodeHTTPCreateDebtorRequest,
+		encodeHTTPCreatedDebtorResponse,
+		options...,
+	))
+	m.Methods("GET").Path("/debtors").Handler(httptransport.NewServer(
+		endpoints.GetAllDebtorsEndpoint,
+		decodeHTTPGetAllRequest,
+		encodeHTTPGetAllResponse,
+		options...,
+	))
+	m.Methods("POST").Path("/debtors/statements/import").Handler(httptransport.NewServer(
+		endpoints.ImportStatementsEndpoint,
+		decodeHTTPImportStatementsRequest,
+		encodeHTTPImportStatementsResponse,
+		options...,
+	))
+	m.Methods("POST").Path("/debtors/import").Handler(httptransport.NewServer(
+		endpoints.ImportDebtorsEndpoint,
+		decodeHTTPImportRequest,
+		encodeHTTPImportResponse,
+		options...,
+	))
+	m.Methods("GET").Path("/debtors/export").Handler(httptransport.NewServer(
+		endpoints.ExportDebtorsEndpoint,
+		decodeHTTPExportRequest,
+		encodeHTTPExportResponse,
+		options...,
+	))
+	m.Methods("GET").Path("/debtors/deleted").Handler(httptransport.NewServer(
+		endpoints.ListDeletedEndpoint,
+		decodeHTTPGetAllRequest,
+		encodeHTTPDeletedResponse,
+		options...,
+	))
+	m.Methods("POST").Path("/debtors/purge").Handler(httptransport.NewServer(
+		endpoints.PurgeEndpoint,
+		decodeHTTPPurgeRequest,
+		EncodeHTTPGenericResponse,
+		options...,
+	))
+	m.Methods("POST").Path("/debtors/{id}/restore").Handler(httptransport.NewServer(
+		endpoints.RestoreEndpoint,
+		decodeHTTPDebtorByIDRequest,
+		encodeHTTPDebtorResponse,
+		options...,
+	))
+	m.Methods("POST").Path("/debtors/{id}/purge").Handler(httptransport.NewServer(
+		endpoints.PurgeEndpoint,
+		decodeHTTPPurgeRequest,
+		EncodeHTTPGenericResponse,
+		options...,
+	))
+	m.Methods("GET").Path("/debtors/{id}/bank-details").Handler(httptransport.NewServer(
+		endpoints.ListBankDetailsEndpoint,
+		decodeHTTPBankDetailRequest,
+		encodeHTTPBankDetailsResponse,
+		options...,
+	))
+	m.Methods("POST").Path("/debtors/{id}/bank-details").Handler(httptransport.NewServer(
+		endpoints.AddBankDetailEndpoint,
+		decodeHTTPBankDetailRequest,
+		encodeHTTPCreatedBankDetailResponse,
+		options...,
+	))
+	m.Methods("PUT").Path("/debtors/{id}/bank-details/{bid}").Handler(httptransport.NewServer(
+		endpoints.SaveBankDetailEndpoint,
+		decodeHTTPBankDetailRequest,
+		encodeHTTPBankDetailResponse,
+		options...,
+	))
+	m.Methods("DELETE").Path("/debtors/{id}/bank-details/{bid}").Handler(httptransport.NewServer(
+		endpoints.CloseBankDetailEndpoint,
+		decodeHTTPBankDetailRequest,
+		EncodeHTTPGenericResponse,
+		options...,
+	))
+	m.Methods("GET").Path("/debtors/{id}/bank-details/{bid}/statements").Handler(httptransport.NewServer(
+		endpoints.ListStatementsEndpoint,
+		decodeHTTPBankDetailRequest,
+		encodeHTTPStatementsResponse,
+		options...,
+	))
+	m.Methods("POST").Path("/debtors/{id}/bank-details/{bid}/statements").Handler(httptransport.NewServer(
+		endpoints.PostStatementEndpoint,
+		decodeHTTPStatementRequest,
+		encodeHTTPStatementResponse,
+		options...,
+	))
+	m.Methods("GET").Path("/debtors/{id}/cash-flow").Handler(httptransport.NewServer(
+		endpoints.CashFlowEndpoint,
+		decodeHTTPCashFlowRequest,
+		encodeHTTPCashFlowResponse,
+		options...,
+	))
+	m.Methods("GET").Path("/debtors/{id}/history").Handler(httptransport.NewServer(
+		endpoints.HistoryEndpoint,
+		decodeHTTPDebtorByIDRequest,
+		encodeHTTPHistoryResponse,
+		options...,
+	))
+	m.Methods("GET").Path("/debtors/{id}").Handler(httptransport.NewServer(
+		endpoints.GetDebtorEndpoint,
+		decodeHTTPDebtorByIDRequest,
+		encodeHTTPDebtorResponse,
+		options...,
+	))
+	m.Methods("PUT", "PATCH").Path("/debtors/{id}").Handler(httptransport.NewServer(
+		endpoints.SaveDebtorEndpoint,
+		decodeHTTPSaveDebtorRequest,
+		encodeHTTPDebtorResponse,
+		options...,
+	))
+	m.Methods("DELETE").Path("/debtors/{id}").Handler(httptransport.NewServer(
+		endpoints.DeleteDebtorEndpoint,
+		decodeHTTPDebtorByIDRequest,
+		EncodeHTTPGenericResponse,
+		options...,
+	))
 	return m
 }
 
@@ -37,27 +231,523 @@ func DecodeHTTPHealthRequest(_ context.Context, _ *http.Request) (interface{}, e
 	return debtorendpoint.HealthRequest{}, nil
 }
 
-func encodeError(_ context.Context, err error, w http.ResponseWriter) {
-	w.WriteHeader(err2code(err))
-	json.NewEncoder(w).Encode(errorWrapper{Error: err.Error()})
+func decodeHTTPCreateDebtorRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	d, err := decodeHTTPDebtor(r)
+	if err != nil {
+		return nil, err
+	}
+	return d, nil
 }
 
-func err2code(err error) int {
-	switch err {
-	default:
-		return http.StatusInternalServerError
+func decodeHTTPGetAllRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	q := r.URL.Query()
+	req := &pb.Pagination{
+		Name:   q.Get("name"),
+		Sort:   q.Get("sort"),
+		Cursor: q.Get("cursor"),
+	}
+	var err error
+	if v := q.Get("limit"); v != "" {
+		if req.Limit, err = strconv.ParseInt(v, 10, 64); err != nil {
+			return nil, badRequest(err)
+		}
+	}
+	if v := q.Get("from"); v != "" {
+		if req.From, err = strconv.ParseInt(v, 10, 64); err != nil {
+			return nil, badRequest(err)
+		}
 	}
+	if req.Filter, err = decodeHTTPFilter(q); err != nil {
+		return nil, err
+	}
+	// order=name,-decisionDate sorts by name, then by decision date descending.
+	if v := q.Get("order"); v != "" {
+		for _, field := range strings.Split(v, ",") {
+			req.Order = append(req.Order, &pb.SortKey{
+				Field: strings.TrimPrefix(field, "-"),
+				Desc:  strings.HasPrefix(field, "-"),
+			})
+		}
+	}
+	return req, nil
+}
+
+// decodeHTTPPurgeRequest reads the debtor id of the path, if any, and the
+// deleted_before date.
+func decodeHTTPPurgeRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	req := &pb.PurgeRequest{}
+	if _, ok := mux.Vars(r)["id"]; ok {
+		id, err := debtorID(r)
+		if err != nil {
+			return nil, err
+		}
+		req.ID = uint32(id)
+	}
+	before, err := queryTimestamp(r.URL.Query().Get("deleted_before"))
+	if err != nil {
+		return nil, err
+	}
+	req.DeletedBefore = before
+	if req.ID == 0 && before == nil {
+		return nil, apperr.NewValidation("nothing to purge", apperr.Field{Field: "deleted_before", Description: "is required to purge all debtors"})
+	}
+	return req, nil
+}
+
+// decodeHTTPFilter reads the debtor filter shared by the listing and the
+// export.
+func decodeHTTPFilter(q url.Values) (*pb.DebtorFilter, error) {
+	f := &pb.DebtorFilter{
+		INN:               q.Get("inn"),
+		OGRN:              q.Get("ogrn"),
+		CaseNo:            q.Get("case_no"),
+		ArbitrationID:     q.Get("arbitration_id"),
+		HasActiveBiddings: q.Get("has_active_biddings") == "true",
+	}
+	if v := q.Get("manager_id"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			return nil, badRequest(err)
+		}
+		f.BankruptcyManagerID = uint32(id)
+	}
+	var err error
+	if f.DecisionDateFrom, err = queryTimestamp(q.Get("decided_from")); err != nil {
+		return nil, err
+	}
+	if f.DecisionDateTo, err = queryEndTimestamp(q.Get("decided_to")); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// decodeHTTPExportRequest reads the format, csv by default, and the
+// filter of GET /debtors.
+func decodeHTTPExportRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	q := r.URL.Query()
+	f, err := decodeHTTPFilter(q)
+	if err != nil {
+		return nil, err
+	}
+	req := debtorendpoint.ExportRequest{Format: q.Get("format")}
+	if req.Format == "" {
+		req.Format = "csv"
+	}
+	if req.Filter, err = debtorendpoint.FilterFromPB(f); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+// decodeHTTPImportRequest reads a multipart form with the file in the
+// "file" field, an optional "format" (csv or xlsx, taken from the file
+// name by default) and an optional "mapping" JSON object of column headers
+// to debtor fields.
+func decodeHTTPImportRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	if err := r.ParseMultipartForm(32 << 20); err != nil {
+		return nil, badRequest(err)
+	}
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		return nil, apperr.NewValidation(err.Error(), apperr.Field{Field: "file", Description: "is required"})
+	}
+	req := debtorendpoint.ImportRequest{
+		Format: r.FormValue("format"),
+		Body:   file,
+	}
+	if req.Format == "" {
+		req.Format = strings.TrimPrefix(path.Ext(header.Filename), ".")
+	}
+	if v := r.FormValue("mapping"); v != "" {
+		if err := json.Unmarshal([]byte(v), &req.Mapping); err != nil {
+			return nil, apperr.NewValidation(err.Error(), apperr.Field{Field: "mapping", Description: "must be a JSON object"})
+		}
+	}
+	return req, nil
 }
 
-type errorWrapper struct {
-	Error string `json:"error"`
+// decodeHTTPImportStatementsRequest reads a multipart form with the file
+// in the "file" field.
+func decodeHTTPImportStatementsRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	if err := r.ParseMultipartForm(32 << 20); err != nil {
+		return nil, badRequest(err)
+	}
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		return nil, apperr.NewValidation(err.Error(), apperr.Field{Field: "file", Description: "is required"})
+	}
+	return debtorendpoint.ImportStatementsRequest{Body: file}, nil
+}
+
+// queryTimestamp parses a date (2006-01-02) or an RFC 3339 time.
+func queryTimestamp(v string) (*timestamp.Timestamp, error) {
+	if v == "" {
+		return nil, nil
+	}
+	t, err := time.Parse("2006-01-02", v)
+	if err != nil {
+		if t, err = time.Parse(time.RFC3339, v); err != nil {
+			return nil, badRequest(err)
+		}
+	}
+	ts, err := ptypes.TimestampProto(t)
+	if err != nil {
+		return nil, badRequest(err)
+	}
+	return ts, nil
+}
+
+// queryEndTimestamp parses an upper bound like queryTimestamp, a date
+// meaning the end of the day so that the whole day is included.
+func queryEndTimestamp(v string) (*timestamp.Timestamp, error) {
+	if t, err := time.Parse("2006-01-02", v); err == nil {
+		v = t.AddDate(0, 0, 1).Add(-time.Microsecond).Format(time.RFC3339Nano)
+	}
+	return queryTimestamp(v)
+}
+
+func decodeHTTPDebtorByIDRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	id, err := debtorID(r)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.DebtorByID{ID: uint32(id)}, nil
+}
+
+// decodeHTTPSaveDebtorRequest reads the debtor of the body. A JSON Merge
+// Patch (RFC 7386) body writes exactly its members, null clearing a field.
+func decodeHTTPSaveDebtorRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	id, err := debtorID(r)
+	if err != nil {
+		return nil, err
+	}
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return nil, badRequest(err)
+	}
+	req := debtorendpoint.SaveRequest{ID: id}
+	if t, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); t == MergePatchType {
+		members := map[string]json.RawMessage{}
+		if err := json.Unmarshal(body, &members); err != nil {
+			return nil, badRequest(err)
+		}
+		req.Mask = debtorservice.FieldMask{}
+		for name := range members {
+			if name != "id" && name != "version" {
+				req.Mask = append(req.Mask, name)
+			}
+		}
+		sort.Strings(req.Mask)
+	}
+	req.Debtor, req.Version, err = decodeHTTPVersionedDebtor(bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	// The If-Match header takes precedence over the version of the body.
+	if v := r.Header.Get("If-Match"); v != "" {
+		req.Version = strings.Trim(strings.TrimPrefix(v, "W/"), `"`)
+	}
+	return req, nil
+}
+
+// decodeHTTPDebtor reads a pb.Debtor JSON document from the request body.
+func decodeHTTPDebtor(r *http.Request) (model.Debtor, error) {
+	d, _, err := decodeHTTPVersionedDebtor(r.Body)
+	return d, err
+}
+
+func decodeHTTPVersionedDebtor(r io.Reader) (model.Debtor, string, error) {
+	d := model.Debtor{}
+	req := pb.Debtor{}
+	u := jsonpb.Unmarshaler{AllowUnknownFields: true}
+	if err := u.Unmarshal(r, &req); err != nil {
+		return d, "", badRequest(err)
+	}
+	copier.Copy(&d, &req)
+	return d, req.Version, nil
+}
+
+// decodeHTTPBankDetailRequest reads the debtor and bank detail ids of the
+// path, the initiator_id and, for POST and PUT, the bank detail of the body.
+func decodeHTTPBankDetailRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	req, err := decodeHTTPBankDetailScope(r)
+	if err != nil {
+		return nil, err
+	}
+	if r.Method == "POST" || r.Method == "PUT" {
+		req.BankDetail = &pb.BankDetail{}
+		u := jsonpb.Unmarshaler{AllowUnknownFields: true}
+		if err := u.Unmarshal(r.Body, req.BankDetail); err != nil {
+			return nil, badRequest(err)
+		}
+	}
+	return req, nil
+}
+
+// decodeHTTPBankDetailScope reads the debtor and bank detail ids of the
+// path and the initiator_id.
+func decodeHTTPBankDetailScope(r *http.Request) (*pb.BankDetailRequest, error) {
+	id, err := debtorID(r)
+	if err != nil {
+		return nil, err
+	}
+	req := &pb.BankDetailRequest{DebtorID: uint32(id)}
+	if _, ok := mux.Vars(r)["bid"]; ok {
+		bid, err := pathID(r, "bid")
+		if err != nil {
+			return nil, err
+		}
+		req.ID = uint32(bid)
+	}
+	if v := r.URL.Query().Get("initiator_id"); v != "" {
+		initiator, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			return nil, badRequest(err)
+		}
+		req.InitiatorID = uint32(initiator)
+	}
+	return req, nil
+}
+
+// decodeHTTPStatementRequest reads a pb.Remaining JSON document posted to
+// the bank detail of the path.
+func decodeHTTPStatementRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	b, err := decodeHTTPBankDetailScope(r)
+	if err != nil {
+		return nil, err
+	}
+	req := &pb.StatementRequest{DebtorID: b.DebtorID, InitiatorID: b.InitiatorID, BankDetailID: b.ID, Statement: &pb.Remaining{}}
+	u := jsonpb.Unmarshaler{AllowUnknownFields: true}
+	if err := u.Unmarshal(r.Body, req.Statement); err != nil {
+		return nil, badRequest(err)
+	}
+	return req, nil
+}
+
+// decodeHTTPCashFlowRequest reads the from and to dates of the report.
+func decodeHTTPCashFlowRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	id, err := debtorID(r)
+	if err != nil {
+		return nil, err
+	}
+	req := &pb.CashFlowRequest{DebtorID: uint32(id)}
+	q := r.URL.Query()
+	if req.From, err = queryTimestamp(q.Get("from")); err != nil {
+		return nil, err
+	}
+	if req.To, err = queryTimestamp(q.Get("to")); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+func debtorID(r *http.Request) (uint, error) {
+	return pathID(r, "id")
+}
+
+func pathID(r *http.Request, name string) (uint, error) {
+	vars := mux.Vars(r)
+	id, ok := vars[name]
+	if !ok {
+		return 0, ErrBadRouting
+	}
+	n, err := strconv.ParseUint(id, 10, 32)
+	if err != nil {
+		return 0, badRequest(err)
+	}
+	return uint(n), nil
+}
+
+func encodeHTTPCreatedDebtorResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	if f, ok := response.(debtorendpoint.Failer); ok && f.Failed() != nil {
+		apperr.EncodeHTTPError(ctx, f.Failed(), w)
+		return nil
+	}
+	return encodeHTTPDebtor(w, http.StatusCreated, response.(model.DebtorResponse).Debtor)
+}
+
+func encodeHTTPDebtorResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	if f, ok := response.(debtorendpoint.Failer); ok && f.Failed() != nil {
+		apperr.EncodeHTTPError(ctx, f.Failed(), w)
+		return nil
+	}
+	return encodeHTTPDebtor(w, http.StatusOK, response.(model.DebtorResponse).Debtor)
+}
+
+// encodeHTTPDebtor writes the debtor with its version as the ETag.
+func encodeHTTPDebtor(w http.ResponseWriter, code int, d model.Debtor) error {
+	res := debtorToPB(d)
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("ETag", `"`+res.Version+`"`)
+	w.WriteHeader(code)
+	m := jsonpb.Marshaler{}
+	return m.Marshal(w, res)
+}
+
+func encodeHTTPGetAllResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	if f, ok := response.(debtorendpoint.Failer); ok && f.Failed() != nil {
+		apperr.EncodeHTTPError(ctx, f.Failed(), w)
+		return nil
+	}
+	result := response.(debtorservice.Page)
+	res := pb.DebtorsResponse{Count: uint32(result.Count), NextCursor: result.NextCursor}
+	res.Debtors = debtorsToPB(result.Debtors)
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	m := jsonpb.Marshaler{}
+	return m.Marshal(w, &res)
+}
+
+func encodeHTTPImportResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	if f, ok := response.(debtorendpoint.Failer); ok && f.Failed() != nil {
+		apperr.EncodeHTTPError(ctx, f.Failed(), w)
+		return nil
+	}
+	res := importReportToPB(response.(debtorendpoint.ImportResponse).Report)
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	m := jsonpb.Marshaler{EmitDefaults: true}
+	return m.Marshal(w, res)
+}
+
+func encodeHTTPImportStatementsResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	if f, ok := response.(debtorendpoint.Failer); ok && f.Failed() != nil {
+		apperr.EncodeHTTPError(ctx, f.Failed(), w)
+		return nil
+	}
+	res := statementImportReportToPB(response.(debtorendpoint.ImportStatementsResponse).Report)
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	m := jsonpb.Marshaler{EmitDefaults: true}
+	return m.Marshal(w, res)
+}
+
+// encodeHTTPExportResponse streams the export. An error after the first
+// bytes cannot change the status any more, the connection is aborted so
+// that the client sees a truncated download.
+func encodeHTTPExportResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	if f, ok := response.(debtorendpoint.Failer); ok && f.Failed() != nil {
+		apperr.EncodeHTTPError(ctx, f.Failed(), w)
+		return nil
+	}
+	res := response.(debtorendpoint.ExportResponse)
+	w.Header().Set("Content-Type", debtorservice.ExportContentTypes[res.Format])
+	w.Header().Set("Content-Disposition", `attachment; filename="debtors.`+res.Format+`"`)
+	fw := &flushWriter{w: w}
+	err := res.Write(fw)
+	if err != nil && fw.written {
+		panic(http.ErrAbortHandler)
+	}
+	return err
+}
+
+// flushWriter sends each write to the client right away.
+type flushWriter struct {
+	w       http.ResponseWriter
+	written bool
+}
+
+func (fw *flushWriter) Write(p []byte) (int, error) {
+	fw.written = true
+	n, err := fw.w.Write(p)
+	if f, ok := fw.w.(http.Flusher); ok {
+		f.Flush()
+	}
+	return n, err
+}
+
+func encodeHTTPDeletedResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	if f, ok := response.(debtorendpoint.Failer); ok && f.Failed() != nil {
+		apperr.EncodeHTTPError(ctx, f.Failed(), w)
+		return nil
+	}
+	res := deletedToPB(response.(model.DebtorsResponse))
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	m := jsonpb.Marshaler{}
+	return m.Marshal(w, res)
+}
+
+func encodeHTTPBankDetailsResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	if f, ok := response.(debtorendpoint.Failer); ok && f.Failed() != nil {
+		apperr.EncodeHTTPError(ctx, f.Failed(), w)
+		return nil
+	}
+	res := bankDetailsToPB(response.(debtorendpoint.BankDetailsResponse).BankDetails)
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	m := jsonpb.Marshaler{EmitDefaults: true}
+	return m.Marshal(w, res)
+}
+
+func encodeHTTPCreatedBankDetailResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	if f, ok := response.(debtorendpoint.Failer); ok && f.Failed() != nil {
+		apperr.EncodeHTTPError(ctx, f.Failed(), w)
+		return nil
+	}
+	return encodeHTTPBankDetail(w, http.StatusCreated, response.(debtorendpoint.BankDetailResponse).BankDetail)
+}
+
+func encodeHTTPBankDetailResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	if f, ok := response.(debtorendpoint.Failer); ok && f.Failed() != nil {
+		apperr.EncodeHTTPError(ctx, f.Failed(), w)
+		return nil
+	}
+	return encodeHTTPBankDetail(w, http.StatusOK, response.(debtorendpoint.BankDetailResponse).BankDetail)
+}
+
+func encodeHTTPBankDetail(w http.ResponseWriter, code int, b model.BankDetail) error {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(code)
+	m := jsonpb.Marshaler{}
+	return m.Marshal(w, bankDetailToPB(b))
+}
+
+func encodeHTTPStatementResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	if f, ok := response.(debtorendpoint.Failer); ok && f.Failed() != nil {
+		apperr.EncodeHTTPError(ctx, f.Failed(), w)
+		return nil
+	}
+	res := statementToPB(response.(debtorendpoint.StatementResponse).Statement)
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusCreated)
+	m := jsonpb.Marshaler{EmitDefaults: true}
+	return m.Marshal(w, res)
+}
+
+func encodeHTTPStatementsResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	if f, ok := response.(debtorendpoint.Failer); ok && f.Failed() != nil {
+		apperr.EncodeHTTPError(ctx, f.Failed(), w)
+		return nil
+	}
+	res := statementsToPB(response.(debtorendpoint.StatementsResponse).Statements)
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	m := jsonpb.Marshaler{EmitDefaults: true}
+	return m.Marshal(w, res)
+}
+
+func encodeHTTPCashFlowResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	if f, ok := response.(debtorendpoint.Failer); ok && f.Failed() != nil {
+		apperr.EncodeHTTPError(ctx, f.Failed(), w)
+		return nil
+	}
+	res := cashFlowToPB(response.(debtorendpoint.CashFlowResponse).Report)
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	m := jsonpb.Marshaler{EmitDefaults: true}
+	return m.Marshal(w, res)
+}
+
+func encodeHTTPHistoryResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	if f, ok := response.(debtorendpoint.Failer); ok && f.Failed() != nil {
+		apperr.EncodeHTTPError(ctx, f.Failed(), w)
+		return nil
+	}
+	res := historyToPB(response.(debtorendpoint.HistoryResponse).Records)
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	m := jsonpb.Marshaler{EmitDefaults: true}
+	return m.Marshal(w, res)
 }
 
 // EncodeHTTPGenericResponse is a transport/http.EncodeResponseFunc that encodes
 // the response as JSON to the response writer
 func EncodeHTTPGenericResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
 	if f, ok := response.(debtorendpoint.Failer); ok && f.Failed() != nil {
-		encodeError(ctx, f.Failed(), w)
+		apperr.EncodeHTTPError(ctx, f.Failed(), w)
 		return nil
 	}
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
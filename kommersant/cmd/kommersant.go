@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"net"
@@ -18,6 +19,7 @@ import (
 	"microsrv/kommersant/service"
 	"microsrv/kommersant/transport"
 	"microsrv/pb"
+	"microsrv/schedule"
 	"google.golang.org/grpc"
 )
 
@@ -30,6 +32,8 @@ func main() {
 		httpAddr   = fs.String("http.addr", "", "HTTP Listen Address")
 		httpPort   = fs.String("http.port", "9110", "HTTP Listen Port")
 		grpcAddr   = fs.String("grpc-addr", ":9120", "gRPC listen address")
+		dsn        = fs.String("db.dsn", "", "MySQL DSN of the orders database, in-memory store if empty")
+		memPub     = fs.Bool("publisher.mem", false, "Keep orders in the in-memory publisher, for development only")
 	)
 	fs.Usage = usageFor(fs, os.Args[0]+" [flags]")
 	fs.Parse(os.Args[1:])
@@ -40,9 +44,35 @@ func main() {
 		logger = log.With(logger, "ts", log.DefaultTimestampUTC)
 		logger = log.With(logger, "caller", log.DefaultCaller)
 	}
+	var (
+		store     kommersantsvc.Store
+		jobs      schedule.Store
+		scheduler *schedule.Scheduler
+	)
+	if *dsn == "" {
+		store = kommersantsvc.NewMemStore()
+		jobs = schedule.NewMemStore()
+	} else {
+		var err error
+		store, err = kommersantsvc.NewDB(*dsn)
+		if err == nil {
+			jobs, err = schedule.NewDB(*dsn)
+		}
+		if err != nil {
+			logger.Log("during", "NewDB", "err", err)
+			os.Exit(1)
+		}
+	}
+	// There is no publishing house API client yet, the in-memory publisher
+	// has to be asked for explicitly.
+	if !*memPub {
+		logger.Log("during", "NewPublisher", "err", "no publisher configured, use -publisher.mem for development")
+		os.Exit(1)
+	}
+	scheduler = schedule.New(jobs, log.With(logger, "component", "scheduler"))
 	var service kommersantsvc.Service
 	{
-		service = kommersantsvc.NewBasicService()
+		service = kommersantsvc.NewBasicService(store, kommersantsvc.NewMemPublisher(), scheduler)
 		service = kommersantsvc.LoggingMiddleware(logger)(service)
 	}
 
@@ -61,6 +91,9 @@ func main() {
 			logger.Log("transport", "debug/HTTP", "during", "Listen", "err", err)
 			os.Exit(1)
 		}
+		// Scheduled jobs are managed on the debug listener.
+		http.Handle("/jobs", schedule.NewHTTPHandler(scheduler))
+		http.Handle("/jobs/", schedule.NewHTTPHandler(scheduler))
 		g.Add(func() error {
 			logger.Log("transport", "debug/HTTP", "addr", *debugAddr)
 			return http.Serve(debugListener, http.DefaultServeMux)
@@ -94,6 +127,15 @@ func main() {
 			grpcListener.Close()
 		})
 	}
+	{
+		// The scheduler runs scheduled publication orders.
+		ctx, cancel := context.WithCancel(context.Background())
+		g.Add(func() error {
+			return scheduler.Run(ctx)
+		}, func(error) {
+			cancel()
+		})
+	}
 	{
 		// This function just sits and waits for ctrl-C.
 		cancelInterrupt := make(chan struct{})
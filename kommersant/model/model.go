@@ -1,5 +1,13 @@
 package model
 
+import (
+	"time"
+
+	"microsrv/schedule"
+
+	"github.com/jinzhu/gorm"
+)
+
 // HealthRequest collects the request parameters for the Health method.
 type HealthRequest struct{}
 
@@ -12,17 +20,59 @@ type HealthResponse struct {
 // Failed implements Failer.
 func (r HealthResponse) Failed() error { return r.Err }
 
-// CreateRequest collects the request parameters for the Greeting method.
+// CreateRequest collects the request parameters for the Create and Result methods.
 type CreateRequest struct {
-	AdNum string `json:"ad_num,omitempty"`
+	AdNum    string            `json:"ad_num,omitempty"`
+	Schedule *schedule.Request `json:"schedule,omitempty"`
 }
 
-// CreateResponse collects the response values for the Greeting method.
+// CreateResponse collects the response values for the Create and Result methods.
 type CreateResponse struct {
-	Status  int32  `json:"status,omitempty"`
-	Message string `json:"message,omitempty"`
-	Err     error  `json:"err,omitempty"`
+	Status      int32      `json:"status,omitempty"`
+	Message     string     `json:"message,omitempty"`
+	State       State      `json:"state,omitempty"`
+	PublishedAt *time.Time `json:"published_at,omitempty"`
+	IssueNo     string     `json:"issue_no,omitempty"`
+	Job         string     `json:"job,omitempty"`
+	Err         error      `json:"err,omitempty"`
 }
 
 // Failed implements Failer.
 func (r CreateResponse) Failed() error { return r.Err }
+
+// State of a publication order.
+type State string
+
+const (
+	// Draft order is stored but not sent to the publisher yet.
+	Draft = State("draft")
+	// Submitted order is sent to the publisher.
+	Submitted = State("submitted")
+	// Accepted order is accepted by the publisher and waits for an issue.
+	Accepted = State("accepted")
+	// Published order is printed in an issue.
+	Published = State("published")
+	// Rejected order is declined by the publisher.
+	Rejected = State("rejected")
+)
+
+// Final reports whether no further transitions are possible from the state.
+func (s State) Final() bool {
+	return s == Published || s == Rejected
+}
+
+// Order is a publication order for an advert.
+type Order struct {
+	gorm.Model
+	AdNum       string `gorm:"unique_index"`
+	State       State
+	Reference   string
+	PublishedAt *time.Time
+	IssueNo     string
+	Reason      string
+}
+
+// TableName sets Order's table name to be `kommersant_orders`.
+func (Order) TableName() string {
+	return "kommersant_orders"
+}
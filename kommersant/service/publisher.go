@@ -0,0 +1,85 @@
+package kommersantsvc
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"microsrv/kommersant/model"
+)
+
+// Publisher is a client of the publishing house.
+type Publisher interface {
+	// Submit sends the order and returns the publisher's reference for it.
+	Submit(ctx context.Context, o model.Order) (string, error)
+	// Status returns the current state of a submitted order.
+	Status(ctx context.Context, reference string) (Publication, error)
+}
+
+// Publication is the publisher's view of an order.
+type Publication struct {
+	State       model.State
+	PublishedAt *time.Time
+	IssueNo     string
+	Reason      string
+}
+
+// MemPublisher is an in-memory Publisher. Orders stay submitted until
+// Accept, Publish or Reject is called, which lets tests drive the workflow.
+type MemPublisher struct {
+	mtx          sync.Mutex
+	publications map[string]Publication
+	next         int
+}
+
+// NewMemPublisher returns an empty MemPublisher.
+func NewMemPublisher() *MemPublisher {
+	return &MemPublisher{publications: map[string]Publication{}}
+}
+
+// Submit implements Publisher.
+func (p *MemPublisher) Submit(_ context.Context, o model.Order) (string, error) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	p.next++
+	ref := fmt.Sprintf("%s-%d", o.AdNum, p.next)
+	p.publications[ref] = Publication{State: model.Submitted}
+	return ref, nil
+}
+
+// Status implements Publisher.
+func (p *MemPublisher) Status(_ context.Context, reference string) (Publication, error) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	pub, ok := p.publications[reference]
+	if !ok {
+		return pub, ErrNotFound
+	}
+	return pub, nil
+}
+
+// Accept marks the order as accepted by the publisher.
+func (p *MemPublisher) Accept(reference string) error {
+	return p.set(reference, Publication{State: model.Accepted})
+}
+
+// Publish marks the order as printed in the given issue.
+func (p *MemPublisher) Publish(reference string, date time.Time, issueNo string) error {
+	return p.set(reference, Publication{State: model.Published, PublishedAt: &date, IssueNo: issueNo})
+}
+
+// Reject marks the order as declined by the publisher.
+func (p *MemPublisher) Reject(reference string, reason string) error {
+	return p.set(reference, Publication{State: model.Rejected, Reason: reason})
+}
+
+func (p *MemPublisher) set(reference string, pub Publication) error {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	if _, ok := p.publications[reference]; !ok {
+		return ErrNotFound
+	}
+	p.publications[reference] = pub
+	return nil
+}
@@ -3,50 +3,193 @@ package kommersantsvc
 import (
 	"context"
 	"fmt"
-	"math/rand"
 
+	"microsrv/apperr"
 	"microsrv/kommersant/model"
+	"microsrv/schedule"
 
 	"github.com/go-kit/kit/log"
 )
 
-// Service describe greetings service.
+// Service describe publication service.
 type Service interface {
 	Health() bool
 	Create(ctx context.Context, ad model.CreateRequest) (model.CreateResponse, error)
 	Result(ctx context.Context, ad model.CreateRequest) (model.CreateResponse, error)
 }
 
+var (
+	// ErrNotFound var
+	ErrNotFound = apperr.NewNotFound("order not found")
+	// ErrAlreadyExists var
+	ErrAlreadyExists = apperr.NewConflict("order already exists")
+	// ErrEmptyAdNum var
+	ErrEmptyAdNum = apperr.NewValidation("invalid request", apperr.Field{Field: "ad_num", Description: "must not be empty"})
+	// ErrInvalidTransition var
+	ErrInvalidTransition = apperr.NewConflict("invalid order state transition")
+	// ErrNoScheduler var
+	ErrNoScheduler = apperr.NewUnavailable("scheduled orders are not supported")
+)
+
+// OrderJob is the name of the scheduler handler that submits an order and
+// then refreshes its state on every following run.
+const OrderJob = "kommersant.order"
+
+// transitions lists the states an order may move to from each state.
+var transitions = map[model.State][]model.State{
+	model.Draft:     {model.Submitted},
+	model.Submitted: {model.Accepted, model.Published, model.Rejected},
+	model.Accepted:  {model.Published, model.Rejected},
+}
+
 // New returns a basic Service with all of the expected middlewares wired in.
-func New(logger log.Logger) Service {
+func New(store Store, publisher Publisher, scheduler *schedule.Scheduler, logger log.Logger) Service {
 	var svc Service
 	{
-		svc = NewBasicService()
+		svc = NewBasicService(store, publisher, scheduler)
 		svc = LoggingMiddleware(logger)(svc)
 	}
 	return svc
 }
 
-// NewBasicService returns a naïve, stateless implementation of Service.
-func NewBasicService() Service {
-	return basicService{}
+// NewBasicService returns a Service that keeps orders in the store and
+// sends them to the publisher. The scheduler is optional, without it orders
+// with a schedule are refused.
+func NewBasicService(store Store, publisher Publisher, scheduler *schedule.Scheduler) Service {
+	svc := basicService{store: store, publisher: publisher, scheduler: scheduler}
+	if scheduler != nil {
+		scheduler.Register(OrderJob, svc.runOrder)
+	}
+	return svc
 }
 
-type basicService struct{}
+type basicService struct {
+	store     Store
+	publisher Publisher
+	scheduler *schedule.Scheduler
+}
 
 // Health implementation of the Service.
 func (s basicService) Health() bool {
-	return true
+	return s.store.Ping() == nil
+}
+
+// Create stores a draft order for the advert and submits it to the publisher,
+// either right away or by the scheduler when the request has a schedule.
+// A draft left by a failed submission is resumed rather than refused, so
+// the caller may simply retry.
+func (s basicService) Create(ctx context.Context, ad model.CreateRequest) (model.CreateResponse, error) {
+	if ad.AdNum == "" {
+		return model.CreateResponse{}, ErrEmptyAdNum
+	}
+	if ad.Schedule != nil && s.scheduler == nil {
+		return model.CreateResponse{}, ErrNoScheduler
+	}
+	o, err := s.store.Get(ctx, ad.AdNum)
+	switch {
+	case err == ErrNotFound:
+		o = model.Order{AdNum: ad.AdNum, State: model.Draft}
+		if err := s.store.Create(ctx, &o); err != nil {
+			return model.CreateResponse{}, err
+		}
+	case err != nil:
+		return model.CreateResponse{}, err
+	case o.State != model.Draft || ad.Schedule != nil:
+		return model.CreateResponse{}, ErrAlreadyExists
+	}
+	if ad.Schedule != nil {
+		j, err := s.scheduler.Add(*ad.Schedule, OrderJob, []byte(o.AdNum))
+		if err != nil {
+			// Nothing would ever submit the draft, drop it so that the
+			// order may be created again.
+			if derr := s.store.Delete(ctx, o.AdNum); derr != nil {
+				return model.CreateResponse{}, derr
+			}
+			return model.CreateResponse{}, err
+		}
+		res := response(o)
+		res.Job = j.ID
+		return res, nil
+	}
+	if err := s.submit(ctx, &o); err != nil {
+		return response(o), err
+	}
+	return response(o), nil
+}
+
+// Result refreshes the order from the publisher and returns its state.
+func (s basicService) Result(ctx context.Context, ad model.CreateRequest) (model.CreateResponse, error) {
+	o, err := s.store.Get(ctx, ad.AdNum)
+	if err != nil {
+		return model.CreateResponse{}, err
+	}
+	if o.State.Final() || o.Reference == "" {
+		return response(o), nil
+	}
+	pub, err := s.publisher.Status(ctx, o.Reference)
+	if err != nil {
+		return response(o), err
+	}
+	if pub.State == o.State {
+		return response(o), nil
+	}
+	if err := transition(&o, pub.State); err != nil {
+		return response(o), err
+	}
+	o.PublishedAt = pub.PublishedAt
+	o.IssueNo = pub.IssueNo
+	o.Reason = pub.Reason
+	if err := s.store.Update(ctx, &o); err != nil {
+		return response(o), err
+	}
+	return response(o), nil
 }
 
-// Create implementation of the Service.
-func (s basicService) Create(_ context.Context, ad model.CreateRequest) (model.CreateResponse, error) {
-	r := rand.New(rand.NewSource(99))
-	return model.CreateResponse{Message: "GO-KIT Hello from Create " + ad.AdNum + " " + fmt.Sprintf("%d", r.Uint32()), Status: 200}, nil
+// runOrder is the OrderJob handler, the payload is the ad_num.
+func (s basicService) runOrder(ctx context.Context, payload []byte) error {
+	o, err := s.store.Get(ctx, string(payload))
+	if err != nil {
+		return err
+	}
+	if o.State == model.Draft {
+		return s.submit(ctx, &o)
+	}
+	_, err = s.Result(ctx, model.CreateRequest{AdNum: o.AdNum})
+	return err
 }
 
-// Result implementation of the Service.
-func (s basicService) Result(_ context.Context, ad model.CreateRequest) (model.CreateResponse, error) {
-	r := rand.New(rand.NewSource(99))
-	return model.CreateResponse{Message: "GO-KIT Hello from result " + ad.AdNum + " " + fmt.Sprintf("%d", r.Uint32()), Status: 200}, nil
+func (s basicService) submit(ctx context.Context, o *model.Order) error {
+	ref, err := s.publisher.Submit(ctx, *o)
+	if err != nil {
+		return err
+	}
+	if err := transition(o, model.Submitted); err != nil {
+		return err
+	}
+	o.Reference = ref
+	return s.store.Update(ctx, o)
+}
+
+func transition(o *model.Order, to model.State) error {
+	for _, s := range transitions[o.State] {
+		if s == to {
+			o.State = to
+			return nil
+		}
+	}
+	return ErrInvalidTransition
+}
+
+func response(o model.Order) model.CreateResponse {
+	res := model.CreateResponse{
+		Status:      200,
+		State:       o.State,
+		PublishedAt: o.PublishedAt,
+		IssueNo:     o.IssueNo,
+		Message:     fmt.Sprintf("order %s is %s", o.AdNum, o.State),
+	}
+	if o.Reason != "" {
+		res.Message += ": " + o.Reason
+	}
+	return res
 }
@@ -0,0 +1,103 @@
+package kommersantsvc
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"microsrv/kommersant/model"
+	"microsrv/schedule"
+
+	"github.com/go-kit/kit/log"
+)
+
+// flakyPublisher fails the given number of submissions before passing them
+// to the in-memory publisher.
+type flakyPublisher struct {
+	*MemPublisher
+	fail int
+}
+
+var errDown = errors.New("publisher is down")
+
+func (p *flakyPublisher) Submit(ctx context.Context, o model.Order) (string, error) {
+	if p.fail > 0 {
+		p.fail--
+		return "", errDown
+	}
+	return p.MemPublisher.Submit(ctx, o)
+}
+
+func TestCreateResumesDraft(t *testing.T) {
+	store := NewMemStore()
+	pub := &flakyPublisher{MemPublisher: NewMemPublisher(), fail: 1}
+	s := NewBasicService(store, pub, nil)
+	ctx := context.Background()
+	req := model.CreateRequest{AdNum: "77010001"}
+
+	if _, err := s.Create(ctx, req); err != errDown {
+		t.Fatalf("Create with the publisher down: %v", err)
+	}
+	o, err := store.Get(ctx, req.AdNum)
+	if err != nil || o.State != model.Draft {
+		t.Fatalf("order after a failed submission %+v, %v", o, err)
+	}
+	res, err := s.Create(ctx, req)
+	if err != nil || res.State != model.Submitted {
+		t.Fatalf("retried Create = %+v, %v", res, err)
+	}
+	if _, err := s.Create(ctx, req); err != ErrAlreadyExists {
+		t.Fatalf("Create of a submitted order: %v, want ErrAlreadyExists", err)
+	}
+}
+
+func TestOrderWorkflow(t *testing.T) {
+	store := NewMemStore()
+	pub := NewMemPublisher()
+	s := NewBasicService(store, pub, nil)
+	ctx := context.Background()
+	req := model.CreateRequest{AdNum: "77010002"}
+
+	if _, err := s.Create(ctx, model.CreateRequest{}); err != ErrEmptyAdNum {
+		t.Fatalf("Create without ad_num: %v", err)
+	}
+	if _, err := s.Create(ctx, req); err != nil {
+		t.Fatal(err)
+	}
+	o, _ := store.Get(ctx, req.AdNum)
+	date := time.Date(2019, 3, 2, 0, 0, 0, 0, time.UTC)
+	if err := pub.Publish(o.Reference, date, "38"); err != nil {
+		t.Fatal(err)
+	}
+	res, err := s.Result(ctx, req)
+	if err != nil || res.State != model.Published || res.IssueNo != "38" || !res.PublishedAt.Equal(date) {
+		t.Fatalf("Result = %+v, %v", res, err)
+	}
+	if _, err := s.Result(ctx, model.CreateRequest{AdNum: "none"}); err != ErrNotFound {
+		t.Fatalf("Result of a missing order: %v", err)
+	}
+}
+
+func TestCreateScheduleFailure(t *testing.T) {
+	store := NewMemStore()
+	scheduler := schedule.New(schedule.NewMemStore(), log.NewNopLogger())
+	s := NewBasicService(store, NewMemPublisher(), scheduler)
+	ctx := context.Background()
+	req := model.CreateRequest{AdNum: "77010003", Schedule: &schedule.Request{Type: schedule.PERIODICALY}}
+
+	if _, err := s.Create(ctx, req); err != schedule.ErrInvalidRequest {
+		t.Fatalf("Create with an invalid schedule: %v", err)
+	}
+	if _, err := store.Get(ctx, req.AdNum); err != ErrNotFound {
+		t.Fatalf("draft left after a failed schedule: %v", err)
+	}
+	req.Schedule.Intervals = 60
+	res, err := s.Create(ctx, req)
+	if err != nil || res.State != model.Draft || res.Job == "" {
+		t.Fatalf("Create with a valid schedule = %+v, %v", res, err)
+	}
+	if len(scheduler.List()) != 1 {
+		t.Fatalf("%d jobs scheduled, want 1", len(scheduler.List()))
+	}
+}
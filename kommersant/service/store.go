@@ -0,0 +1,115 @@
+package kommersantsvc
+
+import (
+	"context"
+	"sync"
+
+	"microsrv/kommersant/model"
+
+	"github.com/jinzhu/gorm"
+	_ "github.com/jinzhu/gorm/dialects/mysql" // Mysql driver
+)
+
+// Store persists publication orders.
+type Store interface {
+	Create(ctx context.Context, o *model.Order) error
+	Get(ctx context.Context, adNum string) (model.Order, error)
+	Update(ctx context.Context, o *model.Order) error
+	// Delete removes the order for good, so that the ad_num may be used again.
+	Delete(ctx context.Context, adNum string) error
+	Ping() error
+}
+
+type databaseStore struct{ db *gorm.DB }
+
+// NewDB returns a Store backed by MySQL.
+func NewDB(DSN string) (Store, error) {
+	db, err := gorm.Open("mysql", DSN)
+	if err != nil {
+		return nil, err
+	}
+	db = db.Set("gorm:table_options", "ENGINE=InnoDB")
+	if err := db.AutoMigrate(&model.Order{}).Error; err != nil {
+		return nil, err
+	}
+	return &databaseStore{db: db}, nil
+}
+
+func (ds *databaseStore) Create(ctx context.Context, o *model.Order) error {
+	return ds.db.Create(o).Error
+}
+
+func (ds *databaseStore) Get(ctx context.Context, adNum string) (model.Order, error) {
+	o := model.Order{}
+	err := ds.db.Where("ad_num = ?", adNum).First(&o).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return o, ErrNotFound
+	}
+	return o, err
+}
+
+func (ds *databaseStore) Update(ctx context.Context, o *model.Order) error {
+	return ds.db.Save(o).Error
+}
+
+func (ds *databaseStore) Delete(ctx context.Context, adNum string) error {
+	return ds.db.Unscoped().Where("ad_num = ?", adNum).Delete(&model.Order{}).Error
+}
+
+func (ds *databaseStore) Ping() error {
+	return ds.db.DB().Ping()
+}
+
+type memStore struct {
+	mtx    sync.RWMutex
+	orders map[string]model.Order
+	nextID uint
+}
+
+// NewMemStore returns an in-memory Store.
+func NewMemStore() Store {
+	return &memStore{orders: map[string]model.Order{}}
+}
+
+func (s *memStore) Create(ctx context.Context, o *model.Order) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if _, ok := s.orders[o.AdNum]; ok {
+		return ErrAlreadyExists
+	}
+	s.nextID++
+	o.ID = s.nextID
+	s.orders[o.AdNum] = *o
+	return nil
+}
+
+func (s *memStore) Get(ctx context.Context, adNum string) (model.Order, error) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	o, ok := s.orders[adNum]
+	if !ok {
+		return o, ErrNotFound
+	}
+	return o, nil
+}
+
+func (s *memStore) Update(ctx context.Context, o *model.Order) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if _, ok := s.orders[o.AdNum]; !ok {
+		return ErrNotFound
+	}
+	s.orders[o.AdNum] = *o
+	return nil
+}
+
+func (s *memStore) Delete(ctx context.Context, adNum string) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	delete(s.orders, adNum)
+	return nil
+}
+
+func (s *memStore) Ping() error {
+	return nil
+}
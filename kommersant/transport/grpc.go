@@ -3,12 +3,16 @@ package transport
 import (
 	"context"
 
+	"microsrv/apperr"
+
 	"github.com/go-kit/kit/log"
 
 	grpctransport "github.com/go-kit/kit/transport/grpc"
+	"github.com/golang/protobuf/ptypes"
 	kommendpoint "microsrv/kommersant/endpoint"
 	"microsrv/kommersant/model"
 	"microsrv/pb"
+	"microsrv/schedule"
 	oldcontext "golang.org/x/net/context"
 )
 
@@ -41,7 +45,7 @@ func NewGRPCServer(endpoints kommendpoint.Endpoints, logger log.Logger) pb.Komme
 func (s *grpcServer) Create(ctx oldcontext.Context, req *pb.KommersantRequest) (*pb.KommersantResponse, error) {
 	_, res, err := s.create.ServeGRPC(ctx, req)
 	if err != nil {
-		return nil, err
+		return nil, apperr.ToGRPC(err)
 	}
 	return res.(*pb.KommersantResponse), nil
 }
@@ -50,7 +54,7 @@ func (s *grpcServer) Create(ctx oldcontext.Context, req *pb.KommersantRequest) (
 func (s *grpcServer) Result(ctx oldcontext.Context, req *pb.KommersantRequest) (*pb.KommersantResponse, error) {
 	_, res, err := s.result.ServeGRPC(ctx, req)
 	if err != nil {
-		return nil, err
+		return nil, apperr.ToGRPC(err)
 	}
 	return res.(*pb.KommersantResponse), nil
 }
@@ -59,12 +63,41 @@ func (s *grpcServer) Result(ctx oldcontext.Context, req *pb.KommersantRequest) (
 // a gRPC greeting request to a user-domain greeting request.
 func decodeGRPCCreateRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
 	req := grpcReq.(*pb.KommersantRequest)
-	return model.CreateRequest{AdNum: req.AdNum}, nil
+	res := model.CreateRequest{AdNum: req.AdNum}
+	if req.Schedule != nil {
+		res.Schedule = &schedule.Request{
+			Type:      schedule.Type(req.Schedule.Type),
+			Intervals: req.Schedule.Intervals,
+			Max:       int16(req.Schedule.Max),
+		}
+		if req.Schedule.FirstRun != nil {
+			t, err := ptypes.Timestamp(req.Schedule.FirstRun)
+			if err != nil {
+				return nil, err
+			}
+			res.Schedule.FirstRun = &t
+		}
+	}
+	return res, nil
 }
 
 // encodeGRPCGreetingResponse is a transport/grpc.EncodeResponseFunc that converts
 // a user-domain greeting response to a gRPC greeting response.
 func encodeGRPCCreateResponse(_ context.Context, response interface{}) (interface{}, error) {
 	res := response.(model.CreateResponse)
-	return &pb.KommersantResponse{Status: res.Status}, nil
+	result := &pb.KommersantResponse{
+		Status:  res.Status,
+		Message: res.Message,
+		State:   string(res.State),
+		IssueNo: res.IssueNo,
+		Job:     res.Job,
+	}
+	if res.PublishedAt != nil {
+		ts, err := ptypes.TimestampProto(*res.PublishedAt)
+		if err != nil {
+			return nil, err
+		}
+		result.PublishedAt = ts
+	}
+	return result, nil
 }
@@ -3,9 +3,10 @@ package transport
 import (
 	"context"
 	"encoding/json"
-	"errors"
 	"net/http"
 
+	"microsrv/apperr"
+
 	"github.com/gorilla/mux"
 
 	httptransport "github.com/go-kit/kit/transport/http"
@@ -17,18 +18,20 @@ import (
 
 var (
 	// ErrBadRouting is returned when an expected path variable is missing.
-	ErrBadRouting = errors.New("inconsistent mapping between route and handler")
+	ErrBadRouting = apperr.New(apperr.Internal, "inconsistent mapping between route and handler")
 )
 
 // NewHTTPHandler returns an HTTP handler that makes a set of endpoints
 // available on predefined paths.
 func NewHTTPHandler(endpoints kommendpoint.Endpoints, logger log.Logger) http.Handler {
 	options := []httptransport.ServerOption{
-		httptransport.ServerErrorEncoder(encodeError),
+		httptransport.ServerErrorEncoder(apperr.EncodeHTTPError),
 		httptransport.ServerErrorLogger(logger),
 	}
 
-	// GET /health         retrieves service heath information
+	// GET  /health         retrieves service heath information
+	// POST /create         creates a publication order for the advert
+	// GET  /result?ad_num  retrieves the publication state of the advert
 
 	// m := http.NewServeMux()
 	// m.Handle("/sum", httptransport.NewServer(
@@ -47,7 +50,7 @@ func NewHTTPHandler(endpoints kommendpoint.Endpoints, logger log.Logger) http.Ha
 	))
 	m.Methods("GET").Path("/result").Handler(httptransport.NewServer(
 		endpoints.ResultEndpoint,
-		DecodeHTTPKommersantRequest,
+		DecodeHTTPResultRequest,
 		EncodeHTTPGenericResponse,
 		options...,
 	))
@@ -62,31 +65,25 @@ func DecodeHTTPHealthRequest(_ context.Context, _ *http.Request) (interface{}, e
 // DecodeHTTPKommersantRequest method.
 func DecodeHTTPKommersantRequest(_ context.Context, r *http.Request) (interface{}, error) {
 	var req model.CreateRequest
-	err := json.NewDecoder(r.Body).Decode(&req)
-	return req, err
-}
-
-func encodeError(_ context.Context, err error, w http.ResponseWriter) {
-	w.WriteHeader(err2code(err))
-	json.NewEncoder(w).Encode(errorWrapper{Error: err.Error()})
-}
-
-func err2code(err error) int {
-	switch err {
-	default:
-		return http.StatusInternalServerError
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, apperr.NewValidation(err.Error())
 	}
+	return req, nil
 }
 
-type errorWrapper struct {
-	Error string `json:"error"`
+// DecodeHTTPResultRequest method.
+func DecodeHTTPResultRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	if adNum := r.URL.Query().Get("ad_num"); adNum != "" {
+		return model.CreateRequest{AdNum: adNum}, nil
+	}
+	return DecodeHTTPKommersantRequest(ctx, r)
 }
 
 // EncodeHTTPGenericResponse is a transport/http.EncodeResponseFunc that encodes
 // the response as JSON to the response writer
 func EncodeHTTPGenericResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
 	if f, ok := response.(kommendpoint.Failer); ok && f.Failed() != nil {
-		encodeError(ctx, f.Failed(), w)
+		apperr.EncodeHTTPError(ctx, f.Failed(), w)
 		return nil
 	}
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
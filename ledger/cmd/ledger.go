@@ -0,0 +1,177 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"text/tabwriter"
+
+	"microsrv/config"
+	"microsrv/pdf"
+
+	"github.com/go-kit/kit/log"
+	kitgrpc "github.com/go-kit/kit/transport/grpc"
+	"github.com/oklog/oklog/pkg/group"
+	"google.golang.org/grpc"
+	"microsrv/ledger/endpoint"
+	"microsrv/ledger/sd"
+	"microsrv/ledger/service"
+	"microsrv/ledger/transport"
+	"microsrv/pb"
+)
+
+func main() {
+	cfg := config.Parameters{}
+	fs := flag.NewFlagSet("ledger", flag.ExitOnError)
+	iniFile := config.File(os.Args[1:])
+	fs.String("cfg", iniFile, "Location of config file")
+	err := cfg.Read(iniFile)
+	if err != nil {
+		fmt.Println(err)
+	}
+	var (
+		debugPort  = fs.String("debug.port", fmt.Sprintf(":%d", cfg.Service.DebugPort), "Debug and metrics listen address")
+		grpcPort   = fs.String("grpc.port", fmt.Sprintf("%d", cfg.Service.GrpcPort), "gRPC listen address")
+		httpAddr   = fs.String("http.addr", cfg.Service.HTTPAddr, "HTTP Listen Address")
+		httpPort   = fs.String("http.port", fmt.Sprintf("%d", cfg.Service.HTTPPort), "HTTP Listen Port")
+		consulAddr = fs.String("consul.addr", cfg.Service.ConsulAddr, "Consul Address")
+		consulPort = fs.String("consul.port", fmt.Sprintf("%d", cfg.Service.ConsulPort), "Consul Port")
+		driver     = fs.String("db.driver", cfg.DB.Driver, "Database driver: mysql, postgres or memory")
+		dbHost     = fs.String("db.host", cfg.DB.DbHost, "Database host")
+		dbPort     = fs.Uint("db.port", uint(cfg.DB.DbPort), "Database port")
+		db         = fs.String("db.database", cfg.DB.DB, "Database name, the one of the debtor service")
+		user       = fs.String("db.user", cfg.DB.DbUser, "Database user")
+		password   = fs.String("db.password", cfg.DB.DbPassword, "Database password")
+		pdfFont    = fs.String("pdf.font", cfg.Ledger.PDFFont, "TrueType font of the PDF statements")
+	)
+	fs.Usage = usageFor(fs, os.Args[0]+" [flags]")
+	fs.Parse(os.Args[1:])
+	dbConfig := config.DB{
+		Driver:     *driver,
+		DbHost:     *dbHost,
+		DbPort:     uint16(*dbPort),
+		DB:         *db,
+		DbUser:     *user,
+		DbPassword: *password,
+	}
+
+	iGrpcPort, _ := strconv.Atoi(*grpcPort)
+
+	var logger log.Logger
+	{
+		logger = log.NewLogfmtLogger(os.Stderr)
+		logger = log.With(logger, "ts", log.DefaultTimestampUTC)
+		logger = log.With(logger, "caller", log.DefaultCaller)
+	}
+	var font *pdf.Font
+	{
+		data, err := ioutil.ReadFile(*pdfFont)
+		if err != nil {
+			logger.Log("during", "ReadFile", "font", *pdfFont, "err", err)
+			os.Exit(1)
+		}
+		if font, err = pdf.ParseFont(data); err != nil {
+			logger.Log("during", "ParseFont", "font", *pdfFont, "err", err)
+			os.Exit(1)
+		}
+	}
+	var service ledgerservice.Service
+	{
+		store, err := ledgerservice.NewStore(dbConfig)
+		if err != nil {
+			logger.Log("during", "NewStore", "err", err)
+			os.Exit(1)
+		}
+		service = ledgerservice.NewBasicService(store, font)
+		service = ledgerservice.LoggingMiddleware(logger)(service)
+	}
+
+	var (
+		endpoints   = ledgerendpoint.MakeServerEndpoints(service)
+		httpHandler = transport.NewHTTPHandler(endpoints, logger)
+		grpcServer  = transport.NewGRPCServer(endpoints, logger)
+		registar    = consulsd.ConsulRegister(*consulAddr, *consulPort, *httpAddr, *httpPort, iGrpcPort)
+	)
+	var g group.Group
+	{
+		// The debug listener mounts the http.DefaultServeMux, and serves up
+		// stuff like the Go debug and profiling routes, and so on.
+		debugListener, err := net.Listen("tcp", *debugPort)
+		if err != nil {
+			logger.Log("transport", "debug/HTTP", "during", "Listen", "err", err)
+			os.Exit(1)
+		}
+		g.Add(func() error {
+			logger.Log("transport", "debug/HTTP", "addr", *debugPort)
+			return http.Serve(debugListener, http.DefaultServeMux)
+		}, func(error) {
+			debugListener.Close()
+		})
+	}
+	{
+		// The service discovery registration.
+		g.Add(func() error {
+			logger.Log("transport", "HTTP", "addr", *httpAddr, "port", *httpPort)
+			registar.Register()
+			return http.ListenAndServe(":"+*httpPort, httpHandler)
+		}, func(error) {
+			registar.Deregister()
+		})
+		defer registar.Deregister()
+	}
+	{
+		// The gRPC listener mounts the Go kit gRPC server we created.
+		grpcListener, err := net.Listen("tcp", fmt.Sprintf(":%s", *grpcPort))
+		if err != nil {
+			logger.Log("transport", "gRPC", "during", "Listen", "err", err)
+			os.Exit(1)
+		}
+		g.Add(func() error {
+			logger.Log("transport", "gRPC", "addr", *grpcPort)
+			baseServer := grpc.NewServer(grpc.UnaryInterceptor(kitgrpc.Interceptor))
+			pb.RegisterLedgerSvcServer(baseServer, grpcServer)
+			return baseServer.Serve(grpcListener)
+		}, func(error) {
+			grpcListener.Close()
+		})
+	}
+	{
+		// This function just sits and waits for ctrl-C.
+		cancelInterrupt := make(chan struct{})
+		g.Add(func() error {
+			c := make(chan os.Signal, 1)
+			signal.Notify(c, syscall.SIGINT, syscall.SIGTERM)
+			select {
+			case sig := <-c:
+				return fmt.Errorf("received signal %s", sig)
+			case <-cancelInterrupt:
+				return nil
+			}
+		}, func(error) {
+			close(cancelInterrupt)
+		})
+	}
+	logger.Log("exit", g.Run())
+
+}
+
+func usageFor(fs *flag.FlagSet, short string) func() {
+	return func() {
+		fmt.Fprintf(os.Stderr, "USAGE\n")
+		fmt.Fprintf(os.Stderr, "  %s\n", short)
+		fmt.Fprintf(os.Stderr, "\n")
+		fmt.Fprintf(os.Stderr, "FLAGS\n")
+		w := tabwriter.NewWriter(os.Stderr, 0, 2, 2, ' ', 0)
+		fs.VisitAll(func(f *flag.Flag) {
+			fmt.Fprintf(w, "\t-%s %s\t%s\n", f.Name, f.DefValue, f.Usage)
+		})
+		w.Flush()
+		fmt.Fprintf(os.Stderr, "\n")
+	}
+}
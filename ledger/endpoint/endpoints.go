@@ -0,0 +1,215 @@
+package ledgerendpoint
+
+import (
+	"context"
+	"io"
+
+	"microsrv/ledger/service"
+	"microsrv/pb"
+
+	"github.com/go-kit/kit/endpoint"
+)
+
+// Endpoints struct
+type Endpoints struct {
+	HealthEndpoint            endpoint.Endpoint // used by Consul for the healthcheck
+	CreateTradingCodeEndpoint endpoint.Endpoint
+	GetTradingCodeEndpoint    endpoint.Endpoint
+	ListTradingCodesEndpoint  endpoint.Endpoint
+	AddCalculationEndpoint    endpoint.Endpoint
+	BalancesEndpoint          endpoint.Endpoint
+	StatementEndpoint         endpoint.Endpoint
+	ExportStatementEndpoint   endpoint.Endpoint
+}
+
+// MakeServerEndpoints func
+func MakeServerEndpoints(s ledgerservice.Service) Endpoints {
+	return Endpoints{
+		HealthEndpoint:            HealthEndpoint(s),
+		CreateTradingCodeEndpoint: CreateTradingCodeEndpoint(s),
+		GetTradingCodeEndpoint:    GetTradingCodeEndpoint(s),
+		ListTradingCodesEndpoint:  ListTradingCodesEndpoint(s),
+		AddCalculationEndpoint:    AddCalculationEndpoint(s),
+		BalancesEndpoint:          BalancesEndpoint(s),
+		StatementEndpoint:         StatementEndpoint(s),
+		ExportStatementEndpoint:   ExportStatementEndpoint(s),
+	}
+}
+
+// compile time assertions for our response types implementing endpoint.Failer.
+var (
+	_ endpoint.Failer = HealthResponse{}
+	_ endpoint.Failer = TradingCodeResponse{}
+	_ endpoint.Failer = TradingCodesResponse{}
+	_ endpoint.Failer = CalculationResponse{}
+	_ endpoint.Failer = BalancesResponse{}
+	_ endpoint.Failer = StatementResponse{}
+	_ endpoint.Failer = ExportResponse{}
+)
+
+// HealthEndpoint constructs a Health endpoint wrapping the service.
+func HealthEndpoint(s ledgerservice.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		healthy := s.Health()
+		return HealthResponse{Healthy: healthy}, nil
+	}
+}
+
+// CreateTradingCodeEndpoint func
+func CreateTradingCodeEndpoint(s ledgerservice.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(ledgerservice.TradingCode)
+		res, e := s.CreateTradingCode(ctx, req)
+		return TradingCodeResponse{TradingCode: res, Err: e}, nil
+	}
+}
+
+// GetTradingCodeEndpoint func
+func GetTradingCodeEndpoint(s ledgerservice.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(*pb.TradingCodeByID)
+		res, e := s.GetTradingCode(ctx, uint(req.ID))
+		return TradingCodeResponse{TradingCode: res, Err: e}, nil
+	}
+}
+
+// ListTradingCodesEndpoint func
+func ListTradingCodesEndpoint(s ledgerservice.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(*pb.BiddingByID)
+		res, e := s.ListTradingCodes(ctx, uint(req.ID))
+		return TradingCodesResponse{TradingCodes: res, Err: e}, nil
+	}
+}
+
+// AddCalculationEndpoint func
+func AddCalculationEndpoint(s ledgerservice.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(ledgerservice.Calculation)
+		res, e := s.AddCalculation(ctx, req)
+		return CalculationResponse{Calculation: res, Err: e}, nil
+	}
+}
+
+// BalancesEndpoint func
+func BalancesEndpoint(s ledgerservice.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(*pb.TradingCodeByID)
+		res, e := s.Balances(ctx, uint(req.ID))
+		return BalancesResponse{TradingCodeID: uint(req.ID), Balances: res, Err: e}, nil
+	}
+}
+
+// StatementEndpoint func
+func StatementEndpoint(s ledgerservice.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(*pb.BiddingByID)
+		res, e := s.Statement(ctx, uint(req.ID))
+		return StatementResponse{Statement: res, Err: e}, nil
+	}
+}
+
+// ExportStatementEndpoint func. The export is written by the response
+// encoder, an error found before the first byte is still reported as such.
+func ExportStatementEndpoint(s ledgerservice.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(ExportRequest)
+		if _, ok := ledgerservice.ExportContentTypes[req.Format]; !ok {
+			return ExportResponse{Err: ledgerservice.ErrExportFormat}, nil
+		}
+		return ExportResponse{
+			BiddingID: req.BiddingID,
+			Format:    req.Format,
+			Write: func(w io.Writer) error {
+				return s.ExportStatement(ctx, req.BiddingID, req.Format, w)
+			},
+		}, nil
+	}
+}
+
+// Failer is an interface that should be implemented by response types.
+// Response encoders can check if responses are Failer, and if so if they've
+// failed, and if so encode them using a separate write path based on the error.
+type Failer interface {
+	Failed() error
+}
+
+// TradingCodeResponse collects the response values of the methods
+// returning a trading code.
+type TradingCodeResponse struct {
+	TradingCode ledgerservice.TradingCode `json:"tradingCode"`
+	Err         error                     `json:"err,omitempty"`
+}
+
+// Failed implements Failer.
+func (r TradingCodeResponse) Failed() error { return r.Err }
+
+// TradingCodesResponse collects the response values for the
+// ListTradingCodes method.
+type TradingCodesResponse struct {
+	TradingCodes []ledgerservice.TradingCode `json:"tradingCodes"`
+	Err          error                       `json:"err,omitempty"`
+}
+
+// Failed implements Failer.
+func (r TradingCodesResponse) Failed() error { return r.Err }
+
+// CalculationResponse collects the response values for the
+// AddCalculation method.
+type CalculationResponse struct {
+	Calculation ledgerservice.Calculation `json:"calculation"`
+	Err         error                     `json:"err,omitempty"`
+}
+
+// Failed implements Failer.
+func (r CalculationResponse) Failed() error { return r.Err }
+
+// BalancesResponse collects the response values for the Balances method.
+type BalancesResponse struct {
+	TradingCodeID uint                    `json:"tradingCodeID"`
+	Balances      []ledgerservice.Balance `json:"balances"`
+	Err           error                   `json:"err,omitempty"`
+}
+
+// Failed implements Failer.
+func (r BalancesResponse) Failed() error { return r.Err }
+
+// StatementResponse collects the response values for the Statement
+// method.
+type StatementResponse struct {
+	Statement ledgerservice.Statement `json:"statement"`
+	Err       error                   `json:"err,omitempty"`
+}
+
+// Failed implements Failer.
+func (r StatementResponse) Failed() error { return r.Err }
+
+// ExportRequest collects the request parameters for the ExportStatement
+// method.
+type ExportRequest struct {
+	BiddingID uint
+	Format    string
+}
+
+// ExportResponse carries the writer of the export, run by the transport.
+type ExportResponse struct {
+	BiddingID uint
+	Format    string
+	Write     func(w io.Writer) error
+	Err       error `json:"err,omitempty"`
+}
+
+// Failed implements Failer.
+func (r ExportResponse) Failed() error { return r.Err }
+
+// HealthRequest collects the request parameters for the Health method.
+type HealthRequest struct{}
+
+// HealthResponse collects the response values for the Health method.
+type HealthResponse struct {
+	Healthy bool  `json:"healthy,omitempty"`
+	Err     error `json:"err,omitempty"`
+}
+
+// Failed implements Failer.
+func (r HealthResponse) Failed() error { return r.Err }
@@ -0,0 +1,231 @@
+package ledgerservice
+
+import (
+	"io"
+	"strconv"
+
+	"microsrv/apperr"
+	"microsrv/pdf"
+	"microsrv/xlsx"
+)
+
+// ErrExportFormat var
+var ErrExportFormat = apperr.NewValidation("unsupported export format", apperr.Field{Field: "format", Description: "must be pdf or xlsx"})
+
+// ExportContentTypes maps the export formats to their media types.
+var ExportContentTypes = map[string]string{
+	"pdf":  "application/pdf",
+	"xlsx": "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+}
+
+var (
+	calculationColumns = []string{"Date", "Type", "Source", "Description", "Amount"}
+	balanceColumns     = []string{"Type", "Source", "Receivable", "Payable", "Net"}
+)
+
+// WriteStatement writes the statement to w in the format, a PDF document
+// set in the font or an xlsx workbook. Amounts are in roubles.
+func WriteStatement(w io.Writer, st Statement, format string, font *pdf.Font) error {
+	switch format {
+	case "pdf":
+		return writePDF(w, st, font)
+	case "xlsx":
+		return writeXLSX(w, st)
+	default:
+		return ErrExportFormat
+	}
+}
+
+// statementRows returns the statement as rows of cells, the sections
+// separated by empty rows.
+func statementRows(st Statement) [][]string {
+	rows := [][]string{
+		{"Settlement statement"},
+		{"Bidding", id(st.BiddingID), st.BiddingName},
+		{"Debtor", id(st.DebtorID)},
+		{"Date", st.CreatedAt.Format("2006-01-02")},
+		{},
+	}
+	for _, cb := range st.TradingCodes {
+		rows = append(rows, []string{"Trading code", id(cb.TradingCode.ID), cb.TradingCode.Name}, calculationColumns)
+		for _, c := range cb.TradingCode.Calculations {
+			rows = append(rows, calculationRow(c))
+		}
+		rows = append(rows, []string{"", "", "", "Net", formatAmount(cb.Net)}, []string{})
+	}
+	rows = append(rows, []string{"Totals"}, balanceColumns)
+	for _, b := range st.Totals {
+		rows = append(rows, balanceRow(b))
+	}
+	rows = append(rows, []string{}, []string{"Settlement"}, []string{"Source", "Net"})
+	for _, s := range st.Settlements {
+		rows = append(rows, []string{s.Source.String(), formatAmount(s.Net)})
+	}
+	return append(rows,
+		[]string{},
+		[]string{"Debtor owes the organiser", formatAmount(st.DebtorOwes)},
+		[]string{"Organiser owes the debtor", formatAmount(st.OrganiserOwes)},
+	)
+}
+
+func calculationRow(c Calculation) []string {
+	return []string{c.Date.Format("2006-01-02"), c.Type.String(), c.Source.String(), c.Description, formatAmount(c.Amount())}
+}
+
+func balanceRow(b Balance) []string {
+	return []string{b.Type.String(), b.Source.String(), formatAmount(b.Receivable), formatAmount(b.Payable), formatAmount(b.Net)}
+}
+
+func writeXLSX(w io.Writer, st Statement) error {
+	xw, err := xlsx.NewWriter(w, "Statement")
+	if err != nil {
+		return err
+	}
+	for _, row := range statementRows(st) {
+		if err := xw.WriteRow(row); err != nil {
+			return err
+		}
+	}
+	return xw.Close()
+}
+
+// Layout of the PDF statement, in points.
+const (
+	margin    = 40.0
+	textSize  = 9.0
+	lineSpace = 13.0
+)
+
+// right is the right edge of the text of the PDF statement.
+const right = pdf.PageWidth - margin
+
+// column of a PDF table, x is the left edge of a text column and the right
+// edge of an amount one.
+type column struct {
+	x      float64
+	amount bool
+}
+
+var (
+	calculationTable = []column{{margin, false}, {100, false}, {175, false}, {235, false}, {right, true}}
+	balanceTable     = []column{{margin, false}, {140, false}, {360, true}, {460, true}, {right, true}}
+	settlementTable  = []column{{margin, false}, {right, true}}
+)
+
+func writePDF(w io.Writer, st Statement, font *pdf.Font) error {
+	l := &layout{doc: pdf.New(font)}
+	l.newPage()
+	l.text(margin, 16, "Settlement statement")
+	l.y -= 8
+	l.line(0)
+	l.text(margin, 10, "Bidding "+id(st.BiddingID)+" "+st.BiddingName)
+	l.line(0)
+	l.text(margin, 10, "Debtor "+id(st.DebtorID)+", "+st.CreatedAt.Format("2006-01-02"))
+	for _, cb := range st.TradingCodes {
+		l.y -= 10
+		l.line(3 * lineSpace)
+		l.text(margin, 11, "Trading code "+id(cb.TradingCode.ID)+" "+cb.TradingCode.Name)
+		l.table(calculationTable, calculationColumns, true)
+		for _, c := range cb.TradingCode.Calculations {
+			l.table(calculationTable, calculationRow(c), false)
+		}
+		l.table(calculationTable, []string{"", "", "", "Net", formatAmount(cb.Net)}, true)
+	}
+	l.y -= 10
+	l.line(3 * lineSpace)
+	l.text(margin, 11, "Totals")
+	l.table(balanceTable, balanceColumns, true)
+	for _, b := range st.Totals {
+		l.table(balanceTable, balanceRow(b), false)
+	}
+	l.y -= 10
+	l.line(3 * lineSpace)
+	l.text(margin, 11, "Settlement")
+	for _, s := range st.Settlements {
+		l.table(settlementTable, []string{s.Source.String(), formatAmount(s.Net)}, false)
+	}
+	l.y -= 4
+	l.table(settlementTable, []string{"Debtor owes the organiser", formatAmount(st.DebtorOwes)}, true)
+	l.table(settlementTable, []string{"Organiser owes the debtor", formatAmount(st.OrganiserOwes)}, true)
+	return l.doc.Write(w)
+}
+
+// layout sets the statement from the top of the pages down.
+type layout struct {
+	doc  *pdf.Document
+	page *pdf.Page
+	y    float64
+}
+
+func (l *layout) newPage() {
+	l.page = l.doc.AddPage()
+	l.y = pdf.PageHeight - margin
+}
+
+// line moves to the next line, on a new page unless there is room for
+// the line and the height below it.
+func (l *layout) line(below float64) {
+	l.y -= lineSpace
+	if l.y-below < margin {
+		l.newPage()
+		l.y -= lineSpace
+	}
+}
+
+func (l *layout) text(x, size float64, s string) {
+	l.page.Text(x, l.y, size, s)
+}
+
+// table writes a row of cells in the columns, the text ones cut to the
+// room left by the next column. A rule is drawn below the ruled rows.
+func (l *layout) table(cols []column, cells []string, ruled bool) {
+	l.line(0)
+	for i, c := range cells {
+		switch {
+		case c == "":
+		case cols[i].amount:
+			l.text(cols[i].x-l.doc.TextWidth(c, textSize), textSize, c)
+		default:
+			width := right - cols[i].x
+			if i+1 < len(cols) {
+				width = cols[i+1].x - cols[i].x - 6
+				if cols[i+1].amount {
+					width -= 70
+				}
+			}
+			l.text(cols[i].x, textSize, l.fit(c, width))
+		}
+	}
+	if ruled {
+		l.page.Line(margin, l.y-3, right, l.y-3, 0.5)
+	}
+}
+
+// fit cuts s to the width.
+func (l *layout) fit(s string, width float64) string {
+	if l.doc.TextWidth(s, textSize) <= width {
+		return s
+	}
+	r := []rune(s)
+	for len(r) > 0 && l.doc.TextWidth(string(r)+"...", textSize) > width {
+		r = r[:len(r)-1]
+	}
+	return string(r) + "..."
+}
+
+func id(v uint) string {
+	return strconv.FormatUint(uint64(v), 10)
+}
+
+// formatAmount formats kopecks as roubles.
+func formatAmount(kopecks int64) string {
+	sign := ""
+	if kopecks < 0 {
+		sign, kopecks = "-", -kopecks
+	}
+	k := strconv.FormatInt(kopecks%100, 10)
+	if len(k) < 2 {
+		k = "0" + k
+	}
+	return sign + strconv.FormatInt(kopecks/100, 10) + "." + k
+}
@@ -0,0 +1,119 @@
+package ledgerservice
+
+import (
+	"bytes"
+	"compress/zlib"
+	"io/ioutil"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"microsrv/xlsx"
+)
+
+func testStatement(calculations int) Statement {
+	date := time.Date(2019, 3, 1, 0, 0, 0, 0, time.UTC)
+	tc := code(3, calc(Reward, Debtor, false, 10000), calc(Transfer, Debtor, true, 4000))
+	for i := 0; i < calculations; i++ {
+		tc.Calculations = append(tc.Calculations, calc(Cost, Manager, false, 100))
+	}
+	for i := range tc.Calculations {
+		tc.Calculations[i].Date = date
+		tc.Calculations[i].Description = "payment"
+	}
+	tc.Calculations[0].Description = strings.Repeat("very long description ", 10)
+	st := NewStatement(7, []TradingCode{tc}, date)
+	st.BiddingName = "Auction"
+	st.DebtorID = 5
+	return st
+}
+
+func TestWriteStatementXLSX(t *testing.T) {
+	st := testStatement(0)
+	buf := &bytes.Buffer{}
+	if err := WriteStatement(buf, st, "xlsx", nil); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := xlsx.ReadRows(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := statementRows(st); !reflect.DeepEqual(rows, want) {
+		t.Fatalf("read %q, want %q", rows, want)
+	}
+	for _, want := range [][]string{
+		{"Bidding", "7", "Auction"},
+		{"2019-03-01", "transfer", "debtor", "payment", "-40.00"},
+		{"", "", "", "Net", "60.00"},
+		{"reward", "debtor", "100.00", "0.00", "100.00"},
+		{"Debtor owes the organiser", "60.00"},
+		{"Organiser owes the debtor", "0.00"},
+	} {
+		found := false
+		for _, row := range rows {
+			found = found || reflect.DeepEqual(row, want)
+		}
+		if !found {
+			t.Errorf("no row %q", want)
+		}
+	}
+}
+
+var streams = regexp.MustCompile(`(?s)/FlateDecode >>\nstream\n(.*?)\nendstream`)
+
+// pdfText returns the decompressed content streams of the document.
+func pdfText(t *testing.T, doc []byte) string {
+	text := ""
+	for _, m := range streams.FindAllSubmatch(doc, -1) {
+		z, err := zlib.NewReader(bytes.NewReader(m[1]))
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := ioutil.ReadAll(z)
+		if err != nil {
+			t.Fatal(err)
+		}
+		text += string(b)
+	}
+	return text
+}
+
+func TestWriteStatementPDF(t *testing.T) {
+	tests := []struct {
+		name         string
+		calculations int
+		pages        int
+	}{
+		{"one page", 0, 1},
+		{"page breaks", 100, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			if err := WriteStatement(buf, testStatement(tt.calculations), "pdf", nil); err != nil {
+				t.Fatal(err)
+			}
+			doc := buf.Bytes()
+			if !bytes.HasPrefix(doc, []byte("%PDF-1.4")) || !bytes.HasSuffix(doc, []byte("%%EOF\n")) {
+				t.Fatalf("not a PDF document: %.20q", doc)
+			}
+			if n := bytes.Count(doc, []byte("/Type /Page /")); n != tt.pages {
+				t.Errorf("%d pages, want %d", n, tt.pages)
+			}
+			text := pdfText(t, doc)
+			for _, want := range []string{"(Bidding 7 Auction)", "(-40.00)", "(60.00)", "(Debtor owes the organiser)", "(Organiser owes the debtor)", "...)"} {
+				if !strings.Contains(text, want) {
+					t.Errorf("no text %s", want)
+				}
+			}
+		})
+	}
+}
+
+func TestWriteStatementFormat(t *testing.T) {
+	if err := WriteStatement(ioutil.Discard, testStatement(0), "csv", nil); err != ErrExportFormat {
+		t.Errorf("WriteStatement as csv: %v, want ErrExportFormat", err)
+	}
+}
@@ -0,0 +1,203 @@
+package ledgerservice
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// Type of a calculation, numbered as pb.Types.
+type Type int
+
+// Types of the calculations.
+const (
+	Reward Type = iota
+	Compensation
+	Cost
+	Transfer
+)
+
+// Types lists the calculation types in order.
+var Types = []Type{Reward, Compensation, Cost, Transfer}
+
+var typeNames = []string{"reward", "compensation", "cost", "transfer"}
+
+func (t Type) String() string {
+	if t < 0 || int(t) >= len(typeNames) {
+		return "unknown"
+	}
+	return typeNames[t]
+}
+
+// Source of a calculation, the party it is settled with, numbered as
+// pb.Source.
+type Source int
+
+// Sources of the calculations.
+const (
+	Debtor Source = iota
+	Manager
+	Other
+)
+
+// Sources lists the calculation sources in order.
+var Sources = []Source{Debtor, Manager, Other}
+
+var sourceNames = []string{"debtor", "manager", "other"}
+
+func (s Source) String() string {
+	if s < 0 || int(s) >= len(sourceNames) {
+		return "unknown"
+	}
+	return sourceNames[s]
+}
+
+// TradingCode is a lot of a bidding, the calculations of the organiser are
+// recorded against it.
+type TradingCode struct {
+	gorm.Model
+	Name           string
+	BiddingID      uint
+	SourceID       uint
+	OrganisationID uint
+	Calculations   []Calculation `gorm:"foreignkey:TradingCodeID"`
+}
+
+// TableName of the trading codes.
+func (TradingCode) TableName() string {
+	return "trading_codes"
+}
+
+// Calculation is an entry of the ledger of a trading code. Costs is the
+// positive amount in kopecks owed to the organiser by the source, or owed
+// by the organiser to the source when Sign is set, like a transfer
+// received. Calculations are never changed, a wrong one is corrected by
+// recording the opposite one.
+type Calculation struct {
+	ID               uint `gorm:"primary_key"`
+	TradingCodeID    uint
+	Sign             bool
+	Costs            int64
+	Type             Type
+	Source           Source
+	OriginalFileName string
+	File             string
+	Description      string
+	Date             time.Time
+	CreatorID        uint
+	CreatedAt        time.Time
+}
+
+// TableName of the calculations.
+func (Calculation) TableName() string {
+	return "calculations"
+}
+
+// Amount returns the amount owed to the organiser, negative when it is
+// owed by the organiser.
+func (c Calculation) Amount() int64 {
+	if c.Sign {
+		return -c.Costs
+	}
+	return c.Costs
+}
+
+// Balance of the calculations of a type and a source, in kopecks.
+// Receivable sums the amounts owed to the organiser, Payable those owed by
+// the organiser and Net is their difference.
+type Balance struct {
+	Type       Type
+	Source     Source
+	Receivable int64
+	Payable    int64
+	Net        int64
+}
+
+// Balances returns the balances of the calculations for every type and
+// source having some, by type then source.
+func Balances(calculations []Calculation) []Balance {
+	sums := map[[2]int]*Balance{}
+	for _, c := range calculations {
+		k := [2]int{int(c.Type), int(c.Source)}
+		b := sums[k]
+		if b == nil {
+			b = &Balance{Type: c.Type, Source: c.Source}
+			sums[k] = b
+		}
+		if c.Sign {
+			b.Payable += c.Costs
+		} else {
+			b.Receivable += c.Costs
+		}
+		b.Net += c.Amount()
+	}
+	res := []Balance{}
+	for _, t := range Types {
+		for _, s := range Sources {
+			if b := sums[[2]int{int(t), int(s)}]; b != nil {
+				res = append(res, *b)
+			}
+		}
+	}
+	return res
+}
+
+// Statement is the settlement of the calculations of the trading codes of
+// a bidding between its organiser and the other parties.
+type Statement struct {
+	BiddingID    uint
+	BiddingName  string
+	DebtorID     uint
+	TradingCodes []CodeBalance
+	// Totals are the balances over all the trading codes.
+	Totals []Balance
+	// Settlements are the net amounts per source, owed to the organiser
+	// when positive.
+	Settlements []Settlement
+	// DebtorOwes is what the debtor owes the organiser, OrganiserOwes what
+	// the organiser owes the debtor; one of them is zero.
+	DebtorOwes    int64
+	OrganiserOwes int64
+	CreatedAt     time.Time
+}
+
+// CodeBalance is a trading code, with its calculations, and its balances.
+type CodeBalance struct {
+	TradingCode TradingCode
+	Balances    []Balance
+	Net         int64
+}
+
+// Settlement is the net amount of the calculations with a source.
+type Settlement struct {
+	Source Source
+	Net    int64
+}
+
+// NewStatement returns the statement of the trading codes of the bidding.
+func NewStatement(bidding uint, codes []TradingCode, now time.Time) Statement {
+	st := Statement{BiddingID: bidding, TradingCodes: []CodeBalance{}, CreatedAt: now}
+	all := []Calculation{}
+	for _, tc := range codes {
+		cb := CodeBalance{TradingCode: tc, Balances: Balances(tc.Calculations)}
+		for _, c := range tc.Calculations {
+			cb.Net += c.Amount()
+		}
+		st.TradingCodes = append(st.TradingCodes, cb)
+		all = append(all, tc.Calculations...)
+	}
+	st.Totals = Balances(all)
+	nets := map[Source]int64{}
+	for _, b := range st.Totals {
+		nets[b.Source] += b.Net
+	}
+	for _, s := range Sources {
+		st.Settlements = append(st.Settlements, Settlement{Source: s, Net: nets[s]})
+	}
+	if n := nets[Debtor]; n > 0 {
+		st.DebtorOwes = n
+	} else {
+		st.OrganiserOwes = -n
+	}
+	return st
+}
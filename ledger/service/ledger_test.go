@@ -0,0 +1,139 @@
+package ledgerservice
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+func calc(t Type, s Source, sign bool, costs int64) Calculation {
+	return Calculation{Type: t, Source: s, Sign: sign, Costs: costs}
+}
+
+func TestBalances(t *testing.T) {
+	tests := []struct {
+		name         string
+		calculations []Calculation
+		want         []Balance
+	}{
+		{"none", nil, []Balance{}},
+		{
+			"mixed signs",
+			[]Calculation{calc(Reward, Debtor, false, 10000), calc(Reward, Debtor, true, 2500), calc(Reward, Debtor, false, 500)},
+			[]Balance{{Type: Reward, Source: Debtor, Receivable: 10500, Payable: 2500, Net: 8000}},
+		},
+		{
+			"zero net",
+			[]Calculation{calc(Cost, Manager, false, 700), calc(Cost, Manager, true, 700)},
+			[]Balance{{Type: Cost, Source: Manager, Receivable: 700, Payable: 700, Net: 0}},
+		},
+		{
+			"ordered by type then source",
+			[]Calculation{
+				calc(Transfer, Debtor, true, 300),
+				calc(Reward, Other, false, 100),
+				calc(Reward, Debtor, false, 200),
+				calc(Compensation, Manager, true, 50),
+			},
+			[]Balance{
+				{Type: Reward, Source: Debtor, Receivable: 200, Net: 200},
+				{Type: Reward, Source: Other, Receivable: 100, Net: 100},
+				{Type: Compensation, Source: Manager, Payable: 50, Net: -50},
+				{Type: Transfer, Source: Debtor, Payable: 300, Net: -300},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Balances(tt.calculations); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Balances = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func code(id uint, calculations ...Calculation) TradingCode {
+	return TradingCode{Model: gorm.Model{ID: id}, Name: "lot", Calculations: calculations}
+}
+
+func TestNewStatement(t *testing.T) {
+	now := time.Date(2019, 3, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name          string
+		codes         []TradingCode
+		nets          []int64
+		settlements   []int64
+		debtorOwes    int64
+		organiserOwes int64
+	}{
+		{"no codes", nil, []int64{}, []int64{0, 0, 0}, 0, 0},
+		{
+			"debtor owes",
+			[]TradingCode{code(1, calc(Reward, Debtor, false, 10000), calc(Transfer, Debtor, true, 4000))},
+			[]int64{6000}, []int64{6000, 0, 0}, 6000, 0,
+		},
+		{
+			"organiser owes",
+			[]TradingCode{code(1, calc(Reward, Debtor, false, 1000), calc(Transfer, Debtor, true, 4000))},
+			[]int64{-3000}, []int64{-3000, 0, 0}, 0, 3000,
+		},
+		{
+			"netted over several codes",
+			[]TradingCode{
+				code(1, calc(Reward, Debtor, false, 5000), calc(Cost, Manager, false, 800)),
+				code(2, calc(Transfer, Debtor, true, 7000), calc(Compensation, Other, true, 200)),
+				code(3, calc(Cost, Debtor, false, 500)),
+			},
+			[]int64{5800, -7200, 500}, []int64{-1500, 800, -200}, 0, 1500,
+		},
+		{
+			"zero net",
+			[]TradingCode{
+				code(1, calc(Reward, Debtor, false, 2500)),
+				code(2, calc(Transfer, Debtor, true, 2500), calc(Cost, Manager, false, 300)),
+			},
+			[]int64{2500, -2200}, []int64{0, 300, 0}, 0, 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st := NewStatement(7, tt.codes, now)
+			if st.BiddingID != 7 || !st.CreatedAt.Equal(now) {
+				t.Errorf("statement of bidding %d at %v", st.BiddingID, st.CreatedAt)
+			}
+			nets := []int64{}
+			for i, cb := range st.TradingCodes {
+				nets = append(nets, cb.Net)
+				if want := Balances(tt.codes[i].Calculations); !reflect.DeepEqual(cb.Balances, want) {
+					t.Errorf("code %d balances %+v, want %+v", cb.TradingCode.ID, cb.Balances, want)
+				}
+			}
+			if !reflect.DeepEqual(nets, tt.nets) {
+				t.Errorf("code nets %v, want %v", nets, tt.nets)
+			}
+			settlements := []int64{}
+			for i, s := range st.Settlements {
+				if s.Source != Sources[i] {
+					t.Errorf("settlement %d of %s, want %s", i, s.Source, Sources[i])
+				}
+				settlements = append(settlements, s.Net)
+			}
+			if !reflect.DeepEqual(settlements, tt.settlements) {
+				t.Errorf("settlements %v, want %v", settlements, tt.settlements)
+			}
+			if st.DebtorOwes != tt.debtorOwes || st.OrganiserOwes != tt.organiserOwes {
+				t.Errorf("debtor owes %d, organiser owes %d, want %d, %d", st.DebtorOwes, st.OrganiserOwes, tt.debtorOwes, tt.organiserOwes)
+			}
+		})
+	}
+}
+
+func TestFormatAmount(t *testing.T) {
+	for kopecks, want := range map[int64]string{0: "0.00", 5: "0.05", 12345: "123.45", -100: "-1.00", -7: "-0.07"} {
+		if got := formatAmount(kopecks); got != want {
+			t.Errorf("formatAmount(%d) = %q, want %q", kopecks, got, want)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package ledgerservice
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/go-kit/kit/log"
+)
+
+// Middleware describes a service (as opposed to endpoint) middleware.
+type Middleware func(Service) Service
+
+// LoggingMiddleware takes a logger as a dependency and returns a ServiceMiddleware.
+func LoggingMiddleware(logger log.Logger) Middleware {
+	return func(next Service) Service {
+		return loggingMiddleware{next, logger}
+	}
+}
+
+type loggingMiddleware struct {
+	next   Service
+	logger log.Logger
+}
+
+// Health func
+func (mw loggingMiddleware) Health() bool {
+	defer func(begin time.Time) {
+		mw.logger.Log(
+			"method", "Health",
+			"healthy", true,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return mw.next.Health()
+}
+
+// CreateTradingCode func
+func (mw loggingMiddleware) CreateTradingCode(ctx context.Context, tc TradingCode) (res TradingCode, err error) {
+	defer func(begin time.Time) {
+		mw.logger.Log(
+			"method", "CreateTradingCode",
+			"TradingCode.ID", res.ID,
+			"BiddingID", tc.BiddingID,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return mw.next.CreateTradingCode(ctx, tc)
+}
+
+// GetTradingCode func
+func (mw loggingMiddleware) GetTradingCode(ctx context.Context, id uint) (TradingCode, error) {
+	defer func(begin time.Time) {
+		mw.logger.Log(
+			"method", "GetTradingCode",
+			"TradingCode.ID", id,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return mw.next.GetTradingCode(ctx, id)
+}
+
+// ListTradingCodes func
+func (mw loggingMiddleware) ListTradingCodes(ctx context.Context, biddingID uint) (res []TradingCode, err error) {
+	defer func(begin time.Time) {
+		mw.logger.Log(
+			"method", "ListTradingCodes",
+			"BiddingID", biddingID,
+			"count", len(res),
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return mw.next.ListTradingCodes(ctx, biddingID)
+}
+
+// AddCalculation func
+func (mw loggingMiddleware) AddCalculation(ctx context.Context, c Calculation) (res Calculation, err error) {
+	defer func(begin time.Time) {
+		mw.logger.Log(
+			"method", "AddCalculation",
+			"Calculation.ID", res.ID,
+			"TradingCodeID", c.TradingCodeID,
+			"amount", c.Amount(),
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return mw.next.AddCalculation(ctx, c)
+}
+
+// Balances func
+func (mw loggingMiddleware) Balances(ctx context.Context, tradingCodeID uint) (res []Balance, err error) {
+	defer func(begin time.Time) {
+		mw.logger.Log(
+			"method", "Balances",
+			"TradingCodeID", tradingCodeID,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return mw.next.Balances(ctx, tradingCodeID)
+}
+
+// Statement func
+func (mw loggingMiddleware) Statement(ctx context.Context, biddingID uint) (res Statement, err error) {
+	defer func(begin time.Time) {
+		mw.logger.Log(
+			"method", "Statement",
+			"BiddingID", biddingID,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return mw.next.Statement(ctx, biddingID)
+}
+
+// ExportStatement func
+func (mw loggingMiddleware) ExportStatement(ctx context.Context, biddingID uint, format string, w io.Writer) (err error) {
+	defer func(begin time.Time) {
+		mw.logger.Log(
+			"method", "ExportStatement",
+			"BiddingID", biddingID,
+			"format", format,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return mw.next.ExportStatement(ctx, biddingID, format, w)
+}
@@ -0,0 +1,166 @@
+package ledgerservice
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"microsrv/apperr"
+	"microsrv/pdf"
+)
+
+// Service keeps the ledger of the calculations of the trading codes.
+type Service interface {
+	Health() bool
+	CreateTradingCode(ctx context.Context, tc TradingCode) (TradingCode, error)
+	GetTradingCode(ctx context.Context, id uint) (TradingCode, error)
+	ListTradingCodes(ctx context.Context, biddingID uint) ([]TradingCode, error)
+	// AddCalculation records a calculation, dated today when it has no
+	// date.
+	AddCalculation(ctx context.Context, c Calculation) (Calculation, error)
+	// Balances returns the balances of the trading code per type and
+	// source.
+	Balances(ctx context.Context, tradingCodeID uint) ([]Balance, error)
+	// Statement returns the settlement statement of the bidding.
+	Statement(ctx context.Context, biddingID uint) (Statement, error)
+	// ExportStatement writes the settlement statement of the bidding to w
+	// in the format, pdf or xlsx.
+	ExportStatement(ctx context.Context, biddingID uint, format string, w io.Writer) error
+}
+
+var (
+	// ErrAlreadyExists var
+	ErrAlreadyExists = apperr.NewConflict("trading code already exists")
+	// ErrNotFound var
+	ErrNotFound = apperr.NewNotFound("trading code not found")
+	// ErrBiddingNotFound var
+	ErrBiddingNotFound = apperr.NewNotFound("bidding not found")
+	// ErrUnknownBidding var
+	ErrUnknownBidding = apperr.NewValidation("unknown bidding", apperr.Field{Field: "biddingID", Description: "must be an existing bidding"})
+	// ErrUnknownTradingCode var
+	ErrUnknownTradingCode = apperr.NewValidation("unknown trading code", apperr.Field{Field: "tradingCodeID", Description: "must be an existing trading code"})
+)
+
+// NewBasicService returns a Service writing the PDF statements in the
+// font, in Courier when it is nil.
+func NewBasicService(store Store, font *pdf.Font) Service {
+	return basicService{store: store, font: font}
+}
+
+type basicService struct {
+	store Store
+	font  *pdf.Font
+}
+
+// Health implementation of the Service.
+func (s basicService) Health() bool {
+	return s.store.Ping() == nil
+}
+
+func (s basicService) CreateTradingCode(ctx context.Context, tc TradingCode) (TradingCode, error) {
+	if err := ValidateTradingCode(tc); err != nil {
+		return TradingCode{}, err
+	}
+	if _, err := s.store.Bidding(ctx, tc.BiddingID); err != nil {
+		return TradingCode{}, err
+	}
+	if err := s.store.CreateTradingCode(ctx, &tc); err != nil {
+		return TradingCode{}, err
+	}
+	return s.store.GetTradingCode(ctx, tc.ID)
+}
+
+func (s basicService) GetTradingCode(ctx context.Context, id uint) (TradingCode, error) {
+	return s.store.GetTradingCode(ctx, id)
+}
+
+func (s basicService) ListTradingCodes(ctx context.Context, biddingID uint) ([]TradingCode, error) {
+	return s.store.ListTradingCodes(ctx, biddingID)
+}
+
+func (s basicService) AddCalculation(ctx context.Context, c Calculation) (Calculation, error) {
+	if err := ValidateCalculation(c); err != nil {
+		return Calculation{}, err
+	}
+	c.ID = 0
+	if c.Date.IsZero() {
+		now := time.Now()
+		c.Date = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	}
+	if err := s.store.AddCalculation(ctx, &c); err != nil {
+		return Calculation{}, err
+	}
+	return c, nil
+}
+
+func (s basicService) Balances(ctx context.Context, tradingCodeID uint) ([]Balance, error) {
+	tc, err := s.store.GetTradingCode(ctx, tradingCodeID)
+	if err != nil {
+		return nil, err
+	}
+	return Balances(tc.Calculations), nil
+}
+
+func (s basicService) Statement(ctx context.Context, biddingID uint) (Statement, error) {
+	b, err := s.store.Bidding(ctx, biddingID)
+	if err == ErrUnknownBidding {
+		return Statement{}, ErrBiddingNotFound
+	}
+	if err != nil {
+		return Statement{}, err
+	}
+	codes, err := s.store.ListTradingCodes(ctx, biddingID)
+	if err != nil {
+		return Statement{}, err
+	}
+	st := NewStatement(biddingID, codes, time.Now())
+	st.BiddingName, st.DebtorID = b.Name, b.DebtorID
+	return st, nil
+}
+
+func (s basicService) ExportStatement(ctx context.Context, biddingID uint, format string, w io.Writer) error {
+	if _, ok := ExportContentTypes[format]; !ok {
+		return ErrExportFormat
+	}
+	st, err := s.Statement(ctx, biddingID)
+	if err != nil {
+		return err
+	}
+	return WriteStatement(w, st, format, s.font)
+}
+
+// ValidateTradingCode checks the fields of a trading code.
+func ValidateTradingCode(tc TradingCode) error {
+	var fields []apperr.Field
+	if tc.Name == "" {
+		fields = append(fields, apperr.Field{Field: "name", Description: "is required"})
+	}
+	if tc.BiddingID == 0 {
+		fields = append(fields, apperr.Field{Field: "biddingID", Description: "is required"})
+	}
+	if len(fields) > 0 {
+		return apperr.NewValidation("invalid trading code", fields...)
+	}
+	return nil
+}
+
+// ValidateCalculation checks the fields of a calculation.
+func ValidateCalculation(c Calculation) error {
+	var fields []apperr.Field
+	if c.TradingCodeID == 0 {
+		fields = append(fields, apperr.Field{Field: "tradingCodeID", Description: "is required"})
+	}
+	if c.Costs <= 0 {
+		fields = append(fields, apperr.Field{Field: "costs", Description: "must be positive, the sign gives the direction"})
+	}
+	if c.Type < Reward || c.Type > Transfer {
+		fields = append(fields, apperr.Field{Field: "type", Description: "is unknown"})
+	}
+	if c.Source < Debtor || c.Source > Other {
+		fields = append(fields, apperr.Field{Field: "source", Description: "is unknown"})
+	}
+	if len(fields) > 0 {
+		return apperr.NewValidation("invalid calculation", fields...)
+	}
+	return nil
+}
@@ -0,0 +1,195 @@
+package ledgerservice
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"microsrv/config"
+	"microsrv/model"
+
+	"github.com/jinzhu/gorm"
+	_ "github.com/jinzhu/gorm/dialects/mysql"    // Mysql driver
+	_ "github.com/jinzhu/gorm/dialects/postgres" // Postgres driver
+)
+
+// Store persists the trading codes and their calculations.
+type Store interface {
+	CreateTradingCode(ctx context.Context, tc *TradingCode) error
+	// GetTradingCode returns the trading code with its calculations by
+	// date.
+	GetTradingCode(ctx context.Context, id uint) (TradingCode, error)
+	// ListTradingCodes returns the trading codes of the bidding with their
+	// calculations.
+	ListTradingCodes(ctx context.Context, biddingID uint) ([]TradingCode, error)
+	AddCalculation(ctx context.Context, c *Calculation) error
+	// Bidding returns the bidding with the id, ErrUnknownBidding if there
+	// is none.
+	Bidding(ctx context.Context, id uint) (model.Bidding, error)
+	Ping() error
+}
+
+// NewStore returns the Store selected by cfg.Driver. The tables are
+// created by the migrations of package migrate.
+func NewStore(cfg config.DB) (Store, error) {
+	switch cfg.Driver {
+	case "", "mysql":
+		return NewDB("mysql", cfg.DSN())
+	case "postgres":
+		return NewDB("postgres", cfg.DSN())
+	case "memory":
+		return NewMemStore(), nil
+	default:
+		return nil, fmt.Errorf("unknown database driver %q", cfg.Driver)
+	}
+}
+
+type databaseStore struct{ db *gorm.DB }
+
+// NewDB returns a Store backed by MySQL or PostgreSQL.
+func NewDB(dialect, DSN string) (Store, error) {
+	db, err := gorm.Open(dialect, DSN)
+	if err != nil {
+		return nil, err
+	}
+	return &databaseStore{db: db}, nil
+}
+
+// calculations preloads the calculations by date.
+func calculations(db *gorm.DB) *gorm.DB {
+	return db.Order("date, id")
+}
+
+func (ds *databaseStore) CreateTradingCode(ctx context.Context, tc *TradingCode) error {
+	if tc.ID != 0 && !ds.db.Unscoped().First(&TradingCode{}, tc.ID).RecordNotFound() {
+		return ErrAlreadyExists
+	}
+	return ds.db.Create(tc).Error
+}
+
+func (ds *databaseStore) GetTradingCode(ctx context.Context, id uint) (TradingCode, error) {
+	tc := TradingCode{}
+	err := ds.db.Preload("Calculations", calculations).First(&tc, id).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return tc, ErrNotFound
+	}
+	return tc, err
+}
+
+func (ds *databaseStore) ListTradingCodes(ctx context.Context, biddingID uint) ([]TradingCode, error) {
+	res := []TradingCode{}
+	err := ds.db.Preload("Calculations", calculations).
+		Where("bidding_id = ?", biddingID).Order("id").Find(&res).Error
+	return res, err
+}
+
+func (ds *databaseStore) AddCalculation(ctx context.Context, c *Calculation) error {
+	if ds.db.First(&TradingCode{}, c.TradingCodeID).RecordNotFound() {
+		return ErrUnknownTradingCode
+	}
+	return ds.db.Create(c).Error
+}
+
+func (ds *databaseStore) Bidding(ctx context.Context, id uint) (model.Bidding, error) {
+	b := model.Bidding{}
+	err := ds.db.First(&b, id).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return b, ErrUnknownBidding
+	}
+	return b, err
+}
+
+func (ds *databaseStore) Ping() error {
+	return ds.db.DB().Ping()
+}
+
+type memStore struct {
+	mtx          sync.RWMutex
+	codes        map[uint]TradingCode
+	calculations []Calculation
+	nextCode     uint
+}
+
+// NewMemStore returns an in-memory Store. It has no biddings table, every
+// bidding is taken to exist.
+func NewMemStore() Store {
+	return &memStore{codes: map[uint]TradingCode{}}
+}
+
+func (s *memStore) CreateTradingCode(ctx context.Context, tc *TradingCode) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if tc.ID == 0 {
+		s.nextCode++
+		tc.ID = s.nextCode
+	} else if _, ok := s.codes[tc.ID]; ok {
+		return ErrAlreadyExists
+	} else if tc.ID > s.nextCode {
+		s.nextCode = tc.ID
+	}
+	now := time.Now()
+	tc.CreatedAt, tc.UpdatedAt, tc.DeletedAt = now, now, nil
+	tc.Calculations = nil
+	s.codes[tc.ID] = *tc
+	return nil
+}
+
+func (s *memStore) GetTradingCode(ctx context.Context, id uint) (TradingCode, error) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	tc, ok := s.codes[id]
+	if !ok {
+		return TradingCode{}, ErrNotFound
+	}
+	return s.withCalculations(tc), nil
+}
+
+func (s *memStore) ListTradingCodes(ctx context.Context, biddingID uint) ([]TradingCode, error) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	res := []TradingCode{}
+	for _, tc := range s.codes {
+		if tc.BiddingID == biddingID {
+			res = append(res, s.withCalculations(tc))
+		}
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].ID < res[j].ID })
+	return res, nil
+}
+
+func (s *memStore) withCalculations(tc TradingCode) TradingCode {
+	tc.Calculations = []Calculation{}
+	for _, c := range s.calculations {
+		if c.TradingCodeID == tc.ID {
+			tc.Calculations = append(tc.Calculations, c)
+		}
+	}
+	sort.SliceStable(tc.Calculations, func(i, j int) bool {
+		return tc.Calculations[i].Date.Before(tc.Calculations[j].Date)
+	})
+	return tc
+}
+
+func (s *memStore) AddCalculation(ctx context.Context, c *Calculation) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if _, ok := s.codes[c.TradingCodeID]; !ok {
+		return ErrUnknownTradingCode
+	}
+	c.ID = uint(len(s.calculations) + 1)
+	c.CreatedAt = time.Now()
+	s.calculations = append(s.calculations, *c)
+	return nil
+}
+
+func (s *memStore) Bidding(ctx context.Context, id uint) (model.Bidding, error) {
+	b := model.Bidding{}
+	b.ID = id
+	return b, nil
+}
+
+func (s *memStore) Ping() error {
+	return nil
+}
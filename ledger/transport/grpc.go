@@ -0,0 +1,358 @@
+package transport
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"math"
+
+	"microsrv/apperr"
+	"microsrv/ledger/endpoint"
+	"microsrv/ledger/service"
+	"microsrv/pb"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/log"
+	grpctransport "github.com/go-kit/kit/transport/grpc"
+	"github.com/golang/protobuf/ptypes"
+	oldcontext "golang.org/x/net/context"
+)
+
+// chunkSize is the size of the data of the chunks of an export.
+const chunkSize = 64 << 10
+
+type grpcServer struct {
+	createTradingCode grpctransport.Handler
+	getTradingCode    grpctransport.Handler
+	listTradingCodes  grpctransport.Handler
+	addCalculation    grpctransport.Handler
+	getBalances       grpctransport.Handler
+	getStatement      grpctransport.Handler
+	exportStatement   endpoint.Endpoint
+}
+
+// NewGRPCServer makes a set of endpoints available as a gRPC LedgerSvcServer.
+func NewGRPCServer(endpoints ledgerendpoint.Endpoints, logger log.Logger) pb.LedgerSvcServer {
+	options := []grpctransport.ServerOption{
+		grpctransport.ServerErrorLogger(logger),
+	}
+
+	return &grpcServer{
+		createTradingCode: grpctransport.NewServer(
+			endpoints.CreateTradingCodeEndpoint,
+			decodeGRPCTradingCode,
+			encodeGRPCTradingCodeResponse,
+			options...,
+		),
+		getTradingCode: grpctransport.NewServer(
+			endpoints.GetTradingCodeEndpoint,
+			decodeGRPCPassThrough,
+			encodeGRPCTradingCodeResponse,
+			options...,
+		),
+		listTradingCodes: grpctransport.NewServer(
+			endpoints.ListTradingCodesEndpoint,
+			decodeGRPCPassThrough,
+			encodeGRPCTradingCodesResponse,
+			options...,
+		),
+		addCalculation: grpctransport.NewServer(
+			endpoints.AddCalculationEndpoint,
+			decodeGRPCCalculation,
+			encodeGRPCCalculationResponse,
+			options...,
+		),
+		getBalances: grpctransport.NewServer(
+			endpoints.BalancesEndpoint,
+			decodeGRPCPassThrough,
+			encodeGRPCBalancesResponse,
+			options...,
+		),
+		getStatement: grpctransport.NewServer(
+			endpoints.StatementEndpoint,
+			decodeGRPCPassThrough,
+			encodeGRPCStatementResponse,
+			options...,
+		),
+		exportStatement: endpoints.ExportStatementEndpoint,
+	}
+}
+
+// CreateTradingCode implementation of the method of the LedgerSvcServer
+// interface.
+func (s *grpcServer) CreateTradingCode(ctx oldcontext.Context, req *pb.TradingCode) (*pb.TradingCode, error) {
+	_, res, err := s.createTradingCode.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, apperr.ToGRPC(err)
+	}
+	return res.(*pb.TradingCode), nil
+}
+
+// GetTradingCode implementation of the method of the LedgerSvcServer
+// interface.
+func (s *grpcServer) GetTradingCode(ctx oldcontext.Context, req *pb.TradingCodeByID) (*pb.TradingCode, error) {
+	_, res, err := s.getTradingCode.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, apperr.ToGRPC(err)
+	}
+	return res.(*pb.TradingCode), nil
+}
+
+// ListTradingCodes implementation of the method of the LedgerSvcServer
+// interface.
+func (s *grpcServer) ListTradingCodes(ctx oldcontext.Context, req *pb.BiddingByID) (*pb.TradingCodes, error) {
+	_, res, err := s.listTradingCodes.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, apperr.ToGRPC(err)
+	}
+	return res.(*pb.TradingCodes), nil
+}
+
+// AddCalculation implementation of the method of the LedgerSvcServer
+// interface.
+func (s *grpcServer) AddCalculation(ctx oldcontext.Context, req *pb.Calculation) (*pb.Calculation, error) {
+	_, res, err := s.addCalculation.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, apperr.ToGRPC(err)
+	}
+	return res.(*pb.Calculation), nil
+}
+
+// GetBalances implementation of the method of the LedgerSvcServer
+// interface.
+func (s *grpcServer) GetBalances(ctx oldcontext.Context, req *pb.TradingCodeByID) (*pb.Balances, error) {
+	_, res, err := s.getBalances.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, apperr.ToGRPC(err)
+	}
+	return res.(*pb.Balances), nil
+}
+
+// GetStatement implementation of the method of the LedgerSvcServer
+// interface.
+func (s *grpcServer) GetStatement(ctx oldcontext.Context, req *pb.BiddingByID) (*pb.SettlementStatement, error) {
+	_, res, err := s.getStatement.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, apperr.ToGRPC(err)
+	}
+	return res.(*pb.SettlementStatement), nil
+}
+
+// ExportStatement implementation of the method of the LedgerSvcServer
+// interface. The first chunk carries the content type and the file name of
+// the export.
+func (s *grpcServer) ExportStatement(req *pb.StatementExportRequest, stream pb.LedgerSvc_ExportStatementServer) error {
+	res, err := s.exportStatement(stream.Context(), ledgerendpoint.ExportRequest{BiddingID: uint(req.BiddingID), Format: req.Format})
+	if err == nil {
+		err = res.(ledgerendpoint.ExportResponse).Err
+	}
+	if err != nil {
+		return apperr.ToGRPC(err)
+	}
+	export := res.(ledgerendpoint.ExportResponse)
+	cw := &chunkWriter{stream: stream, file: &pb.File{
+		ContentType:      ledgerservice.ExportContentTypes[export.Format],
+		OriginalFileName: exportFileName(export),
+	}}
+	bw := bufio.NewWriterSize(cw, chunkSize)
+	if err := export.Write(bw); err != nil {
+		return apperr.ToGRPC(err)
+	}
+	return bw.Flush()
+}
+
+// chunkWriter sends each write as a chunk, the first one with the file.
+type chunkWriter struct {
+	stream pb.LedgerSvc_ExportStatementServer
+	file   *pb.File
+}
+
+func (cw *chunkWriter) Write(p []byte) (int, error) {
+	chunk := &pb.FileChunk{File: cw.file, Data: p}
+	cw.file = nil
+	if err := cw.stream.Send(chunk); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func exportFileName(r ledgerendpoint.ExportResponse) string {
+	return fmt.Sprintf("statement-%d.%s", r.BiddingID, r.Format)
+}
+
+func decodeGRPCPassThrough(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	return grpcReq, nil
+}
+
+func decodeGRPCTradingCode(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	return tradingCodeFromPB(grpcReq.(*pb.TradingCode)), nil
+}
+
+func decodeGRPCCalculation(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	return calculationFromPB(grpcReq.(*pb.Calculation))
+}
+
+func encodeGRPCTradingCodeResponse(_ context.Context, response interface{}) (interface{}, error) {
+	result := response.(ledgerendpoint.TradingCodeResponse)
+	if result.Err != nil {
+		return nil, result.Err
+	}
+	return tradingCodeToPB(result.TradingCode), nil
+}
+
+func encodeGRPCTradingCodesResponse(_ context.Context, response interface{}) (interface{}, error) {
+	result := response.(ledgerendpoint.TradingCodesResponse)
+	if result.Err != nil {
+		return nil, result.Err
+	}
+	return tradingCodesToPB(result.TradingCodes), nil
+}
+
+func encodeGRPCCalculationResponse(_ context.Context, response interface{}) (interface{}, error) {
+	result := response.(ledgerendpoint.CalculationResponse)
+	if result.Err != nil {
+		return nil, result.Err
+	}
+	return calculationToPB(result.Calculation), nil
+}
+
+func encodeGRPCBalancesResponse(_ context.Context, response interface{}) (interface{}, error) {
+	result := response.(ledgerendpoint.BalancesResponse)
+	if result.Err != nil {
+		return nil, result.Err
+	}
+	return &pb.Balances{TradingCodeID: uint32(result.TradingCodeID), Balances: balancesToPB(result.Balances)}, nil
+}
+
+func encodeGRPCStatementResponse(_ context.Context, response interface{}) (interface{}, error) {
+	result := response.(ledgerendpoint.StatementResponse)
+	if result.Err != nil {
+		return nil, result.Err
+	}
+	return statementToPB(result.Statement), nil
+}
+
+func tradingCodeToPB(tc ledgerservice.TradingCode) *pb.TradingCode {
+	res := &pb.TradingCode{
+		ID:             uint32(tc.ID),
+		Name:           tc.Name,
+		BiddingID:      uint32(tc.BiddingID),
+		SourceID:       uint32(tc.SourceID),
+		OrganisationID: uint32(tc.OrganisationID),
+	}
+	for _, c := range tc.Calculations {
+		res.Calculations = append(res.Calculations, calculationToPB(c))
+	}
+	return res
+}
+
+func tradingCodesToPB(codes []ledgerservice.TradingCode) *pb.TradingCodes {
+	res := &pb.TradingCodes{TradingCodes: make([]*pb.TradingCode, len(codes))}
+	for i, tc := range codes {
+		res.TradingCodes[i] = tradingCodeToPB(tc)
+	}
+	return res
+}
+
+// tradingCodeFromPB returns the trading code, its calculations are added
+// separately.
+func tradingCodeFromPB(tc *pb.TradingCode) ledgerservice.TradingCode {
+	res := ledgerservice.TradingCode{
+		Name:           tc.Name,
+		BiddingID:      uint(tc.BiddingID),
+		SourceID:       uint(tc.SourceID),
+		OrganisationID: uint(tc.OrganisationID),
+	}
+	res.ID = uint(tc.ID)
+	return res
+}
+
+func calculationToPB(c ledgerservice.Calculation) *pb.Calculation {
+	res := &pb.Calculation{
+		ID:               uint32(c.ID),
+		TradingCodeID:    uint32(c.TradingCodeID),
+		Sign:             c.Sign,
+		Costs:            roubles(c.Costs),
+		CostsKop:         c.Costs,
+		Type:             pb.Types(c.Type),
+		Source:           pb.Source(c.Source),
+		OriginalFileName: c.OriginalFileName,
+		File:             c.File,
+		Description:      c.Description,
+		CreatorID:        uint32(c.CreatorID),
+	}
+	if !c.Date.IsZero() {
+		res.Date, _ = ptypes.TimestampProto(c.Date)
+	}
+	return res
+}
+
+func calculationFromPB(c *pb.Calculation) (ledgerservice.Calculation, error) {
+	res := ledgerservice.Calculation{
+		TradingCodeID:    uint(c.TradingCodeID),
+		Sign:             c.Sign,
+		Costs:            c.CostsKop,
+		Type:             ledgerservice.Type(c.Type),
+		Source:           ledgerservice.Source(c.Source),
+		OriginalFileName: c.OriginalFileName,
+		File:             c.File,
+		Description:      c.Description,
+		CreatorID:        uint(c.CreatorID),
+	}
+	if res.Costs == 0 {
+		res.Costs = kopecks(c.Costs)
+	}
+	if c.Date != nil {
+		t, err := ptypes.Timestamp(c.Date)
+		if err != nil {
+			return res, apperr.NewValidation(err.Error(), apperr.Field{Field: "date", Description: "is invalid"})
+		}
+		res.Date = t
+	}
+	return res, nil
+}
+
+func balancesToPB(balances []ledgerservice.Balance) []*pb.Balance {
+	res := make([]*pb.Balance, len(balances))
+	for i, b := range balances {
+		res[i] = &pb.Balance{
+			Type:       pb.Types(b.Type),
+			Source:     pb.Source(b.Source),
+			Receivable: b.Receivable,
+			Payable:    b.Payable,
+			Net:        b.Net,
+		}
+	}
+	return res
+}
+
+func statementToPB(st ledgerservice.Statement) *pb.SettlementStatement {
+	res := &pb.SettlementStatement{
+		BiddingID:     uint32(st.BiddingID),
+		BiddingName:   st.BiddingName,
+		DebtorID:      uint32(st.DebtorID),
+		Totals:        balancesToPB(st.Totals),
+		DebtorOwes:    st.DebtorOwes,
+		OrganiserOwes: st.OrganiserOwes,
+	}
+	for _, cb := range st.TradingCodes {
+		res.TradingCodes = append(res.TradingCodes, &pb.TradingCodeBalance{
+			TradingCode: tradingCodeToPB(cb.TradingCode),
+			Balances:    balancesToPB(cb.Balances),
+			Net:         cb.Net,
+		})
+	}
+	for _, s := range st.Settlements {
+		res.Settlements = append(res.Settlements, &pb.Settlement{Source: pb.Source(s.Source), Net: s.Net})
+	}
+	res.CreatedAt, _ = ptypes.TimestampProto(st.CreatedAt)
+	return res
+}
+
+func kopecks(roubles float32) int64 {
+	return int64(math.Round(float64(roubles) * 100))
+}
+
+func roubles(kopecks int64) float32 {
+	return float32(kopecks) / 100
+}
@@ -0,0 +1,257 @@
+package transport
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"microsrv/apperr"
+	"microsrv/ledger/endpoint"
+	"microsrv/ledger/service"
+	"microsrv/pb"
+
+	"github.com/go-kit/kit/log"
+	httptransport "github.com/go-kit/kit/transport/http"
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/proto"
+	"github.com/gorilla/mux"
+)
+
+var (
+	// ErrBadRouting is returned when an expected path variable is missing.
+	ErrBadRouting = apperr.New(apperr.Internal, "inconsistent mapping between route and handler")
+)
+
+// badRequest reports a malformed request.
+func badRequest(err error) error {
+	return apperr.NewValidation(err.Error())
+}
+
+// NewHTTPHandler returns an HTTP handler that makes a set of endpoints
+// available on predefined paths.
+func NewHTTPHandler(endpoints ledgerendpoint.Endpoints, logger log.Logger) http.Handler {
+	m := mux.NewRouter()
+	options := []httptransport.ServerOption{
+		httptransport.ServerErrorEncoder(apperr.EncodeHTTPError),
+		httptransport.ServerErrorLogger(logger),
+	}
+
+	// GET    /health                               retrieves service heath information
+	// POST   /trading-codes                        adds another trading code
+	// GET    /trading-codes?bidding_id             retrieves the trading codes of the
+	//                                              bidding with their calculations
+	// GET    /trading-codes/{id}                   retrieves the given trading code
+	// POST   /trading-codes/{id}/calculations      records a calculation of the
+	//                                              trading code
+	// GET    /trading-codes/{id}/balances          retrieves the balances of the trading
+	//                                              code per type and source
+	// GET    /biddings/{id}/statement              retrieves the settlement statement
+	//                                              of the bidding
+	// GET    /biddings/{id}/statement/export       downloads the settlement statement,
+	//        ?format                               format pdf (default) or xlsx
+
+	m.Methods("GET").Path("/health").Handler(httptransport.NewServer(
+		endpoints.HealthEndpoint,
+		DecodeHTTPHealthRequest,
+		EncodeHTTPGenericResponse,
+		options...,
+	))
+	m.Methods("POST").Path("/trading-codes").Handler(httptransport.NewServer(
+		endpoints.CreateTradingCodeEndpoint,
+		decodeHTTPCreateTradingCodeRequest,
+		encodeHTTPProtoResponse(http.StatusCreated, encodeGRPCTradingCodeResponse),
+		options...,
+	))
+	m.Methods("GET").Path("/trading-codes").Handler(httptransport.NewServer(
+		endpoints.ListTradingCodesEndpoint,
+		decodeHTTPListTradingCodesRequest,
+		encodeHTTPProtoResponse(http.StatusOK, encodeGRPCTradingCodesResponse),
+		options...,
+	))
+	m.Methods("GET").Path("/trading-codes/{id}").Handler(httptransport.NewServer(
+		endpoints.GetTradingCodeEndpoint,
+		decodeHTTPTradingCodeByIDRequest,
+		encodeHTTPProtoResponse(http.StatusOK, encodeGRPCTradingCodeResponse),
+		options...,
+	))
+	m.Methods("POST").Path("/trading-codes/{id}/calculations").Handler(httptransport.NewServer(
+		endpoints.AddCalculationEndpoint,
+		decodeHTTPAddCalculationRequest,
+		encodeHTTPProtoResponse(http.StatusCreated, encodeGRPCCalculationResponse),
+		options...,
+	))
+	m.Methods("GET").Path("/trading-codes/{id}/balances").Handler(httptransport.NewServer(
+		endpoints.BalancesEndpoint,
+		decodeHTTPTradingCodeByIDRequest,
+		encodeHTTPProtoResponse(http.StatusOK, encodeGRPCBalancesResponse),
+		options...,
+	))
+	m.Methods("GET").Path("/biddings/{id}/statement").Handler(httptransport.NewServer(
+		endpoints.StatementEndpoint,
+		decodeHTTPBiddingByIDRequest,
+		encodeHTTPProtoResponse(http.StatusOK, encodeGRPCStatementResponse),
+		options...,
+	))
+	m.Methods("GET").Path("/biddings/{id}/statement/export").Handler(httptransport.NewServer(
+		endpoints.ExportStatementEndpoint,
+		decodeHTTPExportRequest,
+		encodeHTTPExportResponse,
+		options...,
+	))
+	return m
+}
+
+// DecodeHTTPHealthRequest method.
+func DecodeHTTPHealthRequest(_ context.Context, _ *http.Request) (interface{}, error) {
+	return ledgerendpoint.HealthRequest{}, nil
+}
+
+func decodeHTTPCreateTradingCodeRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	req := pb.TradingCode{}
+	if err := unmarshalHTTP(r, &req); err != nil {
+		return nil, err
+	}
+	return tradingCodeFromPB(&req), nil
+}
+
+func decodeHTTPListTradingCodesRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	s := r.URL.Query().Get("bidding_id")
+	if s == "" {
+		return nil, apperr.NewValidation("bidding is required", apperr.Field{Field: "bidding_id", Description: "is required"})
+	}
+	n, err := strconv.ParseUint(s, 10, 32)
+	if err != nil {
+		return nil, badRequest(err)
+	}
+	return &pb.BiddingByID{ID: uint32(n)}, nil
+}
+
+func decodeHTTPTradingCodeByIDRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	id, err := pathID(r)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.TradingCodeByID{ID: uint32(id)}, nil
+}
+
+// decodeHTTPAddCalculationRequest reads a pb.Calculation JSON document,
+// the trading code is the one of the path.
+func decodeHTTPAddCalculationRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	id, err := pathID(r)
+	if err != nil {
+		return nil, err
+	}
+	req := pb.Calculation{}
+	if err := unmarshalHTTP(r, &req); err != nil {
+		return nil, err
+	}
+	req.TradingCodeID = uint32(id)
+	return calculationFromPB(&req)
+}
+
+func decodeHTTPBiddingByIDRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	id, err := pathID(r)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.BiddingByID{ID: uint32(id)}, nil
+}
+
+func decodeHTTPExportRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	id, err := pathID(r)
+	if err != nil {
+		return nil, err
+	}
+	req := ledgerendpoint.ExportRequest{BiddingID: id, Format: r.URL.Query().Get("format")}
+	if req.Format == "" {
+		req.Format = "pdf"
+	}
+	return req, nil
+}
+
+// unmarshalHTTP reads a JSON document of the message from the request body.
+func unmarshalHTTP(r *http.Request, msg proto.Message) error {
+	u := jsonpb.Unmarshaler{AllowUnknownFields: true}
+	if err := u.Unmarshal(r.Body, msg); err != nil {
+		return badRequest(err)
+	}
+	return nil
+}
+
+func pathID(r *http.Request) (uint, error) {
+	vars := mux.Vars(r)
+	id, ok := vars["id"]
+	if !ok {
+		return 0, ErrBadRouting
+	}
+	n, err := strconv.ParseUint(id, 10, 32)
+	if err != nil {
+		return 0, badRequest(err)
+	}
+	return uint(n), nil
+}
+
+// encodeHTTPProtoResponse returns an encoder writing the message made by
+// the gRPC encoder of the response as JSON, with the status code.
+func encodeHTTPProtoResponse(code int, encode func(context.Context, interface{}) (interface{}, error)) httptransport.EncodeResponseFunc {
+	return func(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+		if f, ok := response.(ledgerendpoint.Failer); ok && f.Failed() != nil {
+			apperr.EncodeHTTPError(ctx, f.Failed(), w)
+			return nil
+		}
+		msg, err := encode(ctx, response)
+		if err != nil {
+			return err
+		}
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(code)
+		m := jsonpb.Marshaler{EmitDefaults: true}
+		return m.Marshal(w, msg.(proto.Message))
+	}
+}
+
+// encodeHTTPExportResponse writes the export. The statement is rendered
+// before the first byte is written, so an error is still reported with
+// its status; a later one aborts the connection.
+func encodeHTTPExportResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	if f, ok := response.(ledgerendpoint.Failer); ok && f.Failed() != nil {
+		apperr.EncodeHTTPError(ctx, f.Failed(), w)
+		return nil
+	}
+	res := response.(ledgerendpoint.ExportResponse)
+	hw := &headerWriter{w: w, header: func() {
+		w.Header().Set("Content-Type", ledgerservice.ExportContentTypes[res.Format])
+		w.Header().Set("Content-Disposition", `attachment; filename="`+exportFileName(res)+`"`)
+	}}
+	err := res.Write(hw)
+	if err != nil && hw.header == nil {
+		panic(http.ErrAbortHandler)
+	}
+	return err
+}
+
+// headerWriter sets the headers of the response on the first write.
+type headerWriter struct {
+	w      http.ResponseWriter
+	header func()
+}
+
+func (hw *headerWriter) Write(p []byte) (int, error) {
+	if hw.header != nil {
+		hw.header()
+		hw.header = nil
+	}
+	return hw.w.Write(p)
+}
+
+// EncodeHTTPGenericResponse is a transport/http.EncodeResponseFunc that encodes
+// the response as JSON to the response writer
+func EncodeHTTPGenericResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	if f, ok := response.(ledgerendpoint.Failer); ok && f.Failed() != nil {
+		apperr.EncodeHTTPError(ctx, f.Failed(), w)
+		return nil
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	return json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,275 @@
+package managerendpoint
+
+import (
+	"context"
+
+	"microsrv/model"
+	"microsrv/pb"
+
+	"microsrv/manager/service"
+
+	"github.com/go-kit/kit/endpoint"
+)
+
+// Endpoints struct
+type Endpoints struct {
+	HealthEndpoint         endpoint.Endpoint // used by Consul for the healthcheck
+	CreateManagerEndpoint  endpoint.Endpoint
+	GetManagerEndpoint     endpoint.Endpoint
+	ListManagersEndpoint   endpoint.Endpoint
+	SaveManagerEndpoint    endpoint.Endpoint
+	DeleteManagerEndpoint  endpoint.Endpoint
+	ManagerDebtorsEndpoint endpoint.Endpoint
+	CreateSROEndpoint      endpoint.Endpoint
+	GetSROEndpoint         endpoint.Endpoint
+	ListSROsEndpoint       endpoint.Endpoint
+	SaveSROEndpoint        endpoint.Endpoint
+	DeleteSROEndpoint      endpoint.Endpoint
+}
+
+// MakeServerEndpoints func
+func MakeServerEndpoints(s managerservice.Service) Endpoints {
+	return Endpoints{
+		HealthEndpoint:         HealthEndpoint(s),
+		CreateManagerEndpoint:  CreateEndpoint(s),
+		GetManagerEndpoint:     GetEndpoint(s),
+		ListManagersEndpoint:   ListEndpoint(s),
+		SaveManagerEndpoint:    SaveEndpoint(s),
+		DeleteManagerEndpoint:  DeleteEndpoint(s),
+		ManagerDebtorsEndpoint: DebtorsEndpoint(s),
+		CreateSROEndpoint:      CreateSROEndpoint(s),
+		GetSROEndpoint:         GetSROEndpoint(s),
+		ListSROsEndpoint:       ListSROsEndpoint(s),
+		SaveSROEndpoint:        SaveSROEndpoint(s),
+		DeleteSROEndpoint:      DeleteSROEndpoint(s),
+	}
+}
+
+// compile time assertions for our response types implementing endpoint.Failer.
+var (
+	_ endpoint.Failer = HealthResponse{}
+	_ endpoint.Failer = ManagerResponse{}
+	_ endpoint.Failer = ManagersResponse{}
+	_ endpoint.Failer = DebtorsResponse{}
+	_ endpoint.Failer = SROResponse{}
+	_ endpoint.Failer = SROsResponse{}
+	_ endpoint.Failer = DeleteResponse{}
+)
+
+// HealthEndpoint constructs a Health endpoint wrapping the service.
+func HealthEndpoint(s managerservice.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		healthy := s.Health()
+		return HealthResponse{Healthy: healthy}, nil
+	}
+}
+
+// CreateEndpoint func
+func CreateEndpoint(s managerservice.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(model.BankruptcyManager)
+		res, e := s.CreateManager(ctx, req)
+		return ManagerResponse{Manager: res, Err: e}, nil
+	}
+}
+
+// GetEndpoint func
+func GetEndpoint(s managerservice.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(*pb.ManagerByID)
+		res, e := s.GetManager(ctx, uint(req.ID))
+		return ManagerResponse{Manager: res, Err: e}, nil
+	}
+}
+
+// ListEndpoint func
+func ListEndpoint(s managerservice.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(*pb.ManagerQuery)
+		res, e := s.ListManagers(ctx, QueryFromPB(req))
+		return ManagersResponse{Page: res, Err: e}, nil
+	}
+}
+
+// SaveEndpoint func
+func SaveEndpoint(s managerservice.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(SaveRequest)
+		res, e := s.SaveManager(ctx, req.ID, req.Manager)
+		return ManagerResponse{Manager: res, Err: e}, nil
+	}
+}
+
+// DeleteEndpoint func
+func DeleteEndpoint(s managerservice.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(*pb.ManagerByID)
+		e := s.DeleteManager(ctx, uint(req.ID))
+		return DeleteResponse{Err: e}, nil
+	}
+}
+
+// DebtorsEndpoint func
+func DebtorsEndpoint(s managerservice.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(*pb.ManagerDebtorsQuery)
+		res, e := s.ManagerDebtors(ctx, uint(req.ID), managerservice.DebtorsQuery{Limit: int(req.Limit), From: int(req.From)})
+		return DebtorsResponse{Debtors: res, Err: e}, nil
+	}
+}
+
+// CreateSROEndpoint func
+func CreateSROEndpoint(s managerservice.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(managerservice.SRO)
+		res, e := s.CreateSRO(ctx, req)
+		return SROResponse{SRO: res, Err: e}, nil
+	}
+}
+
+// GetSROEndpoint func
+func GetSROEndpoint(s managerservice.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(*pb.SROByID)
+		res, e := s.GetSRO(ctx, uint(req.ID))
+		return SROResponse{SRO: res, Err: e}, nil
+	}
+}
+
+// ListSROsEndpoint func
+func ListSROsEndpoint(s managerservice.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(*pb.SROQuery)
+		res, e := s.ListSROs(ctx, SROQueryFromPB(req))
+		return SROsResponse{Page: res, Err: e}, nil
+	}
+}
+
+// SaveSROEndpoint func
+func SaveSROEndpoint(s managerservice.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(SaveSRORequest)
+		res, e := s.SaveSRO(ctx, req.ID, req.SRO)
+		return SROResponse{SRO: res, Err: e}, nil
+	}
+}
+
+// DeleteSROEndpoint func
+func DeleteSROEndpoint(s managerservice.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(*pb.SROByID)
+		e := s.DeleteSRO(ctx, uint(req.ID))
+		return DeleteResponse{Err: e}, nil
+	}
+}
+
+// QueryFromPB converts a search request.
+func QueryFromPB(req *pb.ManagerQuery) managerservice.Query {
+	return managerservice.Query{
+		Name:  req.Name,
+		INN:   req.INN,
+		SNILS: req.SNILS,
+		SroID: uint(req.SroID),
+		Limit: int(req.Limit),
+		From:  int(req.From),
+	}
+}
+
+// SROQueryFromPB converts an SRO listing request.
+func SROQueryFromPB(req *pb.SROQuery) managerservice.SROQuery {
+	return managerservice.SROQuery{
+		Name:  req.Name,
+		INN:   req.INN,
+		Limit: int(req.Limit),
+		From:  int(req.From),
+	}
+}
+
+// Failer is an interface that should be implemented by response types.
+// Response encoders can check if responses are Failer, and if so if they've
+// failed, and if so encode them using a separate write path based on the error.
+type Failer interface {
+	Failed() error
+}
+
+// SaveRequest collects the request parameters for the SaveManager method.
+type SaveRequest struct {
+	ID      uint
+	Manager model.BankruptcyManager
+}
+
+// SaveSRORequest collects the request parameters for the SaveSRO method.
+type SaveSRORequest struct {
+	ID  uint
+	SRO managerservice.SRO
+}
+
+// ManagerResponse collects the response values of the methods returning
+// a manager.
+type ManagerResponse struct {
+	Manager model.BankruptcyManager `json:"manager"`
+	Err     error                   `json:"err,omitempty"`
+}
+
+// Failed implements Failer.
+func (r ManagerResponse) Failed() error { return r.Err }
+
+// ManagersResponse collects the response values for the ListManagers
+// method.
+type ManagersResponse struct {
+	Page managerservice.Page
+	Err  error `json:"err,omitempty"`
+}
+
+// Failed implements Failer.
+func (r ManagersResponse) Failed() error { return r.Err }
+
+// DebtorsResponse collects the response values for the ManagerDebtors
+// method.
+type DebtorsResponse struct {
+	Debtors managerservice.ManagerDebtors
+	Err     error `json:"err,omitempty"`
+}
+
+// Failed implements Failer.
+func (r DebtorsResponse) Failed() error { return r.Err }
+
+// SROResponse collects the response values of the methods returning an
+// SRO.
+type SROResponse struct {
+	SRO managerservice.SRO `json:"sro"`
+	Err error              `json:"err,omitempty"`
+}
+
+// Failed implements Failer.
+func (r SROResponse) Failed() error { return r.Err }
+
+// SROsResponse collects the response values for the ListSROs method.
+type SROsResponse struct {
+	Page managerservice.SROPage
+	Err  error `json:"err,omitempty"`
+}
+
+// Failed implements Failer.
+func (r SROsResponse) Failed() error { return r.Err }
+
+// DeleteResponse collects the response values for the DeleteManager and
+// DeleteSRO methods.
+type DeleteResponse struct {
+	Err error `json:"err,omitempty"`
+}
+
+// Failed implements Failer.
+func (r DeleteResponse) Failed() error { return r.Err }
+
+// HealthRequest collects the request parameters for the Health method.
+type HealthRequest struct{}
+
+// HealthResponse collects the response values for the Health method.
+type HealthResponse struct {
+	Healthy bool  `json:"healthy,omitempty"`
+	Err     error `json:"err,omitempty"`
+}
+
+// Failed implements Failer.
+func (r HealthResponse) Failed() error { return r.Err }
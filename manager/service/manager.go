@@ -0,0 +1,104 @@
+package managerservice
+
+import (
+	"strings"
+
+	"microsrv/model"
+
+	"github.com/jinzhu/gorm"
+)
+
+// SRO is a self-regulatory organisation of arbitration managers, the
+// managers refer to their SRO by SroID.
+type SRO struct {
+	gorm.Model
+	Name    string
+	INN     string
+	OGRN    string
+	RegNum  string
+	Address string
+}
+
+// TableName of the SROs.
+func (SRO) TableName() string {
+	return "sros"
+}
+
+// Debtor statuses. Debtors have no status of their own, it is derived from
+// their state by DebtorStatus.
+const (
+	// Pending debtors have no bankruptcy decision yet.
+	Pending = "pending"
+	// Bankrupt debtors were declared bankrupt and have no live bidding.
+	Bankrupt = "bankrupt"
+	// Trading debtors have live biddings.
+	Trading = "trading"
+	// Deleted debtors are soft deleted.
+	Deleted = "deleted"
+)
+
+// Statuses in the order of the counts of ManagerDebtors.
+var Statuses = []string{Pending, Bankrupt, Trading, Deleted}
+
+// DebtorStatus returns the status of the debtor, activeBiddings reporting
+// whether it has live biddings.
+func DebtorStatus(d model.Debtor, activeBiddings bool) string {
+	switch {
+	case d.DeletedAt != nil:
+		return Deleted
+	case activeBiddings:
+		return Trading
+	case d.DecisionDate != nil:
+		return Bankrupt
+	default:
+		return Pending
+	}
+}
+
+// ManagerDebtor is a debtor led by a manager, with its status.
+type ManagerDebtor struct {
+	Debtor model.Debtor
+	Status string
+}
+
+// StatusCount is the number of the debtors with the status.
+type StatusCount struct {
+	Status string
+	Count  uint
+}
+
+// ManagerDebtors lists a page of the debtors led by a manager, deleted ones
+// included, and counts all of them per status.
+type ManagerDebtors struct {
+	ManagerID uint
+	Debtors   []ManagerDebtor
+	Counts    []StatusCount
+}
+
+// newManagerDebtors returns the page of debtors of the manager in the
+// given order, active reporting the debtors with live biddings, and the
+// counts in the order of Statuses, the missing ones being zero.
+func newManagerDebtors(id uint, debtors []model.Debtor, active map[uint]bool, counts []StatusCount) ManagerDebtors {
+	res := ManagerDebtors{
+		ManagerID: id,
+		Debtors:   make([]ManagerDebtor, len(debtors)),
+		Counts:    make([]StatusCount, len(Statuses)),
+	}
+	for i, d := range debtors {
+		res.Debtors[i] = ManagerDebtor{Debtor: d, Status: DebtorStatus(d, active[d.ID])}
+	}
+	byStatus := map[string]uint{}
+	for _, c := range counts {
+		byStatus[c.Status] = c.Count
+	}
+	for i, s := range Statuses {
+		res.Counts[i] = StatusCount{Status: s, Count: byStatus[s]}
+	}
+	return res
+}
+
+// snilsDigits strips the separators of a SNILS, which is stored and
+// searched by its digits.
+func snilsDigits(snils string) string {
+	return strings.NewReplacer("-", "", " ", "").Replace(snils)
+}
@@ -0,0 +1,303 @@
+package managerservice
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"microsrv/model"
+)
+
+type memoryStore struct {
+	mtx      sync.RWMutex
+	managers map[uint]model.BankruptcyManager
+	sros     map[uint]SRO
+	nextID   uint
+	nextSRO  uint
+}
+
+// NewMemory returns a Service keeping managers and SROs in memory. It is
+// meant for tests and for running the service without a database. It
+// knows no debtors: managers are deleted without a check and lead no
+// debtors.
+func NewMemory() Service {
+	return &memoryStore{
+		managers: map[uint]model.BankruptcyManager{},
+		sros:     map[uint]SRO{},
+	}
+}
+
+// Health implementation of the Service.
+func (ms *memoryStore) Health() bool {
+	return true
+}
+
+func (ms *memoryStore) CreateManager(ctx context.Context, m model.BankruptcyManager) (model.BankruptcyManager, error) {
+	ms.mtx.Lock()
+	defer ms.mtx.Unlock()
+	if m.ID != 0 {
+		if _, ok := ms.managers[m.ID]; ok {
+			return model.BankruptcyManager{}, ErrAlreadyExists
+		}
+	}
+	if err := ms.sroExists(m.SroID); err != nil {
+		return model.BankruptcyManager{}, err
+	}
+	if m.ID == 0 {
+		ms.nextID++
+		m.ID = ms.nextID
+	}
+	if m.ID > ms.nextID {
+		ms.nextID = m.ID
+	}
+	m.SNILS = snilsDigits(m.SNILS)
+	now := time.Now()
+	m.CreatedAt, m.UpdatedAt, m.DeletedAt = now, now, nil
+	ms.managers[m.ID] = m
+	return m, nil
+}
+
+// sroExists returns ErrUnknownSRO unless the SRO with the id is live. Must
+// be called with mtx held.
+func (ms *memoryStore) sroExists(id uint) error {
+	if id == 0 {
+		return nil
+	}
+	if _, err := ms.getSRO(id); err != nil {
+		return ErrUnknownSRO
+	}
+	return nil
+}
+
+func (ms *memoryStore) GetManager(ctx context.Context, id uint) (model.BankruptcyManager, error) {
+	ms.mtx.RLock()
+	defer ms.mtx.RUnlock()
+	return ms.get(id)
+}
+
+// get returns a live manager. Must be called with mtx held.
+func (ms *memoryStore) get(id uint) (model.BankruptcyManager, error) {
+	m, ok := ms.managers[id]
+	if !ok || m.DeletedAt != nil {
+		return model.BankruptcyManager{}, ErrNotFound
+	}
+	return m, nil
+}
+
+func (ms *memoryStore) ListManagers(ctx context.Context, p Query) (Page, error) {
+	ms.mtx.RLock()
+	defer ms.mtx.RUnlock()
+	if p.Limit == 0 {
+		p.Limit = 20
+	}
+	words := strings.Fields(strings.ToLower(p.Name))
+	matched := []model.BankruptcyManager{}
+	for _, m := range ms.managers {
+		switch {
+		case m.DeletedAt != nil:
+		case !matchName(m, words):
+		case p.INN != "" && m.INN != p.INN:
+		case p.SNILS != "" && m.SNILS != snilsDigits(p.SNILS):
+		case p.SroID != 0 && m.SroID != p.SroID:
+		default:
+			matched = append(matched, m)
+		}
+	}
+	sort.Slice(matched, func(i, j int) bool {
+		a, b := matched[i], matched[j]
+		switch {
+		case a.Surname != b.Surname:
+			return a.Surname < b.Surname
+		case a.Name != b.Name:
+			return a.Name < b.Name
+		case a.Patronymicname != b.Patronymicname:
+			return a.Patronymicname < b.Patronymicname
+		}
+		return a.ID < b.ID
+	})
+	res := Page{Managers: []model.BankruptcyManager{}, Count: uint(len(matched))}
+	if p.From < len(matched) {
+		matched = matched[p.From:]
+		if len(matched) > p.Limit {
+			matched = matched[:p.Limit]
+		}
+		res.Managers = matched
+	}
+	return res, nil
+}
+
+// matchName reports whether each of the lower case words is contained in
+// the surname, the name or the patronymic name of the manager.
+func matchName(m model.BankruptcyManager, words []string) bool {
+	names := []string{strings.ToLower(m.Surname), strings.ToLower(m.Name), strings.ToLower(m.Patronymicname)}
+	for _, w := range words {
+		found := false
+		for _, n := range names {
+			if strings.Contains(n, w) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func (ms *memoryStore) SaveManager(ctx context.Context, id uint, m model.BankruptcyManager) (model.BankruptcyManager, error) {
+	ms.mtx.Lock()
+	defer ms.mtx.Unlock()
+	if m.ID != 0 && m.ID != id {
+		return model.BankruptcyManager{}, ErrInconsistentIDs
+	}
+	manager, err := ms.get(id)
+	if err != nil {
+		return manager, err
+	}
+	if m.SroID != manager.SroID {
+		if err := ms.sroExists(m.SroID); err != nil {
+			return manager, err
+		}
+	}
+	manager.Name = m.Name
+	manager.Surname = m.Surname
+	manager.Patronymicname = m.Patronymicname
+	manager.INN = m.INN
+	manager.SNILS = snilsDigits(m.SNILS)
+	manager.Address = m.Address
+	manager.SroID = m.SroID
+	manager.UpdatedAt = time.Now()
+	ms.managers[id] = manager
+	return manager, nil
+}
+
+func (ms *memoryStore) DeleteManager(ctx context.Context, id uint) error {
+	ms.mtx.Lock()
+	defer ms.mtx.Unlock()
+	m, err := ms.get(id)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	m.DeletedAt = &now
+	ms.managers[id] = m
+	return nil
+}
+
+func (ms *memoryStore) ManagerDebtors(ctx context.Context, id uint, p DebtorsQuery) (ManagerDebtors, error) {
+	ms.mtx.RLock()
+	defer ms.mtx.RUnlock()
+	if _, err := ms.get(id); err != nil {
+		return ManagerDebtors{}, err
+	}
+	return newManagerDebtors(id, nil, nil, nil), nil
+}
+
+func (ms *memoryStore) CreateSRO(ctx context.Context, s SRO) (SRO, error) {
+	ms.mtx.Lock()
+	defer ms.mtx.Unlock()
+	if s.ID != 0 {
+		if _, ok := ms.sros[s.ID]; ok {
+			return SRO{}, ErrSROAlreadyExists
+		}
+	} else {
+		ms.nextSRO++
+		s.ID = ms.nextSRO
+	}
+	if s.ID > ms.nextSRO {
+		ms.nextSRO = s.ID
+	}
+	now := time.Now()
+	s.CreatedAt, s.UpdatedAt, s.DeletedAt = now, now, nil
+	ms.sros[s.ID] = s
+	return s, nil
+}
+
+func (ms *memoryStore) GetSRO(ctx context.Context, id uint) (SRO, error) {
+	ms.mtx.RLock()
+	defer ms.mtx.RUnlock()
+	return ms.getSRO(id)
+}
+
+// getSRO returns a live SRO. Must be called with mtx held.
+func (ms *memoryStore) getSRO(id uint) (SRO, error) {
+	s, ok := ms.sros[id]
+	if !ok || s.DeletedAt != nil {
+		return SRO{}, ErrSRONotFound
+	}
+	return s, nil
+}
+
+func (ms *memoryStore) ListSROs(ctx context.Context, p SROQuery) (SROPage, error) {
+	ms.mtx.RLock()
+	defer ms.mtx.RUnlock()
+	if p.Limit == 0 {
+		p.Limit = 20
+	}
+	matched := []SRO{}
+	for _, s := range ms.sros {
+		switch {
+		case s.DeletedAt != nil:
+		case p.Name != "" && !strings.Contains(strings.ToLower(s.Name), strings.ToLower(p.Name)):
+		case p.INN != "" && s.INN != p.INN:
+		default:
+			matched = append(matched, s)
+		}
+	}
+	sort.Slice(matched, func(i, j int) bool {
+		if matched[i].Name != matched[j].Name {
+			return matched[i].Name < matched[j].Name
+		}
+		return matched[i].ID < matched[j].ID
+	})
+	res := SROPage{SROs: []SRO{}, Count: uint(len(matched))}
+	if p.From < len(matched) {
+		matched = matched[p.From:]
+		if len(matched) > p.Limit {
+			matched = matched[:p.Limit]
+		}
+		res.SROs = matched
+	}
+	return res, nil
+}
+
+func (ms *memoryStore) SaveSRO(ctx context.Context, id uint, s SRO) (SRO, error) {
+	ms.mtx.Lock()
+	defer ms.mtx.Unlock()
+	if s.ID != 0 && s.ID != id {
+		return SRO{}, ErrInconsistentIDs
+	}
+	sro, err := ms.getSRO(id)
+	if err != nil {
+		return sro, err
+	}
+	sro.Name = s.Name
+	sro.INN = s.INN
+	sro.OGRN = s.OGRN
+	sro.RegNum = s.RegNum
+	sro.Address = s.Address
+	sro.UpdatedAt = time.Now()
+	ms.sros[id] = sro
+	return sro, nil
+}
+
+func (ms *memoryStore) DeleteSRO(ctx context.Context, id uint) error {
+	ms.mtx.Lock()
+	defer ms.mtx.Unlock()
+	s, err := ms.getSRO(id)
+	if err != nil {
+		return err
+	}
+	for _, m := range ms.managers {
+		if m.DeletedAt == nil && m.SroID == id {
+			return ErrSROHasManagers
+		}
+	}
+	now := time.Now()
+	s.DeletedAt = &now
+	ms.sros[id] = s
+	return nil
+}
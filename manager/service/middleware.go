@@ -0,0 +1,182 @@
+package managerservice
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"microsrv/model"
+
+	"github.com/go-kit/kit/log"
+)
+
+// Middleware describes a service (as opposed to endpoint) middleware.
+type Middleware func(Service) Service
+
+// LoggingMiddleware takes a logger as a dependency and returns a ServiceMiddleware.
+func LoggingMiddleware(logger log.Logger) Middleware {
+	return func(next Service) Service {
+		return loggingMiddleware{next, logger}
+	}
+}
+
+type loggingMiddleware struct {
+	next   Service
+	logger log.Logger
+}
+
+// Health func
+func (mw loggingMiddleware) Health() bool {
+	defer func(begin time.Time) {
+		mw.logger.Log(
+			"method", "Health",
+			"healthy", true,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return mw.next.Health()
+}
+
+// CreateManager func
+func (mw loggingMiddleware) CreateManager(ctx context.Context, m model.BankruptcyManager) (res model.BankruptcyManager, err error) {
+	defer func(begin time.Time) {
+		mw.logger.Log(
+			"method", "CreateManager",
+			"Manager.ID", res.ID,
+			"SRO.ID", m.SroID,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return mw.next.CreateManager(ctx, m)
+}
+
+// GetManager func
+func (mw loggingMiddleware) GetManager(ctx context.Context, id uint) (model.BankruptcyManager, error) {
+	defer func(begin time.Time) {
+		mw.logger.Log(
+			"method", "GetManager",
+			"Manager.ID", id,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return mw.next.GetManager(ctx, id)
+}
+
+// ListManagers logs the query without the INN and SNILS, which are
+// personal data.
+func (mw loggingMiddleware) ListManagers(ctx context.Context, q Query) (Page, error) {
+	defer func(begin time.Time) {
+		mw.logger.Log(
+			"method", "ListManagers",
+			"Name", q.Name,
+			"SRO.ID", q.SroID,
+			"Page", fmt.Sprintf("%d+%d", q.From, q.Limit),
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return mw.next.ListManagers(ctx, q)
+}
+
+// SaveManager func
+func (mw loggingMiddleware) SaveManager(ctx context.Context, id uint, m model.BankruptcyManager) (res model.BankruptcyManager, err error) {
+	defer func(begin time.Time) {
+		mw.logger.Log(
+			"method", "SaveManager",
+			"Manager.ID", id,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return mw.next.SaveManager(ctx, id, m)
+}
+
+// DeleteManager func
+func (mw loggingMiddleware) DeleteManager(ctx context.Context, id uint) (err error) {
+	defer func(begin time.Time) {
+		mw.logger.Log(
+			"method", "DeleteManager",
+			"Manager.ID", id,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return mw.next.DeleteManager(ctx, id)
+}
+
+// ManagerDebtors func
+func (mw loggingMiddleware) ManagerDebtors(ctx context.Context, id uint, p DebtorsQuery) (res ManagerDebtors, err error) {
+	defer func(begin time.Time) {
+		mw.logger.Log(
+			"method", "ManagerDebtors",
+			"Manager.ID", id,
+			"Debtors", len(res.Debtors),
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return mw.next.ManagerDebtors(ctx, id, p)
+}
+
+// CreateSRO func
+func (mw loggingMiddleware) CreateSRO(ctx context.Context, s SRO) (res SRO, err error) {
+	defer func(begin time.Time) {
+		mw.logger.Log(
+			"method", "CreateSRO",
+			"SRO.ID", res.ID,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return mw.next.CreateSRO(ctx, s)
+}
+
+// GetSRO func
+func (mw loggingMiddleware) GetSRO(ctx context.Context, id uint) (SRO, error) {
+	defer func(begin time.Time) {
+		mw.logger.Log(
+			"method", "GetSRO",
+			"SRO.ID", id,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return mw.next.GetSRO(ctx, id)
+}
+
+// ListSROs func
+func (mw loggingMiddleware) ListSROs(ctx context.Context, q SROQuery) (SROPage, error) {
+	defer func(begin time.Time) {
+		mw.logger.Log(
+			"method", "ListSROs",
+			"Query", fmt.Sprintf("%+v", q),
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return mw.next.ListSROs(ctx, q)
+}
+
+// SaveSRO func
+func (mw loggingMiddleware) SaveSRO(ctx context.Context, id uint, s SRO) (res SRO, err error) {
+	defer func(begin time.Time) {
+		mw.logger.Log(
+			"method", "SaveSRO",
+			"SRO.ID", id,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return mw.next.SaveSRO(ctx, id, s)
+}
+
+// DeleteSRO func
+func (mw loggingMiddleware) DeleteSRO(ctx context.Context, id uint) (err error) {
+	defer func(begin time.Time) {
+		mw.logger.Log(
+			"method", "DeleteSRO",
+			"SRO.ID", id,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return mw.next.DeleteSRO(ctx, id)
+}
@@ -0,0 +1,431 @@
+package managerservice
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"microsrv/apperr"
+	"microsrv/config"
+	"microsrv/model"
+
+	"github.com/jinzhu/gorm"
+	_ "github.com/jinzhu/gorm/dialects/mysql"    // Mysql driver
+	_ "github.com/jinzhu/gorm/dialects/postgres" // Postgres driver
+)
+
+// Service interface
+type Service interface {
+	Health() bool
+	CreateManager(ctx context.Context, m model.BankruptcyManager) (model.BankruptcyManager, error)
+	GetManager(ctx context.Context, id uint) (model.BankruptcyManager, error)
+	// ListManagers returns a page of the managers matching the query,
+	// ordered by their full name.
+	ListManagers(ctx context.Context, q Query) (Page, error)
+	// SaveManager updates every field of the manager, zero values
+	// included.
+	SaveManager(ctx context.Context, id uint, m model.BankruptcyManager) (model.BankruptcyManager, error)
+	// DeleteManager refuses to delete a manager leading live debtors.
+	DeleteManager(ctx context.Context, id uint) error
+	// ManagerDebtors returns a page of the debtors led by the manager,
+	// with their statuses, and counts all of them per status.
+	ManagerDebtors(ctx context.Context, id uint, p DebtorsQuery) (ManagerDebtors, error)
+	CreateSRO(ctx context.Context, s SRO) (SRO, error)
+	GetSRO(ctx context.Context, id uint) (SRO, error)
+	// ListSROs returns a page of the SROs matching the query, ordered by
+	// name.
+	ListSROs(ctx context.Context, q SROQuery) (SROPage, error)
+	SaveSRO(ctx context.Context, id uint, s SRO) (SRO, error)
+	// DeleteSRO refuses to delete an SRO having live managers.
+	DeleteSRO(ctx context.Context, id uint) error
+}
+
+var (
+	// ErrInconsistentIDs var
+	ErrInconsistentIDs = apperr.NewValidation("inconsistent IDs", apperr.Field{Field: "id", Description: "differs from the path id"})
+	// ErrAlreadyExists var
+	ErrAlreadyExists = apperr.NewConflict("bankruptcy manager already exists")
+	// ErrNotFound var
+	ErrNotFound = apperr.NewNotFound("bankruptcy manager not found")
+	// ErrHasDebtors var
+	ErrHasDebtors = apperr.NewConflict("bankruptcy manager leads debtors")
+	// ErrSROAlreadyExists var
+	ErrSROAlreadyExists = apperr.NewConflict("SRO already exists")
+	// ErrSRONotFound var
+	ErrSRONotFound = apperr.NewNotFound("SRO not found")
+	// ErrSROHasManagers var
+	ErrSROHasManagers = apperr.NewConflict("SRO has bankruptcy managers")
+	// ErrUnknownSRO var
+	ErrUnknownSRO = apperr.NewValidation("unknown SRO", apperr.Field{Field: "sroID", Description: "must be an existing SRO"})
+)
+
+// Query selects the managers by name, INN, SNILS or SRO. Each word of Name
+// must be contained in the surname, the name or the patronymic name; INN
+// and SNILS must match, the SNILS separators being ignored. Zero fields
+// are not filtered on.
+type Query struct {
+	Name  string
+	INN   string
+	SNILS string
+	SroID uint
+	Limit int
+	From  int
+}
+
+// Page of managers. Count is the number of managers matching the query.
+type Page struct {
+	Managers []model.BankruptcyManager
+	Count    uint
+}
+
+// DebtorsQuery pages the debtors of a manager, in id order.
+type DebtorsQuery struct {
+	Limit int
+	From  int
+}
+
+// SROQuery selects the SROs with a name containing Name or with the INN.
+// Zero fields are not filtered on.
+type SROQuery struct {
+	Name  string
+	INN   string
+	Limit int
+	From  int
+}
+
+// SROPage of SROs. Count is the number of SROs matching the query.
+type SROPage struct {
+	SROs  []SRO
+	Count uint
+}
+
+// New returns the Service backed by the storage selected by cfg.Driver.
+func New(cfg config.DB) (Service, error) {
+	switch cfg.Driver {
+	case "", "mysql":
+		return NewDB("mysql", cfg.DSN())
+	case "postgres":
+		return NewDB("postgres", cfg.DSN())
+	case "memory":
+		return NewMemory(), nil
+	default:
+		return nil, fmt.Errorf("unknown database driver %q", cfg.Driver)
+	}
+}
+
+type databaseStore struct {
+	db      *gorm.DB
+	dialect string
+}
+
+// NewDB returns a Service stored in a MySQL or PostgreSQL database. The
+// bankruptcy_managers and sros tables are created by the migrations of
+// package migrate.
+func NewDB(dialect, DSN string) (Service, error) {
+	db, err := gorm.Open(dialect, DSN)
+	if err != nil {
+		return nil, err
+	}
+	if dialect == "mysql" {
+		db = db.Set("gorm:table_options", "ENGINE=InnoDB")
+	}
+	return &databaseStore{db: db, dialect: dialect}, nil
+}
+
+// like returns a case insensitive LIKE condition on the column, to be
+// given a contains pattern.
+func (ds *databaseStore) like(column string) string {
+	if ds.dialect == "postgres" {
+		return column + " ILIKE ?"
+	}
+	return column + " COLLATE UTF8_GENERAL_CI LIKE ?"
+}
+
+// contains returns the LIKE pattern of the values containing s. The
+// wildcards of s are escaped with the default escape character of MySQL
+// and PostgreSQL.
+func contains(s string) string {
+	return "%" + likeEscaper.Replace(s) + "%"
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// Health implementation of the Service.
+func (ds *databaseStore) Health() bool {
+	return ds.db.DB().Ping() == nil
+}
+
+// CreateManager func
+func (ds *databaseStore) CreateManager(ctx context.Context, m model.BankruptcyManager) (model.BankruptcyManager, error) {
+	if m.ID != 0 && !ds.db.Unscoped().First(&model.BankruptcyManager{}, m.ID).RecordNotFound() {
+		return model.BankruptcyManager{}, ErrAlreadyExists
+	}
+	if err := ds.sroExists(m.SroID); err != nil {
+		return model.BankruptcyManager{}, err
+	}
+	m.SNILS = snilsDigits(m.SNILS)
+	if err := ds.db.Create(&m).Error; err != nil {
+		return model.BankruptcyManager{}, err
+	}
+	return ds.GetManager(ctx, m.ID)
+}
+
+// sroExists returns ErrUnknownSRO unless the SRO with the id is live. A
+// zero id is the absence of an SRO.
+func (ds *databaseStore) sroExists(id uint) error {
+	if id != 0 && ds.db.First(&SRO{}, id).RecordNotFound() {
+		return ErrUnknownSRO
+	}
+	return nil
+}
+
+// GetManager func
+func (ds *databaseStore) GetManager(ctx context.Context, id uint) (model.BankruptcyManager, error) {
+	m := model.BankruptcyManager{}
+	err := ds.db.First(&m, id).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return m, ErrNotFound
+	}
+	return m, err
+}
+
+// ListManagers func
+func (ds *databaseStore) ListManagers(ctx context.Context, p Query) (Page, error) {
+	if p.Limit == 0 {
+		p.Limit = 20
+	}
+	res := Page{Managers: []model.BankruptcyManager{}}
+	q := ds.db.Model(&model.BankruptcyManager{})
+	for _, w := range strings.Fields(p.Name) {
+		w = contains(w)
+		q = q.Where("("+ds.like("surname")+" OR "+ds.like("name")+" OR "+ds.like("patronymicname")+")", w, w, w)
+	}
+	if p.INN != "" {
+		q = q.Where("inn = ?", p.INN)
+	}
+	if p.SNILS != "" {
+		q = q.Where("snils = ?", snilsDigits(p.SNILS))
+	}
+	if p.SroID != 0 {
+		q = q.Where("sro_id = ?", p.SroID)
+	}
+	count := 0
+	if err := q.Count(&count).Error; err != nil {
+		return res, err
+	}
+	res.Count = uint(count)
+	err := q.
+		Order("surname").
+		Order("name").
+		Order("patronymicname").
+		Order("id").
+		Limit(p.Limit).
+		Offset(p.From).
+		Find(&res.Managers).
+		Error
+	return res, err
+}
+
+// SaveManager func
+func (ds *databaseStore) SaveManager(ctx context.Context, id uint, m model.BankruptcyManager) (model.BankruptcyManager, error) {
+	if m.ID != 0 && m.ID != id {
+		return model.BankruptcyManager{}, ErrInconsistentIDs
+	}
+	manager, err := ds.GetManager(ctx, id)
+	if err != nil {
+		return manager, err
+	}
+	if m.SroID != manager.SroID {
+		if err := ds.sroExists(m.SroID); err != nil {
+			return manager, err
+		}
+	}
+	if err := ds.db.Model(&manager).Updates(managerUpdates(m)).Error; err != nil {
+		return manager, err
+	}
+	return ds.GetManager(ctx, id)
+}
+
+// managerUpdates returns the editable fields of the manager, zero values
+// included.
+func managerUpdates(m model.BankruptcyManager) map[string]interface{} {
+	return map[string]interface{}{
+		"Name":           m.Name,
+		"Surname":        m.Surname,
+		"Patronymicname": m.Patronymicname,
+		"INN":            m.INN,
+		"SNILS":          snilsDigits(m.SNILS),
+		"Address":        m.Address,
+		"SroID":          m.SroID,
+	}
+}
+
+// DeleteManager func
+func (ds *databaseStore) DeleteManager(ctx context.Context, id uint) error {
+	if _, err := ds.GetManager(ctx, id); err != nil {
+		return err
+	}
+	count := 0
+	if err := ds.db.Model(&model.Debtor{}).Where("bankruptcy_manager_id = ?", id).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrHasDebtors
+	}
+	q := ds.db.Delete(model.BankruptcyManager{}, id)
+	if q.Error != nil {
+		return q.Error
+	}
+	if q.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// debtorStatus is the SQL expression of the status of a debtor, as
+// derived by DebtorStatus.
+var debtorStatus = fmt.Sprintf(`CASE
+	WHEN debtors.deleted_at IS NOT NULL THEN '%s'
+	WHEN EXISTS (SELECT 1 FROM biddings WHERE biddings.debtor_id = debtors.id AND biddings.deleted_at IS NULL) THEN '%s'
+	WHEN debtors.decision_date IS NOT NULL THEN '%s'
+	ELSE '%s' END`, Deleted, Trading, Bankrupt, Pending)
+
+// ManagerDebtors func
+func (ds *databaseStore) ManagerDebtors(ctx context.Context, id uint, p DebtorsQuery) (ManagerDebtors, error) {
+	if _, err := ds.GetManager(ctx, id); err != nil {
+		return ManagerDebtors{}, err
+	}
+	if p.Limit == 0 {
+		p.Limit = 20
+	}
+	q := ds.db.Unscoped().Model(&model.Debtor{}).Where("bankruptcy_manager_id = ?", id)
+	counts := []StatusCount{}
+	err := q.
+		Select(debtorStatus + " AS status, COUNT(*) AS count").
+		Group("status").
+		Scan(&counts).
+		Error
+	if err != nil {
+		return ManagerDebtors{}, err
+	}
+	debtors := []model.Debtor{}
+	if err := q.Order("id").Limit(p.Limit).Offset(p.From).Find(&debtors).Error; err != nil {
+		return ManagerDebtors{}, err
+	}
+	active := map[uint]bool{}
+	if len(debtors) > 0 {
+		ids := make([]uint, len(debtors))
+		for i, d := range debtors {
+			ids[i] = d.ID
+		}
+		withBiddings := []uint{}
+		err := ds.db.Model(&model.Bidding{}).
+			Where("debtor_id IN (?)", ids).
+			Pluck("DISTINCT debtor_id", &withBiddings).
+			Error
+		if err != nil {
+			return ManagerDebtors{}, err
+		}
+		for _, debtorID := range withBiddings {
+			active[debtorID] = true
+		}
+	}
+	return newManagerDebtors(id, debtors, active, counts), nil
+}
+
+// CreateSRO func
+func (ds *databaseStore) CreateSRO(ctx context.Context, s SRO) (SRO, error) {
+	if s.ID != 0 && !ds.db.Unscoped().First(&SRO{}, s.ID).RecordNotFound() {
+		return SRO{}, ErrSROAlreadyExists
+	}
+	if err := ds.db.Create(&s).Error; err != nil {
+		return SRO{}, err
+	}
+	return ds.GetSRO(ctx, s.ID)
+}
+
+// GetSRO func
+func (ds *databaseStore) GetSRO(ctx context.Context, id uint) (SRO, error) {
+	s := SRO{}
+	err := ds.db.First(&s, id).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return s, ErrSRONotFound
+	}
+	return s, err
+}
+
+// ListSROs func
+func (ds *databaseStore) ListSROs(ctx context.Context, p SROQuery) (SROPage, error) {
+	if p.Limit == 0 {
+		p.Limit = 20
+	}
+	res := SROPage{SROs: []SRO{}}
+	q := ds.db.Model(&SRO{})
+	if p.Name != "" {
+		q = q.Where(ds.like("name"), contains(p.Name))
+	}
+	if p.INN != "" {
+		q = q.Where("inn = ?", p.INN)
+	}
+	count := 0
+	if err := q.Count(&count).Error; err != nil {
+		return res, err
+	}
+	res.Count = uint(count)
+	err := q.
+		Order("name").
+		Order("id").
+		Limit(p.Limit).
+		Offset(p.From).
+		Find(&res.SROs).
+		Error
+	return res, err
+}
+
+// SaveSRO func
+func (ds *databaseStore) SaveSRO(ctx context.Context, id uint, s SRO) (SRO, error) {
+	if s.ID != 0 && s.ID != id {
+		return SRO{}, ErrInconsistentIDs
+	}
+	sro, err := ds.GetSRO(ctx, id)
+	if err != nil {
+		return sro, err
+	}
+	if err := ds.db.Model(&sro).Updates(sroUpdates(s)).Error; err != nil {
+		return sro, err
+	}
+	return ds.GetSRO(ctx, id)
+}
+
+// sroUpdates returns the editable fields of the SRO, zero values included.
+func sroUpdates(s SRO) map[string]interface{} {
+	return map[string]interface{}{
+		"Name":    s.Name,
+		"INN":     s.INN,
+		"OGRN":    s.OGRN,
+		"RegNum":  s.RegNum,
+		"Address": s.Address,
+	}
+}
+
+// DeleteSRO func
+func (ds *databaseStore) DeleteSRO(ctx context.Context, id uint) error {
+	if _, err := ds.GetSRO(ctx, id); err != nil {
+		return err
+	}
+	count := 0
+	if err := ds.db.Model(&model.BankruptcyManager{}).Where("sro_id = ?", id).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrSROHasManagers
+	}
+	q := ds.db.Delete(SRO{}, id)
+	if q.Error != nil {
+		return q.Error
+	}
+	if q.RowsAffected == 0 {
+		return ErrSRONotFound
+	}
+	return nil
+}
@@ -0,0 +1,30 @@
+package managerservice
+
+import (
+	"context"
+	"testing"
+
+	"microsrv/model"
+)
+
+func TestContains(t *testing.T) {
+	if got, want := contains(`50%_a\b`), `%50\%\_a\\b%`; got != want {
+		t.Errorf("contains = %q, want %q", got, want)
+	}
+}
+
+func TestSNILSStoredAsDigits(t *testing.T) {
+	s := NewMemory()
+	ctx := context.Background()
+	m, err := s.CreateManager(ctx, model.BankruptcyManager{Surname: "Иванов", Name: "Иван", SNILS: "112-233-445 95"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.SNILS != "11223344595" {
+		t.Errorf("SNILS %q", m.SNILS)
+	}
+	p, _ := s.ListManagers(ctx, Query{SNILS: "112 233 445-95"})
+	if p.Count != 1 {
+		t.Errorf("%d managers found by SNILS", p.Count)
+	}
+}
@@ -0,0 +1,99 @@
+package managerservice
+
+import (
+	"context"
+
+	"microsrv/apperr"
+	"microsrv/model"
+	"microsrv/requisites"
+)
+
+// ValidationMiddleware returns a Middleware that rejects managers and SROs
+// without a name or with malformed identifiers before they reach the next
+// Service.
+func ValidationMiddleware() Middleware {
+	return func(next Service) Service {
+		return validationMiddleware{next}
+	}
+}
+
+type validationMiddleware struct {
+	Service
+}
+
+// CreateManager func
+func (mw validationMiddleware) CreateManager(ctx context.Context, m model.BankruptcyManager) (model.BankruptcyManager, error) {
+	if err := ValidateManager(m); err != nil {
+		return model.BankruptcyManager{}, err
+	}
+	return mw.Service.CreateManager(ctx, m)
+}
+
+// SaveManager func
+func (mw validationMiddleware) SaveManager(ctx context.Context, id uint, m model.BankruptcyManager) (model.BankruptcyManager, error) {
+	if err := ValidateManager(m); err != nil {
+		return model.BankruptcyManager{}, err
+	}
+	return mw.Service.SaveManager(ctx, id, m)
+}
+
+// CreateSRO func
+func (mw validationMiddleware) CreateSRO(ctx context.Context, s SRO) (SRO, error) {
+	if err := ValidateSRO(s); err != nil {
+		return SRO{}, err
+	}
+	return mw.Service.CreateSRO(ctx, s)
+}
+
+// SaveSRO func
+func (mw validationMiddleware) SaveSRO(ctx context.Context, id uint, s SRO) (SRO, error) {
+	if err := ValidateSRO(s); err != nil {
+		return SRO{}, err
+	}
+	return mw.Service.SaveSRO(ctx, id, s)
+}
+
+// ValidateManager requires the surname and the name of the manager and
+// checks its INN and SNILS when they are set.
+func ValidateManager(m model.BankruptcyManager) error {
+	fields := []apperr.Field{}
+	if m.Surname == "" {
+		fields = append(fields, apperr.Field{Field: "surname", Description: "is required"})
+	}
+	if m.Name == "" {
+		fields = append(fields, apperr.Field{Field: "name", Description: "is required"})
+	}
+	fields = check(fields, "INN", m.INN, requisites.PersonINN)
+	fields = check(fields, "SNILS", m.SNILS, requisites.SNILS)
+	if len(fields) > 0 {
+		return apperr.NewValidation("invalid bankruptcy manager", fields...)
+	}
+	return nil
+}
+
+// ValidateSRO requires the name of the SRO and checks its INN and OGRN
+// when they are set.
+func ValidateSRO(s SRO) error {
+	fields := []apperr.Field{}
+	if s.Name == "" {
+		fields = append(fields, apperr.Field{Field: "name", Description: "is required"})
+	}
+	fields = check(fields, "INN", s.INN, requisites.INN)
+	fields = check(fields, "OGRN", s.OGRN, requisites.OGRN)
+	if len(fields) > 0 {
+		return apperr.NewValidation("invalid SRO", fields...)
+	}
+	return nil
+}
+
+// check appends the field to fields when the value is set and fn rejects
+// it.
+func check(fields []apperr.Field, field, value string, fn func(string) error) []apperr.Field {
+	if value == "" {
+		return fields
+	}
+	if err := fn(value); err != nil {
+		return append(fields, apperr.Field{Field: field, Description: err.Error()})
+	}
+	return fields
+}
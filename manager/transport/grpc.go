@@ -0,0 +1,382 @@
+package transport
+
+import (
+	"context"
+
+	"microsrv/apperr"
+	"microsrv/manager/endpoint"
+	"microsrv/manager/service"
+	"microsrv/model"
+	"microsrv/pb"
+
+	"github.com/go-kit/kit/log"
+	grpctransport "github.com/go-kit/kit/transport/grpc"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/jinzhu/copier"
+	oldcontext "golang.org/x/net/context"
+)
+
+type grpcServer struct {
+	createManager  grpctransport.Handler
+	getManager     grpctransport.Handler
+	listManagers   grpctransport.Handler
+	saveManager    grpctransport.Handler
+	deleteManager  grpctransport.Handler
+	managerDebtors grpctransport.Handler
+	createSRO      grpctransport.Handler
+	getSRO         grpctransport.Handler
+	listSROs       grpctransport.Handler
+	saveSRO        grpctransport.Handler
+	deleteSRO      grpctransport.Handler
+}
+
+// NewGRPCServer makes a set of endpoints available as a gRPC ManagerSvcServer.
+func NewGRPCServer(endpoints managerendpoint.Endpoints, logger log.Logger) pb.ManagerSvcServer {
+	options := []grpctransport.ServerOption{
+		grpctransport.ServerErrorLogger(logger),
+	}
+
+	return &grpcServer{
+		createManager: grpctransport.NewServer(
+			endpoints.CreateManagerEndpoint,
+			decodeGRPCManager,
+			encodeGRPCManagerResponse,
+			options...,
+		),
+		getManager: grpctransport.NewServer(
+			endpoints.GetManagerEndpoint,
+			decodeGRPCManagerByID,
+			encodeGRPCManagerResponse,
+			options...,
+		),
+		listManagers: grpctransport.NewServer(
+			endpoints.ListManagersEndpoint,
+			decodeGRPCManagerQuery,
+			encodeGRPCManagersResponse,
+			options...,
+		),
+		saveManager: grpctransport.NewServer(
+			endpoints.SaveManagerEndpoint,
+			decodeGRPCSaveManager,
+			encodeGRPCManagerResponse,
+			options...,
+		),
+		deleteManager: grpctransport.NewServer(
+			endpoints.DeleteManagerEndpoint,
+			decodeGRPCManagerByID,
+			encodeGRPCDeleteResponse,
+			options...,
+		),
+		managerDebtors: grpctransport.NewServer(
+			endpoints.ManagerDebtorsEndpoint,
+			decodeGRPCManagerDebtorsQuery,
+			encodeGRPCDebtorsResponse,
+			options...,
+		),
+		createSRO: grpctransport.NewServer(
+			endpoints.CreateSROEndpoint,
+			decodeGRPCSRO,
+			encodeGRPCSROResponse,
+			options...,
+		),
+		getSRO: grpctransport.NewServer(
+			endpoints.GetSROEndpoint,
+			decodeGRPCSROByID,
+			encodeGRPCSROResponse,
+			options...,
+		),
+		listSROs: grpctransport.NewServer(
+			endpoints.ListSROsEndpoint,
+			decodeGRPCSROQuery,
+			encodeGRPCSROsResponse,
+			options...,
+		),
+		saveSRO: grpctransport.NewServer(
+			endpoints.SaveSROEndpoint,
+			decodeGRPCSaveSRO,
+			encodeGRPCSROResponse,
+			options...,
+		),
+		deleteSRO: grpctransport.NewServer(
+			endpoints.DeleteSROEndpoint,
+			decodeGRPCSROByID,
+			encodeGRPCDeleteResponse,
+			options...,
+		),
+	}
+}
+
+// CreateManager implementation of the method of the ManagerSvcServer
+// interface.
+func (s *grpcServer) CreateManager(ctx oldcontext.Context, req *pb.BankruptcyManager) (*pb.BankruptcyManager, error) {
+	_, res, err := s.createManager.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, apperr.ToGRPC(err)
+	}
+	return res.(*pb.BankruptcyManager), nil
+}
+
+func decodeGRPCManager(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	return managerFromPB(grpcReq.(*pb.BankruptcyManager)), nil
+}
+
+func encodeGRPCManagerResponse(_ context.Context, response interface{}) (interface{}, error) {
+	result := response.(managerendpoint.ManagerResponse)
+	if result.Err != nil {
+		return nil, result.Err
+	}
+	return managerToPB(result.Manager), nil
+}
+
+// GetManager implementation of the method of the ManagerSvcServer
+// interface.
+func (s *grpcServer) GetManager(ctx oldcontext.Context, req *pb.ManagerByID) (*pb.BankruptcyManager, error) {
+	_, res, err := s.getManager.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, apperr.ToGRPC(err)
+	}
+	return res.(*pb.BankruptcyManager), nil
+}
+
+func decodeGRPCManagerByID(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	return grpcReq.(*pb.ManagerByID), nil
+}
+
+// ListManagers implementation of the method of the ManagerSvcServer
+// interface.
+func (s *grpcServer) ListManagers(ctx oldcontext.Context, req *pb.ManagerQuery) (*pb.BankruptcyManagers, error) {
+	_, res, err := s.listManagers.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, apperr.ToGRPC(err)
+	}
+	return res.(*pb.BankruptcyManagers), nil
+}
+
+func decodeGRPCManagerQuery(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	return grpcReq.(*pb.ManagerQuery), nil
+}
+
+func encodeGRPCManagersResponse(_ context.Context, response interface{}) (interface{}, error) {
+	result := response.(managerendpoint.ManagersResponse)
+	if result.Err != nil {
+		return nil, result.Err
+	}
+	return managersToPB(result.Page.Managers, result.Page.Count), nil
+}
+
+// SaveManager implementation of the method of the ManagerSvcServer
+// interface. The manager is selected by its ID.
+func (s *grpcServer) SaveManager(ctx oldcontext.Context, req *pb.BankruptcyManager) (*pb.BankruptcyManager, error) {
+	_, res, err := s.saveManager.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, apperr.ToGRPC(err)
+	}
+	return res.(*pb.BankruptcyManager), nil
+}
+
+func decodeGRPCSaveManager(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.BankruptcyManager)
+	return managerendpoint.SaveRequest{ID: uint(req.ID), Manager: managerFromPB(req)}, nil
+}
+
+// DeleteManager implementation of the method of the ManagerSvcServer
+// interface.
+func (s *grpcServer) DeleteManager(ctx oldcontext.Context, req *pb.ManagerByID) (*pb.ErrorResponse, error) {
+	_, res, err := s.deleteManager.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, apperr.ToGRPC(err)
+	}
+	return res.(*pb.ErrorResponse), nil
+}
+
+func encodeGRPCDeleteResponse(_ context.Context, response interface{}) (interface{}, error) {
+	result := response.(managerendpoint.DeleteResponse)
+	if result.Err != nil {
+		return nil, result.Err
+	}
+	return &pb.ErrorResponse{}, nil
+}
+
+// GetManagerDebtors implementation of the method of the ManagerSvcServer
+// interface.
+func (s *grpcServer) GetManagerDebtors(ctx oldcontext.Context, req *pb.ManagerDebtorsQuery) (*pb.ManagerDebtors, error) {
+	_, res, err := s.managerDebtors.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, apperr.ToGRPC(err)
+	}
+	return res.(*pb.ManagerDebtors), nil
+}
+
+func decodeGRPCManagerDebtorsQuery(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	return grpcReq.(*pb.ManagerDebtorsQuery), nil
+}
+
+func encodeGRPCDebtorsResponse(_ context.Context, response interface{}) (interface{}, error) {
+	result := response.(managerendpoint.DebtorsResponse)
+	if result.Err != nil {
+		return nil, result.Err
+	}
+	return managerDebtorsToPB(result.Debtors), nil
+}
+
+// CreateSRO implementation of the method of the ManagerSvcServer
+// interface.
+func (s *grpcServer) CreateSRO(ctx oldcontext.Context, req *pb.SRO) (*pb.SRO, error) {
+	_, res, err := s.createSRO.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, apperr.ToGRPC(err)
+	}
+	return res.(*pb.SRO), nil
+}
+
+func decodeGRPCSRO(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	return sroFromPB(grpcReq.(*pb.SRO)), nil
+}
+
+func encodeGRPCSROResponse(_ context.Context, response interface{}) (interface{}, error) {
+	result := response.(managerendpoint.SROResponse)
+	if result.Err != nil {
+		return nil, result.Err
+	}
+	return sroToPB(result.SRO), nil
+}
+
+// GetSRO implementation of the method of the ManagerSvcServer interface.
+func (s *grpcServer) GetSRO(ctx oldcontext.Context, req *pb.SROByID) (*pb.SRO, error) {
+	_, res, err := s.getSRO.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, apperr.ToGRPC(err)
+	}
+	return res.(*pb.SRO), nil
+}
+
+func decodeGRPCSROByID(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	return grpcReq.(*pb.SROByID), nil
+}
+
+// ListSROs implementation of the method of the ManagerSvcServer interface.
+func (s *grpcServer) ListSROs(ctx oldcontext.Context, req *pb.SROQuery) (*pb.SROs, error) {
+	_, res, err := s.listSROs.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, apperr.ToGRPC(err)
+	}
+	return res.(*pb.SROs), nil
+}
+
+func decodeGRPCSROQuery(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	return grpcReq.(*pb.SROQuery), nil
+}
+
+func encodeGRPCSROsResponse(_ context.Context, response interface{}) (interface{}, error) {
+	result := response.(managerendpoint.SROsResponse)
+	if result.Err != nil {
+		return nil, result.Err
+	}
+	return srosToPB(result.Page.SROs, result.Page.Count), nil
+}
+
+// SaveSRO implementation of the method of the ManagerSvcServer interface.
+// The SRO is selected by its ID.
+func (s *grpcServer) SaveSRO(ctx oldcontext.Context, req *pb.SRO) (*pb.SRO, error) {
+	_, res, err := s.saveSRO.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, apperr.ToGRPC(err)
+	}
+	return res.(*pb.SRO), nil
+}
+
+func decodeGRPCSaveSRO(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.SRO)
+	return managerendpoint.SaveSRORequest{ID: uint(req.ID), SRO: sroFromPB(req)}, nil
+}
+
+// DeleteSRO implementation of the method of the ManagerSvcServer
+// interface.
+func (s *grpcServer) DeleteSRO(ctx oldcontext.Context, req *pb.SROByID) (*pb.ErrorResponse, error) {
+	_, res, err := s.deleteSRO.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, apperr.ToGRPC(err)
+	}
+	return res.(*pb.ErrorResponse), nil
+}
+
+func managerFromPB(m *pb.BankruptcyManager) model.BankruptcyManager {
+	res := model.BankruptcyManager{
+		Name:           m.Name,
+		Surname:        m.Surname,
+		Patronymicname: m.Patronymicname,
+		INN:            m.INN,
+		SNILS:          m.SNILS,
+		Address:        m.Address,
+		SroID:          uint(m.SroID),
+	}
+	res.ID = uint(m.ID)
+	return res
+}
+
+func managerToPB(m model.BankruptcyManager) *pb.BankruptcyManager {
+	res := &pb.BankruptcyManager{}
+	copier.Copy(res, m)
+	return res
+}
+
+func managersToPB(managers []model.BankruptcyManager, count uint) *pb.BankruptcyManagers {
+	res := &pb.BankruptcyManagers{Managers: make([]*pb.BankruptcyManager, len(managers)), Count: uint32(count)}
+	for i, m := range managers {
+		res.Managers[i] = managerToPB(m)
+	}
+	return res
+}
+
+func sroFromPB(s *pb.SRO) managerservice.SRO {
+	res := managerservice.SRO{
+		Name:    s.Name,
+		INN:     s.INN,
+		OGRN:    s.OGRN,
+		RegNum:  s.RegNum,
+		Address: s.Address,
+	}
+	res.ID = uint(s.ID)
+	return res
+}
+
+func sroToPB(s managerservice.SRO) *pb.SRO {
+	return &pb.SRO{
+		ID:      uint32(s.ID),
+		Name:    s.Name,
+		INN:     s.INN,
+		OGRN:    s.OGRN,
+		RegNum:  s.RegNum,
+		Address: s.Address,
+	}
+}
+
+func srosToPB(sros []managerservice.SRO, count uint) *pb.SROs {
+	res := &pb.SROs{SROs: make([]*pb.SRO, len(sros)), Count: uint32(count)}
+	for i, s := range sros {
+		res.SROs[i] = sroToPB(s)
+	}
+	return res
+}
+
+// managerDebtorsToPB converts the debtors of a manager, with the deletion
+// time of the deleted ones.
+func managerDebtorsToPB(r managerservice.ManagerDebtors) *pb.ManagerDebtors {
+	res := &pb.ManagerDebtors{
+		ManagerID: uint32(r.ManagerID),
+		Debtors:   make([]*pb.ManagerDebtor, len(r.Debtors)),
+		Counts:    make([]*pb.StatusCount, len(r.Counts)),
+	}
+	for i, d := range r.Debtors {
+		debtor := &pb.Debtor{}
+		copier.Copy(debtor, d.Debtor)
+		if d.Debtor.DeletedAt != nil {
+			debtor.DeletedAt, _ = ptypes.TimestampProto(*d.Debtor.DeletedAt)
+		}
+		res.Debtors[i] = &pb.ManagerDebtor{Debtor: debtor, Status: d.Status}
+	}
+	for i, c := range r.Counts {
+		res.Counts[i] = &pb.StatusCount{Status: c.Status, Count: uint32(c.Count)}
+	}
+	return res
+}
@@ -0,0 +1,353 @@
+package transport
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"microsrv/apperr"
+	"microsrv/manager/endpoint"
+	"microsrv/manager/service"
+	"microsrv/model"
+	"microsrv/pb"
+
+	"github.com/go-kit/kit/log"
+	httptransport "github.com/go-kit/kit/transport/http"
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/proto"
+	"github.com/gorilla/mux"
+)
+
+var (
+	// ErrBadRouting is returned when an expected path variable is missing.
+	ErrBadRouting = apperr.New(apperr.Internal, "inconsistent mapping between route and handler")
+)
+
+// badRequest reports a malformed request.
+func badRequest(err error) error {
+	return apperr.NewValidation(err.Error())
+}
+
+// NewHTTPHandler returns an HTTP handler that makes a set of endpoints
+// available on predefined paths.
+func NewHTTPHandler(endpoints managerendpoint.Endpoints, logger log.Logger) http.Handler {
+	m := mux.NewRouter()
+	options := []httptransport.ServerOption{
+		httptransport.ServerErrorEncoder(apperr.EncodeHTTPError),
+		httptransport.ServerErrorLogger(logger),
+	}
+
+	// GET    /health                          retrieves service heath information
+	// POST   /managers                        adds another bankruptcy manager
+	// GET    /managers?name&inn&snils&sro_id  retrieves a page of the managers
+	//        &limit&from                      found by name, INN, SNILS or SRO
+	// GET    /managers/{id}                   retrieves the given manager by id
+	// PUT    /managers/{id}                   updates the given manager
+	// DELETE /managers/{id}                   removes the given manager
+	// GET    /managers/{id}/debtors           retrieves a page of the debtors led
+	//        ?limit&from                      by the manager and their count per
+	//                                         status
+	// POST   /sros                            adds another SRO
+	// GET    /sros?name&inn&limit&from        retrieves a page of SROs
+	// GET    /sros/{id}                       retrieves the given SRO by id
+	// PUT    /sros/{id}                       updates the given SRO
+	// DELETE /sros/{id}                       removes the given SRO
+
+	m.Methods("GET").Path("/health").Handler(httptransport.NewServer(
+		endpoints.HealthEndpoint,
+		DecodeHTTPHealthRequest,
+		EncodeHTTPGenericResponse,
+		options...,
+	))
+	m.Methods("POST").Path("/managers").Handler(httptransport.NewServer(
+		endpoints.CreateManagerEndpoint,
+		decodeHTTPCreateManagerRequest,
+		encodeHTTPCreatedManagerResponse,
+		options...,
+	))
+	m.Methods("GET").Path("/managers").Handler(httptransport.NewServer(
+		endpoints.ListManagersEndpoint,
+		decodeHTTPListManagersRequest,
+		encodeHTTPManagersResponse,
+		options...,
+	))
+	m.Methods("GET").Path("/managers/{id}").Handler(httptransport.NewServer(
+		endpoints.GetManagerEndpoint,
+		decodeHTTPManagerByIDRequest,
+		encodeHTTPManagerResponse,
+		options...,
+	))
+	m.Methods("PUT").Path("/managers/{id}").Handler(httptransport.NewServer(
+		endpoints.SaveManagerEndpoint,
+		decodeHTTPSaveManagerRequest,
+		encodeHTTPManagerResponse,
+		options...,
+	))
+	m.Methods("DELETE").Path("/managers/{id}").Handler(httptransport.NewServer(
+		endpoints.DeleteManagerEndpoint,
+		decodeHTTPManagerByIDRequest,
+		EncodeHTTPGenericResponse,
+		options...,
+	))
+	m.Methods("GET").Path("/managers/{id}/debtors").Handler(httptransport.NewServer(
+		endpoints.ManagerDebtorsEndpoint,
+		decodeHTTPManagerDebtorsRequest,
+		encodeHTTPDebtorsResponse,
+		options...,
+	))
+	m.Methods("POST").Path("/sros").Handler(httptransport.NewServer(
+		endpoints.CreateSROEndpoint,
+		decodeHTTPCreateSRORequest,
+		encodeHTTPCreatedSROResponse,
+		options...,
+	))
+	m.Methods("GET").Path("/sros").Handler(httptransport.NewServer(
+		endpoints.ListSROsEndpoint,
+		decodeHTTPListSROsRequest,
+		encodeHTTPSROsResponse,
+		options...,
+	))
+	m.Methods("GET").Path("/sros/{id}").Handler(httptransport.NewServer(
+		endpoints.GetSROEndpoint,
+		decodeHTTPSROByIDRequest,
+		encodeHTTPSROResponse,
+		options...,
+	))
+	m.Methods("PUT").Path("/sros/{id}").Handler(httptransport.NewServer(
+		endpoints.SaveSROEndpoint,
+		decodeHTTPSaveSRORequest,
+		encodeHTTPSROResponse,
+		options...,
+	))
+	m.Methods("DELETE").Path("/sros/{id}").Handler(httptransport.NewServer(
+		endpoints.DeleteSROEndpoint,
+		decodeHTTPSROByIDRequest,
+		EncodeHTTPGenericResponse,
+		options...,
+	))
+	return m
+}
+
+// DecodeHTTPHealthRequest method.
+func DecodeHTTPHealthRequest(_ context.Context, _ *http.Request) (interface{}, error) {
+	return managerendpoint.HealthRequest{}, nil
+}
+
+func decodeHTTPCreateManagerRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	return decodeHTTPManager(r)
+}
+
+func decodeHTTPListManagersRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	q := r.URL.Query()
+	req := &pb.ManagerQuery{Name: q.Get("name"), INN: q.Get("inn"), SNILS: q.Get("snils")}
+	if s := q.Get("sro_id"); s != "" {
+		n, err := strconv.ParseUint(s, 10, 32)
+		if err != nil {
+			return nil, badRequest(err)
+		}
+		req.SroID = uint32(n)
+	}
+	if err := parsePage(q, &req.Limit, &req.From); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+// parsePage parses the limit and from parameters of a listing.
+func parsePage(q url.Values, limit, from *int64) error {
+	for name, v := range map[string]*int64{"limit": limit, "from": from} {
+		if s := q.Get(name); s != "" {
+			n, err := strconv.ParseInt(s, 10, 64)
+			if err != nil {
+				return badRequest(err)
+			}
+			*v = n
+		}
+	}
+	return nil
+}
+
+func decodeHTTPManagerByIDRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	id, err := pathID(r)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.ManagerByID{ID: uint32(id)}, nil
+}
+
+func decodeHTTPManagerDebtorsRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	id, err := pathID(r)
+	if err != nil {
+		return nil, err
+	}
+	req := &pb.ManagerDebtorsQuery{ID: uint32(id)}
+	if err := parsePage(r.URL.Query(), &req.Limit, &req.From); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+func decodeHTTPSaveManagerRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	id, err := pathID(r)
+	if err != nil {
+		return nil, err
+	}
+	m, err := decodeHTTPManager(r)
+	if err != nil {
+		return nil, err
+	}
+	return managerendpoint.SaveRequest{ID: id, Manager: m}, nil
+}
+
+// decodeHTTPManager reads a pb.BankruptcyManager JSON document from the
+// request body.
+func decodeHTTPManager(r *http.Request) (model.BankruptcyManager, error) {
+	req := pb.BankruptcyManager{}
+	if err := unmarshalHTTP(r, &req); err != nil {
+		return model.BankruptcyManager{}, err
+	}
+	return managerFromPB(&req), nil
+}
+
+func decodeHTTPCreateSRORequest(_ context.Context, r *http.Request) (interface{}, error) {
+	return decodeHTTPSRO(r)
+}
+
+func decodeHTTPListSROsRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	q := r.URL.Query()
+	req := &pb.SROQuery{Name: q.Get("name"), INN: q.Get("inn")}
+	if err := parsePage(q, &req.Limit, &req.From); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+func decodeHTTPSROByIDRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	id, err := pathID(r)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.SROByID{ID: uint32(id)}, nil
+}
+
+func decodeHTTPSaveSRORequest(_ context.Context, r *http.Request) (interface{}, error) {
+	id, err := pathID(r)
+	if err != nil {
+		return nil, err
+	}
+	s, err := decodeHTTPSRO(r)
+	if err != nil {
+		return nil, err
+	}
+	return managerendpoint.SaveSRORequest{ID: id, SRO: s}, nil
+}
+
+// decodeHTTPSRO reads a pb.SRO JSON document from the request body.
+func decodeHTTPSRO(r *http.Request) (managerservice.SRO, error) {
+	req := pb.SRO{}
+	if err := unmarshalHTTP(r, &req); err != nil {
+		return managerservice.SRO{}, err
+	}
+	return sroFromPB(&req), nil
+}
+
+func unmarshalHTTP(r *http.Request, m proto.Message) error {
+	u := jsonpb.Unmarshaler{AllowUnknownFields: true}
+	if err := u.Unmarshal(r.Body, m); err != nil {
+		return badRequest(err)
+	}
+	return nil
+}
+
+func pathID(r *http.Request) (uint, error) {
+	vars := mux.Vars(r)
+	id, ok := vars["id"]
+	if !ok {
+		return 0, ErrBadRouting
+	}
+	n, err := strconv.ParseUint(id, 10, 32)
+	if err != nil {
+		return 0, badRequest(err)
+	}
+	return uint(n), nil
+}
+
+func encodeHTTPCreatedManagerResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	if f, ok := response.(managerendpoint.Failer); ok && f.Failed() != nil {
+		apperr.EncodeHTTPError(ctx, f.Failed(), w)
+		return nil
+	}
+	return encodeHTTPProto(w, http.StatusCreated, managerToPB(response.(managerendpoint.ManagerResponse).Manager))
+}
+
+func encodeHTTPManagerResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	if f, ok := response.(managerendpoint.Failer); ok && f.Failed() != nil {
+		apperr.EncodeHTTPError(ctx, f.Failed(), w)
+		return nil
+	}
+	return encodeHTTPProto(w, http.StatusOK, managerToPB(response.(managerendpoint.ManagerResponse).Manager))
+}
+
+func encodeHTTPManagersResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	if f, ok := response.(managerendpoint.Failer); ok && f.Failed() != nil {
+		apperr.EncodeHTTPError(ctx, f.Failed(), w)
+		return nil
+	}
+	page := response.(managerendpoint.ManagersResponse).Page
+	return encodeHTTPProto(w, http.StatusOK, managersToPB(page.Managers, page.Count))
+}
+
+func encodeHTTPDebtorsResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	if f, ok := response.(managerendpoint.Failer); ok && f.Failed() != nil {
+		apperr.EncodeHTTPError(ctx, f.Failed(), w)
+		return nil
+	}
+	return encodeHTTPProto(w, http.StatusOK, managerDebtorsToPB(response.(managerendpoint.DebtorsResponse).Debtors))
+}
+
+func encodeHTTPCreatedSROResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	if f, ok := response.(managerendpoint.Failer); ok && f.Failed() != nil {
+		apperr.EncodeHTTPError(ctx, f.Failed(), w)
+		return nil
+	}
+	return encodeHTTPProto(w, http.StatusCreated, sroToPB(response.(managerendpoint.SROResponse).SRO))
+}
+
+func encodeHTTPSROResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	if f, ok := response.(managerendpoint.Failer); ok && f.Failed() != nil {
+		apperr.EncodeHTTPError(ctx, f.Failed(), w)
+		return nil
+	}
+	return encodeHTTPProto(w, http.StatusOK, sroToPB(response.(managerendpoint.SROResponse).SRO))
+}
+
+func encodeHTTPSROsResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	if f, ok := response.(managerendpoint.Failer); ok && f.Failed() != nil {
+		apperr.EncodeHTTPError(ctx, f.Failed(), w)
+		return nil
+	}
+	page := response.(managerendpoint.SROsResponse).Page
+	return encodeHTTPProto(w, http.StatusOK, srosToPB(page.SROs, page.Count))
+}
+
+// encodeHTTPProto writes the message as JSON, with its zero values so that
+// empty lists and counts are present.
+func encodeHTTPProto(w http.ResponseWriter, code int, msg proto.Message) error {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(code)
+	m := jsonpb.Marshaler{EmitDefaults: true}
+	return m.Marshal(w, msg)
+}
+
+// EncodeHTTPGenericResponse is a transport/http.EncodeResponseFunc that encodes
+// the response as JSON to the response writer
+func EncodeHTTPGenericResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	if f, ok := response.(managerendpoint.Failer); ok && f.Failed() != nil {
+		apperr.EncodeHTTPError(ctx, f.Failed(), w)
+		return nil
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	return json.NewEncoder(w).Encode(response)
+}
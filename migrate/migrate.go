@@ -0,0 +1,159 @@
+// Package migrate keeps the schema of the database shared by the services
+// up to date with ordered, versioned migrations. It imports no service
+// package, the migrations carry their own snapshots of the tables.
+package migrate
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+var (
+	// ErrNothingToRevert is returned by Down on a database without migrations.
+	ErrNothingToRevert = errors.New("no applied migrations")
+	// ErrUnknownVersion is returned when the database has a migration this
+	// binary does not know about.
+	ErrUnknownVersion = errors.New("unknown migration version in database")
+)
+
+// Migration is a versioned schema change.
+type Migration struct {
+	Version uint
+	Name    string
+	Up      func(tx *gorm.DB) error
+	Down    func(tx *gorm.DB) error
+}
+
+// Record is a row of the migration-state table.
+type Record struct {
+	Version   uint `gorm:"primary_key;auto_increment:false"`
+	Name      string
+	AppliedAt time.Time
+}
+
+// TableName sets Record's table name to be `schema_migrations`.
+func (Record) TableName() string {
+	return "schema_migrations"
+}
+
+// Status of a migration.
+type Status struct {
+	Migration
+	Applied   bool
+	AppliedAt time.Time
+}
+
+// Migrator applies migrations to a database.
+type Migrator struct {
+	db         *gorm.DB
+	migrations []Migration
+}
+
+// New returns a Migrator for the migrations, which are sorted by version.
+func New(db *gorm.DB, migrations []Migration) *Migrator {
+	ms := append([]Migration(nil), migrations...)
+	sort.Slice(ms, func(i, j int) bool { return ms[i].Version < ms[j].Version })
+	return &Migrator{db: db, migrations: ms}
+}
+
+// Up applies all pending migrations in order and returns them.
+func (m *Migrator) Up() ([]Migration, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+	done := []Migration{}
+	for _, mg := range m.migrations {
+		if _, ok := applied[mg.Version]; ok {
+			continue
+		}
+		err := m.run(mg, mg.Up, func(tx *gorm.DB) error {
+			return tx.Create(&Record{Version: mg.Version, Name: mg.Name, AppliedAt: time.Now()}).Error
+		})
+		if err != nil {
+			return done, err
+		}
+		done = append(done, mg)
+	}
+	return done, nil
+}
+
+// Down reverts the last applied migration and returns it.
+func (m *Migrator) Down() (Migration, error) {
+	last := Record{}
+	if err := m.init(); err != nil {
+		return Migration{}, err
+	}
+	err := m.db.Order("version desc").First(&last).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return Migration{}, ErrNothingToRevert
+	}
+	if err != nil {
+		return Migration{}, err
+	}
+	for _, mg := range m.migrations {
+		if mg.Version != last.Version {
+			continue
+		}
+		return mg, m.run(mg, mg.Down, func(tx *gorm.DB) error {
+			return tx.Delete(&Record{Version: mg.Version}).Error
+		})
+	}
+	return Migration{}, fmt.Errorf("%v: %d", ErrUnknownVersion, last.Version)
+}
+
+// Status lists all known migrations and whether they are applied.
+func (m *Migrator) Status() ([]Status, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+	res := make([]Status, len(m.migrations))
+	for i, mg := range m.migrations {
+		r, ok := applied[mg.Version]
+		res[i] = Status{Migration: mg, Applied: ok, AppliedAt: r.AppliedAt}
+	}
+	return res, nil
+}
+
+func (m *Migrator) init() error {
+	return m.db.AutoMigrate(&Record{}).Error
+}
+
+func (m *Migrator) applied() (map[uint]Record, error) {
+	if err := m.init(); err != nil {
+		return nil, err
+	}
+	records := []Record{}
+	if err := m.db.Find(&records).Error; err != nil {
+		return nil, err
+	}
+	res := map[uint]Record{}
+	for _, r := range records {
+		res[r.Version] = r
+	}
+	return res, nil
+}
+
+// run executes the migration step and the state change in a transaction.
+// MySQL commits DDL implicitly, so there a failed step may leave a partly
+// applied migration behind.
+func (m *Migrator) run(mg Migration, step, record func(tx *gorm.DB) error) error {
+	tx := m.db.Begin()
+	if tx.Error != nil {
+		return tx.Error
+	}
+	if err := step(tx); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("migration %d %s: %v", mg.Version, mg.Name, err)
+	}
+	if err := record(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit().Error
+}
@@ -0,0 +1,427 @@
+package migrate
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// Migrations of the database shared by the services. New migrations are appended with the
+// next version, applied ones are never changed. Each migration describes
+// its tables with its own snapshot of the columns, so that later changes
+// of the models do not change what it creates.
+var Migrations = []Migration{
+	{
+		Version: 1,
+		Name:    "create arbitrations",
+		Up: createTable("arbitrations", &struct {
+			ID   string
+			Name string
+		}{}),
+		Down: dropTable("arbitrations"),
+	},
+	{
+		Version: 2,
+		Name:    "create bankruptcy_managers",
+		Up: createTable("bankruptcy_managers", &struct {
+			gorm.Model
+			Name           string
+			Surname        string
+			Patronymicname string
+			INN            string
+			SNILS          string
+			Address        string
+			SroID          uint
+		}{},
+			index{"idx_bankruptcy_managers_inn", "inn"},
+			index{"idx_bankruptcy_managers_deleted_at", "deleted_at"},
+		),
+		Down: dropTable("bankruptcy_managers"),
+	},
+	{
+		Version: 3,
+		Name:    "create debtors",
+		Up: createTable("debtors", &struct {
+			gorm.Model
+			Name                string
+			FullName            string
+			INN                 string
+			KPP                 string
+			OGRN                string
+			Address             string
+			PostAddress         string
+			PostAddressMatch    bool
+			ArbitrationID       string
+			CaseNo              string
+			DecisionDate        *time.Time
+			BankruptcyManagerID uint
+			Contacts            string
+		}{},
+			index{"idx_debtors_name", "name"},
+			index{"idx_debtors_inn", "inn"},
+			index{"idx_debtors_ogrn", "ogrn"},
+			index{"idx_debtors_deleted_at", "deleted_at"},
+		),
+		Down: dropTable("debtors"),
+	},
+	{
+		Version: 4,
+		Name:    "create bank_details",
+		Up: createTable("bank_details", &struct {
+			gorm.Model
+			MarketPlaceID uint
+			DebtorID      uint
+			InitiatorID   uint
+			Name          string
+			BankAccount   string
+			Bank          string
+			CorrAccount   string
+			BIK           string
+		}{},
+			index{"idx_bank_details_debtor_id", "debtor_id"},
+			index{"idx_bank_details_initiator_id", "initiator_id"},
+			index{"idx_bank_details_deleted_at", "deleted_at"},
+		),
+		Down: dropTable("bank_details"),
+	},
+	{
+		Version: 5,
+		Name:    "create biddings",
+		Up: createTable("biddings", &struct {
+			gorm.Model
+			Name        string
+			Description string
+			DebtorID    uint
+			InitiatorID uint
+		}{},
+			index{"idx_biddings_debtor_id", "debtor_id"},
+			index{"idx_biddings_deleted_at", "deleted_at"},
+		),
+		Down: dropTable("biddings"),
+	},
+	{
+		Version: 6,
+		Name:    "index debtor filter columns",
+		Up: addIndexes("debtors",
+			index{"idx_debtors_case_no", "case_no"},
+			index{"idx_debtors_decision_date", "decision_date"},
+			index{"idx_debtors_arbitration_id", "arbitration_id"},
+			index{"idx_debtors_bankruptcy_manager_id", "bankruptcy_manager_id"},
+			index{"idx_debtors_created_at", "created_at"},
+			index{"idx_debtors_updated_at", "updated_at"},
+		),
+		Down: removeIndexes("debtors",
+			"idx_debtors_case_no",
+			"idx_debtors_decision_date",
+			"idx_debtors_arbitration_id",
+			"idx_debtors_bankruptcy_manager_id",
+			"idx_debtors_created_at",
+			"idx_debtors_updated_at",
+		),
+	},
+	{
+		Version: 7,
+		Name:    "create debtor_audit",
+		Up: createTable("debtor_audit", &struct {
+			ID        uint `gorm:"primary_key"`
+			DebtorID  uint `gorm:"index"`
+			Actor     string
+			Operation string
+			At        time.Time
+			Changes   string `gorm:"type:text"`
+		}{}, index{"idx_debtor_audit_debtor_id", "debtor_id"}),
+		Down: dropTable("debtor_audit"),
+	},
+	{
+		// The update time is the version of a debtor, MySQL would round it
+		// to seconds.
+		Version: 8,
+		Name:    "debtors updated_at microseconds",
+		Up:      mysqlOnly("ALTER TABLE debtors MODIFY updated_at DATETIME(6) NULL"),
+		Down:    mysqlOnly("ALTER TABLE debtors MODIFY updated_at DATETIME NULL"),
+	},
+	{
+		Version: 9,
+		Name:    "index bank accounts",
+		Up:      addIndexes("bank_details", index{"idx_bank_details_bik_bank_account", "bik, bank_account"}),
+		Down:    removeIndexes("bank_details", "idx_bank_details_bik_bank_account"),
+	},
+	{
+		Version: 10,
+		Name:    "create bank_statements",
+		Up: createTable("bank_statements", &struct {
+			ID               uint `gorm:"primary_key"`
+			BankDetailID     uint `gorm:"index"`
+			DebtorID         uint `gorm:"index"`
+			Account          string
+			StartDate        time.Time
+			EndDate          time.Time
+			InitialBalance   int64
+			Income           int64
+			WriteOff         int64
+			FinalBalance     int64
+			PaymentDocuments string `gorm:"type:text"`
+			CreatorID        uint
+			CreatedAt        time.Time
+		}{},
+			index{"idx_bank_statements_bank_detail_id", "bank_detail_id"},
+			index{"idx_bank_statements_debtor_id", "debtor_id, start_date"},
+		),
+		Down: dropTable("bank_statements"),
+	},
+	{
+		Version: 11,
+		Name:    "create adverts",
+		Up: createTable("adverts", &struct {
+			gorm.Model
+			Week                   float32
+			DebtorID               uint
+			BiddingID              uint
+			TradingCodeID          uint
+			CreatorID              uint
+			AdvertTypeID           uint
+			AdvertOther            string
+			OrganiserID            uint
+			ResponsibleID          uint
+			ProjectID              uint
+			LawyerID               uint
+			Comment                string `gorm:"type:text"`
+			RequestCount           uint
+			LotsCount              uint
+			PublicationAdNum       string
+			PublicationState       string
+			PublicationPublishedAt *time.Time
+			PublicationIssueNo     string
+			PublicationMessage     string
+			PublicationJob         string
+			PublicationCheckedAt   *time.Time
+		}{},
+			index{"idx_adverts_week", "week"},
+			index{"idx_adverts_debtor_id", "debtor_id"},
+			index{"idx_adverts_responsible_id", "responsible_id"},
+			index{"idx_adverts_project_id", "project_id"},
+			index{"idx_adverts_deleted_at", "deleted_at"},
+			index{"idx_adverts_publication", "publication_ad_num, publication_state"},
+		),
+		Down: dropTable("adverts"),
+	},
+	{
+		Version: 12,
+		Name:    "create task_templates",
+		Up: createTable("task_templates", &struct {
+			gorm.Model
+			AdvertTypeID     uint
+			Sequence         uint
+			Name             string
+			Mandatory        bool
+			IsBidCode        bool
+			AttachmentTypeID uint
+			DueDays          uint
+		}{},
+			index{"idx_task_templates_advert_type_id", "advert_type_id, sequence"},
+		),
+		Down: dropTable("task_templates"),
+	},
+	{
+		Version: 13,
+		Name:    "create task_lists",
+		Up: createTable("task_lists", &struct {
+			gorm.Model
+			OrganisationID   uint
+			StepID           uint
+			Sequence         uint
+			Name             string
+			Mandatory        bool
+			IsBidCode        bool
+			AttachmentTypeID uint
+			Complete         bool
+			DoerID           uint
+			DueAt            *time.Time
+		}{},
+			index{"idx_task_lists_organisation_id", "organisation_id, sequence"},
+			index{"idx_task_lists_due_at", "complete, due_at"},
+		),
+		Down: dropTable("task_lists"),
+	},
+	{
+		Version: 14,
+		Name:    "create task_histories",
+		Up: createTable("task_histories", &struct {
+			ID         uint `gorm:"primary_key"`
+			TaskListID uint
+			UserID     uint
+			Action     string
+			Comment    string `gorm:"type:text"`
+			Complete   bool
+			Mandatory  bool
+			UpdatedAt  time.Time
+		}{},
+			index{"idx_task_histories_task_list_id", "task_list_id"},
+		),
+		Down: dropTable("task_histories"),
+	},
+	{
+		Version: 15,
+		Name:    "create attachments",
+		Up: createTable("attachments", &struct {
+			gorm.Model
+			OrganisationID   uint
+			TaskListID       uint
+			AttachmentTypeID uint
+			OriginalFileName string
+			File             string
+			Comment          string `gorm:"type:text"`
+			CreatorID        uint
+		}{},
+			index{"idx_attachments_task_list_id", "task_list_id"},
+		),
+		Down: dropTable("attachments"),
+	},
+	{
+		Version: 16,
+		Name:    "create files",
+		Up: createTable("files", &struct {
+			gorm.Model
+			Hash             string `gorm:"size:64"`
+			Size             int64
+			ContentType      string
+			OriginalFileName string
+			CreatorID        uint
+		}{},
+			index{"idx_files_hash", "hash"},
+			index{"idx_files_deleted_at", "deleted_at"},
+		),
+		Down: dropTable("files"),
+	},
+	{
+		Version: 17,
+		Name:    "create trading_codes",
+		Up: createTable("trading_codes", &struct {
+			gorm.Model
+			Name           string
+			BiddingID      uint
+			SourceID       uint
+			OrganisationID uint
+		}{},
+			index{"idx_trading_codes_bidding_id", "bidding_id"},
+			index{"idx_trading_codes_deleted_at", "deleted_at"},
+		),
+		Down: dropTable("trading_codes"),
+	},
+	{
+		Version: 18,
+		Name:    "create calculations",
+		Up: createTable("calculations", &struct {
+			ID               uint `gorm:"primary_key"`
+			TradingCodeID    uint
+			Sign             bool
+			Costs            int64
+			Type             int
+			Source           int
+			OriginalFileName string
+			File             string
+			Description      string
+			Date             time.Time
+			CreatorID        uint
+			CreatedAt        time.Time
+		}{},
+			index{"idx_calculations_trading_code_id", "trading_code_id, date"},
+		),
+		Down: dropTable("calculations"),
+	},
+	{
+		Version: 19,
+		Name:    "create sros",
+		Up: createTable("sros", &struct {
+			gorm.Model
+			Name    string
+			INN     string
+			OGRN    string
+			RegNum  string
+			Address string
+		}{},
+			index{"idx_sros_inn", "inn"},
+			index{"idx_sros_deleted_at", "deleted_at"},
+		),
+		Down: dropTable("sros"),
+	},
+	{
+		Version: 20,
+		Name:    "index bankruptcy manager search columns",
+		Up: addIndexes("bankruptcy_managers",
+			index{"idx_bankruptcy_managers_surname", "surname, name"},
+			index{"idx_bankruptcy_managers_sro_id", "sro_id"},
+		),
+		Down: removeIndexes("bankruptcy_managers",
+			"idx_bankruptcy_managers_surname",
+			"idx_bankruptcy_managers_sro_id",
+		),
+	},
+	{
+		// The SNILS is searched by its digits, the separators are not
+		// restored by Down.
+		Version: 21,
+		Name:    "normalise bankruptcy manager snils",
+		Up: func(tx *gorm.DB) error {
+			if err := tx.Exec("UPDATE bankruptcy_managers SET snils = REPLACE(REPLACE(snils, '-', ''), ' ', '')").Error; err != nil {
+				return err
+			}
+			return addIndexes("bankruptcy_managers", index{"idx_bankruptcy_managers_snils", "snils"})(tx)
+		},
+		Down: removeIndexes("bankruptcy_managers", "idx_bankruptcy_managers_snils"),
+	},
+}
+
+type index struct {
+	name    string
+	columns string
+}
+
+// createTable creates the table with the columns of the snapshot v.
+func createTable(table string, v interface{}, indexes ...index) func(tx *gorm.DB) error {
+	return func(tx *gorm.DB) error {
+		if err := tx.Table(table).CreateTable(v).Error; err != nil {
+			return err
+		}
+		return addIndexes(table, indexes...)(tx)
+	}
+}
+
+func addIndexes(table string, indexes ...index) func(tx *gorm.DB) error {
+	return func(tx *gorm.DB) error {
+		for _, i := range indexes {
+			if err := tx.Table(table).AddIndex(i.name, i.columns).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func removeIndexes(table string, names ...string) func(tx *gorm.DB) error {
+	return func(tx *gorm.DB) error {
+		for _, name := range names {
+			if err := tx.Table(table).RemoveIndex(name).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// mysqlOnly runs the statement on MySQL, other dialects are left as they
+// are.
+func mysqlOnly(sql string) func(tx *gorm.DB) error {
+	return func(tx *gorm.DB) error {
+		if tx.Dialect().GetName() != "mysql" {
+			return nil
+		}
+		return tx.Exec(sql).Error
+	}
+}
+
+func dropTable(table string) func(tx *gorm.DB) error {
+	return func(tx *gorm.DB) error {
+		return tx.DropTableIfExists(table).Error
+	}
+}
@@ -0,0 +1,214 @@
+package pdf
+
+import (
+	"encoding/binary"
+	"errors"
+	"strings"
+	"unicode/utf16"
+)
+
+// ErrFont is returned for a font that is not a TrueType font.
+var ErrFont = errors.New("pdf: unsupported font, a TrueType font is required")
+
+// Font is a TrueType font embedded in the documents using it, so that any
+// character it has a glyph for can be written.
+type Font struct {
+	name       string
+	data       []byte
+	unitsPerEm float64
+	widths     []uint16
+	glyphs     map[rune]uint16
+	bbox       [4]int16
+	ascent     int16
+	descent    int16
+	capHeight  int16
+}
+
+// ParseFont parses a TrueType (.ttf) font.
+func ParseFont(data []byte) (*Font, error) {
+	tables, err := readTables(data)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range []string{"head", "hhea", "hmtx", "maxp", "cmap", "glyf"} {
+		if tables[t] == nil {
+			return nil, ErrFont
+		}
+	}
+	f := &Font{name: "Embedded", data: data}
+	head, hhea, maxp := tables["head"], tables["hhea"], tables["maxp"]
+	if len(head) < 54 || len(hhea) < 36 || len(maxp) < 6 {
+		return nil, ErrFont
+	}
+	f.unitsPerEm = float64(u16(head, 18))
+	if f.unitsPerEm == 0 {
+		return nil, ErrFont
+	}
+	for i := range f.bbox {
+		f.bbox[i] = int16(u16(head, 36+2*i))
+	}
+	f.ascent, f.descent = int16(u16(hhea, 4)), int16(u16(hhea, 6))
+	f.capHeight = f.ascent
+	if os2 := tables["OS/2"]; len(os2) >= 90 && u16(os2, 0) >= 2 {
+		f.capHeight = int16(u16(os2, 88))
+	}
+
+	numGlyphs, numMetrics := int(u16(maxp, 4)), int(u16(hhea, 34))
+	hmtx := tables["hmtx"]
+	if numMetrics == 0 || len(hmtx) < 4*numMetrics {
+		return nil, ErrFont
+	}
+	f.widths = make([]uint16, numGlyphs)
+	for g := range f.widths {
+		if g < numMetrics {
+			f.widths[g] = u16(hmtx, 4*g)
+		} else {
+			f.widths[g] = f.widths[numMetrics-1]
+		}
+	}
+	if f.glyphs, err = readCmap(tables["cmap"]); err != nil {
+		return nil, err
+	}
+	if name := postScriptName(tables["name"]); name != "" {
+		f.name = name
+	}
+	return f, nil
+}
+
+// width returns the advance of the glyph in thousandths of the font size.
+func (f *Font) width(g uint16) float64 {
+	if int(g) >= len(f.widths) {
+		return 0
+	}
+	return float64(f.widths[g]) * 1000 / f.unitsPerEm
+}
+
+// scale converts font units to thousandths of the font size.
+func (f *Font) scale(v int16) int {
+	return int(float64(v) * 1000 / f.unitsPerEm)
+}
+
+func u16(b []byte, off int) uint16 {
+	if off+2 > len(b) {
+		return 0
+	}
+	return binary.BigEndian.Uint16(b[off:])
+}
+
+func u32(b []byte, off int) uint32 {
+	if off+4 > len(b) {
+		return 0
+	}
+	return binary.BigEndian.Uint32(b[off:])
+}
+
+// readTables returns the tables of the font by tag.
+func readTables(data []byte) (map[string][]byte, error) {
+	if len(data) < 12 {
+		return nil, ErrFont
+	}
+	if v := u32(data, 0); v != 0x00010000 && v != 0x74727565 { // 1.0 or "true"
+		return nil, ErrFont
+	}
+	n := int(u16(data, 4))
+	tables := map[string][]byte{}
+	for i := 0; i < n; i++ {
+		rec := 12 + 16*i
+		if rec+16 > len(data) {
+			return nil, ErrFont
+		}
+		off, length := u32(data, rec+8), u32(data, rec+12)
+		if uint64(off)+uint64(length) > uint64(len(data)) {
+			return nil, ErrFont
+		}
+		tables[string(data[rec:rec+4])] = data[off : off+length]
+	}
+	return tables, nil
+}
+
+// readCmap maps the characters to the glyphs with the Unicode subtable of
+// the cmap, the full repertoire one (format 12) if any, the BMP one
+// (format 4) otherwise.
+func readCmap(cmap []byte) (map[rune]uint16, error) {
+	var bmp, full []byte
+	for i := 0; i < int(u16(cmap, 2)); i++ {
+		rec := 4 + 8*i
+		platform, encoding, off := u16(cmap, rec), u16(cmap, rec+2), u32(cmap, rec+4)
+		if uint64(off) >= uint64(len(cmap)) {
+			continue
+		}
+		sub := cmap[off:]
+		unicode := platform == 0 || platform == 3 && (encoding == 1 || encoding == 10)
+		switch {
+		case unicode && u16(sub, 0) == 12:
+			full = sub
+		case unicode && u16(sub, 0) == 4:
+			bmp = sub
+		}
+	}
+	glyphs := map[rune]uint16{}
+	switch {
+	case full != nil:
+		n := int(u32(full, 12))
+		for i := 0; i < n; i++ {
+			grp := 16 + 12*i
+			start, end, g := u32(full, grp), u32(full, grp+4), u32(full, grp+8)
+			for c := start; c <= end && c <= 0x10FFFF; c++ {
+				glyphs[rune(c)] = uint16(g + c - start)
+			}
+		}
+	case bmp != nil:
+		segs := int(u16(bmp, 6)) / 2
+		ends, starts := 14, 16+2*segs
+		deltas, ranges := starts+2*segs, starts+4*segs
+		for s := 0; s < segs; s++ {
+			start, end := u16(bmp, starts+2*s), u16(bmp, ends+2*s)
+			delta, rangeOff := u16(bmp, deltas+2*s), u16(bmp, ranges+2*s)
+			for c := uint32(start); c <= uint32(end) && c < 0xFFFF; c++ {
+				g := uint16(c) + delta
+				if rangeOff != 0 {
+					g = u16(bmp, ranges+2*s+int(rangeOff)+2*int(c-uint32(start)))
+					if g != 0 {
+						g += delta
+					}
+				}
+				if g != 0 {
+					glyphs[rune(c)] = g
+				}
+			}
+		}
+	default:
+		return nil, ErrFont
+	}
+	return glyphs, nil
+}
+
+// postScriptName returns the PostScript name of the font from the name
+// table, limited to the characters allowed in a PDF name.
+func postScriptName(name []byte) string {
+	count, storage := int(u16(name, 2)), int(u16(name, 4))
+	for i := 0; i < count; i++ {
+		rec := 6 + 12*i
+		platform, id := u16(name, rec), u16(name, rec+6)
+		length, off := int(u16(name, rec+8)), storage+int(u16(name, rec+10))
+		if id != 6 || off+length > len(name) {
+			continue
+		}
+		raw := name[off : off+length]
+		s := string(raw)
+		if platform == 0 || platform == 3 {
+			u := make([]uint16, len(raw)/2)
+			for j := range u {
+				u[j] = u16(raw, 2*j)
+			}
+			s = string(utf16.Decode(u))
+		}
+		return strings.Map(func(r rune) rune {
+			if r > ' ' && r < 0x7f && !strings.ContainsRune("()<>[]{}/%#", r) {
+				return r
+			}
+			return -1
+		}, s)
+	}
+	return ""
+}
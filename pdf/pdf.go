@@ -0,0 +1,237 @@
+// Package pdf writes simple PDF documents of text and lines on A4 pages.
+// Text is set in an embedded TrueType font, or in the standard Courier
+// font, limited to Latin-1, when there is none.
+package pdf
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// Size of an A4 page in points. Positions on a page are in points from
+// its bottom left corner.
+const (
+	PageWidth  = 595.28
+	PageHeight = 841.89
+)
+
+// Document is a PDF document under construction.
+type Document struct {
+	font  *Font
+	used  map[uint16]rune
+	pages []*Page
+}
+
+// Page of a document.
+type Page struct {
+	doc     *Document
+	content bytes.Buffer
+}
+
+// New returns an empty document setting text in the font, in Courier when
+// font is nil.
+func New(font *Font) *Document {
+	return &Document{font: font, used: map[uint16]rune{}}
+}
+
+// AddPage appends a page to the document.
+func (d *Document) AddPage() *Page {
+	p := &Page{doc: d}
+	d.pages = append(d.pages, p)
+	return p
+}
+
+// TextWidth returns the width of s set in the size, in points.
+func (d *Document) TextWidth(s string, size float64) float64 {
+	w := 0.0
+	for _, r := range s {
+		if d.font == nil {
+			w += 600
+		} else {
+			w += d.font.width(d.font.glyphs[r])
+		}
+	}
+	return w * size / 1000
+}
+
+// Text writes s with its baseline starting at x, y.
+func (p *Page) Text(x, y, size float64, s string) {
+	fmt.Fprintf(&p.content, "BT /F1 %s Tf %s %s Td ", num(size), num(x), num(y))
+	if f := p.doc.font; f != nil {
+		p.content.WriteByte('<')
+		for _, r := range s {
+			g := f.glyphs[r]
+			if _, ok := p.doc.used[g]; !ok {
+				p.doc.used[g] = r
+			}
+			fmt.Fprintf(&p.content, "%04X", g)
+		}
+		p.content.WriteByte('>')
+	} else {
+		p.content.WriteByte('(')
+		for _, r := range s {
+			switch {
+			case r == '(' || r == ')' || r == '\\':
+				p.content.WriteByte('\\')
+				p.content.WriteByte(byte(r))
+			case r < ' ' || r >= 0x7f && r < 0xa0 || r > 0xff:
+				p.content.WriteByte('?')
+			default:
+				p.content.WriteByte(byte(r))
+			}
+		}
+		p.content.WriteByte(')')
+	}
+	p.content.WriteString(" Tj ET\n")
+}
+
+// Line draws a line of the width from x1, y1 to x2, y2.
+func (p *Page) Line(x1, y1, x2, y2, width float64) {
+	fmt.Fprintf(&p.content, "%s w %s %s m %s %s l S\n", num(width), num(x1), num(y1), num(x2), num(y2))
+}
+
+// Write writes the document to w.
+func (d *Document) Write(w io.Writer) error {
+	if len(d.pages) == 0 {
+		d.AddPage()
+	}
+	pw := &writer{w: bufio.NewWriter(w)}
+	pw.printf("%%PDF-1.4\n%%\xe2\xe3\xcf\xd3\n")
+
+	// Objects 1 and 2 are the catalog and the page tree, the font follows
+	// and each page is a page object and its content stream.
+	const catalog, pages, font = 1, 2, 3
+	next := font + 1
+	if d.font != nil {
+		next = d.writeFont(pw, font)
+	} else {
+		pw.object(font, "<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>")
+	}
+	kids := make([]string, len(d.pages))
+	for i, p := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", next)
+		pw.object(next, fmt.Sprintf("<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %s %s] /Resources << /Font << /F1 %d 0 R >> >> /Contents %d 0 R >>",
+			pages, num(PageWidth), num(PageHeight), font, next+1))
+		pw.stream(next+1, "", p.content.Bytes())
+		next += 2
+	}
+	pw.object(pages, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	pw.object(catalog, fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pages))
+
+	xref := pw.n
+	pw.printf("xref\n0 %d\n0000000000 65535 f \n", next)
+	for i := 1; i < next; i++ {
+		pw.printf("%010d 00000 n \n", pw.offsets[i])
+	}
+	pw.printf("trailer\n<< /Size %d /Root %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", next, catalog, xref)
+	if pw.err != nil {
+		return pw.err
+	}
+	return pw.w.Flush()
+}
+
+// writeFont writes the embedded font as a composite font with the glyph
+// ids as character codes, and returns the next free object number.
+func (d *Document) writeFont(pw *writer, id int) int {
+	f := d.font
+	cid, desc, file, toUnicode := id+1, id+2, id+3, id+4
+	pw.object(id, fmt.Sprintf("<< /Type /Font /Subtype /Type0 /BaseFont /%s /Encoding /Identity-H /DescendantFonts [%d 0 R] /ToUnicode %d 0 R >>",
+		f.name, cid, toUnicode))
+
+	glyphs := make([]int, 0, len(d.used))
+	for g := range d.used {
+		glyphs = append(glyphs, int(g))
+	}
+	sort.Ints(glyphs)
+	widths := strings.Builder{}
+	for _, g := range glyphs {
+		fmt.Fprintf(&widths, "%d [%d] ", g, int(f.width(uint16(g))))
+	}
+	pw.object(cid, fmt.Sprintf("<< /Type /Font /Subtype /CIDFontType2 /BaseFont /%s /CIDSystemInfo << /Registry (Adobe) /Ordering (Identity) /Supplement 0 >> /FontDescriptor %d 0 R /CIDToGIDMap /Identity /W [%s] >>",
+		f.name, desc, widths.String()))
+	pw.object(desc, fmt.Sprintf("<< /Type /FontDescriptor /FontName /%s /Flags 32 /FontBBox [%d %d %d %d] /ItalicAngle 0 /Ascent %d /Descent %d /CapHeight %d /StemV 80 /FontFile2 %d 0 R >>",
+		f.name, f.scale(f.bbox[0]), f.scale(f.bbox[1]), f.scale(f.bbox[2]), f.scale(f.bbox[3]),
+		f.scale(f.ascent), f.scale(f.descent), f.scale(f.capHeight), file))
+	pw.stream(file, fmt.Sprintf("/Length1 %d", len(f.data)), f.data)
+
+	cmap := bytes.Buffer{}
+	cmap.WriteString("/CIDInit /ProcSet findresource begin\n12 dict begin\nbegincmap\n" +
+		"/CIDSystemInfo << /Registry (Adobe) /Ordering (UCS) /Supplement 0 >> def\n" +
+		"/CMapName /Adobe-Identity-UCS def\n/CMapType 2 def\n" +
+		"1 begincodespacerange\n<0000> <FFFF>\nendcodespacerange\n")
+	for i := 0; i < len(glyphs); i += 100 {
+		block := glyphs[i:]
+		if len(block) > 100 {
+			block = block[:100]
+		}
+		fmt.Fprintf(&cmap, "%d beginbfchar\n", len(block))
+		for _, g := range block {
+			fmt.Fprintf(&cmap, "<%04X> <", g)
+			for _, u := range utf16.Encode([]rune{d.used[uint16(g)]}) {
+				fmt.Fprintf(&cmap, "%04X", u)
+			}
+			cmap.WriteString(">\n")
+		}
+		cmap.WriteString("endbfchar\n")
+	}
+	cmap.WriteString("endcmap\nCMapName currentdict /CMap defineresource pop\nend\nend\n")
+	pw.stream(toUnicode, "", cmap.Bytes())
+	return toUnicode + 1
+}
+
+// writer keeps the offsets of the objects for the cross-reference table.
+type writer struct {
+	w       *bufio.Writer
+	n       int
+	offsets map[int]int
+	err     error
+}
+
+func (pw *writer) printf(format string, args ...interface{}) {
+	if pw.err != nil {
+		return
+	}
+	n, err := fmt.Fprintf(pw.w, format, args...)
+	pw.n += n
+	pw.err = err
+}
+
+func (pw *writer) object(id int, body string) {
+	if pw.offsets == nil {
+		pw.offsets = map[int]int{}
+	}
+	pw.offsets[id] = pw.n
+	pw.printf("%d 0 obj\n%s\nendobj\n", id, body)
+}
+
+// stream writes a compressed stream object, extra are additional entries
+// of its dictionary.
+func (pw *writer) stream(id int, extra string, data []byte) {
+	b := bytes.Buffer{}
+	z := zlib.NewWriter(&b)
+	z.Write(data)
+	z.Close()
+	if extra != "" {
+		extra = " " + extra
+	}
+	pw.object(id, fmt.Sprintf("<< /Length %d /Filter /FlateDecode%s >>\nstream\n%s\nendstream", b.Len(), extra, b.Bytes()))
+}
+
+// num formats a number with at most two decimals.
+func num(v float64) string {
+	return strconv.FormatFloat(float64(int64(v*100+0.5*sign(v)))/100, 'f', -1, 64)
+}
+
+func sign(v float64) float64 {
+	if v < 0 {
+		return -1
+	}
+	return 1
+}
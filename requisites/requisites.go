@@ -0,0 +1,207 @@
+// Package requisites checks Russian legal identifiers: INN, KPP, OGRN,
+// OGRNIP, SNILS, BIK and bank account numbers.
+package requisites
+
+import (
+	"errors"
+	"strings"
+)
+
+var (
+	// ErrINNLength var
+	ErrINNLength = errors.New("must have 10 or 12 digits")
+	// ErrPersonINNLength var
+	ErrPersonINNLength = errors.New("must have 12 digits")
+	// ErrKPPFormat var
+	ErrKPPFormat = errors.New("must have 9 characters: 4 digits, 2 digits or capital letters, 3 digits")
+	// ErrOGRNLength var
+	ErrOGRNLength = errors.New("must have 13 (OGRN) or 15 (OGRNIP) digits")
+	// ErrSNILSLength var
+	ErrSNILSLength = errors.New("must have 11 digits")
+	// ErrBIKLength var
+	ErrBIKLength = errors.New("must have 9 digits")
+	// ErrAccountLength var
+	ErrAccountLength = errors.New("must have 20 digits")
+	// ErrNotDigits var
+	ErrNotDigits = errors.New("must contain digits only")
+	// ErrChecksum var
+	ErrChecksum = errors.New("control digit mismatch")
+	// ErrNoBIK var
+	ErrNoBIK = errors.New("cannot be checked without a valid BIK")
+)
+
+var (
+	inn10Weights  = []int{2, 4, 10, 3, 5, 9, 4, 6, 8}
+	inn11Weights  = []int{7, 2, 4, 10, 3, 5, 9, 4, 6, 8}
+	inn12Weights  = []int{3, 7, 2, 4, 10, 3, 5, 9, 4, 6, 8}
+	accountWeight = []int{7, 1, 3}
+)
+
+// INN checks the taxpayer number of an organisation (10 digits) or of a
+// person (12 digits).
+func INN(inn string) error {
+	d, err := digits(inn)
+	if err != nil {
+		return err
+	}
+	switch len(d) {
+	case 10:
+		if weighted(d[:9], inn10Weights)%11%10 != d[9] {
+			return ErrChecksum
+		}
+	case 12:
+		if weighted(d[:10], inn11Weights)%11%10 != d[10] ||
+			weighted(d[:11], inn12Weights)%11%10 != d[11] {
+			return ErrChecksum
+		}
+	default:
+		return ErrINNLength
+	}
+	return nil
+}
+
+// PersonINN checks the 12 digit taxpayer number of a person.
+func PersonINN(inn string) error {
+	if len(inn) != 12 {
+		return ErrPersonINNLength
+	}
+	return INN(inn)
+}
+
+// KPP checks the tax registration reason code.
+func KPP(kpp string) error {
+	if len(kpp) != 9 {
+		return ErrKPPFormat
+	}
+	for i, c := range kpp {
+		digit := c >= '0' && c <= '9'
+		if !digit && !(i >= 4 && i < 6 && c >= 'A' && c <= 'Z') {
+			return ErrKPPFormat
+		}
+	}
+	return nil
+}
+
+// OGRN checks the primary state registration number of an organisation
+// (13 digits) or of an individual entrepreneur, OGRNIP (15 digits).
+func OGRN(ogrn string) error {
+	d, err := digits(ogrn)
+	if err != nil {
+		return err
+	}
+	var mod int64
+	switch len(d) {
+	case 13:
+		mod = 11
+	case 15:
+		mod = 13
+	default:
+		return ErrOGRNLength
+	}
+	var n int64
+	for _, v := range d[:len(d)-1] {
+		n = n*10 + int64(v)
+	}
+	if int(n%mod%10) != d[len(d)-1] {
+		return ErrChecksum
+	}
+	return nil
+}
+
+// SNILS checks the insurance number of a person. Spaces and dashes of the
+// usual "123-456-789 01" notation are ignored.
+func SNILS(snils string) error {
+	d, err := digits(strings.NewReplacer("-", "", " ", "").Replace(snils))
+	if err != nil {
+		return err
+	}
+	if len(d) != 11 {
+		return ErrSNILSLength
+	}
+	number := 0
+	sum := 0
+	for i, v := range d[:9] {
+		number = number*10 + v
+		sum += v * (9 - i)
+	}
+	// Numbers up to 001-001-998 were issued without a control sum.
+	if number <= 1001998 {
+		return nil
+	}
+	sum %= 101
+	if sum == 100 {
+		sum = 0
+	}
+	if sum != d[9]*10+d[10] {
+		return ErrChecksum
+	}
+	return nil
+}
+
+// BIK checks the bank identification code.
+func BIK(bik string) error {
+	d, err := digits(bik)
+	if err != nil {
+		return err
+	}
+	if len(d) != 9 {
+		return ErrBIKLength
+	}
+	return nil
+}
+
+// Account checks the control key of a settlement account opened in the
+// bank with the BIK.
+func Account(account, bik string) error {
+	if BIK(bik) != nil {
+		return ErrNoBIK
+	}
+	return accountKey(bik[6:9], account)
+}
+
+// CorrAccount checks the control key of the correspondent account of the
+// bank with the BIK.
+func CorrAccount(account, bik string) error {
+	if BIK(bik) != nil {
+		return ErrNoBIK
+	}
+	return accountKey("0"+bik[4:6], account)
+}
+
+func accountKey(prefix, account string) error {
+	d, err := digits(account)
+	if err != nil {
+		return err
+	}
+	if len(d) != 20 {
+		return ErrAccountLength
+	}
+	p, _ := digits(prefix)
+	sum := 0
+	for i, v := range append(p, d...) {
+		sum += v * accountWeight[i%3] % 10
+	}
+	if sum%10 != 0 {
+		return ErrChecksum
+	}
+	return nil
+}
+
+func weighted(d []int, weights []int) int {
+	sum := 0
+	for i, w := range weights {
+		sum += d[i] * w
+	}
+	return sum
+}
+
+func digits(s string) ([]int, error) {
+	d := make([]int, len(s))
+	for i, c := range s {
+		if c < '0' || c > '9' {
+			return nil, ErrNotDigits
+		}
+		d[i] = int(c - '0')
+	}
+	return d, nil
+}
@@ -0,0 +1,69 @@
+package requisites
+
+import "testing"
+
+func TestChecksums(t *testing.T) {
+	cases := []struct {
+		name  string
+		check func(string) error
+		in    string
+		want  error
+	}{
+		{"INN", INN, "7707083893", nil},
+		{"INN", INN, "500100732259", nil},
+		{"INN", INN, "7707083894", ErrChecksum},
+		{"INN", INN, "500100732269", ErrChecksum},
+		{"INN", INN, "500100732258", ErrChecksum},
+		{"INN", INN, "77070838", ErrINNLength},
+		{"INN", INN, "77070838a3", ErrNotDigits},
+		{"PersonINN", PersonINN, "500100732259", nil},
+		{"PersonINN", PersonINN, "7707083893", ErrPersonINNLength},
+		{"KPP", KPP, "773601001", nil},
+		{"KPP", KPP, "7736AB001", nil},
+		{"KPP", KPP, "77360100", ErrKPPFormat},
+		{"KPP", KPP, "77A601001", ErrKPPFormat},
+		{"KPP", KPP, "7736ab001", ErrKPPFormat},
+		{"OGRN", OGRN, "1027700132195", nil},
+		{"OGRN", OGRN, "1027700132196", ErrChecksum},
+		{"OGRN", OGRN, "304500116000157", nil},
+		{"OGRN", OGRN, "304500116000158", ErrChecksum},
+		{"OGRN", OGRN, "10277001321", ErrOGRNLength},
+		{"SNILS", SNILS, "112-233-445 95", nil},
+		{"SNILS", SNILS, "11223344595", nil},
+		{"SNILS", SNILS, "112-233-445 96", ErrChecksum},
+		{"SNILS", SNILS, "001-001-998 00", nil},
+		{"SNILS", SNILS, "112-233-445", ErrSNILSLength},
+		{"SNILS", SNILS, "112-233-445 9x", ErrNotDigits},
+		{"BIK", BIK, "044525225", nil},
+		{"BIK", BIK, "04452522", ErrBIKLength},
+	}
+	for _, c := range cases {
+		if err := c.check(c.in); err != c.want {
+			t.Errorf("%s(%q) = %v, want %v", c.name, c.in, err, c.want)
+		}
+	}
+}
+
+func TestAccounts(t *testing.T) {
+	cases := []struct {
+		name    string
+		check   func(string, string) error
+		account string
+		bik     string
+		want    error
+	}{
+		{"Account", Account, "40702810380000000031", "044525225", nil},
+		{"Account", Account, "40702810380000000032", "044525225", ErrChecksum},
+		{"Account", Account, "40702810380000000031", "044525226", ErrChecksum},
+		{"Account", Account, "4070281038000000003", "044525225", ErrAccountLength},
+		{"Account", Account, "40702810380000000031", "04452522", ErrNoBIK},
+		{"CorrAccount", CorrAccount, "30101810400000000225", "044525225", nil},
+		{"CorrAccount", CorrAccount, "30101810400000000226", "044525225", ErrChecksum},
+		{"CorrAccount", CorrAccount, "30101810400000000225", "", ErrNoBIK},
+	}
+	for _, c := range cases {
+		if err := c.check(c.account, c.bik); err != c.want {
+			t.Errorf("%s(%q, %q) = %v, want %v", c.name, c.account, c.bik, err, c.want)
+		}
+	}
+}
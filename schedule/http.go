@@ -0,0 +1,61 @@
+package schedule
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// NewHTTPHandler returns an HTTP handler for managing the scheduler's jobs.
+func NewHTTPHandler(s *Scheduler) http.Handler {
+	// GET    /jobs              lists jobs
+	// GET    /jobs/{id}         retrieves the given job
+	// DELETE /jobs/{id}         cancels the given job
+	// POST   /jobs/{id}/pause   pauses the given job
+	// POST   /jobs/{id}/resume  resumes the given job
+	m := mux.NewRouter()
+	m.Methods("GET").Path("/jobs").HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		encodeJSON(w, s.List(), nil)
+	})
+	m.Methods("GET").Path("/jobs/{id}").HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		j, err := s.Get(mux.Vars(r)["id"])
+		encodeJSON(w, j, err)
+	})
+	m.Methods("DELETE").Path("/jobs/{id}").HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		encodeStatus(w, s, mux.Vars(r)["id"], s.Cancel(mux.Vars(r)["id"]))
+	})
+	m.Methods("POST").Path("/jobs/{id}/pause").HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		encodeStatus(w, s, mux.Vars(r)["id"], s.Pause(mux.Vars(r)["id"]))
+	})
+	m.Methods("POST").Path("/jobs/{id}/resume").HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		encodeStatus(w, s, mux.Vars(r)["id"], s.Resume(mux.Vars(r)["id"]))
+	})
+	return m
+}
+
+func encodeStatus(w http.ResponseWriter, s *Scheduler, id string, err error) {
+	if err != nil {
+		encodeJSON(w, nil, err)
+		return
+	}
+	j, err := s.Get(id)
+	encodeJSON(w, j, err)
+}
+
+func encodeJSON(w http.ResponseWriter, v interface{}, err error) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	if err != nil {
+		switch err {
+		case ErrNotFound:
+			w.WriteHeader(http.StatusNotFound)
+		case ErrFinished:
+			w.WriteHeader(http.StatusConflict)
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,92 @@
+package schedule
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"time"
+)
+
+// Handler runs a job with its payload.
+type Handler func(ctx context.Context, payload []byte) error
+
+// Status type
+type Status string
+
+const (
+	// Active job waits for its next run.
+	Active = Status("active")
+	// Paused job is kept but not run until resumed.
+	Paused = Status("paused")
+	// Done job has made all of its runs.
+	Done = Status("done")
+	// Canceled job is stopped by the user.
+	Canceled = Status("canceled")
+)
+
+var (
+	// ErrNotFound var
+	ErrNotFound = errors.New("job not found")
+	// ErrUnknownHandler var
+	ErrUnknownHandler = errors.New("unknown job handler")
+	// ErrInvalidRequest var
+	ErrInvalidRequest = errors.New("periodical request without intervals")
+	// ErrFinished var
+	ErrFinished = errors.New("job is finished")
+)
+
+// Job is a Request bound to a registered handler.
+type Job struct {
+	ID        string    `json:"id" gorm:"primary_key;size:32"`
+	Handler   string    `json:"handler"`
+	Payload   []byte    `json:"payload,omitempty"`
+	Request   Request   `json:"request" gorm:"embedded"`
+	Status    Status    `json:"status"`
+	Runs      int16     `json:"runs"`
+	NextRun   time.Time `json:"next_run"`
+	LastRun   time.Time `json:"last_run,omitempty"`
+	LastError string    `json:"last_error,omitempty"`
+}
+
+// TableName sets Job's table name to be `schedule_jobs`.
+func (Job) TableName() string {
+	return "schedule_jobs"
+}
+
+// newJob validates the request and computes the first run.
+func newJob(req Request, handler string, payload []byte, now time.Time) (Job, error) {
+	if req.Type == PERIODICALY && req.Intervals <= 0 {
+		return Job{}, ErrInvalidRequest
+	}
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return Job{}, err
+	}
+	j := Job{
+		ID:      hex.EncodeToString(id),
+		Handler: handler,
+		Payload: payload,
+		Request: req,
+		Status:  Active,
+		NextRun: now,
+	}
+	if req.FirstRun != nil && req.FirstRun.After(now) {
+		j.NextRun = *req.FirstRun
+	}
+	return j, nil
+}
+
+// advance counts a run started at now and moves the job to its next run.
+func (j *Job) advance(now time.Time) {
+	j.Runs++
+	j.LastRun = now
+	if j.Request.Type != PERIODICALY || (j.Request.Max > 0 && j.Runs >= j.Request.Max) {
+		j.Status = Done
+		return
+	}
+	interval := time.Duration(j.Request.Intervals) * time.Second
+	for !j.NextRun.After(now) {
+		j.NextRun = j.NextRun.Add(interval)
+	}
+}
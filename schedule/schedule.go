@@ -25,10 +25,11 @@ var TypeValue = map[string]int8{
 }
 
 func (x Type) String() string {
-	return x.String()
+	return TypeName[int8(x)]
 }
 
-// Request struct
+// Request struct. Intervals is the period between runs in seconds, Max is
+// the number of runs of a PERIODICALY request, zero means unlimited.
 type Request struct {
 	Type      Type       `json:"type,omitempty"`
 	FirstRun  *time.Time `json:"first_run,omitempty"`
@@ -0,0 +1,239 @@
+package schedule
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+)
+
+// Scheduler runs jobs according to their requests. A job is written to the
+// store with its next run before the handler starts, so runs are at most
+// once: a restart does not repeat a run, but loses the one interrupted.
+type Scheduler struct {
+	store  Store
+	logger log.Logger
+
+	mtx      sync.Mutex
+	handlers map[string]Handler
+	jobs     map[string]*Job
+	running  map[string]bool
+	wake     chan struct{}
+}
+
+// New returns a Scheduler keeping its jobs in the store.
+func New(store Store, logger log.Logger) *Scheduler {
+	return &Scheduler{
+		store:    store,
+		logger:   logger,
+		handlers: map[string]Handler{},
+		jobs:     map[string]*Job{},
+		running:  map[string]bool{},
+		wake:     make(chan struct{}, 1),
+	}
+}
+
+// Register makes the handler available to jobs under the name. Handlers
+// must be registered before Run, so that persisted jobs can find them.
+func (s *Scheduler) Register(name string, h Handler) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.handlers[name] = h
+}
+
+// Add schedules the named handler with the payload.
+func (s *Scheduler) Add(req Request, handler string, payload []byte) (Job, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if _, ok := s.handlers[handler]; !ok {
+		return Job{}, ErrUnknownHandler
+	}
+	j, err := newJob(req, handler, payload, time.Now())
+	if err != nil {
+		return j, err
+	}
+	if err := s.store.Save(j); err != nil {
+		return j, err
+	}
+	s.jobs[j.ID] = &j
+	s.notify()
+	return j, nil
+}
+
+// Get returns the job by id.
+func (s *Scheduler) Get(id string) (Job, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	j, ok := s.jobs[id]
+	if !ok {
+		return Job{}, ErrNotFound
+	}
+	return *j, nil
+}
+
+// List returns all known jobs ordered by their next run.
+func (s *Scheduler) List() []Job {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	jobs := make([]Job, 0, len(s.jobs))
+	for _, j := range s.jobs {
+		jobs = append(jobs, *j)
+	}
+	sort.Slice(jobs, func(i, k int) bool { return jobs[i].NextRun.Before(jobs[k].NextRun) })
+	return jobs
+}
+
+// Cancel stops the job for good.
+func (s *Scheduler) Cancel(id string) error {
+	return s.setStatus(id, Canceled, Active, Paused)
+}
+
+// Pause suspends the job until Resume.
+func (s *Scheduler) Pause(id string) error {
+	return s.setStatus(id, Paused, Active)
+}
+
+// Resume continues a paused job. Runs missed while paused are skipped.
+func (s *Scheduler) Resume(id string) error {
+	return s.setStatus(id, Active, Paused)
+}
+
+func (s *Scheduler) setStatus(id string, to Status, from ...Status) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	j, ok := s.jobs[id]
+	if !ok {
+		return ErrNotFound
+	}
+	allowed := false
+	for _, st := range from {
+		allowed = allowed || j.Status == st
+	}
+	if !allowed {
+		return ErrFinished
+	}
+	updated := *j
+	updated.Status = to
+	if to == Active && j.Request.Type == PERIODICALY {
+		now := time.Now()
+		interval := time.Duration(j.Request.Intervals) * time.Second
+		for updated.NextRun.Before(now) {
+			updated.NextRun = updated.NextRun.Add(interval)
+		}
+	}
+	if err := s.store.Save(updated); err != nil {
+		return err
+	}
+	*j = updated
+	s.notify()
+	return nil
+}
+
+// Run loads persisted jobs and runs them until the context is canceled.
+func (s *Scheduler) Run(ctx context.Context) error {
+	jobs, err := s.store.List()
+	if err != nil {
+		return err
+	}
+	s.mtx.Lock()
+	for i := range jobs {
+		if _, ok := s.jobs[jobs[i].ID]; !ok {
+			s.jobs[jobs[i].ID] = &jobs[i]
+		}
+	}
+	s.mtx.Unlock()
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		next := s.dispatch(ctx, &wg)
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		if !next.IsZero() {
+			timer.Reset(time.Until(next))
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-s.wake:
+		case <-timer.C:
+		}
+	}
+}
+
+// dispatch starts all due jobs and returns the time of the nearest future run.
+func (s *Scheduler) dispatch(ctx context.Context, wg *sync.WaitGroup) time.Time {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	now := time.Now()
+	var next time.Time
+	for id, j := range s.jobs {
+		if j.Status != Active || s.running[id] {
+			continue
+		}
+		if j.NextRun.After(now) {
+			if next.IsZero() || j.NextRun.Before(next) {
+				next = j.NextRun
+			}
+			continue
+		}
+		h, ok := s.handlers[j.Handler]
+		if !ok {
+			s.logger.Log("job", id, "handler", j.Handler, "err", ErrUnknownHandler)
+			continue
+		}
+		updated := *j
+		updated.advance(now)
+		if err := s.store.Save(updated); err != nil {
+			s.logger.Log("job", id, "during", "Save", "err", err)
+			continue
+		}
+		*j = updated
+		if j.Status == Active && (next.IsZero() || j.NextRun.Before(next)) {
+			next = j.NextRun
+		}
+		s.running[id] = true
+		wg.Add(1)
+		go s.run(ctx, wg, *j, h)
+	}
+	return next
+}
+
+func (s *Scheduler) run(ctx context.Context, wg *sync.WaitGroup, j Job, h Handler) {
+	defer wg.Done()
+	begin := time.Now()
+	err := h(ctx, j.Payload)
+	s.logger.Log("job", j.ID, "handler", j.Handler, "run", j.Runs, "took", time.Since(begin), "err", err)
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	delete(s.running, j.ID)
+	cur, ok := s.jobs[j.ID]
+	if !ok {
+		return
+	}
+	cur.LastError = ""
+	if err != nil {
+		cur.LastError = err.Error()
+	}
+	if err := s.store.Save(*cur); err != nil {
+		s.logger.Log("job", j.ID, "during", "Save", "err", err)
+	}
+	s.notify()
+}
+
+// notify wakes up the Run loop. Must be called with mtx held.
+func (s *Scheduler) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,181 @@
+package schedule
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+)
+
+func TestAdvance(t *testing.T) {
+	start := time.Date(2019, 3, 1, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		req    Request
+		runs   int16
+		now    time.Time
+		status Status
+		next   time.Time
+	}{
+		{"immediate", Request{Type: IMMEDIATELY}, 0, start, Done, start},
+		{"on time", Request{Type: PERIODICALY, Intervals: 60}, 0, start, Active, start.Add(time.Minute)},
+		{"late", Request{Type: PERIODICALY, Intervals: 60}, 0, start.Add(150 * time.Second), Active, start.Add(3 * time.Minute)},
+		{"last run", Request{Type: PERIODICALY, Intervals: 60, Max: 3}, 2, start, Done, start},
+		{"unlimited", Request{Type: PERIODICALY, Intervals: 60}, 1000, start, Active, start.Add(time.Minute)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			j := Job{Request: tt.req, Status: Active, Runs: tt.runs, NextRun: start}
+			j.advance(tt.now)
+			if j.Status != tt.status || !j.NextRun.Equal(tt.next) || j.Runs != tt.runs+1 || !j.LastRun.Equal(tt.now) {
+				t.Errorf("advance(%v) = %s next %v runs %d last %v, want %s next %v runs %d",
+					tt.now, j.Status, j.NextRun, j.Runs, j.LastRun, tt.status, tt.next, tt.runs+1)
+			}
+		})
+	}
+}
+
+func TestNewJob(t *testing.T) {
+	now := time.Date(2019, 3, 1, 10, 0, 0, 0, time.UTC)
+	past, future := now.Add(-time.Hour), now.Add(time.Hour)
+	tests := []struct {
+		name string
+		req  Request
+		next time.Time
+		err  error
+	}{
+		{"now", Request{Type: IMMEDIATELY}, now, nil},
+		{"first run ahead", Request{Type: PERIODICALY, Intervals: 60, FirstRun: &future}, future, nil},
+		{"first run passed", Request{Type: PERIODICALY, Intervals: 60, FirstRun: &past}, now, nil},
+		{"no intervals", Request{Type: PERIODICALY}, time.Time{}, ErrInvalidRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			j, err := newJob(tt.req, "h", nil, now)
+			if err != tt.err || !j.NextRun.Equal(tt.next) {
+				t.Errorf("newJob = next %v, %v, want %v, %v", j.NextRun, err, tt.next, tt.err)
+			}
+			if err == nil && (j.ID == "" || j.Status != Active) {
+				t.Errorf("newJob = id %q status %s", j.ID, j.Status)
+			}
+		})
+	}
+}
+
+func TestResumeSkipsMissedRuns(t *testing.T) {
+	s := New(NewMemStore(), log.NewNopLogger())
+	s.Register("h", func(context.Context, []byte) error { return nil })
+	first := time.Now().Add(time.Hour)
+	j, err := s.Add(Request{Type: PERIODICALY, Intervals: 60, FirstRun: &first}, "h", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Pause(j.ID); err != nil {
+		t.Fatal(err)
+	}
+	s.jobs[j.ID].NextRun = time.Now().Add(-150 * time.Second)
+	if err := s.Resume(j.ID); err != nil {
+		t.Fatal(err)
+	}
+	got, _ := s.Get(j.ID)
+	if got.Status != Active || got.NextRun.Before(time.Now()) || got.NextRun.After(time.Now().Add(time.Minute)) {
+		t.Errorf("resumed job = %s next %v, want active within a minute", got.Status, got.NextRun)
+	}
+	if err := s.Cancel(j.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Resume(j.ID); err != ErrFinished {
+		t.Errorf("Resume of a canceled job: %v, want ErrFinished", err)
+	}
+}
+
+func TestRunLoadsStore(t *testing.T) {
+	store := NewMemStore()
+	noop := func(context.Context, []byte) error { return nil }
+	first := New(store, log.NewNopLogger())
+	first.Register("h", noop)
+	later := time.Now().Add(time.Hour)
+	j, err := first.Add(Request{Type: PERIODICALY, Intervals: 60, FirstRun: &later}, "h", []byte("p"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan []byte, 1)
+	second := New(store, log.NewNopLogger())
+	second.Register("h", func(ctx context.Context, payload []byte) error {
+		done <- payload
+		return nil
+	})
+	if _, err := second.Get(j.ID); err != ErrNotFound {
+		t.Fatalf("Get before Run: %v, want ErrNotFound", err)
+	}
+	// The first scheduler never runs, the job falls due while it is down.
+	due := j
+	due.NextRun = time.Now()
+	store.Save(due)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ran := make(chan error, 1)
+	go func() { ran <- second.Run(ctx) }()
+	select {
+	case payload := <-done:
+		if string(payload) != "p" {
+			t.Errorf("payload %q, want %q", payload, "p")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("persisted job was not run")
+	}
+	cancel()
+	if err := <-ran; err != context.Canceled {
+		t.Errorf("Run = %v, want context.Canceled", err)
+	}
+
+	jobs, _ := store.List()
+	if len(jobs) != 1 || jobs[0].Runs != 1 || !jobs[0].NextRun.After(time.Now()) {
+		t.Fatalf("stored jobs after the run %+v", jobs)
+	}
+	if got, err := second.Get(j.ID); err != nil || got.Runs != 1 {
+		t.Errorf("Get after Run = %+v, %v", got, err)
+	}
+}
+
+func TestDispatchSkipsRunningJob(t *testing.T) {
+	s := New(NewMemStore(), log.NewNopLogger())
+	release := make(chan struct{})
+	var mtx sync.Mutex
+	calls := 0
+	s.Register("h", func(context.Context, []byte) error {
+		mtx.Lock()
+		calls++
+		mtx.Unlock()
+		<-release
+		return nil
+	})
+	j, err := s.Add(Request{Type: PERIODICALY, Intervals: 60}, "h", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	ctx := context.Background()
+	s.dispatch(ctx, &wg)
+	// The job is due again but its first run has not finished.
+	s.mtx.Lock()
+	s.jobs[j.ID].NextRun = time.Now().Add(-time.Second)
+	s.mtx.Unlock()
+	s.dispatch(ctx, &wg)
+	close(release)
+	wg.Wait()
+
+	got, _ := s.Get(j.ID)
+	if calls != 1 || got.Runs != 1 {
+		t.Fatalf("handler called %d times, %d runs, want 1", calls, got.Runs)
+	}
+	s.dispatch(ctx, &wg)
+	wg.Wait()
+	if got, _ := s.Get(j.ID); calls != 2 || got.Runs != 2 {
+		t.Errorf("after the run finished: handler called %d times, %d runs, want 2", calls, got.Runs)
+	}
+}
@@ -0,0 +1,84 @@
+package schedule
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/jinzhu/gorm"
+	_ "github.com/jinzhu/gorm/dialects/mysql" // Mysql driver
+)
+
+// Store persists jobs so that they survive restarts.
+type Store interface {
+	Save(j Job) error
+	Delete(id string) error
+	List() ([]Job, error)
+}
+
+type databaseStore struct{ db *gorm.DB }
+
+// NewDB returns a Store backed by MySQL.
+func NewDB(DSN string) (Store, error) {
+	db, err := gorm.Open("mysql", DSN)
+	if err != nil {
+		return nil, err
+	}
+	return NewDBStore(db)
+}
+
+// NewDBStore returns a Store on an open connection.
+func NewDBStore(db *gorm.DB) (Store, error) {
+	if err := db.AutoMigrate(&Job{}).Error; err != nil {
+		return nil, err
+	}
+	return &databaseStore{db: db}, nil
+}
+
+func (ds *databaseStore) Save(j Job) error {
+	return ds.db.Save(&j).Error
+}
+
+func (ds *databaseStore) Delete(id string) error {
+	return ds.db.Where("id = ?", id).Delete(Job{}).Error
+}
+
+func (ds *databaseStore) List() ([]Job, error) {
+	jobs := []Job{}
+	err := ds.db.Order("next_run").Find(&jobs).Error
+	return jobs, err
+}
+
+type memStore struct {
+	mtx  sync.RWMutex
+	jobs map[string]Job
+}
+
+// NewMemStore returns an in-memory Store.
+func NewMemStore() Store {
+	return &memStore{jobs: map[string]Job{}}
+}
+
+func (s *memStore) Save(j Job) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.jobs[j.ID] = j
+	return nil
+}
+
+func (s *memStore) Delete(id string) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	delete(s.jobs, id)
+	return nil
+}
+
+func (s *memStore) List() ([]Job, error) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	jobs := make([]Job, 0, len(s.jobs))
+	for _, j := range s.jobs {
+		jobs = append(jobs, j)
+	}
+	sort.Slice(jobs, func(i, k int) bool { return jobs[i].NextRun.Before(jobs[k].NextRun) })
+	return jobs, nil
+}
@@ -0,0 +1,215 @@
+// Package xlsx reads and writes the cell values of Office Open XML
+// spreadsheets. Styles, formulas and other sheet features are ignored.
+package xlsx
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"errors"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrNoSheet var
+	ErrNoSheet = errors.New("xlsx: workbook has no sheets")
+	// ErrCellRef var
+	ErrCellRef = errors.New("xlsx: invalid cell reference")
+	// ErrRowRef var
+	ErrRowRef = errors.New("xlsx: invalid row number")
+)
+
+// Limits of a sheet, column XFD and row 1048576.
+const (
+	MaxColumns = 16384
+	MaxRows    = 1048576
+)
+
+// MaxPartSize bounds the uncompressed size of a part of the workbook, the
+// larger ones are reported as invalid.
+const MaxPartSize = 256 << 20
+
+type workbook struct {
+	Sheets []struct {
+		Name string `xml:"name,attr"`
+		RID  string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+type relationships struct {
+	Relationships []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+// richText is a shared or an inline string, either plain or split into
+// formatted runs.
+type richText struct {
+	T string `xml:"t"`
+	R []struct {
+		T string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (t richText) String() string {
+	if len(t.R) == 0 {
+		return t.T
+	}
+	b := strings.Builder{}
+	for _, r := range t.R {
+		b.WriteString(r.T)
+	}
+	return b.String()
+}
+
+type sharedStrings struct {
+	SI []richText `xml:"si"`
+}
+
+type worksheet struct {
+	Rows []struct {
+		Ref   string `xml:"r,attr"`
+		Cells []struct {
+			Ref    string   `xml:"r,attr"`
+			Type   string   `xml:"t,attr"`
+			Value  string   `xml:"v"`
+			Inline richText `xml:"is"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+// ReadRows returns the cell values of the first sheet, row by row from the
+// first one. Empty cells are empty strings and the rows missing from the
+// sheet are empty, so that the index of a row is its number less one.
+// Numbers and dates are returned as stored, dates being serial day numbers.
+func ReadRows(r io.ReaderAt, size int64) ([][]string, error) {
+	z, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, err
+	}
+	files := map[string]*zip.File{}
+	for _, f := range z.File {
+		files[f.Name] = f
+	}
+	sheet, err := firstSheet(files)
+	if err != nil {
+		return nil, err
+	}
+	strs := sharedStrings{}
+	if f, ok := files["xl/sharedStrings.xml"]; ok {
+		if err := decode(f, &strs); err != nil {
+			return nil, err
+		}
+	}
+	ws := worksheet{}
+	if err := decode(sheet, &ws); err != nil {
+		return nil, err
+	}
+	rows := make([][]string, 0, len(ws.Rows))
+	for _, row := range ws.Rows {
+		n := len(rows) + 1
+		if row.Ref != "" {
+			if n, err = strconv.Atoi(row.Ref); err != nil || n <= len(rows) || n > MaxRows {
+				return nil, ErrRowRef
+			}
+		}
+		for len(rows) < n-1 {
+			rows = append(rows, []string{})
+		}
+		values := []string{}
+		col := -1
+		for _, c := range row.Cells {
+			col++
+			if c.Ref != "" {
+				if col, err = column(c.Ref); err != nil {
+					return nil, err
+				}
+			}
+			if col >= MaxColumns {
+				return nil, ErrCellRef
+			}
+			for len(values) <= col {
+				values = append(values, "")
+			}
+			switch c.Type {
+			case "s":
+				n, err := strconv.Atoi(c.Value)
+				if err == nil && n < len(strs.SI) {
+					values[col] = strs.SI[n].String()
+				}
+			case "inlineStr":
+				values[col] = c.Inline.String()
+			default:
+				values[col] = c.Value
+			}
+		}
+		rows = append(rows, values)
+	}
+	return rows, nil
+}
+
+// firstSheet finds the part of the first sheet through the workbook
+// relationships.
+func firstSheet(files map[string]*zip.File) (*zip.File, error) {
+	wb, rels := workbook{}, relationships{}
+	f, ok := files["xl/workbook.xml"]
+	if !ok {
+		return nil, ErrNoSheet
+	}
+	if err := decode(f, &wb); err != nil {
+		return nil, err
+	}
+	if len(wb.Sheets) == 0 {
+		return nil, ErrNoSheet
+	}
+	if f, ok := files["xl/_rels/workbook.xml.rels"]; ok {
+		if err := decode(f, &rels); err != nil {
+			return nil, err
+		}
+	}
+	for _, rel := range rels.Relationships {
+		if rel.ID != wb.Sheets[0].RID {
+			continue
+		}
+		name := path.Join("xl", rel.Target)
+		if strings.HasPrefix(rel.Target, "/") {
+			name = strings.TrimPrefix(rel.Target, "/")
+		}
+		if f, ok := files[name]; ok {
+			return f, nil
+		}
+	}
+	if f, ok := files["xl/worksheets/sheet1.xml"]; ok {
+		return f, nil
+	}
+	return nil, ErrNoSheet
+}
+
+func decode(f *zip.File, v interface{}) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	return xml.NewDecoder(io.LimitReader(rc, MaxPartSize)).Decode(v)
+}
+
+// column returns the zero based column of a cell reference such as "AB12",
+// up to XFD.
+func column(ref string) (int, error) {
+	col := 0
+	i := 0
+	for ; i < len(ref) && ref[i] >= 'A' && ref[i] <= 'Z'; i++ {
+		col = col*26 + int(ref[i]-'A'+1)
+		if col > MaxColumns {
+			return 0, ErrCellRef
+		}
+	}
+	if i == 0 {
+		return 0, ErrCellRef
+	}
+	return col - 1, nil
+}
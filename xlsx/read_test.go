@@ -0,0 +1,113 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+// book returns a workbook whose first sheet has the sheetData.
+func book(t *testing.T, sheetData string) *bytes.Reader {
+	buf := &bytes.Buffer{}
+	z := zip.NewWriter(buf)
+	parts := map[string]string{
+		"xl/workbook.xml": `<workbook xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+			`<sheets><sheet name="A" r:id="rId1"/></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<Relationships><Relationship Id="rId1" Target="worksheets/data.xml"/></Relationships>`,
+		"xl/sharedStrings.xml":       `<sst><si><t>shared</t></si><si><r><t>ri</t></r><r><t>ch</t></r></si></sst>`,
+		"xl/worksheets/data.xml":     `<worksheet><sheetData>` + sheetData + `</sheetData></worksheet>`,
+	}
+	for name, content := range parts {
+		w, err := z.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(content))
+	}
+	if err := z.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return bytes.NewReader(buf.Bytes())
+}
+
+func TestReadRows(t *testing.T) {
+	cases := []struct {
+		name  string
+		sheet string
+		rows  [][]string
+		err   error
+	}{
+		{
+			"types",
+			`<row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1" t="s"><v>1</v></c><c r="C1" t="inlineStr"><is><t>inline</t></is></c><c r="D1"><v>42.5</v></c></row>`,
+			[][]string{{"shared", "rich", "inline", "42.5"}},
+			nil,
+		},
+		{
+			"skipped cells",
+			`<row r="1"><c r="B1"><v>b</v></c><c r="D1"><v>d</v></c><c><v>e</v></c></row>`,
+			[][]string{{"", "b", "", "d", "e"}},
+			nil,
+		},
+		{
+			"skipped rows",
+			`<row r="2"><c r="A2"><v>2</v></c></row><row r="5"><c r="A5"><v>5</v></c></row><row><c><v>6</v></c></row>`,
+			[][]string{{}, {"2"}, {}, {}, {"5"}, {"6"}},
+			nil,
+		},
+		{
+			"last column",
+			`<row r="1"><c r="XFD1"><v>x</v></c></row>`,
+			nil,
+			nil,
+		},
+		{"column after XFD", `<row r="1"><c r="XFE1"><v>x</v></c></row>`, nil, ErrCellRef},
+		{"long column", `<row r="1"><c r="AAAAAAAAAAAAAAAAAAAA1"><v>x</v></c></row>`, nil, ErrCellRef},
+		{"no column", `<row r="1"><c r="12"><v>x</v></c></row>`, nil, ErrCellRef},
+		{"rows out of order", `<row r="3"></row><row r="2"></row>`, nil, ErrRowRef},
+		{"row after the last", `<row r="1048577"></row>`, nil, ErrRowRef},
+		{"row number", `<row r="x"></row>`, nil, ErrRowRef},
+	}
+	for _, c := range cases {
+		r := book(t, c.sheet)
+		rows, err := ReadRows(r, r.Size())
+		if err != c.err {
+			t.Errorf("%s: %v, want %v", c.name, err, c.err)
+			continue
+		}
+		if c.name == "last column" {
+			if err != nil || len(rows) != 1 || len(rows[0]) != MaxColumns || rows[0][MaxColumns-1] != "x" {
+				t.Errorf("%s: %d rows", c.name, len(rows))
+			}
+			continue
+		}
+		if err == nil && !reflect.DeepEqual(rows, c.rows) {
+			t.Errorf("%s: %q, want %q", c.name, rows, c.rows)
+		}
+	}
+}
+
+func TestWriteRead(t *testing.T) {
+	buf := &bytes.Buffer{}
+	w, err := NewWriter(buf, "Должники")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{{"ИНН", "Наименование"}, {"7707083893", "ПАО <Сбербанк> & Co"}}
+	for _, row := range want {
+		if err := w.WriteRow(row); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := ReadRows(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Fatalf("read %q, want %q", rows, want)
+	}
+}
@@ -0,0 +1,118 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Writer writes a workbook with a single sheet row by row. Cells are
+// written as inline strings, so rows are not kept in memory.
+type Writer struct {
+	z     *zip.Writer
+	sheet *bufio.Writer
+	name  string
+	rows  int
+	err   error
+}
+
+// NewWriter starts a workbook on w with a sheet of the given name.
+func NewWriter(w io.Writer, sheet string) (*Writer, error) {
+	z := zip.NewWriter(w)
+	f, err := z.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+	xw := &Writer{z: z, sheet: bufio.NewWriter(f), name: sheet}
+	xw.sheet.WriteString(xml.Header)
+	xw.sheet.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	return xw, nil
+}
+
+// WriteRow appends a row of string cells.
+func (w *Writer) WriteRow(values []string) error {
+	if w.err != nil {
+		return w.err
+	}
+	w.rows++
+	r := strconv.Itoa(w.rows)
+	b := w.sheet
+	b.WriteString(`<row r="` + r + `">`)
+	for i, v := range values {
+		if v == "" {
+			continue
+		}
+		b.WriteString(`<c r="` + ref(i) + r + `" t="inlineStr"><is><t xml:space="preserve">`)
+		if w.err = xml.EscapeText(b, []byte(v)); w.err != nil {
+			return w.err
+		}
+		b.WriteString(`</t></is></c>`)
+	}
+	_, w.err = b.WriteString(`</row>`)
+	return w.err
+}
+
+// Flush writes the buffered rows to the underlying writer.
+func (w *Writer) Flush() error {
+	if w.err != nil {
+		return w.err
+	}
+	if w.err = w.sheet.Flush(); w.err != nil {
+		return w.err
+	}
+	w.err = w.z.Flush()
+	return w.err
+}
+
+// Close finishes the sheet and writes the remaining parts of the workbook.
+// It does not close the underlying writer.
+func (w *Writer) Close() error {
+	if w.err != nil {
+		return w.err
+	}
+	w.sheet.WriteString(`</sheetData></worksheet>`)
+	if err := w.sheet.Flush(); err != nil {
+		return err
+	}
+	name := strings.Builder{}
+	xml.EscapeText(&name, []byte(w.name))
+	parts := []struct{ name, body string }{
+		{"[Content_Types].xml", `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+			`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+			`<Default Extension="xml" ContentType="application/xml"/>` +
+			`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+			`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+			`</Types>`},
+		{"_rels/.rels", `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+			`</Relationships>`},
+		{"xl/workbook.xml", `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+			`<sheets><sheet name="` + name.String() + `" sheetId="1" r:id="rId1"/></sheets>` +
+			`</workbook>`},
+		{"xl/_rels/workbook.xml.rels", `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+			`</Relationships>`},
+	}
+	for _, p := range parts {
+		f, err := w.z.Create(p.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(f, xml.Header+p.body); err != nil {
+			return err
+		}
+	}
+	return w.z.Close()
+}
+
+// ref returns the column letters of a zero based column.
+func ref(col int) string {
+	s := ""
+	for col++; col > 0; col = (col - 1) / 26 {
+		s = string(rune('A'+(col-1)%26)) + s
+	}
+	return s
+}